@@ -15,25 +15,22 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/v1/appointment": {
+        "/calendar/{token}": {
             "get": {
-                "description": "Api for get a new appointment",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "iCalendar subscription of a feed, the token is the secret of its link. Patients are shown by initials only in a masked feed.",
                 "produces": [
-                    "application/json"
+                    "text/calendar"
                 ],
                 "tags": [
-                    "appointment"
+                    "calendar"
                 ],
-                "summary": "GetAppointment",
+                "summary": "GetCalendar",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "id",
-                        "name": "id",
-                        "in": "query",
+                        "description": "token of the feed followed by .ics",
+                        "name": "token",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -41,69 +38,50 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Appointment"
+                            "type": "file"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Api for update appointment",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Liveness probe, answers as long as the process serves http",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "appointment"
-                ],
-                "summary": "UpdateAppointment",
-                "parameters": [
-                    {
-                        "description": "Appointment",
-                        "name": "Appointment",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Appointment"
-                        }
-                    }
+                    "health"
                 ],
+                "summary": "Healthz",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Appointment"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/models.Error"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
-            },
+            }
+        },
+        "/public/booking": {
             "post": {
-                "description": "Api for creat a new appointment",
+                "description": "Books a procedure from the website. The client is matched by phone and name or created, and the time is held by a pending appointment until the booking is confirmed, with the code sent to the phone or by staff depending on the clinic. Unconfirmed bookings expire and free their time. Bookings from an address and pending ones of a phone are limited.",
                 "consumes": [
                     "application/json"
                 ],
@@ -111,145 +89,146 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "appointment"
+                    "booking"
                 ],
-                "summary": "CreateAppointment",
+                "summary": "CreateBooking",
                 "parameters": [
                     {
-                        "description": "CreateAppointment",
-                        "name": "Appointment",
+                        "description": "booking",
+                        "name": "booking",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ReqAppointment"
+                            "$ref": "#/definitions/github_com_dentist_api_models.PublicBookingRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Appointment"
+                            "$ref": "#/definitions/github_com_dentist_api_models.PublicBooking"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the time was taken meanwhile",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "the time cannot be booked",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "502": {
+                        "description": "the code could not be sent",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Api for delete appointment",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/public/booking/procedures": {
+            "get": {
+                "description": "The procedures clients can book on the website",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "appointment"
-                ],
-                "summary": "DeleteAppointment",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "id",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
-                    }
+                    "booking"
                 ],
+                "summary": "GetBookingProcedures",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "boolean"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/models.Error"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.BookingProcedure"
+                            }
                         }
                     }
                 }
             }
         },
-        "/v1/appointmentid": {
+        "/public/booking/slots": {
             "get": {
-                "description": "Api for get appointment with client id",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "The times of a day a procedure can be booked at, with the doctors free at each",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "appointment"
+                    "booking"
                 ],
-                "summary": "GetAppointmentWithClientId",
+                "summary": "GetBookingSlots",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "client_id",
-                        "name": "client_id",
+                        "description": "procedure name",
+                        "name": "procedure",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "page",
-                        "name": "page",
+                        "description": "day as YYYY-MM-DD",
+                        "name": "date",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
+                        "description": "only the times of this doctor",
+                        "name": "doctor_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.AllAppointments"
+                            "$ref": "#/definitions/github_com_dentist_api_models.BookingSlots"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     }
                 }
             }
         },
-        "/v1/appointmentnew": {
+        "/public/booking/{id}/confirm": {
             "post": {
-                "description": "Api for creating a new client",
+                "description": "Confirms a booking with the code sent to the phone. A booking is rejected and its time freed after too many wrong codes.",
                 "consumes": [
                     "application/json"
                 ],
@@ -257,17 +236,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "appointment"
+                    "booking"
                 ],
-                "summary": "CreateAppointmentWithClient",
+                "summary": "ConfirmBooking",
                 "parameters": [
                     {
-                        "description": "CreateAppointmentWithClient",
-                        "name": "Client",
+                        "type": "string",
+                        "description": "booking id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "code",
+                        "name": "code",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ReqNew"
+                            "$ref": "#/definitions/github_com_dentist_api_models.BookingConfirmRequest"
                         }
                     }
                 ],
@@ -275,136 +261,77 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.New"
+                            "$ref": "#/definitions/github_com_dentist_api_models.PublicBooking"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
-                    }
-                }
-            }
-        },
-        "/v1/appointments": {
-            "get": {
-                "description": "Api for get all appointments",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "appointment"
-                ],
-                "summary": "GetAllAppointments",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "page",
-                        "name": "page",
-                        "in": "query",
-                        "required": true
                     },
-                    {
-                        "type": "string",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    "410": {
+                        "description": "the booking expired or is closed",
                         "schema": {
-                            "$ref": "#/definitions/models.AllAppointments"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "422": {
+                        "description": "wrong code",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     }
                 }
             }
         },
-        "/v1/appointmentsdate": {
+        "/readyz": {
             "get": {
-                "description": "Api for get appointments with date",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Readiness probe, checks that postgres is reachable",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "appointment"
-                ],
-                "summary": "GetAppointmentsWithDate",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "integer",
-                        "name": "integer",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "page",
-                        "name": "page",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
-                    }
+                    "health"
                 ],
+                "summary": "Readyz",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.AllAppointments"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/v1/client": {
+        "/v1/appointment": {
             "get": {
-                "description": "Api for get client",
+                "description": "Api for get a new appointment",
                 "consumes": [
                     "application/json"
                 ],
@@ -412,9 +339,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "client"
+                    "appointment"
                 ],
-                "summary": "GetClient",
+                "summary": "GetAppointment",
                 "parameters": [
                     {
                         "type": "string",
@@ -428,25 +355,31 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Client"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Appointment"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Api for update client",
+                "description": "Api for update appointment",
                 "consumes": [
                     "application/json"
                 ],
@@ -454,17 +387,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "client"
+                    "appointment"
                 ],
-                "summary": "UpdateClient",
+                "summary": "UpdateAppointment",
                 "parameters": [
                     {
-                        "description": "UpdateClient",
-                        "name": "Client",
+                        "description": "Appointment",
+                        "name": "Appointment",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Client"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Appointment"
                         }
                     }
                 ],
@@ -472,25 +405,37 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Client"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Appointment"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "confirming, checking in or completing the fitting of a lab case not received",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Api for creating a new client",
+                "description": "Api for creat a new appointment",
                 "consumes": [
                     "application/json"
                 ],
@@ -498,17 +443,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "client"
+                    "appointment"
                 ],
-                "summary": "CreateClient",
+                "summary": "CreateAppointment",
                 "parameters": [
                     {
-                        "description": "CreateClient",
-                        "name": "Client",
+                        "description": "CreateAppointment",
+                        "name": "Appointment",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ReqClient"
+                            "$ref": "#/definitions/github_com_dentist_api_models.ReqAppointment"
                         }
                     }
                 ],
@@ -516,25 +461,31 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Client"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Appointment"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Api for delete client",
+                "description": "Api for delete appointment",
                 "consumes": [
                     "application/json"
                 ],
@@ -542,9 +493,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "client"
+                    "appointment"
                 ],
-                "summary": "DeleteClient",
+                "summary": "DeleteAppointment",
                 "parameters": [
                     {
                         "type": "string",
@@ -564,21 +515,21 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     }
                 }
             }
         },
-        "/v1/clientappointment": {
+        "/v1/appointmentid": {
             "get": {
-                "description": "Api for get client with appointments",
+                "description": "Api for get appointment with client id",
                 "consumes": [
                     "application/json"
                 ],
@@ -586,57 +537,113 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "client"
+                    "appointment"
                 ],
-                "summary": "GetClientWithAppointments",
+                "summary": "GetAppointmentWithClientId",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "id",
-                        "name": "id",
+                        "description": "client_id",
+                        "name": "client_id",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "page",
+                        "type": "integer",
+                        "description": "page number, starts from 1",
                         "name": "page",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Client"
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_storage_repo_Appointment"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     }
                 }
             }
         },
-        "/v1/clients": {
+        "/v1/appointmentnew": {
+            "post": {
+                "description": "Api for creating a new client",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "appointment"
+                ],
+                "summary": "CreateAppointmentWithClient",
+                "parameters": [
+                    {
+                        "description": "CreateAppointmentWithClient",
+                        "name": "Client",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ReqNew"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.New"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/appointments": {
             "get": {
-                "description": "Api for get all clients",
+                "description": "Api for get all appointments",
                 "consumes": [
                     "application/json"
                 ],
@@ -644,50 +651,60 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "client"
+                    "appointment"
                 ],
-                "summary": "GetAllClients",
+                "summary": "GetAllAppointments",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "page",
+                        "type": "integer",
+                        "description": "page number, starts from 1",
                         "name": "page",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "limit",
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
                         "name": "limit",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.AllClients"
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_storage_repo_Appointment"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     }
                 }
             }
         },
-        "/v1/count": {
+        "/v1/appointmentsdate": {
             "get": {
-                "description": "Api for get all clients count",
+                "description": "Api for get appointments with date",
                 "consumes": [
                     "application/json"
                 ],
@@ -695,50 +712,261 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "client"
+                    "appointment"
+                ],
+                "summary": "GetAppointmentsWithDate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "integer",
+                        "name": "integer",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
                 ],
-                "summary": "GetAllClientsCount",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_storage_repo_Appointment"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     }
                 }
             }
         },
-        "/v1/search": {
+        "/v1/board/stream": {
             "get": {
-                "description": "Api for searching clients",
-                "consumes": [
+                "description": "Server-Sent Events of the appointments of a day, of one doctor with doctor_id: appointment.created, appointment.updated, appointment.checked_in, appointment.cancelled and appointment.deleted, a moved appointment is sent to the board it left too. The id of an event is sent back in Last-Event-ID on reconnection to get the missed ones, a reset event asks the board to load the day again when too many were missed. Needs a board token as a bearer token or the access_token query parameter.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "board"
+                ],
+                "summary": "BoardStream",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "day, YYYY-MM-DD in the clinic timezone, today by default",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "doctor id",
+                        "name": "doctor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "board token, when the Authorization header cannot be set",
+                        "name": "access_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "id of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/booking-requests": {
+            "get": {
+                "description": "Api for get the bookings made on the website, the oldest first",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "booking"
+                ],
+                "summary": "GetBookingRequests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending, confirmed, rejected or expired",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_BookingRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/booking-requests/{id}/confirm": {
+            "post": {
+                "description": "Api for confirm a pending booking, its appointment is scheduled",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "client"
+                    "booking"
                 ],
-                "summary": "SearchingClients",
+                "summary": "ConfirmBookingRequest",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "SearchClients",
-                        "name": "str",
-                        "in": "query",
+                        "description": "booking id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.BookingRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "410": {
+                        "description": "the booking expired or is closed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/booking-requests/{id}/reject": {
+            "post": {
+                "description": "Api for reject a pending booking, its appointment is cancelled",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "booking"
+                ],
+                "summary": "RejectBookingRequest",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "booking id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -746,215 +974,8812 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.AllClients"
+                            "$ref": "#/definitions/github_com_dentist_api_models.BookingRequest"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "410": {
+                        "description": "the booking expired or is closed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/models.Error"
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.AllAppointments": {
+        },
+        "/v1/calendar-feeds": {
+            "get": {
+                "description": "Api for get the calendar feeds, revoked ones included. Their links are not shown again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "GetCalendarFeeds",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.CalendarFeed"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for create a secret link to the schedule of a doctor, or of the whole clinic without doctor_id, for calendar apps. The links are returned only here, a lost link is revoked and a new feed created.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "CreateCalendarFeed",
+                "parameters": [
+                    {
+                        "description": "feed",
+                        "name": "feed",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.CalendarFeedRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.CalendarFeedCreated"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/calendar-feeds/{id}": {
+            "delete": {
+                "description": "Api for revoke a calendar feed, its links stop working at once",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "RevokeCalendarFeed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "feed id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/client": {
+            "get": {
+                "description": "Api for get client",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client"
+                ],
+                "summary": "GetClient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Client"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Api for update client",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client"
+                ],
+                "summary": "UpdateClient",
+                "parameters": [
+                    {
+                        "description": "UpdateClient",
+                        "name": "Client",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Client"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Client"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for creating a new client",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client"
+                ],
+                "summary": "CreateClient",
+                "parameters": [
+                    {
+                        "description": "CreateClient",
+                        "name": "Client",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ReqClient"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Client"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Api for delete client",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client"
+                ],
+                "summary": "DeleteClient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/clientappointment": {
+            "get": {
+                "description": "Api for get client with appointments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client"
+                ],
+                "summary": "GetClientWithAppointments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Client"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/clients": {
+            "get": {
+                "description": "Api for get all clients",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client"
+                ],
+                "summary": "GetAllClients",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_storage_repo_Client"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/clients/{id}/anonymize": {
+            "post": {
+                "description": "Api for erase a client: the personal data and appointment notes are wiped for good, the appointment dates and amounts stay for statistics",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client"
+                ],
+                "summary": "AnonymizeClient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "confirm must be true",
+                        "name": "Confirm",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.AnonymizeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.AnonymizeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/clients/{id}/contact": {
+            "get": {
+                "description": "Api for get who is contacted about a client: its guarantor, or the client itself",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "family"
+                ],
+                "summary": "GetContact",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.FamilyClient"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/clients/{id}/duplicates": {
+            "get": {
+                "description": "Api for get the clients that are likely the same person: same phone number, or a similar name with the same birth date",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "merge"
+                ],
+                "summary": "FindClientDuplicates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.DuplicateMatch"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/clients/{id}/export": {
+            "get": {
+                "description": "Api for get a copy of everything stored about a client, deleted appointments included",
+                "produces": [
+                    "application/json",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "client"
+                ],
+                "summary": "ExportClient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "json",
+                        "description": "json or pdf",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ClientExport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/clients/{id}/family": {
+            "get": {
+                "description": "Api for get everyone related to a client, their upcoming appointments and the combined balance",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "family"
+                ],
+                "summary": "GetFamily",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.FamilyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/clients/{id}/guarantor": {
+            "put": {
+                "description": "Api for set who gets the reminders and bills of a client, it must be a guardian or spouse of the client. An empty guarantor_id makes the client answer for itself.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "family"
+                ],
+                "summary": "SetGuarantor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "SetGuarantor",
+                        "name": "Guarantor",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.GuarantorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.FamilyClient"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/clients/{id}/merges": {
+            "get": {
+                "description": "Api for get the merge history of a client, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "merge"
+                ],
+                "summary": "GetClientMerges",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.MergeResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/clients/{id}/relations": {
+            "get": {
+                "description": "Api for get the relations of a client, the children it is the guardian of are listed as wards",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "family"
+                ],
+                "summary": "GetRelations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.RelationResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for add a relation between two clients, spouse and sibling are stored for both. The first guardian of a minor without a guarantor becomes its guarantor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "family"
+                ],
+                "summary": "AddRelation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "AddRelation",
+                        "name": "Relation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.RelationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.RelationResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/clients/{id}/relations/{related_id}": {
+            "delete": {
+                "description": "Api for delete a relation between two clients, the last guardian of a minor cannot be removed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "family"
+                ],
+                "summary": "DeleteRelation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "related client id",
+                        "name": "related_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "guardian, spouse or sibling",
+                        "name": "kind",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/count": {
+            "get": {
+                "description": "Api for get all clients count, deprecated: lists report total themselves",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client"
+                ],
+                "summary": "GetAllClientsCount",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/duplicates": {
+            "get": {
+                "description": "Api for get every pair of clients that are likely the same person, phone matches first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "merge"
+                ],
+                "summary": "FindDuplicates",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_DuplicateMatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/import": {
+            "post": {
+                "description": "Api for import clients or appointments from a CSV or XLSX file. Columns are found by their title, mapping names the column of a field otherwise. Every row is checked first, with dry_run nothing is saved. Appointment rows find their client by client_id, phone number, or name and birth date, and create it when not found.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "import"
+                ],
+                "summary": "Import",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "the .csv or .xlsx file, the first row is the header",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "clients or appointments",
+                        "name": "kind",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only check the rows",
+                        "name": "dry_run",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON object from field to column title, e.g. phone_number: Tel",
+                        "name": "mapping",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "dry run",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ImportResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/imports/{id}": {
+            "get": {
+                "description": "Api for get the outcome of an import",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "import"
+                ],
+                "summary": "GetImport",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "import id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/imports/{id}/errors": {
+            "get": {
+                "description": "Api for download the rows of an import that were not imported as CSV, with the reason and the original cells. It can be fixed and imported again.",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "import"
+                ],
+                "summary": "GetImportErrors",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "import id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/inventory/alerts": {
+            "get": {
+                "description": "Api for get the items below their minimum stock and the batches in stock that expire soon or did. An item falling below its minimum is also sent as the inventory.low_stock event.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "GetInventoryAlerts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.InventoryAlerts"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/inventory/batches/{id}/adjustments": {
+            "post": {
+                "description": "Api for correct the quantity of a batch by hand, such as after a count or for waste. The note is kept in the stock history.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "AdjustInventoryBatch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "batch id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "adjustment",
+                        "name": "adjustment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.AdjustmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.InventoryBatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "the batch holds less than taken out",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/inventory/items": {
+            "get": {
+                "description": "Api for get the inventory items with their stock, by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "GetInventoryItems",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_InventoryItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for add a material to the inventory, its stock is received in batches",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "CreateInventoryItem",
+                "parameters": [
+                    {
+                        "description": "item",
+                        "name": "item",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.InventoryItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.InventoryItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "another item has the name",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/inventory/items/{id}": {
+            "get": {
+                "description": "Api for get an inventory item with its stock per location and its batches, first expiring first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "GetInventoryItem",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "item id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.InventoryItemStock"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Api for update an inventory item, its stock is changed with batches and adjustments",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "UpdateInventoryItem",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "item id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "item",
+                        "name": "item",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.InventoryItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.InventoryItem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "another item has the name",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Api for delete an inventory item, procedures stop using it and its history is kept",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "DeleteInventoryItem",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "item id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/inventory/items/{id}/batches": {
+            "post": {
+                "description": "Api for put a delivered batch of an item into stock at a location",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "ReceiveInventoryBatch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "item id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "batch",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.InventoryBatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/inventory/locations": {
+            "get": {
+                "description": "Api for get the places stock is kept in",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "GetStorageLocations",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.StorageLocation"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for add a place stock is kept in",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "CreateStorageLocation",
+                "parameters": [
+                    {
+                        "description": "location",
+                        "name": "location",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.StorageLocationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.StorageLocation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "another location has the name",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/inventory/materials": {
+            "get": {
+                "description": "Api for get the bills of materials, what completing an appointment with the procedure as its treatment takes out of stock",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "GetProcedureMaterials",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only this procedure",
+                        "name": "procedure",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.ProcedureMaterial"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Api for replace the bill of materials of a procedure, matched to the treatment of appointments without regard to case. An empty list clears it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "SetProcedureMaterials",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "procedure",
+                        "name": "procedure",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "materials",
+                        "name": "materials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.ProcedureMaterialRequest"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/inventory/movements": {
+            "get": {
+                "description": "Api for get the history of stock, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "inventory"
+                ],
+                "summary": "GetStockMovements",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "item id",
+                        "name": "item_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "appointment id",
+                        "name": "appointment_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "receipt, consumption or adjustment",
+                        "name": "reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_StockMovement"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab-cases": {
+            "get": {
+                "description": "Api for get the lab cases newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab cases"
+                ],
+                "summary": "GetLabCases",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "lab id",
+                        "name": "lab_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created, sent, received, fitted or cancelled",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_LabCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for add prosthetic work such as a crown, bridge or denture made by an outside lab for a client. The lab is a supplier. While the case is not received its fitting appointment cannot be confirmed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab cases"
+                ],
+                "summary": "CreateLabCase",
+                "parameters": [
+                    {
+                        "description": "case",
+                        "name": "case",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.LabCaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.LabCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the appointment is confirmed already and the case not received",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "the client, lab or appointment of the client does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab-cases/overdue": {
+            "get": {
+                "description": "Api for get the lab cases not received that were due before today, longest overdue first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab cases"
+                ],
+                "summary": "GetOverdueLabCases",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.LabCase"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab-cases/{id}": {
+            "get": {
+                "description": "Api for get a lab case",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab cases"
+                ],
+                "summary": "GetLabCase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "case id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.LabCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Api for update the details of a lab case not fitted or cancelled, its client cannot be changed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab cases"
+                ],
+                "summary": "UpdateLabCase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "case id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "case",
+                        "name": "case",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.LabCaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.LabCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the case is fitted or cancelled, or not received and the appointment is confirmed already",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "the lab or appointment of the client does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab-cases/{id}/cancel": {
+            "post": {
+                "description": "Api for cancel a lab case not fitted yet, its fitting appointment is not held back by it any more",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab cases"
+                ],
+                "summary": "CancelLabCase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "case id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.LabCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the case is fitted or cancelled, or not received and the appointment is confirmed already",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab-cases/{id}/fit": {
+            "post": {
+                "description": "Api for mark a received lab case as fitted to the client",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab cases"
+                ],
+                "summary": "FitLabCase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "case id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "fitted",
+                        "name": "fitted",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.LabCaseDateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.LabCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the case is not received",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab-cases/{id}/receive": {
+            "post": {
+                "description": "Api for mark a sent lab case as delivered by the lab, its fitting appointment can be confirmed then",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab cases"
+                ],
+                "summary": "ReceiveLabCase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "case id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "received",
+                        "name": "received",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.LabCaseDateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.LabCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the case is not sent",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/lab-cases/{id}/send": {
+            "post": {
+                "description": "Api for mark a lab case as sent to the lab",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lab cases"
+                ],
+                "summary": "SendLabCase",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "case id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "sent",
+                        "name": "sent",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.LabCaseSendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.LabCase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the case was sent already",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/merges": {
+            "post": {
+                "description": "Api for merge a duplicate into the surviving client: appointments move over, empty fields of the survivor are filled and the duplicate is deleted. It can be undone for a while.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "merge"
+                ],
+                "summary": "MergeClients",
+                "parameters": [
+                    {
+                        "description": "MergeClients",
+                        "name": "Merge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.MergeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.MergeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/merges/{id}/undo": {
+            "post": {
+                "description": "Api for undo a merge within the undo window, the duplicate comes back with its appointments",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "merge"
+                ],
+                "summary": "UndoMerge",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "merge id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.MergeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/purchase-orders": {
+            "get": {
+                "description": "Api for get the purchase orders newest first, without their lines",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "GetPurchaseOrders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "supplier id",
+                        "name": "supplier_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "draft, ordered, partially_received, received or cancelled",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_PurchaseOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for draft an order of items from a supplier. The draft can be edited until it is placed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "CreatePurchaseOrder",
+                "parameters": [
+                    {
+                        "description": "order",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.PurchaseOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.PurchaseOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "the supplier or an item does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/purchase-orders/{id}": {
+            "get": {
+                "description": "Api for get a purchase order with its lines and what is still to be delivered",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "GetPurchaseOrder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "order id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.PurchaseOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Api for replace the supplier, delivery date, note and lines of a draft purchase order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "UpdatePurchaseOrder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "order id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "order",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.PurchaseOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.PurchaseOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the order is not a draft",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "the supplier or an item does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/purchase-orders/{id}/cancel": {
+            "post": {
+                "description": "Api for cancel a purchase order not fully received, what was received stays in stock",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "CancelPurchaseOrder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "order id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.PurchaseOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the order is received or cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/purchase-orders/{id}/place": {
+            "post": {
+                "description": "Api for mark a draft purchase order as ordered from the supplier, its unit prices are added to the price history of the supplier",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "PlacePurchaseOrder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "order id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.PurchaseOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the order is not a draft",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/purchase-orders/{id}/receipts": {
+            "post": {
+                "description": "Api for put a delivery of a placed purchase order into stock, a batch per line. A delivery may cover part of the order, it is received once every line is.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "ReceivePurchaseOrder",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "order id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "receipt",
+                        "name": "receipt",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ReceiptRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.PurchaseOrder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the order is not placed, or received or cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "the location or a line does not exist, or more is received than outstanding",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/purchasing/spend": {
+            "get": {
+                "description": "Api for get what was spent per supplier per month, counted from deliveries at the unit prices ordered. Months are of the clinic time zone and default to the last twelve.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "GetSupplierSpend",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "first month, YYYY-MM",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "last month, YYYY-MM",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.SupplierSpend"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/search": {
+            "get": {
+                "description": "Api for searching clients",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "client"
+                ],
+                "summary": "SearchingClients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SearchClients",
+                        "name": "str",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_storage_repo_Client"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sterilization/autoclaves": {
+            "get": {
+                "description": "Api for get the autoclaves by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sterilization"
+                ],
+                "summary": "GetAutoclaves",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.Autoclave"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for add an autoclave instruments are sterilized in",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sterilization"
+                ],
+                "summary": "CreateAutoclave",
+                "parameters": [
+                    {
+                        "description": "autoclave",
+                        "name": "autoclave",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.AutoclaveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Autoclave"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the name is taken",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sterilization/cycles": {
+            "get": {
+                "description": "Api for get the autoclave cycles last started first, without their packs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sterilization"
+                ],
+                "summary": "GetCycles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "autoclave id",
+                        "name": "autoclave_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, passed or failed",
+                        "name": "result",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_Cycle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for log an autoclave cycle with its parameters and the instrument packs processed in it. Its result is pending until the indicator is read, its packs cannot be used before it passed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sterilization"
+                ],
+                "summary": "CreateCycle",
+                "parameters": [
+                    {
+                        "description": "cycle",
+                        "name": "cycle",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.CycleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Cycle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the cycle number of the autoclave or a pack code is recorded",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "the autoclave does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sterilization/cycles/{id}": {
+            "get": {
+                "description": "Api for get an autoclave cycle with its packs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sterilization"
+                ],
+                "summary": "GetCycle",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "cycle id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Cycle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sterilization/cycles/{id}/exposures": {
+            "get": {
+                "description": "Api for trace the appointments, with their clients, the packs of a cycle were used at, earliest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sterilization"
+                ],
+                "summary": "GetCycleExposures",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "cycle id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.Exposure"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sterilization/cycles/{id}/result": {
+            "post": {
+                "description": "Api for record the indicator result of a pending cycle, or fail a cycle that passed when its biological indicator grows. A failed cycle sends sterilization.cycle_failed with the appointments its packs were used at.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sterilization"
+                ],
+                "summary": "RecordCycleResult",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "cycle id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "result",
+                        "name": "result",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.CycleResultRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Cycle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the cycle failed already or passed already",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sterilization/packs": {
+            "get": {
+                "description": "Api for get the instrument packs used at an appointment",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sterilization"
+                ],
+                "summary": "GetAppointmentPacks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "appointment id",
+                        "name": "appointment_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.Pack"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sterilization/packs/{code}": {
+            "get": {
+                "description": "Api for get an instrument pack by the code on its label, with the result of its cycle",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sterilization"
+                ],
+                "summary": "GetPack",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pack code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Pack"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sterilization/packs/{code}/use": {
+            "post": {
+                "description": "Api for record that an instrument pack was opened at an appointment. Only packs of a cycle that passed can be used, and each once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sterilization"
+                ],
+                "summary": "UsePack",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pack code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "use",
+                        "name": "use",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.PackUseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Pack"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "the pack was used or its cycle has not passed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "the appointment does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/suppliers": {
+            "get": {
+                "description": "Api for get the suppliers by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "GetSuppliers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_Supplier"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for add a supplier materials are ordered from",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "CreateSupplier",
+                "parameters": [
+                    {
+                        "description": "supplier",
+                        "name": "supplier",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.SupplierRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Supplier"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "another supplier has the name",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/suppliers/{id}": {
+            "get": {
+                "description": "Api for get a supplier",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "GetSupplier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "supplier id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Supplier"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Api for update the details of a supplier",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "UpdateSupplier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "supplier id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "supplier",
+                        "name": "supplier",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.SupplierRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Supplier"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "another supplier has the name",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Api for delete a supplier, its orders and price history are kept",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "DeleteSupplier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "supplier id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/suppliers/{id}/prices": {
+            "get": {
+                "description": "Api for get the unit prices a supplier was ordered items at, newest first. A price is recorded when an order is placed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchasing"
+                ],
+                "summary": "GetSupplierPrices",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "supplier id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "only this item",
+                        "name": "item_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.SupplierPrice"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/trash": {
+            "get": {
+                "description": "Api for get how many clients and appointments are in the trash",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "GetTrash",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.TrashSummary"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/trash/appointments": {
+            "get": {
+                "description": "Api for get deleted appointments, most recently deleted first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "GetDeletedAppointments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_DeletedAppointmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/trash/appointments/{id}/restore": {
+            "post": {
+                "description": "Api for restore a deleted appointment, its client must not be deleted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "RestoreAppointment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "appointment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/trash/clients": {
+            "get": {
+                "description": "Api for get deleted clients, most recently deleted first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "GetDeletedClients",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_DeletedClientResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/trash/clients/{id}/restore": {
+            "post": {
+                "description": "Api for restore a deleted client with the appointments deleted together with it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "RestoreClient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.RestoreClientResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/waitlist": {
+            "get": {
+                "description": "Api for get the waitlist, the longest waiting first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "GetWaitlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "waiting, booked or cancelled",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "doctor id",
+                        "name": "doctor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_WaitlistEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for put a client on the waitlist for an earlier appointment. When an appointment in the window is cancelled or moved, the best matching clients are offered its time and the first to accept gets it. A minor client needs a guardian.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "CreateWaitlistEntry",
+                "parameters": [
+                    {
+                        "description": "entry",
+                        "name": "entry",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.WaitlistRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.WaitlistEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/waitlist-offers/{id}/accept": {
+            "post": {
+                "description": "Api for accept an offer on behalf of the client, such as on the phone. The first offer of a slot accepted books it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "AcceptWaitlistOffer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "offer id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.WaitlistBooking"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "someone else took the slot",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "410": {
+                        "description": "the offer expired or is closed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/waitlist/{id}": {
+            "delete": {
+                "description": "Api for take a waiting client off the waitlist, the links of the pending offers stop working",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "CancelWaitlistEntry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "entry id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/waitlist/{id}/offers": {
+            "get": {
+                "description": "Api for get the slots offered to a waitlist entry, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "GetWaitlistOffers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "entry id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.WaitlistOffer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhook-deliveries/{id}/replay": {
+            "post": {
+                "description": "Api for send the payload of a delivery again, as a new delivery with the same event id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "ReplayWebhookDelivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "delivery id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.WebhookDelivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhooks": {
+            "get": {
+                "description": "Api for get the webhooks, without their secrets",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "GetWebhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_dentist_api_models.Webhook"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for subscribe a URL to events: appointment.created, appointment.updated, appointment.checked_in, appointment.cancelled, appointment.deleted, appointment.restored, client.created, client.updated, client.deleted, client.restored, inventory.low_stock, sterilization.cycle_failed, or * for all. The URL must be https and must not resolve to a loopback, private or link-local address. Every event is sent as a signed JSON POST and retried with backoff until it is answered 2xx. The secret is returned only here.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "CreateWebhook",
+                "parameters": [
+                    {
+                        "description": "webhook",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.WebhookCreated"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhooks/{id}": {
+            "delete": {
+                "description": "Api for delete a webhook, its pending deliveries are not sent. The delivery log is kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "DeleteWebhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Api for get the delivery log of a webhook, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "GetWebhookDeliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number, starts from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip, instead of page",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_WebhookDelivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/appointments": {
+            "get": {
+                "description": "Api for listing appointments, every given filter must match",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 appointment"
+                ],
+                "summary": "ListAppointments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "inclusive start, RFC 3339 or YYYY-MM-DD in the clinic time zone",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "exclusive end, RFC 3339 or YYYY-MM-DD in the clinic time zone",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only appointments of this client",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only appointments of this doctor",
+                        "name": "doctor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated statuses, e.g. scheduled,confirmed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "inclusive lower bound of amount",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "inclusive upper bound of amount",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "search diagnostics and treatment, web search syntax",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "date",
+                        "description": "date, -date, amount or -amount",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_AppointmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for creating a new appointment",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 appointment"
+                ],
+                "summary": "CreateAppointment",
+                "parameters": [
+                    {
+                        "description": "Appointment",
+                        "name": "Appointment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.AppointmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.AppointmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "client or doctor does not exist, or a minor client has no guardian",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/appointments/{id}": {
+            "get": {
+                "description": "Api for get appointment",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 appointment"
+                ],
+                "summary": "GetAppointment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "appointment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.AppointmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Api for replacing appointment's data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 appointment"
+                ],
+                "summary": "UpdateAppointment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "appointment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Appointment",
+                        "name": "Appointment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.AppointmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.AppointmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "confirming, checking in or completing the fitting of a lab case not received",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "client or doctor does not exist, or a minor client has no guardian",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Api for deleting an appointment",
+                "tags": [
+                    "v2 appointment"
+                ],
+                "summary": "DeleteAppointment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "appointment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/clients": {
+            "get": {
+                "description": "Api for listing clients, q searches by name or last name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 client"
+                ],
+                "summary": "ListClients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "search by name or last name",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_ClientResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for creating a new client",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 client"
+                ],
+                "summary": "CreateClient",
+                "parameters": [
+                    {
+                        "description": "Client",
+                        "name": "Client",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ClientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ClientResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/clients/{id}": {
+            "get": {
+                "description": "Api for get client",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 client"
+                ],
+                "summary": "GetClient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ClientResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Api for replacing client's data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 client"
+                ],
+                "summary": "UpdateClient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Client",
+                        "name": "Client",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ClientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ClientResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Api for deleting a client together with the appointments",
+                "tags": [
+                    "v2 client"
+                ],
+                "summary": "DeleteClient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/clients/{id}/appointments": {
+            "get": {
+                "description": "Api for listing appointments of a client",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 client"
+                ],
+                "summary": "ListClientAppointments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_AppointmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for booking an appointment for a client",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 client"
+                ],
+                "summary": "CreateClientAppointment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Appointment",
+                        "name": "Appointment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ClientAppointmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.AppointmentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/doctors": {
+            "get": {
+                "description": "Api for listing doctors",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 doctor"
+                ],
+                "summary": "ListDoctors",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rows to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_DoctorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Api for creating a new doctor",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 doctor"
+                ],
+                "summary": "CreateDoctor",
+                "parameters": [
+                    {
+                        "description": "Doctor",
+                        "name": "Doctor",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.DoctorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.DoctorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/doctors/{id}": {
+            "get": {
+                "description": "Api for get doctor",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 doctor"
+                ],
+                "summary": "GetDoctor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "doctor id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.DoctorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Api for replacing doctor's data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2 doctor"
+                ],
+                "summary": "UpdateDoctor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "doctor id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Doctor",
+                        "name": "Doctor",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.DoctorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.DoctorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Api for deleting a doctor, past appointments keep the reference",
+                "tags": [
+                    "v2 doctor"
+                ],
+                "summary": "DeleteDoctor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "doctor id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/exports/appointments": {
+            "get": {
+                "description": "Api for downloading the appointment journal as a spreadsheet, with the filters of ListAppointments",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "v2 export"
+                ],
+                "summary": "ExportAppointments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "csv",
+                        "description": "csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "language of the column titles: en, ru or uz, else Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "inclusive start, RFC 3339 or YYYY-MM-DD in the clinic time zone",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "exclusive end, RFC 3339 or YYYY-MM-DD in the clinic time zone",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only appointments of this client",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only appointments of this doctor",
+                        "name": "doctor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated statuses, e.g. scheduled,confirmed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "inclusive lower bound of amount",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "inclusive upper bound of amount",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "search diagnostics and treatment, web search syntax",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "date",
+                        "description": "date, -date, amount or -amount",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/exports/clients": {
+            "get": {
+                "description": "Api for downloading the clients as a spreadsheet, the rows are streamed from the database",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "v2 export"
+                ],
+                "summary": "ExportClients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "csv",
+                        "description": "csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "language of the column titles: en, ru or uz, else Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only clients whose name or last name contains this",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/exports/payments": {
+            "get": {
+                "description": "Api for downloading the payments as a spreadsheet ending with their total. Payments are not recorded apart from appointments, they are the completed appointments with an amount.",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "v2 export"
+                ],
+                "summary": "ExportPayments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "csv",
+                        "description": "csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "language of the column titles: en, ru or uz, else Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "inclusive start, RFC 3339 or YYYY-MM-DD in the clinic time zone",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "exclusive end, RFC 3339 or YYYY-MM-DD in the clinic time zone",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only payments of this client",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only payments for this doctor",
+                        "name": "doctor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "inclusive lower bound of amount",
+                        "name": "min_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "inclusive upper bound of amount",
+                        "name": "max_amount",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "date",
+                        "description": "date, -date, amount or -amount",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/waitlist/offers/{token}": {
+            "get": {
+                "description": "The slot offered by the link sent to a client on the waitlist, the token is the secret of the link",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "GetPublicWaitlistOffer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token of the offer link",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.PublicWaitlistOffer"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/waitlist/offers/{token}/accept": {
+            "post": {
+                "description": "Books the slot offered by the link sent to a client on the waitlist, if nobody accepted it first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "waitlist"
+                ],
+                "summary": "AcceptPublicWaitlistOffer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token of the offer link",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.WaitlistBooking"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "someone else took the slot",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "410": {
+                        "description": "the offer expired or is closed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_dentist_api_models.Error"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_dentist_api_models.AdjustmentRequest": {
+            "type": "object",
+            "required": [
+                "delta",
+                "note"
+            ],
+            "properties": {
+                "delta": {
+                    "description": "Delta is added to the batch, negative to take out such as for\nwaste or a count that came short",
+                    "type": "number",
+                    "example": -2.5
+                },
+                "note": {
+                    "type": "string",
+                    "example": "dropped"
+                }
+            }
+        },
+        "github_com_dentist_api_models.AnonymizeRequest": {
+            "type": "object",
+            "properties": {
+                "confirm": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_dentist_api_models.AnonymizeResponse": {
+            "type": "object",
+            "properties": {
+                "anonymized_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.Appointment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "clientId": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "diagnostics": {
+                    "type": "string"
+                },
+                "doctorId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "treatment": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.AppointmentRequest": {
+            "type": "object",
+            "required": [
+                "client_id",
+                "date"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2024-03-18T10:30:00+05:00"
+                },
+                "diagnostics": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "scheduled"
+                },
+                "treatment": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.AppointmentResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "diagnostics": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "treatment": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.Autoclave": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "serial_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.AutoclaveRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Autoclave 1"
+                },
+                "serial_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.BatchRequest": {
+            "type": "object",
+            "required": [
+                "location_id",
+                "quantity"
+            ],
+            "properties": {
+                "expires_on": {
+                    "description": "ExpiresOn is left empty for a batch that does not expire",
+                    "type": "string",
+                    "example": "2027-03-31"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "lot": {
+                    "type": "string",
+                    "example": "L2611"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number",
+                    "example": 100
+                }
+            }
+        },
+        "github_com_dentist_api_models.BookingConfirmRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "github_com_dentist_api_models.BookingDoctor": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "specialty": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.BookingProcedure": {
+            "type": "object",
+            "properties": {
+                "duration_minutes": {
+                    "type": "integer",
+                    "example": 60
+                },
+                "name": {
+                    "type": "string",
+                    "example": "cleaning"
+                }
+            }
+        },
+        "github_com_dentist_api_models.BookingRequest": {
+            "type": "object",
+            "properties": {
+                "appointment_id": {
+                    "type": "string"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "client_ip": {
+                    "type": "string"
+                },
+                "confirmed_by": {
+                    "type": "string",
+                    "enum": [
+                        "code",
+                        "staff"
+                    ]
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "decided_at": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "duration_minutes": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "procedure": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "confirmed",
+                        "rejected",
+                        "expired"
+                    ]
+                }
+            }
+        },
+        "github_com_dentist_api_models.BookingSlot": {
+            "type": "object",
+            "properties": {
+                "doctor_ids": {
+                    "description": "DoctorIds are the doctors free at Start",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.BookingSlots": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2026-11-02"
+                },
+                "doctors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.BookingDoctor"
+                    }
+                },
+                "procedure": {
+                    "$ref": "#/definitions/github_com_dentist_api_models.BookingProcedure"
+                },
+                "slots": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.BookingSlot"
+                    }
+                }
+            }
+        },
+        "github_com_dentist_api_models.CalendarFeed": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "mask_patients": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.CalendarFeedCreated": {
+            "type": "object",
+            "properties": {
+                "caldav_url": {
+                    "description": "CaldavUrl is added as a read-only CalDAV account",
+                    "type": "string",
+                    "example": "https://clinic.example/caldav/3q2-7w/"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "ics_url": {
+                    "description": "IcsUrl is subscribed to as an internet calendar",
+                    "type": "string",
+                    "example": "https://clinic.example/calendar/3q2-7w.ics"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "mask_patients": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.CalendarFeedRequest": {
+            "type": "object",
+            "properties": {
+                "doctor_id": {
+                    "description": "DoctorId limits the feed to one doctor, empty is the whole clinic",
+                    "type": "string"
+                },
+                "mask_patients": {
+                    "description": "MaskPatients leaves only the initials of patients and drops the\ndiagnostics, for calendars shared outside the clinic",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Dr. Karimov"
+                }
+            }
+        },
+        "github_com_dentist_api_models.Charge": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "appointment_id": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.Client": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "allAppointments": {
+                    "$ref": "#/definitions/github_com_dentist_pkg_pagination.Page-github_com_dentist_storage_repo_Appointment"
+                },
+                "birthDate": {
+                    "type": "string",
+                    "example": "1990-05-17"
+                },
+                "fatherName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phoneNumber": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ClientAppointmentRequest": {
+            "type": "object",
+            "required": [
+                "date"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2024-03-18T10:30:00+05:00"
+                },
+                "diagnostics": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "scheduled"
+                },
+                "treatment": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ClientExport": {
+            "type": "object",
+            "properties": {
+                "appointments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.ExportedAppointment"
+                    }
+                },
+                "charges": {
+                    "$ref": "#/definitions/github_com_dentist_api_models.ExportedCharges"
+                },
+                "client": {
+                    "$ref": "#/definitions/github_com_dentist_api_models.ExportedClient"
+                },
+                "exported_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ClientRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "birth_date": {
+                    "type": "string",
+                    "example": "1990-05-17"
+                },
+                "father_name": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ClientResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "birth_date": {
+                    "type": "string",
+                    "example": "1990-05-17"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "father_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.Cycle": {
+            "type": "object",
+            "properties": {
+                "autoclave_id": {
+                    "type": "string"
+                },
+                "autoclave_name": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "cycle_number": {
+                    "type": "integer"
+                },
+                "hold_minutes": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "indicator": {
+                    "description": "Indicator is the indicator the result was read from",
+                    "type": "string",
+                    "enum": [
+                        "chemical",
+                        "biological"
+                    ]
+                },
+                "note": {
+                    "type": "string"
+                },
+                "operator": {
+                    "type": "string"
+                },
+                "packs": {
+                    "description": "Packs are left out of lists",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.Pack"
+                    }
+                },
+                "pressure_kpa": {
+                    "type": "number"
+                },
+                "program": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "passed",
+                        "failed"
+                    ]
+                },
+                "result_at": {
+                    "type": "string"
+                },
+                "result_note": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "temperature_c": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_dentist_api_models.CycleRequest": {
+            "type": "object",
+            "required": [
+                "autoclave_id",
+                "cycle_number",
+                "hold_minutes",
+                "pressure_kpa",
+                "started_at",
+                "temperature_c"
+            ],
+            "properties": {
+                "autoclave_id": {
+                    "type": "string"
+                },
+                "cycle_number": {
+                    "description": "CycleNumber is the counter the autoclave prints for the run",
+                    "type": "integer",
+                    "example": 1024
+                },
+                "hold_minutes": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "note": {
+                    "type": "string"
+                },
+                "operator": {
+                    "type": "string"
+                },
+                "packs": {
+                    "description": "Packs are the instrument packs processed in the cycle",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.PackRequest"
+                    }
+                },
+                "pressure_kpa": {
+                    "type": "number",
+                    "example": 216
+                },
+                "program": {
+                    "type": "string",
+                    "example": "134C wrapped"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "temperature_c": {
+                    "type": "number",
+                    "example": 134
+                }
+            }
+        },
+        "github_com_dentist_api_models.CycleResultRequest": {
+            "type": "object",
+            "required": [
+                "indicator",
+                "passed"
+            ],
+            "properties": {
+                "indicator": {
+                    "type": "string",
+                    "enum": [
+                        "chemical",
+                        "biological"
+                    ]
+                },
+                "note": {
+                    "type": "string"
+                },
+                "passed": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_dentist_api_models.DeletedAppointmentResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "diagnostics": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "purge_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "treatment": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.DeletedClientResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "birth_date": {
+                    "type": "string",
+                    "example": "1990-05-17"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "father_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "purge_at": {
+                    "description": "PurgeAt is when the retention job takes the row, absent when the\ntrash is kept forever",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.DoctorRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "last_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "specialty": {
+                    "type": "string",
+                    "example": "orthodontist"
+                }
+            }
+        },
+        "github_com_dentist_api_models.DoctorResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "specialty": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.DuplicateClient": {
+            "type": "object",
+            "properties": {
+                "birth_date": {
+                    "type": "string",
+                    "example": "1990-05-17"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "father_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.DuplicateMatch": {
+            "type": "object",
+            "properties": {
+                "birth_date_match": {
+                    "type": "boolean"
+                },
+                "client": {
+                    "$ref": "#/definitions/github_com_dentist_api_models.DuplicateClient"
+                },
+                "duplicate": {
+                    "$ref": "#/definitions/github_com_dentist_api_models.DuplicateClient"
+                },
+                "name_similarity": {
+                    "type": "number",
+                    "example": 0.72
+                },
+                "phone_match": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_dentist_api_models.Error": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/github_com_dentist_api_models.StandartError"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ExpiringBatch": {
+            "type": "object",
+            "properties": {
+                "expired": {
+                    "description": "Expired is set when the batch is past its date, it is not used up\nby procedures any more",
+                    "type": "boolean"
+                },
+                "expires_on": {
+                    "type": "string",
+                    "example": "2027-03-31"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "item_id": {
+                    "type": "string"
+                },
+                "item_name": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "location_name": {
+                    "type": "string"
+                },
+                "lot": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "received_at": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ExportedAppointment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "diagnostics": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "doctor_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "treatment": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ExportedCharges": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.Charge"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ExportedClient": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "birth_date": {
+                    "type": "string",
+                    "example": "1990-05-17"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "father_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.Exposure": {
+            "type": "object",
+            "properties": {
+                "appointment_date": {
+                    "type": "string"
+                },
+                "appointment_id": {
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "client_name": {
+                    "type": "string"
+                },
+                "pack_code": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "used_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.FamilyAppointment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "treatment": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.FamilyClient": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "birth_date": {
+                    "type": "string",
+                    "example": "2015-05-17"
+                },
+                "father_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.FamilyMember": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "age": {
+                    "description": "Age is missing when the birth date is unknown",
+                    "type": "integer"
+                },
+                "birth_date": {
+                    "type": "string",
+                    "example": "2015-05-17"
+                },
+                "father_name": {
+                    "type": "string"
+                },
+                "guarantor_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "minor": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.FamilyResponse": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "description": "Balance is the sum of the completed appointments of all members",
+                    "type": "integer"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.FamilyMember"
+                    }
+                },
+                "upcoming_appointments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.FamilyAppointment"
+                    }
+                }
+            }
+        },
+        "github_com_dentist_api_models.GuarantorRequest": {
+            "type": "object",
+            "properties": {
+                "guarantor_id": {
+                    "description": "GuarantorId gets the reminders and bills of the client, empty makes\nthe client answer for itself",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ImportProblem": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "row": {
+                    "description": "Row is the row number in the file, the header being row 1",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "error",
+                        "duplicate"
+                    ]
+                }
+            }
+        },
+        "github_com_dentist_api_models.ImportResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_clients": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "duplicates": {
+                    "type": "integer"
+                },
+                "error_report": {
+                    "type": "string",
+                    "example": "/v1/imports/0b8f.../errors"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "imported": {
+                    "description": "Imported are the rows saved, or that would be saved in a dry run",
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "clients",
+                        "appointments"
+                    ]
+                },
+                "problems": {
+                    "description": "Problems holds the first problems, ErrorReport all of them with the\noriginal cells",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.ImportProblem"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_api_models.InventoryAlerts": {
+            "type": "object",
+            "properties": {
+                "expiring": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.ExpiringBatch"
+                    }
+                },
+                "low_stock": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.InventoryItem"
+                    }
+                }
+            }
+        },
+        "github_com_dentist_api_models.InventoryBatch": {
+            "type": "object",
+            "properties": {
+                "expires_on": {
+                    "type": "string",
+                    "example": "2027-03-31"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "item_id": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "location_name": {
+                    "type": "string"
+                },
+                "lot": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "received_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.InventoryItem": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "low": {
+                    "description": "Low is set when Stock is below MinStock",
+                    "type": "boolean"
+                },
+                "min_stock": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "stock": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.InventoryItemRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "unit"
+            ],
+            "properties": {
+                "min_stock": {
+                    "description": "MinStock is the stock below which the item is reported as low",
+                    "type": "number",
+                    "example": 20
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Composite A2"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "unit": {
+                    "description": "Unit is what stock is counted in",
+                    "type": "string",
+                    "example": "g"
+                }
+            }
+        },
+        "github_com_dentist_api_models.InventoryItemStock": {
+            "type": "object",
+            "properties": {
+                "batches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.InventoryBatch"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.LocationStock"
+                    }
+                },
+                "low": {
+                    "description": "Low is set when Stock is below MinStock",
+                    "type": "boolean"
+                },
+                "min_stock": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "stock": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.LabCase": {
+            "type": "object",
+            "properties": {
+                "appointment_date": {
+                    "description": "AppointmentDate is the time of the fitting appointment",
+                    "type": "string"
+                },
+                "appointment_id": {
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "client_name": {
+                    "type": "string"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "days_overdue": {
+                    "description": "DaysOverdue is how many days past its due date a case not received\nis, 0 when it is not overdue",
+                    "type": "integer"
+                },
+                "due_on": {
+                    "type": "string",
+                    "example": "2026-11-02"
+                },
+                "fitted_on": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lab_id": {
+                    "type": "string"
+                },
+                "lab_name": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "received_on": {
+                    "type": "string"
+                },
+                "sent_on": {
+                    "type": "string",
+                    "example": "2026-10-19"
+                },
+                "shade": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "sent",
+                        "received",
+                        "fitted",
+                        "cancelled"
+                    ]
+                },
+                "teeth": {
+                    "description": "Teeth are in FDI notation",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "work_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.LabCaseDateRequest": {
+            "type": "object",
+            "properties": {
+                "on": {
+                    "description": "On defaults to today",
+                    "type": "string",
+                    "example": "2026-10-30"
+                }
+            }
+        },
+        "github_com_dentist_api_models.LabCaseRequest": {
+            "type": "object",
+            "required": [
+                "client_id",
+                "lab_id",
+                "work_type"
+            ],
+            "properties": {
+                "appointment_id": {
+                    "description": "AppointmentId is the fitting appointment, it cannot be confirmed\nuntil the case is received",
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "cost": {
+                    "type": "number",
+                    "example": 1200000
+                },
+                "due_on": {
+                    "type": "string",
+                    "example": "2026-11-02"
+                },
+                "lab_id": {
+                    "description": "LabId is the supplier making the work",
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "shade": {
+                    "type": "string",
+                    "example": "A2"
+                },
+                "teeth": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        11,
+                        12,
+                        13
+                    ]
+                },
+                "work_type": {
+                    "type": "string",
+                    "enum": [
+                        "crown",
+                        "bridge",
+                        "denture",
+                        "veneer",
+                        "inlay",
+                        "implant",
+                        "other"
+                    ]
+                }
+            }
+        },
+        "github_com_dentist_api_models.LabCaseSendRequest": {
+            "type": "object",
+            "properties": {
+                "due_on": {
+                    "description": "DueOn keeps the due date of the case when left empty",
+                    "type": "string",
+                    "example": "2026-11-02"
+                },
+                "sent_on": {
+                    "description": "SentOn defaults to today",
+                    "type": "string",
+                    "example": "2026-10-19"
+                }
+            }
+        },
+        "github_com_dentist_api_models.LocationStock": {
+            "type": "object",
+            "properties": {
+                "location_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_dentist_api_models.MergeRequest": {
+            "type": "object",
+            "required": [
+                "merged_id",
+                "survivor_id"
+            ],
+            "properties": {
+                "merged_id": {
+                    "type": "string"
+                },
+                "survivor_id": {
+                    "description": "SurvivorId keeps its id, MergedId is deleted after the merge",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.MergeResponse": {
+            "type": "object",
+            "properties": {
+                "filled_fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merged_at": {
+                    "type": "string"
+                },
+                "merged_id": {
+                    "type": "string"
+                },
+                "moved_appointments": {
+                    "type": "integer"
+                },
+                "moved_booking_requests": {
+                    "type": "integer"
+                },
+                "moved_lab_cases": {
+                    "type": "integer"
+                },
+                "moved_waitlist_entries": {
+                    "type": "integer"
+                },
+                "survivor_id": {
+                    "type": "string"
+                },
+                "undo_until": {
+                    "type": "string"
+                },
+                "undone_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.New": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "appointmentId": {
+                    "type": "string"
+                },
+                "clientId": {
+                    "type": "string"
+                },
+                "clientName": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "diagnostics": {
+                    "type": "string"
+                },
+                "phoneNumber": {
+                    "type": "string"
+                },
+                "treatment": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.Pack": {
+            "type": "object",
+            "properties": {
+                "appointment_id": {
+                    "description": "AppointmentId is the appointment the pack was opened at",
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "contents": {
+                    "type": "string"
+                },
+                "cycle_id": {
+                    "type": "string"
+                },
+                "cycle_result": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "passed",
+                        "failed"
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "used_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.PackRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code is the code on the label of the pack",
+                    "type": "string",
+                    "example": "P-000123"
+                },
+                "contents": {
+                    "type": "string",
+                    "example": "extraction kit"
+                }
+            }
+        },
+        "github_com_dentist_api_models.PackUseRequest": {
+            "type": "object",
+            "required": [
+                "appointment_id"
+            ],
+            "properties": {
+                "appointment_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ProcedureMaterial": {
+            "type": "object",
+            "properties": {
+                "item_id": {
+                    "type": "string"
+                },
+                "item_name": {
+                    "type": "string"
+                },
+                "procedure": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ProcedureMaterialRequest": {
+            "type": "object",
+            "required": [
+                "item_id",
+                "quantity"
+            ],
+            "properties": {
+                "item_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number",
+                    "example": 0.5
+                }
+            }
+        },
+        "github_com_dentist_api_models.PublicBooking": {
+            "type": "object",
+            "properties": {
+                "confirmation": {
+                    "description": "Confirmation tells who confirms a pending booking: the client with\nthe code sent to the phone, or staff",
+                    "type": "string",
+                    "enum": [
+                        "otp",
+                        "staff"
+                    ]
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "procedure": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "confirmed",
+                        "rejected",
+                        "expired"
+                    ]
+                }
+            }
+        },
+        "github_com_dentist_api_models.PublicBookingRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "phone_number",
+                "procedure",
+                "start"
+            ],
+            "properties": {
+                "doctor_id": {
+                    "description": "DoctorId is left empty to take any doctor free at Start",
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string",
+                    "example": "+998901234567"
+                },
+                "procedure": {
+                    "type": "string",
+                    "example": "cleaning"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "website": {
+                    "description": "Website must stay empty, it is a field people do not see",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.PublicWaitlistOffer": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "accepted",
+                        "lost",
+                        "withdrawn"
+                    ]
+                },
+                "treatment": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.PurchaseOrder": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expected_on": {
+                    "type": "string",
+                    "example": "2026-11-02"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.PurchaseOrderLine"
+                    }
+                },
+                "note": {
+                    "type": "string"
+                },
+                "ordered_at": {
+                    "type": "string"
+                },
+                "overdue": {
+                    "description": "Overdue is set when the delivery is expected before today and the\norder is not received yet",
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "ordered",
+                        "partially_received",
+                        "received",
+                        "cancelled"
+                    ]
+                },
+                "supplier_id": {
+                    "type": "string"
+                },
+                "supplier_name": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.PurchaseOrderLine": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "item_id": {
+                    "type": "string"
+                },
+                "item_name": {
+                    "type": "string"
+                },
+                "outstanding": {
+                    "description": "Outstanding is what is still to be delivered",
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "received": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "unit_price": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_dentist_api_models.PurchaseOrderLineRequest": {
+            "type": "object",
+            "required": [
+                "item_id",
+                "quantity"
+            ],
+            "properties": {
+                "item_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number",
+                    "example": 10
+                },
+                "unit_price": {
+                    "type": "number",
+                    "example": 45000
+                }
+            }
+        },
+        "github_com_dentist_api_models.PurchaseOrderRequest": {
+            "type": "object",
+            "required": [
+                "lines",
+                "supplier_id"
+            ],
+            "properties": {
+                "expected_on": {
+                    "description": "ExpectedOn is the day the delivery is expected, left empty when not\nknown",
+                    "type": "string",
+                    "example": "2026-11-02"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.PurchaseOrderLineRequest"
+                    }
+                },
+                "note": {
+                    "type": "string"
+                },
+                "supplier_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ReceiptLineRequest": {
+            "type": "object",
+            "required": [
+                "line_id",
+                "quantity"
+            ],
+            "properties": {
+                "expires_on": {
+                    "type": "string",
+                    "example": "2027-03-31"
+                },
+                "line_id": {
+                    "type": "string"
+                },
+                "lot": {
+                    "type": "string",
+                    "example": "L2611"
+                },
+                "quantity": {
+                    "type": "number",
+                    "example": 4
+                }
+            }
+        },
+        "github_com_dentist_api_models.ReceiptRequest": {
+            "type": "object",
+            "required": [
+                "lines",
+                "location_id"
+            ],
+            "properties": {
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.ReceiptLineRequest"
+                    }
+                },
+                "location_id": {
+                    "description": "LocationId is where the delivery is put into stock",
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.RelationRequest": {
+            "type": "object",
+            "required": [
+                "kind",
+                "related_id"
+            ],
+            "properties": {
+                "kind": {
+                    "description": "Kind is what the related client is to this one",
+                    "type": "string",
+                    "enum": [
+                        "guardian",
+                        "spouse",
+                        "sibling"
+                    ]
+                },
+                "related_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.RelationResponse": {
+            "type": "object",
+            "properties": {
+                "client": {
+                    "$ref": "#/definitions/github_com_dentist_api_models.FamilyClient"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "guardian",
+                        "spouse",
+                        "sibling",
+                        "ward"
+                    ]
+                }
+            }
+        },
+        "github_com_dentist_api_models.ReqAppointment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "clientId": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "diagnostics": {
+                    "type": "string"
+                },
+                "doctorId": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "treatment": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ReqClient": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "birthDate": {
+                    "type": "string",
+                    "example": "1990-05-17"
+                },
+                "fatherName": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phoneNumber": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.ReqNew": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "clientName": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "diagnostics": {
+                    "type": "string"
+                },
+                "phoneNumber": {
+                    "type": "string"
+                },
+                "treatment": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.RestoreClientResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "restored_appointments": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_api_models.StandartError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.StockMovement": {
+            "type": "object",
+            "properties": {
+                "appointment_id": {
+                    "type": "string"
+                },
+                "batch_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "item_id": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "description": "Quantity is negative when stock was taken out. A consumption\nwithout a batch is what stock did not cover.",
+                    "type": "number"
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "receipt",
+                        "consumption",
+                        "adjustment"
+                    ]
+                }
+            }
+        },
+        "github_com_dentist_api_models.StorageLocation": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.StorageLocationRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Fridge"
+                }
+            }
+        },
+        "github_com_dentist_api_models.Supplier": {
+            "type": "object",
+            "properties": {
+                "contact": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.SupplierPrice": {
+            "type": "object",
+            "properties": {
+                "item_id": {
+                    "type": "string"
+                },
+                "item_name": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "recorded_at": {
+                    "type": "string"
+                },
+                "unit_price": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_dentist_api_models.SupplierRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "contact": {
+                    "type": "string",
+                    "example": "Aziza"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "orders@supply.uz"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Dental Supply Co"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+998901234567"
+                }
+            }
+        },
+        "github_com_dentist_api_models.SupplierSpend": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "month": {
+                    "description": "Month is in the clinic time zone",
+                    "type": "string",
+                    "example": "2026-10"
+                },
+                "orders": {
+                    "description": "Orders is how many orders had deliveries in the month",
+                    "type": "integer"
+                },
+                "supplier_id": {
+                    "type": "string"
+                },
+                "supplier_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.TrashSummary": {
+            "type": "object",
+            "properties": {
+                "anonymize": {
+                    "type": "boolean"
+                },
+                "appointments": {
+                    "type": "integer"
+                },
+                "clients": {
+                    "type": "integer"
+                },
+                "retention_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_api_models.WaitlistBooking": {
+            "type": "object",
+            "properties": {
+                "appointment_id": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.WaitlistEntry": {
+            "type": "object",
+            "properties": {
+                "appointment_id": {
+                    "type": "string"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date_from": {
+                    "type": "string",
+                    "example": "2026-11-02"
+                },
+                "date_to": {
+                    "type": "string",
+                    "example": "2026-11-20"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "waiting",
+                        "booked",
+                        "cancelled"
+                    ]
+                },
+                "treatment": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_dentist_api_models.WaitlistOffer": {
+            "type": "object",
+            "properties": {
+                "accepted_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "doctor_id": {
+                    "type": "string"
+                },
+                "entry_id": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "notified_at": {
+                    "type": "string"
+                },
+                "rank": {
+                    "description": "Rank is the place of the entry among those offered the slot",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "accepted",
+                        "lost",
+                        "withdrawn"
+                    ]
+                }
+            }
+        },
+        "github_com_dentist_api_models.WaitlistRequest": {
+            "type": "object",
+            "required": [
+                "client_id",
+                "date_from",
+                "date_to"
+            ],
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "date_from": {
+                    "description": "DateFrom and DateTo are the days the client can come, both included",
+                    "type": "string",
+                    "example": "2026-11-02"
+                },
+                "date_to": {
+                    "type": "string",
+                    "example": "2026-11-20"
+                },
+                "doctor_id": {
+                    "description": "DoctorId and Treatment are what the client asks for, empty takes any",
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "priority": {
+                    "description": "Priority puts the entry before those with lower ones",
+                    "type": "integer"
+                },
+                "treatment": {
+                    "type": "string",
+                    "example": "Filling"
+                }
+            }
+        },
+        "github_com_dentist_api_models.Webhook": {
             "type": "object",
             "properties": {
-                "appointments": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "events": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Appointment"
+                        "type": "string"
                     }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         },
-        "models.AllClients": {
+        "github_com_dentist_api_models.WebhookCreated": {
             "type": "object",
             "properties": {
-                "clients": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "events": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Client"
+                        "type": "string"
                     }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         },
-        "models.Appointment": {
+        "github_com_dentist_api_models.WebhookDelivery": {
             "type": "object",
             "properties": {
-                "amount": {
+                "attempts": {
+                    "description": "Attempts counts the requests made, NextAttemptAt is when a pending\none is tried again",
                     "type": "integer"
                 },
-                "clientId": {
+                "created_at": {
                     "type": "string"
                 },
-                "date": {
+                "delivered_at": {
                     "type": "string"
                 },
-                "diagnostics": {
+                "event": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "description": "EventId is the same for every delivery and replay of an event",
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "treatment": {
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "replay_of": {
+                    "type": "string"
+                },
+                "response_status": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "delivered",
+                        "failed"
+                    ]
+                },
+                "webhook_id": {
                     "type": "string"
                 }
             }
         },
-        "models.Client": {
+        "github_com_dentist_api_models.WebhookRequest": {
             "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
             "properties": {
-                "address": {
+                "description": {
                     "type": "string"
                 },
-                "allAppointments": {
+                "events": {
+                    "description": "Events are the event types to send, * for all of them",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/repo.Appointment"
-                    }
+                        "type": "string"
+                    },
+                    "example": [
+                        "appointment.created",
+                        "appointment.cancelled"
+                    ]
                 },
-                "birthDate": {
+                "secret": {
+                    "description": "Secret signs the payloads, one is generated when empty",
                     "type": "string"
                 },
-                "fatherName": {
-                    "type": "string"
+                "url": {
+                    "description": "Url gets a POST of every event, it must answer 2xx",
+                    "type": "string",
+                    "example": "https://crm.example/hooks/dentist"
+                }
+            }
+        },
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_AppointmentResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.AppointmentResponse"
+                    }
                 },
-                "id": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "lastName": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_BookingRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.BookingRequest"
+                    }
                 },
-                "name": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "phoneNumber": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "models.Error": {
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_ClientResponse": {
             "type": "object",
             "properties": {
-                "error": {
-                    "$ref": "#/definitions/models.StandartError"
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.ClientResponse"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "models.New": {
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_Cycle": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "integer"
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.Cycle"
+                    }
                 },
-                "appointmentId": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "clientId": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_DeletedAppointmentResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.DeletedAppointmentResponse"
+                    }
                 },
-                "clientName": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "date": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_DeletedClientResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.DeletedClientResponse"
+                    }
                 },
-                "diagnostics": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "phoneNumber": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_DoctorResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.DoctorResponse"
+                    }
                 },
-                "treatment": {
+                "next_cursor": {
                     "type": "string"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "models.ReqAppointment": {
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_DuplicateMatch": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "integer"
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.DuplicateMatch"
+                    }
                 },
-                "clientId": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "date": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_InventoryItem": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.InventoryItem"
+                    }
                 },
-                "diagnostics": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "treatment": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "models.ReqClient": {
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_LabCase": {
             "type": "object",
             "properties": {
-                "address": {
-                    "type": "string"
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.LabCase"
+                    }
                 },
-                "birthDate": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "fatherName": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_PurchaseOrder": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.PurchaseOrder"
+                    }
                 },
-                "lastName": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "name": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_StockMovement": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.StockMovement"
+                    }
                 },
-                "phoneNumber": {
+                "next_cursor": {
                     "type": "string"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "models.ReqNew": {
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_Supplier": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "integer"
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.Supplier"
+                    }
                 },
-                "clientName": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "date": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_WaitlistEntry": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.WaitlistEntry"
+                    }
                 },
-                "diagnostics": {
+                "next_cursor": {
                     "type": "string"
                 },
-                "phoneNumber": {
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_api_models_WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_api_models.WebhookDelivery"
+                    }
+                },
+                "next_cursor": {
                     "type": "string"
                 },
-                "treatment": {
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_storage_repo_Appointment": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_storage_repo.Appointment"
+                    }
+                },
+                "next_cursor": {
                     "type": "string"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "models.StandartError": {
+        "github_com_dentist_pkg_pagination.Page-github_com_dentist_storage_repo_Client": {
             "type": "object",
             "properties": {
-                "error": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_dentist_storage_repo.Client"
+                    }
+                },
+                "next_cursor": {
                     "type": "string"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "repo.Appointment": {
+        "github_com_dentist_storage_repo.Appointment": {
             "type": "object",
             "properties": {
                 "amount": {
@@ -969,13 +9794,48 @@ const docTemplate = `{
                 "diagnostics": {
                     "type": "string"
                 },
+                "doctorId": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
+                "status": {
+                    "type": "string"
+                },
                 "treatment": {
                     "type": "string"
                 }
             }
+        },
+        "github_com_dentist_storage_repo.Client": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "birthDate": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "fatherName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phoneNumber": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dentist/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout keeps a hanging database from stalling the probe
+// longer than the orchestrator is willing to wait for it.
+const readinessTimeout = 2 * time.Second
+
+type healthHandler struct {
+	storage storage.StorageI
+}
+
+// Healthz ...
+// @Summary Healthz
+// @Description Liveness probe, answers as long as the process serves http
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (h *healthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+// Readyz ...
+// @Summary Readyz
+// @Description Readiness probe, checks that postgres is reachable
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /readyz [get]
+func (h *healthHandler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := h.storage.Ping(ctx); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":   "unavailable",
+			"postgres": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "ok",
+		"postgres": "ok",
+	})
+}
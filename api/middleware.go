@@ -0,0 +1,116 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dentist/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+
+	// userContextKey is where authentication puts the name of the caller,
+	// the access log reports it when present.
+	userContextKey = "user"
+)
+
+// validRequestID limits ids accepted from the caller, so a proxy can pass
+// its own id through but nobody can inject arbitrary text into the logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestID reuses the caller's X-Request-ID or assigns a new one, echoes
+// it in the response and stores it in the request context for the logger.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(logger.ContextWithRequestID(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
+
+// accessLog writes one line per request. The query string is left out on
+// purpose: search terms and ids of patients travel there.
+func accessLog(log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		// a token in the path is a secret link, such as a calendar feed
+		path := c.Request.URL.Path
+		if token := c.Param("token"); token != "" {
+			path = strings.Replace(path, token, "***", 1)
+		}
+
+		fields := []logger.Field{
+			logger.String("method", c.Request.Method),
+			logger.String("path", path),
+			logger.Int("status", c.Writer.Status()),
+			logger.String("latency", time.Since(start).String()),
+			logger.String("client_ip", c.ClientIP()),
+			logger.String("user", c.GetString(userContextKey)),
+		}
+		if len(c.Errors) > 0 {
+			fields = append(fields, logger.String("errors", c.Errors.String()))
+		}
+
+		l := logger.FromContext(c.Request.Context(), log)
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			l.Error("request", fields...)
+		case status >= 400:
+			l.Warn("request", fields...)
+		default:
+			l.Info("request", fields...)
+		}
+	}
+}
+
+// boardAuth lets in the holders of a board token, given as a bearer token
+// or, since browsers cannot set headers on an EventSource, as the
+// access_token query parameter. tokens are name:token pairs separated by
+// commas.
+func boardAuth(tokens string) gin.HandlerFunc {
+	type credential struct {
+		name  string
+		token []byte
+	}
+	var credentials []credential
+	for _, pair := range strings.Split(tokens, ",") {
+		name, token, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && name != "" && token != "" {
+			credentials = append(credentials, credential{name: name, token: []byte(token)})
+		}
+	}
+
+	return func(c *gin.Context) {
+		token := c.Query("access_token")
+		if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			token = bearer
+		}
+
+		for _, cred := range credentials {
+			if token != "" && subtle.ConstantTimeCompare([]byte(token), cred.token) == 1 {
+				c.Set(userContextKey, cred.name)
+				c.Next()
+				return
+			}
+		}
+		c.Header("WWW-Authenticate", `Bearer realm="board"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "a valid board token is required",
+		})
+	}
+}
@@ -1,20 +1,26 @@
 package models
 
+import "time"
+
 type Appointment struct {
 	Id string
 	ClientId string
-	Date string
+	Date time.Time
 	Diagnostics string
 	Treatment string
 	Amount int
+	Status string
+	DoctorId string
 }
 
 type ReqAppointment struct {
 	ClientId string
-	Date string
+	Date time.Time
 	Diagnostics string
 	Treatment string
 	Amount int
+	Status string
+	DoctorId string
 }
 
 type New struct {
@@ -22,7 +28,7 @@ type New struct {
 	PhoneNumber string
 	ClientId string
 	AppointmentId string
-	Date string
+	Date time.Time
 	Diagnostics string
 	Treatment string
 	Amount int
@@ -31,12 +37,8 @@ type New struct {
 type ReqNew struct {
 	ClientName string
 	PhoneNumber string
-	Date string
+	Date time.Time
 	Diagnostics string
 	Treatment string
 	Amount int
 }
-
-type AllAppointments struct {
-	Appointments []*Appointment
-}
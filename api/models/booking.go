@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dentist/pkg/civil"
+)
+
+type BookingProcedure struct {
+	Name            string `json:"name" example:"cleaning"`
+	DurationMinutes int    `json:"duration_minutes" example:"60"`
+}
+
+type BookingDoctor struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	LastName  string `json:"last_name"`
+	Specialty string `json:"specialty"`
+}
+
+type BookingSlot struct {
+	Start time.Time `json:"start"`
+	// DoctorIds are the doctors free at Start
+	DoctorIds []string `json:"doctor_ids"`
+}
+
+type BookingSlots struct {
+	Procedure BookingProcedure `json:"procedure"`
+	Date      civil.Date       `json:"date" swaggertype:"string" example:"2026-11-02"`
+	Doctors   []BookingDoctor  `json:"doctors"`
+	Slots     []BookingSlot    `json:"slots"`
+}
+
+type PublicBookingRequest struct {
+	Procedure string    `json:"procedure" binding:"required" example:"cleaning"`
+	Start     time.Time `json:"start" binding:"required"`
+	// DoctorId is left empty to take any doctor free at Start
+	DoctorId    string `json:"doctor_id"`
+	Name        string `json:"name" binding:"required"`
+	LastName    string `json:"last_name"`
+	PhoneNumber string `json:"phone_number" binding:"required" example:"+998901234567"`
+	// Website must stay empty, it is a field people do not see
+	Website string `json:"website"`
+}
+
+// PublicBooking is what the client is told about a booking
+type PublicBooking struct {
+	Id        string    `json:"id"`
+	Procedure string    `json:"procedure"`
+	Start     time.Time `json:"start"`
+	DoctorId  string    `json:"doctor_id"`
+	Status    string    `json:"status" enums:"pending,confirmed,rejected,expired"`
+	// Confirmation tells who confirms a pending booking: the client with
+	// the code sent to the phone, or staff
+	Confirmation string    `json:"confirmation" enums:"otp,staff"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type BookingConfirmRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type BookingRequest struct {
+	Id              string     `json:"id"`
+	AppointmentId   string     `json:"appointment_id"`
+	ClientId        string     `json:"client_id"`
+	Procedure       string     `json:"procedure"`
+	DurationMinutes int        `json:"duration_minutes"`
+	DoctorId        string     `json:"doctor_id"`
+	Date            time.Time  `json:"date"`
+	ClientIp        string     `json:"client_ip"`
+	Attempts        int        `json:"attempts"`
+	Status          string     `json:"status" enums:"pending,confirmed,rejected,expired"`
+	ConfirmedBy     string     `json:"confirmed_by,omitempty" enums:"code,staff"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type CalendarFeedRequest struct {
+	// DoctorId limits the feed to one doctor, empty is the whole clinic
+	DoctorId string `json:"doctor_id"`
+	Name     string `json:"name" example:"Dr. Karimov"`
+	// MaskPatients leaves only the initials of patients and drops the
+	// diagnostics, for calendars shared outside the clinic
+	MaskPatients bool `json:"mask_patients"`
+}
+
+type CalendarFeed struct {
+	Id           string     `json:"id"`
+	DoctorId     string     `json:"doctor_id,omitempty"`
+	Name         string     `json:"name"`
+	MaskPatients bool       `json:"mask_patients"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CalendarFeedCreated holds the secret links of a new feed, they are not
+// shown again
+type CalendarFeedCreated struct {
+	CalendarFeed
+	// IcsUrl is subscribed to as an internet calendar
+	IcsUrl string `json:"ics_url" example:"https://clinic.example/calendar/3q2-7w.ics"`
+	// CaldavUrl is added as a read-only CalDAV account
+	CaldavUrl string `json:"caldav_url" example:"https://clinic.example/caldav/3q2-7w/"`
+}
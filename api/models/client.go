@@ -1,6 +1,10 @@
 package models
 
-import "github.com/dentist/storage/repo"
+import (
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+)
 
 type Client struct {
 	Id          string
@@ -9,12 +13,8 @@ type Client struct {
 	FatherName  string
 	PhoneNumber string
 	Address     string
-	BirthDate   string
-	AllAppointments []repo.Appointment
-}
-
-type AllClients struct {
-	Clients []*Client
+	BirthDate   civil.Date `swaggertype:"string" example:"1990-05-17"`
+	AllAppointments pagination.Page[*repo.Appointment]
 }
 
 type ReqClient struct {
@@ -23,5 +23,5 @@ type ReqClient struct {
 	FatherName  string
 	PhoneNumber string
 	Address     string
-	BirthDate   string
+	BirthDate   civil.Date `swaggertype:"string" example:"1990-05-17"`
 }
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dentist/pkg/civil"
+)
+
+// ClientExport is the copy of everything stored about a client
+type ClientExport struct {
+	ExportedAt   time.Time             `json:"exported_at"`
+	Client       ExportedClient        `json:"client"`
+	Appointments []ExportedAppointment `json:"appointments"`
+	Charges      ExportedCharges       `json:"charges"`
+}
+
+type ExportedClient struct {
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	LastName    string     `json:"last_name"`
+	FatherName  string     `json:"father_name"`
+	PhoneNumber string     `json:"phone_number"`
+	Address     string     `json:"address"`
+	BirthDate   civil.Date `json:"birth_date" swaggertype:"string" example:"1990-05-17"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+type ExportedAppointment struct {
+	Id          string     `json:"id"`
+	DoctorId    string     `json:"doctor_id"`
+	DoctorName  string     `json:"doctor_name"`
+	Date        time.Time  `json:"date"`
+	Diagnostics string     `json:"diagnostics"`
+	Treatment   string     `json:"treatment"`
+	Amount      int        `json:"amount"`
+	Status      string     `json:"status"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ExportedCharges lists the amounts of the appointments that are not
+// deleted
+type ExportedCharges struct {
+	Total int      `json:"total"`
+	Items []Charge `json:"items"`
+}
+
+type Charge struct {
+	AppointmentId string    `json:"appointment_id"`
+	Date          time.Time `json:"date"`
+	Amount        int       `json:"amount"`
+}
+
+// AnonymizeRequest must confirm the erasure, it cannot be undone
+type AnonymizeRequest struct {
+	Confirm bool `json:"confirm"`
+}
+
+type AnonymizeResponse struct {
+	Id           string    `json:"id"`
+	AnonymizedAt time.Time `json:"anonymized_at"`
+}
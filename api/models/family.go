@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dentist/pkg/civil"
+)
+
+type RelationRequest struct {
+	RelatedId string `json:"related_id" binding:"required"`
+	// Kind is what the related client is to this one
+	Kind string `json:"kind" binding:"required" enums:"guardian,spouse,sibling"`
+}
+
+type FamilyClient struct {
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	LastName    string     `json:"last_name"`
+	FatherName  string     `json:"father_name"`
+	PhoneNumber string     `json:"phone_number"`
+	Address     string     `json:"address"`
+	BirthDate   civil.Date `json:"birth_date" swaggertype:"string" example:"2015-05-17"`
+}
+
+// RelationResponse says the client is the kind of the one it was listed
+// for, ward is the other side of guardian
+type RelationResponse struct {
+	Kind      string       `json:"kind" enums:"guardian,spouse,sibling,ward"`
+	Client    FamilyClient `json:"client"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type GuarantorRequest struct {
+	// GuarantorId gets the reminders and bills of the client, empty makes
+	// the client answer for itself
+	GuarantorId string `json:"guarantor_id"`
+}
+
+type FamilyMember struct {
+	FamilyClient
+	// Age is missing when the birth date is unknown
+	Age         *int   `json:"age,omitempty"`
+	Minor       bool   `json:"minor"`
+	GuarantorId string `json:"guarantor_id,omitempty"`
+}
+
+type FamilyAppointment struct {
+	Id        string    `json:"id"`
+	ClientId  string    `json:"client_id"`
+	DoctorId  string    `json:"doctor_id,omitempty"`
+	Date      time.Time `json:"date"`
+	Treatment string    `json:"treatment"`
+	Amount    int       `json:"amount"`
+	Status    string    `json:"status"`
+}
+
+type FamilyResponse struct {
+	Members              []FamilyMember      `json:"members"`
+	UpcomingAppointments []FamilyAppointment `json:"upcoming_appointments"`
+	// Balance is the sum of the completed appointments of all members
+	Balance int `json:"balance"`
+}
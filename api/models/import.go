@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type ImportProblem struct {
+	// Row is the row number in the file, the header being row 1
+	Row    int      `json:"row"`
+	Status string   `json:"status" enums:"error,duplicate"`
+	Errors []string `json:"errors"`
+}
+
+type ImportResponse struct {
+	Id       string `json:"id"`
+	Kind     string `json:"kind" enums:"clients,appointments"`
+	FileName string `json:"file_name"`
+	DryRun   bool   `json:"dry_run"`
+	Total    int    `json:"total"`
+	// Imported are the rows saved, or that would be saved in a dry run
+	Imported       int       `json:"imported"`
+	CreatedClients int       `json:"created_clients"`
+	Duplicates     int       `json:"duplicates"`
+	Failed         int       `json:"failed"`
+	CreatedAt      time.Time `json:"created_at"`
+	// Problems holds the first problems, ErrorReport all of them with the
+	// original cells
+	Problems    []ImportProblem `json:"problems"`
+	ErrorReport string          `json:"error_report,omitempty" example:"/v1/imports/0b8f.../errors"`
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dentist/pkg/civil"
+)
+
+type InventoryItemRequest struct {
+	Name string `json:"name" binding:"required" example:"Composite A2"`
+	// Unit is what stock is counted in
+	Unit string `json:"unit" binding:"required" example:"g"`
+	Sku  string `json:"sku"`
+	// MinStock is the stock below which the item is reported as low
+	MinStock float64 `json:"min_stock" example:"20"`
+}
+
+type InventoryItem struct {
+	Id       string  `json:"id"`
+	Name     string  `json:"name"`
+	Unit     string  `json:"unit"`
+	Sku      string  `json:"sku"`
+	MinStock float64 `json:"min_stock"`
+	Stock    float64 `json:"stock"`
+	// Low is set when Stock is below MinStock
+	Low       bool      `json:"low"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// InventoryItemStock is an item with the batches and locations its stock
+// is kept in
+type InventoryItemStock struct {
+	InventoryItem
+	Locations []LocationStock  `json:"locations"`
+	Batches   []InventoryBatch `json:"batches"`
+}
+
+type LocationStock struct {
+	LocationId string  `json:"location_id"`
+	Name       string  `json:"name"`
+	Quantity   float64 `json:"quantity"`
+}
+
+type StorageLocationRequest struct {
+	Name string `json:"name" binding:"required" example:"Fridge"`
+}
+
+type StorageLocation struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BatchRequest struct {
+	LocationId string `json:"location_id" binding:"required"`
+	Lot        string `json:"lot" example:"L2611"`
+	// ExpiresOn is left empty for a batch that does not expire
+	ExpiresOn civil.Date `json:"expires_on" swaggertype:"string" example:"2027-03-31"`
+	Quantity  float64    `json:"quantity" binding:"required" example:"100"`
+	Note      string     `json:"note"`
+}
+
+type InventoryBatch struct {
+	Id           string     `json:"id"`
+	ItemId       string     `json:"item_id"`
+	LocationId   string     `json:"location_id"`
+	LocationName string     `json:"location_name"`
+	Lot          string     `json:"lot"`
+	ExpiresOn    civil.Date `json:"expires_on" swaggertype:"string" example:"2027-03-31"`
+	Quantity     float64    `json:"quantity"`
+	ReceivedAt   time.Time  `json:"received_at"`
+}
+
+type AdjustmentRequest struct {
+	// Delta is added to the batch, negative to take out such as for
+	// waste or a count that came short
+	Delta float64 `json:"delta" binding:"required" example:"-2.5"`
+	Note  string  `json:"note" binding:"required" example:"dropped"`
+}
+
+type ProcedureMaterialRequest struct {
+	ItemId   string  `json:"item_id" binding:"required"`
+	Quantity float64 `json:"quantity" binding:"required" example:"0.5"`
+}
+
+type ProcedureMaterial struct {
+	Procedure string  `json:"procedure"`
+	ItemId    string  `json:"item_id"`
+	ItemName  string  `json:"item_name"`
+	Unit      string  `json:"unit"`
+	Quantity  float64 `json:"quantity"`
+}
+
+type StockMovement struct {
+	Id         string `json:"id"`
+	ItemId     string `json:"item_id"`
+	BatchId    string `json:"batch_id,omitempty"`
+	LocationId string `json:"location_id,omitempty"`
+	// Quantity is negative when stock was taken out. A consumption
+	// without a batch is what stock did not cover.
+	Quantity      float64   `json:"quantity"`
+	Reason        string    `json:"reason" enums:"receipt,consumption,adjustment"`
+	AppointmentId string    `json:"appointment_id,omitempty"`
+	Note          string    `json:"note"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ExpiringBatch struct {
+	InventoryBatch
+	ItemName string `json:"item_name"`
+	Unit     string `json:"unit"`
+	// Expired is set when the batch is past its date, it is not used up
+	// by procedures any more
+	Expired bool `json:"expired"`
+}
+
+type InventoryAlerts struct {
+	LowStock []InventoryItem `json:"low_stock"`
+	Expiring []ExpiringBatch `json:"expiring"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dentist/pkg/civil"
+)
+
+type LabCaseRequest struct {
+	ClientId string `json:"client_id" binding:"required"`
+	// AppointmentId is the fitting appointment, it cannot be confirmed
+	// until the case is received
+	AppointmentId string `json:"appointment_id"`
+	// LabId is the supplier making the work
+	LabId    string     `json:"lab_id" binding:"required"`
+	WorkType string     `json:"work_type" binding:"required" enums:"crown,bridge,denture,veneer,inlay,implant,other"`
+	Shade    string     `json:"shade" example:"A2"`
+	Teeth    []int      `json:"teeth" example:"11,12,13"`
+	DueOn    civil.Date `json:"due_on" swaggertype:"string" example:"2026-11-02"`
+	Cost     float64    `json:"cost" example:"1200000"`
+	Note     string     `json:"note"`
+}
+
+type LabCase struct {
+	Id            string `json:"id"`
+	ClientId      string `json:"client_id"`
+	ClientName    string `json:"client_name"`
+	AppointmentId string `json:"appointment_id,omitempty"`
+	// AppointmentDate is the time of the fitting appointment
+	AppointmentDate *time.Time `json:"appointment_date,omitempty"`
+	LabId           string     `json:"lab_id"`
+	LabName         string     `json:"lab_name"`
+	WorkType        string     `json:"work_type"`
+	Shade           string     `json:"shade"`
+	// Teeth are in FDI notation
+	Teeth      []int      `json:"teeth"`
+	Status     string     `json:"status" enums:"created,sent,received,fitted,cancelled"`
+	SentOn     civil.Date `json:"sent_on" swaggertype:"string" example:"2026-10-19"`
+	DueOn      civil.Date `json:"due_on" swaggertype:"string" example:"2026-11-02"`
+	ReceivedOn civil.Date `json:"received_on" swaggertype:"string"`
+	FittedOn   civil.Date `json:"fitted_on" swaggertype:"string"`
+	Cost       float64    `json:"cost"`
+	Note       string     `json:"note"`
+	// DaysOverdue is how many days past its due date a case not received
+	// is, 0 when it is not overdue
+	DaysOverdue int       `json:"days_overdue"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type LabCaseSendRequest struct {
+	// SentOn defaults to today
+	SentOn civil.Date `json:"sent_on" swaggertype:"string" example:"2026-10-19"`
+	// DueOn keeps the due date of the case when left empty
+	DueOn civil.Date `json:"due_on" swaggertype:"string" example:"2026-11-02"`
+}
+
+type LabCaseDateRequest struct {
+	// On defaults to today
+	On civil.Date `json:"on" swaggertype:"string" example:"2026-10-30"`
+}
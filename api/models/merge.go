@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dentist/pkg/civil"
+)
+
+type DuplicateClient struct {
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	LastName    string     `json:"last_name"`
+	FatherName  string     `json:"father_name"`
+	PhoneNumber string     `json:"phone_number"`
+	BirthDate   civil.Date `json:"birth_date" swaggertype:"string" example:"1990-05-17"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// DuplicateMatch says why two clients look like the same person
+type DuplicateMatch struct {
+	Client         DuplicateClient `json:"client"`
+	Duplicate      DuplicateClient `json:"duplicate"`
+	PhoneMatch     bool            `json:"phone_match"`
+	BirthDateMatch bool            `json:"birth_date_match"`
+	NameSimilarity float64         `json:"name_similarity" example:"0.72"`
+}
+
+type MergeRequest struct {
+	// SurvivorId keeps its id, MergedId is deleted after the merge
+	SurvivorId string `json:"survivor_id" binding:"required"`
+	MergedId   string `json:"merged_id" binding:"required"`
+}
+
+type MergeResponse struct {
+	Id                   string     `json:"id"`
+	SurvivorId           string     `json:"survivor_id"`
+	MergedId             string     `json:"merged_id"`
+	MovedAppointments    int        `json:"moved_appointments"`
+	MovedLabCases        int        `json:"moved_lab_cases"`
+	MovedWaitlistEntries int        `json:"moved_waitlist_entries"`
+	MovedBookingRequests int        `json:"moved_booking_requests"`
+	FilledFields         []string   `json:"filled_fields"`
+	MergedAt             time.Time  `json:"merged_at"`
+	UndoUntil            time.Time  `json:"undo_until"`
+	UndoneAt             *time.Time `json:"undone_at,omitempty"`
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dentist/pkg/civil"
+)
+
+type SupplierRequest struct {
+	Name    string `json:"name" binding:"required" example:"Dental Supply Co"`
+	Contact string `json:"contact" example:"Aziza"`
+	Phone   string `json:"phone" example:"+998901234567"`
+	Email   string `json:"email" example:"orders@supply.uz"`
+	Note    string `json:"note"`
+}
+
+type Supplier struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Contact   string    `json:"contact"`
+	Phone     string    `json:"phone"`
+	Email     string    `json:"email"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type PurchaseOrderRequest struct {
+	SupplierId string `json:"supplier_id" binding:"required"`
+	// ExpectedOn is the day the delivery is expected, left empty when not
+	// known
+	ExpectedOn civil.Date                 `json:"expected_on" swaggertype:"string" example:"2026-11-02"`
+	Note       string                     `json:"note"`
+	Lines      []PurchaseOrderLineRequest `json:"lines" binding:"required"`
+}
+
+type PurchaseOrderLineRequest struct {
+	ItemId    string  `json:"item_id" binding:"required"`
+	Quantity  float64 `json:"quantity" binding:"required" example:"10"`
+	UnitPrice float64 `json:"unit_price" example:"45000"`
+}
+
+type PurchaseOrder struct {
+	Id           string     `json:"id"`
+	SupplierId   string     `json:"supplier_id"`
+	SupplierName string     `json:"supplier_name"`
+	Status       string     `json:"status" enums:"draft,ordered,partially_received,received,cancelled"`
+	ExpectedOn   civil.Date `json:"expected_on" swaggertype:"string" example:"2026-11-02"`
+	// Overdue is set when the delivery is expected before today and the
+	// order is not received yet
+	Overdue   bool                `json:"overdue"`
+	Note      string              `json:"note"`
+	Total     float64             `json:"total"`
+	Lines     []PurchaseOrderLine `json:"lines,omitempty"`
+	OrderedAt *time.Time          `json:"ordered_at"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+type PurchaseOrderLine struct {
+	Id        string  `json:"id"`
+	ItemId    string  `json:"item_id"`
+	ItemName  string  `json:"item_name"`
+	Unit      string  `json:"unit"`
+	Quantity  float64 `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Received  float64 `json:"received"`
+	// Outstanding is what is still to be delivered
+	Outstanding float64 `json:"outstanding"`
+}
+
+type ReceiptRequest struct {
+	// LocationId is where the delivery is put into stock
+	LocationId string               `json:"location_id" binding:"required"`
+	Note       string               `json:"note"`
+	Lines      []ReceiptLineRequest `json:"lines" binding:"required"`
+}
+
+type ReceiptLineRequest struct {
+	LineId    string     `json:"line_id" binding:"required"`
+	Quantity  float64    `json:"quantity" binding:"required" example:"4"`
+	Lot       string     `json:"lot" example:"L2611"`
+	ExpiresOn civil.Date `json:"expires_on" swaggertype:"string" example:"2027-03-31"`
+}
+
+type SupplierPrice struct {
+	ItemId     string    `json:"item_id"`
+	ItemName   string    `json:"item_name"`
+	UnitPrice  float64   `json:"unit_price"`
+	OrderId    string    `json:"order_id,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+type SupplierSpend struct {
+	SupplierId   string `json:"supplier_id"`
+	SupplierName string `json:"supplier_name"`
+	// Month is in the clinic time zone
+	Month  string  `json:"month" example:"2026-10"`
+	Amount float64 `json:"amount"`
+	// Orders is how many orders had deliveries in the month
+	Orders int `json:"orders"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+type AutoclaveRequest struct {
+	Name         string `json:"name" binding:"required" example:"Autoclave 1"`
+	SerialNumber string `json:"serial_number"`
+}
+
+type Autoclave struct {
+	Id           string    `json:"id"`
+	Name         string    `json:"name"`
+	SerialNumber string    `json:"serial_number"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type CycleRequest struct {
+	AutoclaveId string `json:"autoclave_id" binding:"required"`
+	// CycleNumber is the counter the autoclave prints for the run
+	CycleNumber  int       `json:"cycle_number" binding:"required" example:"1024"`
+	Program      string    `json:"program" example:"134C wrapped"`
+	StartedAt    time.Time `json:"started_at" binding:"required"`
+	TemperatureC float64   `json:"temperature_c" binding:"required" example:"134"`
+	PressureKpa  float64   `json:"pressure_kpa" binding:"required" example:"216"`
+	HoldMinutes  int       `json:"hold_minutes" binding:"required" example:"5"`
+	Operator     string    `json:"operator"`
+	Note         string    `json:"note"`
+	// Packs are the instrument packs processed in the cycle
+	Packs []PackRequest `json:"packs"`
+}
+
+type PackRequest struct {
+	// Code is the code on the label of the pack
+	Code     string `json:"code" binding:"required" example:"P-000123"`
+	Contents string `json:"contents" example:"extraction kit"`
+}
+
+type Cycle struct {
+	Id            string    `json:"id"`
+	AutoclaveId   string    `json:"autoclave_id"`
+	AutoclaveName string    `json:"autoclave_name"`
+	CycleNumber   int       `json:"cycle_number"`
+	Program       string    `json:"program"`
+	StartedAt     time.Time `json:"started_at"`
+	TemperatureC  float64   `json:"temperature_c"`
+	PressureKpa   float64   `json:"pressure_kpa"`
+	HoldMinutes   int       `json:"hold_minutes"`
+	Operator      string    `json:"operator"`
+	Note          string    `json:"note"`
+	Result        string    `json:"result" enums:"pending,passed,failed"`
+	// Indicator is the indicator the result was read from
+	Indicator  string     `json:"indicator" enums:"chemical,biological"`
+	ResultNote string     `json:"result_note"`
+	ResultAt   *time.Time `json:"result_at,omitempty"`
+	// Packs are left out of lists
+	Packs     []Pack    `json:"packs,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CycleResultRequest struct {
+	Passed    *bool  `json:"passed" binding:"required"`
+	Indicator string `json:"indicator" binding:"required" enums:"chemical,biological"`
+	Note      string `json:"note"`
+}
+
+type Pack struct {
+	Id          string `json:"id"`
+	Code        string `json:"code"`
+	CycleId     string `json:"cycle_id"`
+	CycleResult string `json:"cycle_result" enums:"pending,passed,failed"`
+	Contents    string `json:"contents"`
+	// AppointmentId is the appointment the pack was opened at
+	AppointmentId string     `json:"appointment_id,omitempty"`
+	UsedAt        *time.Time `json:"used_at,omitempty"`
+}
+
+type PackUseRequest struct {
+	AppointmentId string `json:"appointment_id" binding:"required"`
+}
+
+// Exposure is an appointment a pack of a cycle was used at
+type Exposure struct {
+	AppointmentId   string    `json:"appointment_id"`
+	AppointmentDate time.Time `json:"appointment_date"`
+	ClientId        string    `json:"client_id"`
+	ClientName      string    `json:"client_name"`
+	PhoneNumber     string    `json:"phone_number"`
+	PackCode        string    `json:"pack_code"`
+	UsedAt          time.Time `json:"used_at"`
+}
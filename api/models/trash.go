@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dentist/pkg/civil"
+)
+
+// TrashSummary tells how much is in the trash and how long it stays there
+type TrashSummary struct {
+	Clients       int  `json:"clients"`
+	Appointments  int  `json:"appointments"`
+	RetentionDays int  `json:"retention_days"`
+	Anonymize     bool `json:"anonymize"`
+}
+
+type DeletedClientResponse struct {
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	LastName    string     `json:"last_name"`
+	FatherName  string     `json:"father_name"`
+	PhoneNumber string     `json:"phone_number"`
+	Address     string     `json:"address"`
+	BirthDate   civil.Date `json:"birth_date" swaggertype:"string" example:"1990-05-17"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeletedAt   time.Time  `json:"deleted_at"`
+	// PurgeAt is when the retention job takes the row, absent when the
+	// trash is kept forever
+	PurgeAt *time.Time `json:"purge_at,omitempty"`
+}
+
+type DeletedAppointmentResponse struct {
+	Id          string     `json:"id"`
+	ClientId    string     `json:"client_id"`
+	DoctorId    string     `json:"doctor_id"`
+	Date        time.Time  `json:"date"`
+	Diagnostics string     `json:"diagnostics"`
+	Treatment   string     `json:"treatment"`
+	Amount      int        `json:"amount"`
+	Status      string     `json:"status"`
+	DeletedAt   time.Time  `json:"deleted_at"`
+	PurgeAt     *time.Time `json:"purge_at,omitempty"`
+}
+
+type RestoreClientResponse struct {
+	Id                   string `json:"id"`
+	RestoredAppointments int    `json:"restored_appointments"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dentist/pkg/civil"
+)
+
+// The types below are the /v2 wire contracts. Unlike the v1 structs above
+// they carry snake_case json tags, so they must not be reused by v1 handlers.
+// Instants are RFC 3339 with an offset, calendar dates are YYYY-MM-DD.
+
+type ClientRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	LastName    string     `json:"last_name"`
+	FatherName  string     `json:"father_name"`
+	PhoneNumber string     `json:"phone_number"`
+	Address     string     `json:"address"`
+	BirthDate   civil.Date `json:"birth_date" swaggertype:"string" example:"1990-05-17"`
+}
+
+type ClientResponse struct {
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	LastName    string     `json:"last_name"`
+	FatherName  string     `json:"father_name"`
+	PhoneNumber string     `json:"phone_number"`
+	Address     string     `json:"address"`
+	BirthDate   civil.Date `json:"birth_date" swaggertype:"string" example:"1990-05-17"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type AppointmentRequest struct {
+	ClientId    string    `json:"client_id" binding:"required"`
+	Date        time.Time `json:"date" binding:"required" example:"2024-03-18T10:30:00+05:00"`
+	Diagnostics string    `json:"diagnostics"`
+	Treatment   string    `json:"treatment"`
+	Amount      int       `json:"amount"`
+	Status      string    `json:"status" example:"scheduled"`
+	DoctorId    string    `json:"doctor_id"`
+}
+
+// ClientAppointmentRequest books an appointment for the client in the path.
+type ClientAppointmentRequest struct {
+	Date        time.Time `json:"date" binding:"required" example:"2024-03-18T10:30:00+05:00"`
+	Diagnostics string    `json:"diagnostics"`
+	Treatment   string    `json:"treatment"`
+	Amount      int       `json:"amount"`
+	Status      string    `json:"status" example:"scheduled"`
+	DoctorId    string    `json:"doctor_id"`
+}
+
+type AppointmentResponse struct {
+	Id          string    `json:"id"`
+	ClientId    string    `json:"client_id"`
+	Date        time.Time `json:"date"`
+	Diagnostics string    `json:"diagnostics"`
+	Treatment   string    `json:"treatment"`
+	Amount      int       `json:"amount"`
+	Status      string    `json:"status"`
+	DoctorId    string    `json:"doctor_id"`
+}
+
+type DoctorRequest struct {
+	Name        string `json:"name" binding:"required"`
+	LastName    string `json:"last_name"`
+	Specialty   string `json:"specialty" example:"orthodontist"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+type DoctorResponse struct {
+	Id          string    `json:"id"`
+	Name        string    `json:"name"`
+	LastName    string    `json:"last_name"`
+	Specialty   string    `json:"specialty"`
+	PhoneNumber string    `json:"phone_number"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
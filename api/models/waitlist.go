@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dentist/pkg/civil"
+)
+
+type WaitlistRequest struct {
+	ClientId string `json:"client_id" binding:"required"`
+	// DoctorId and Treatment are what the client asks for, empty takes any
+	DoctorId  string `json:"doctor_id"`
+	Treatment string `json:"treatment" example:"Filling"`
+	// DateFrom and DateTo are the days the client can come, both included
+	DateFrom civil.Date `json:"date_from" binding:"required" swaggertype:"string" example:"2026-11-02"`
+	DateTo   civil.Date `json:"date_to" binding:"required" swaggertype:"string" example:"2026-11-20"`
+	// Priority puts the entry before those with lower ones
+	Priority int    `json:"priority"`
+	Note     string `json:"note"`
+}
+
+type WaitlistEntry struct {
+	Id            string     `json:"id"`
+	ClientId      string     `json:"client_id"`
+	DoctorId      string     `json:"doctor_id,omitempty"`
+	Treatment     string     `json:"treatment"`
+	DateFrom      civil.Date `json:"date_from" swaggertype:"string" example:"2026-11-02"`
+	DateTo        civil.Date `json:"date_to" swaggertype:"string" example:"2026-11-20"`
+	Priority      int        `json:"priority"`
+	Note          string     `json:"note"`
+	Status        string     `json:"status" enums:"waiting,booked,cancelled"`
+	AppointmentId string     `json:"appointment_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type WaitlistOffer struct {
+	Id      string `json:"id"`
+	EntryId string `json:"entry_id"`
+	// Rank is the place of the entry among those offered the slot
+	Rank       int        `json:"rank"`
+	DoctorId   string     `json:"doctor_id,omitempty"`
+	Date       time.Time  `json:"date"`
+	Status     string     `json:"status" enums:"pending,accepted,lost,withdrawn"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	NotifiedAt *time.Time `json:"notified_at,omitempty"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// PublicWaitlistOffer is what the link of an offer shows to the client
+type PublicWaitlistOffer struct {
+	Date      time.Time `json:"date"`
+	DoctorId  string    `json:"doctor_id,omitempty"`
+	Treatment string    `json:"treatment"`
+	Status    string    `json:"status" enums:"pending,accepted,lost,withdrawn"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type WaitlistBooking struct {
+	AppointmentId string    `json:"appointment_id"`
+	Date          time.Time `json:"date"`
+	DoctorId      string    `json:"doctor_id,omitempty"`
+}
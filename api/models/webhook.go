@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookRequest struct {
+	// Url gets a POST of every event, it must answer 2xx
+	Url string `json:"url" binding:"required" example:"https://crm.example/hooks/dentist"`
+	// Events are the event types to send, * for all of them
+	Events []string `json:"events" binding:"required" example:"appointment.created,appointment.cancelled"`
+	// Secret signs the payloads, one is generated when empty
+	Secret      string `json:"secret"`
+	Description string `json:"description"`
+}
+
+type Webhook struct {
+	Id          string    `json:"id"`
+	Url         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WebhookCreated holds the secret, it is not shown again. A delivery is
+// signed in the X-Webhook-Signature header as t=<unix time>,v1=<hex
+// HMAC-SHA256 of "<unix time>.<body>" with the secret>.
+type WebhookCreated struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+type WebhookDelivery struct {
+	Id        string `json:"id"`
+	WebhookId string `json:"webhook_id"`
+	// EventId is the same for every delivery and replay of an event
+	EventId string          `json:"event_id"`
+	Event   string          `json:"event"`
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+	Status  string          `json:"status" enums:"pending,delivered,failed"`
+	// Attempts counts the requests made, NextAttemptAt is when a pending
+	// one is tried again
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	ReplayOf       string     `json:"replay_of,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
@@ -1,41 +1,82 @@
 package api
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
+
 	_ "github.com/dentist/api/docs" // swag
 
 	v1 "github.com/dentist/api/v1"
+	v2 "github.com/dentist/api/v2"
 	"github.com/dentist/config"
+	"github.com/dentist/pkg/booking"
+	"github.com/dentist/pkg/live"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/metrics"
+	"github.com/dentist/pkg/ratelimit"
 	"github.com/dentist/storage"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"     // swagger embed files
 	ginSwagger "github.com/swaggo/gin-swagger" // gin-swagger middleware
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type RoutOptions struct {
 	Cfg     *config.Config
 	Storage storage.StorageI
+	Logger  logger.Logger
+	Metrics *metrics.Metrics
+	Board   *live.Hub
+	BookingSchedule *booking.Schedule
+	BookingSender   booking.Sender
+	Limiter         *ratelimit.Limiter
 }
 
 // New...
 // @Title           Dentist
 // @Version         1.0
 // @Description     Dentist-backend
-func New(opts RoutOptions) *gin.Engine {
-	router := gin.Default()
+func New(opts RoutOptions) (*gin.Engine, error) {
+	if opts.Cfg.Environment == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	router := gin.New()
+	// gin trusts every address by default, any caller could then choose
+	// the ClientIP the limits count
+	if err := router.SetTrustedProxies(trustedProxies(opts.Cfg.TrustedProxies)); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	router.Use(
+		requestID(),
+		otelgin.Middleware(opts.Cfg.OtelServiceName),
+		opts.Metrics.Middleware(),
+		accessLog(opts.Logger),
+		gin.Recovery(),
+	)
 
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
 	corsConfig.AllowCredentials = true
 	corsConfig.AllowHeaders = append(corsConfig.AllowHeaders, "*")
+	corsConfig.ExposeHeaders = append(corsConfig.ExposeHeaders, requestIDHeader)
 	router.Use(cors.New(corsConfig))
+	router.Use(opts.Limiter.Middleware())
 
 	handlerV1 := v1.New(&v1.HandlerV1Options{
 		Storage: opts.Storage,
 		Cfg:     opts.Cfg,
+		Logger:  opts.Logger,
+		Board:   opts.Board,
+		BookingSchedule: opts.BookingSchedule,
+		BookingSender:   opts.BookingSender,
 	})
 
-	router.Use(gin.Recovery())
+	health := &healthHandler{storage: opts.Storage}
+	router.GET("/healthz", health.Healthz)
+	router.GET("/readyz", health.Readyz)
+	router.GET("/metrics", gin.WrapH(opts.Metrics.Handler()))
 
 	v1 := router.Group("/v1")
 
@@ -48,6 +89,129 @@ func New(opts RoutOptions) *gin.Engine {
 	v1.GET("/count", handlerV1.GetAllClientsCount)
 	v1.GET("/search", handlerV1.SearchClients)
 	v1.GET("/clientappointment", handlerV1.GetClientWithAppointments)
+	v1.GET("/clients/:id/export", handlerV1.ExportClient)
+	v1.POST("/clients/:id/anonymize", handlerV1.AnonymizeClient)
+
+	//merge...
+	v1.GET("/duplicates", handlerV1.FindDuplicates)
+	v1.GET("/clients/:id/duplicates", handlerV1.FindClientDuplicates)
+	v1.GET("/clients/:id/merges", handlerV1.GetClientMerges)
+	v1.POST("/merges", handlerV1.MergeClients)
+	v1.POST("/merges/:id/undo", handlerV1.UndoMerge)
+
+	//family...
+	v1.GET("/clients/:id/relations", handlerV1.GetRelations)
+	v1.POST("/clients/:id/relations", handlerV1.AddRelation)
+	v1.DELETE("/clients/:id/relations/:related_id", handlerV1.DeleteRelation)
+	v1.PUT("/clients/:id/guarantor", handlerV1.SetGuarantor)
+	v1.GET("/clients/:id/contact", handlerV1.GetContact)
+	v1.GET("/clients/:id/family", handlerV1.GetFamily)
+
+	//import...
+	v1.POST("/import", handlerV1.Import)
+	v1.GET("/imports/:id", handlerV1.GetImport)
+	v1.GET("/imports/:id/errors", handlerV1.GetImportErrors)
+
+	//calendar...
+	v1.POST("/calendar-feeds", handlerV1.CreateCalendarFeed)
+	v1.GET("/calendar-feeds", handlerV1.GetCalendarFeeds)
+	v1.DELETE("/calendar-feeds/:id", handlerV1.RevokeCalendarFeed)
+	// the token in the path is what authorizes these, calendar apps cannot
+	// be given anything else
+	router.GET("/calendar/:token", opts.Limiter.Lockout("calendar", http.StatusNotFound), handlerV1.GetCalendar)
+	for _, method := range []string{http.MethodOptions, http.MethodGet, http.MethodHead, "PROPFIND", "REPORT"} {
+		router.Handle(method, "/caldav/:token/*path", opts.Limiter.Lockout("calendar", http.StatusNotFound), handlerV1.CalDAV)
+	}
+
+	//webhook...
+	v1.POST("/webhooks", handlerV1.CreateWebhook)
+	v1.GET("/webhooks", handlerV1.GetWebhooks)
+	v1.DELETE("/webhooks/:id", handlerV1.DeleteWebhook)
+	v1.GET("/webhooks/:id/deliveries", handlerV1.GetWebhookDeliveries)
+	v1.POST("/webhook-deliveries/:id/replay", handlerV1.ReplayWebhookDelivery)
+
+	//waitlist...
+	v1.POST("/waitlist", handlerV1.CreateWaitlistEntry)
+	v1.GET("/waitlist", handlerV1.GetWaitlist)
+	v1.DELETE("/waitlist/:id", handlerV1.CancelWaitlistEntry)
+	v1.GET("/waitlist/:id/offers", handlerV1.GetWaitlistOffers)
+	v1.POST("/waitlist-offers/:id/accept", handlerV1.AcceptWaitlistOffer)
+	// the token of the link sent to the client authorizes these
+	router.GET("/waitlist/offers/:token", opts.Limiter.Lockout("waitlist", http.StatusNotFound), handlerV1.GetPublicWaitlistOffer)
+	router.POST("/waitlist/offers/:token/accept", opts.Limiter.Lockout("waitlist", http.StatusNotFound), handlerV1.AcceptPublicWaitlistOffer)
+
+	//booking...
+	v1.GET("/booking-requests", handlerV1.GetBookingRequests)
+	v1.POST("/booking-requests/:id/confirm", handlerV1.ConfirmBookingRequest)
+	v1.POST("/booking-requests/:id/reject", handlerV1.RejectBookingRequest)
+	// booking from the website, open to anyone
+	public := router.Group("/public/booking")
+	public.GET("/procedures", handlerV1.GetBookingProcedures)
+	public.GET("/slots", handlerV1.GetBookingSlots)
+	public.POST("", handlerV1.CreateBooking)
+	public.POST("/:id/confirm", opts.Limiter.Lockout("booking", http.StatusUnprocessableEntity), handlerV1.ConfirmBooking)
+
+	//board...
+	v1.GET("/board/stream",
+		opts.Limiter.Lockout("board", http.StatusUnauthorized),
+		boardAuth(opts.Cfg.BoardTokens),
+		opts.Limiter.User(userContextKey),
+		handlerV1.BoardStream,
+	)
+
+	//inventory...
+	v1.POST("/inventory/items", handlerV1.CreateInventoryItem)
+	v1.GET("/inventory/items", handlerV1.GetInventoryItems)
+	v1.GET("/inventory/items/:id", handlerV1.GetInventoryItem)
+	v1.PUT("/inventory/items/:id", handlerV1.UpdateInventoryItem)
+	v1.DELETE("/inventory/items/:id", handlerV1.DeleteInventoryItem)
+	v1.POST("/inventory/items/:id/batches", handlerV1.ReceiveInventoryBatch)
+	v1.POST("/inventory/batches/:id/adjustments", handlerV1.AdjustInventoryBatch)
+	v1.POST("/inventory/locations", handlerV1.CreateStorageLocation)
+	v1.GET("/inventory/locations", handlerV1.GetStorageLocations)
+	v1.GET("/inventory/materials", handlerV1.GetProcedureMaterials)
+	v1.PUT("/inventory/materials", handlerV1.SetProcedureMaterials)
+	v1.GET("/inventory/movements", handlerV1.GetStockMovements)
+	v1.GET("/inventory/alerts", handlerV1.GetInventoryAlerts)
+
+	//purchasing...
+	v1.POST("/suppliers", handlerV1.CreateSupplier)
+	v1.GET("/suppliers", handlerV1.GetSuppliers)
+	v1.GET("/suppliers/:id", handlerV1.GetSupplier)
+	v1.PUT("/suppliers/:id", handlerV1.UpdateSupplier)
+	v1.DELETE("/suppliers/:id", handlerV1.DeleteSupplier)
+	v1.GET("/suppliers/:id/prices", handlerV1.GetSupplierPrices)
+	v1.POST("/purchase-orders", handlerV1.CreatePurchaseOrder)
+	v1.GET("/purchase-orders", handlerV1.GetPurchaseOrders)
+	v1.GET("/purchase-orders/:id", handlerV1.GetPurchaseOrder)
+	v1.PUT("/purchase-orders/:id", handlerV1.UpdatePurchaseOrder)
+	v1.POST("/purchase-orders/:id/place", handlerV1.PlacePurchaseOrder)
+	v1.POST("/purchase-orders/:id/cancel", handlerV1.CancelPurchaseOrder)
+	v1.POST("/purchase-orders/:id/receipts", handlerV1.ReceivePurchaseOrder)
+	v1.GET("/purchasing/spend", handlerV1.GetSupplierSpend)
+
+	//lab case...
+	v1.POST("/lab-cases", handlerV1.CreateLabCase)
+	v1.GET("/lab-cases", handlerV1.GetLabCases)
+	v1.GET("/lab-cases/overdue", handlerV1.GetOverdueLabCases)
+	v1.GET("/lab-cases/:id", handlerV1.GetLabCase)
+	v1.PUT("/lab-cases/:id", handlerV1.UpdateLabCase)
+	v1.POST("/lab-cases/:id/send", handlerV1.SendLabCase)
+	v1.POST("/lab-cases/:id/receive", handlerV1.ReceiveLabCase)
+	v1.POST("/lab-cases/:id/fit", handlerV1.FitLabCase)
+	v1.POST("/lab-cases/:id/cancel", handlerV1.CancelLabCase)
+
+	//sterilization...
+	v1.POST("/sterilization/autoclaves", handlerV1.CreateAutoclave)
+	v1.GET("/sterilization/autoclaves", handlerV1.GetAutoclaves)
+	v1.POST("/sterilization/cycles", handlerV1.CreateCycle)
+	v1.GET("/sterilization/cycles", handlerV1.GetCycles)
+	v1.GET("/sterilization/cycles/:id", handlerV1.GetCycle)
+	v1.POST("/sterilization/cycles/:id/result", handlerV1.RecordCycleResult)
+	v1.GET("/sterilization/cycles/:id/exposures", handlerV1.GetCycleExposures)
+	v1.GET("/sterilization/packs", handlerV1.GetAppointmentPacks)
+	v1.GET("/sterilization/packs/:code", handlerV1.GetPack)
+	v1.POST("/sterilization/packs/:code/use", handlerV1.UsePack)
 
 	//appointment...
 	v1.POST("/appointment", handlerV1.CreateAppointment)
@@ -59,7 +223,62 @@ func New(opts RoutOptions) *gin.Engine {
 	v1.GET("/appointmentid", handlerV1.GetAppointmentWithClientId)
 	v1.POST("/appointmentnew", handlerV1.CreateAppointmentWithClient)
 
+	//trash...
+	v1.GET("/trash", handlerV1.GetTrash)
+	v1.GET("/trash/clients", handlerV1.GetDeletedClients)
+	v1.GET("/trash/appointments", handlerV1.GetDeletedAppointments)
+	v1.POST("/trash/clients/:id/restore", handlerV1.RestoreClient)
+	v1.POST("/trash/appointments/:id/restore", handlerV1.RestoreAppointment)
+
+	handlerV2 := v2.New(&v2.HandlerV2Options{
+		Storage: opts.Storage,
+		Cfg:     opts.Cfg,
+		Logger:  opts.Logger,
+	})
+
+	v2 := router.Group("/v2")
+
+	//client...
+	v2.POST("/clients", handlerV2.CreateClient)
+	v2.GET("/clients", handlerV2.ListClients)
+	v2.GET("/clients/:id", handlerV2.GetClient)
+	v2.PUT("/clients/:id", handlerV2.UpdateClient)
+	v2.DELETE("/clients/:id", handlerV2.DeleteClient)
+	v2.GET("/clients/:id/appointments", handlerV2.ListClientAppointments)
+	v2.POST("/clients/:id/appointments", handlerV2.CreateClientAppointment)
+
+	//appointment...
+	v2.POST("/appointments", handlerV2.CreateAppointment)
+	v2.GET("/appointments", handlerV2.ListAppointments)
+	v2.GET("/appointments/:id", handlerV2.GetAppointment)
+	v2.PUT("/appointments/:id", handlerV2.UpdateAppointment)
+	v2.DELETE("/appointments/:id", handlerV2.DeleteAppointment)
+
+	//doctor...
+	v2.POST("/doctors", handlerV2.CreateDoctor)
+	v2.GET("/doctors", handlerV2.ListDoctors)
+	v2.GET("/doctors/:id", handlerV2.GetDoctor)
+	v2.PUT("/doctors/:id", handlerV2.UpdateDoctor)
+	v2.DELETE("/doctors/:id", handlerV2.DeleteDoctor)
+
+	//export...
+	v2.GET("/exports/clients", handlerV2.ExportClients)
+	v2.GET("/exports/appointments", handlerV2.ExportAppointments)
+	v2.GET("/exports/payments", handlerV2.ExportPayments)
+
 	v1.GET("swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	return router
+	return router, nil
+}
+
+// trustedProxies splits the comma-separated TRUSTED_PROXIES, none when
+// empty
+func trustedProxies(s string) []string {
+	var proxies []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
 }
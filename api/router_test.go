@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{" , ", nil},
+		{"10.0.0.1", []string{"10.0.0.1"}},
+		{" 10.0.0.0/8, ,192.168.1.1 ", []string{"10.0.0.0/8", "192.168.1.1"}},
+	}
+	for _, tt := range tests {
+		if got := trustedProxies(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("trustedProxies(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTrustedProxiesInvalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := gin.New().SetTrustedProxies(trustedProxies("10.0.0.0/8,not-an-ip")); err == nil {
+		t.Fatal("expected an error for an invalid proxy")
+	}
+}
+
+func TestClientIPForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		proxies string
+		remote  string
+		want    string
+	}{
+		{"no proxies ignores the header", "", "203.0.113.9:4000", "203.0.113.9"},
+		{"untrusted peer ignores the header", "10.0.0.0/8", "203.0.113.9:4000", "203.0.113.9"},
+		{"trusted peer honours the header", "10.0.0.0/8", "10.1.2.3:4000", "198.51.100.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			if err := router.SetTrustedProxies(trustedProxies(tt.proxies)); err != nil {
+				t.Fatal(err)
+			}
+			router.GET("/ip", func(c *gin.Context) {
+				c.String(http.StatusOK, c.ClientIP())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = tt.remote
+			req.Header.Set("X-Forwarded-For", "198.51.100.7")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
 	"github.com/dentist/storage/repo"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -19,6 +22,7 @@ import (
 // @Param Appointment body models.ReqAppointment true "CreateAppointment"
 // @Success 200 {object} models.Appointment
 // @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error
 // @Failure 500 {object} models.Error
 // @Router /v1/appointment [post]
 func (h *handlerV1) CreateAppointment(c *gin.Context) {
@@ -26,23 +30,34 @@ func (h *handlerV1) CreateAppointment(c *gin.Context) {
 	err := c.ShouldBindJSON(&req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, err.Error())
-		h.logger.Error("Error to bind json create appointment")
+		h.log(c).Error("Error to bind json create appointment", logger.Error(err))
+		return
+	}
+	if req.Status != "" && !repo.ValidStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown appointment status",
+		})
+		return
+	}
+	if !h.guardianPresent(c, req.ClientId) {
 		return
 	}
 	Id := uuid.NewString()
-	response, err := h.storage.Appointment().CreateAppointment(&repo.Appointment{
+	response, err := h.storage.Appointment().CreateAppointment(c.Request.Context(), &repo.Appointment{
 		Id:          Id,
 		ClientId:    req.ClientId,
 		Date:        req.Date,
 		Diagnostics: req.Diagnostics,
 		Treatment:   req.Treatment,
 		Amount:      req.Amount,
+		Status:      req.Status,
+		DoctorId:    req.DoctorId,
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create appointment",
 		})
-		h.logger.Error("Failed to create appointment")
+		h.log(c).Error("Failed to create appointment", logger.Error(err))
 		return
 	}
 
@@ -58,17 +73,24 @@ func (h *handlerV1) CreateAppointment(c *gin.Context) {
 // @Param id query string true "id"
 // @Success 200 {object} models.Appointment
 // @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
 // @Failure 500 {object} models.Error
 // @Router /v1/appointment [get]
 func (h *handlerV1) GetAppointment(c *gin.Context) {
 	id := c.Query("id")
 
-	response, err := h.storage.Appointment().GetAppointment(id)
+	response, err := h.storage.Appointment().GetAppointment(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Appointment not found",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get appointment",
 		})
-		h.logger.Error("Failed to get appointment")
+		h.log(c).Error("Failed to get appointment", logger.Error(err))
 		return
 	}
 
@@ -84,6 +106,8 @@ func (h *handlerV1) GetAppointment(c *gin.Context) {
 // @Param Appointment body models.Appointment true "Appointment"
 // @Success 200 {object} models.Appointment
 // @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "confirming, checking in or completing the fitting of a lab case not received"
 // @Failure 500 {object} models.Error
 // @Router /v1/appointment [put]
 func (h *handlerV1) UpdateAppointment(c *gin.Context) {
@@ -93,19 +117,39 @@ func (h *handlerV1) UpdateAppointment(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, err.Error())
 		return
 	}
-	response, err := h.storage.Appointment().UpdateAppointment(&repo.Appointment{
+	if req.Status != "" && !repo.ValidStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown appointment status",
+		})
+		return
+	}
+	response, err := h.storage.Appointment().UpdateAppointment(c.Request.Context(), &repo.Appointment{
 		Id:          req.Id,
 		ClientId:    req.ClientId,
 		Date:        req.Date,
 		Diagnostics: req.Diagnostics,
 		Treatment:   req.Treatment,
 		Amount:      req.Amount,
+		Status:      req.Status,
+		DoctorId:    req.DoctorId,
 	})
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Appointment not found",
+		})
+		return
+	}
+	if errors.Is(err, repo.ErrLabCaseNotReceived) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The lab case fitted at this appointment is not received yet",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update appointment",
 		})
-		h.logger.Error("Failed to update appointment")
+		h.log(c).Error("Failed to update appointment", logger.Error(err))
 		return
 	}
 
@@ -126,12 +170,12 @@ func (h *handlerV1) UpdateAppointment(c *gin.Context) {
 func (h *handlerV1) DeleteAppointment(c *gin.Context) {
 	id := c.Query("id")
 
-	response, err := h.storage.Appointment().DeleteAppointment(id)
+	response, err := h.storage.Appointment().DeleteAppointment(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete appointment",
 		})
-		h.logger.Error("Failed to delete appointment")
+		h.log(c).Error("Failed to delete appointment", logger.Error(err))
 		return
 	}
 
@@ -144,35 +188,32 @@ func (h *handlerV1) DeleteAppointment(c *gin.Context) {
 // @Tags appointment
 // @Accept json
 // @Produce json
-// @Param page query string true "page"
-// @Param limit query string true "limit"
-// @Success 200 {object} models.AllAppointments
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[repo.Appointment]
 // @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
 // @Router /v1/appointments [get]
 func (h *handlerV1) GetAllAppointments(c *gin.Context) {
-	page := c.Query("page")
-	limit := c.Query("limit")
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
 
-	response, err := h.storage.Appointment().GetAllAppointments(&repo.GetAllAppointment{
-		Page:  cast.ToInt(page),
-		Limit: cast.ToInt(limit),
+	response, err := h.storage.Appointment().GetAllAppointments(c.Request.Context(), &repo.GetAllAppointment{
+		Params: params,
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get all appointments",
 		})
-		h.logger.Error("Failed to get all appointments")
-		return
-	}
-	if len(response.Appointment) == 0 {
-		c.JSON(http.StatusOK, models.AllAppointments{
-			Appointments: []*models.Appointment{},
-		})
+		h.log(c).Error("Failed to get all appointments", logger.Error(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, pagination.NewPage(response.Appointment, response.Total, response.NextCursor))
 }
 
 // GetAppointmentsWithDate ...
@@ -182,32 +223,30 @@ func (h *handlerV1) GetAllAppointments(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param integer query string true "integer"
-// @Param page query string true "page"
-// @Param limit query string true "limit"
-// @Success 200 {object} models.AllAppointments
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[repo.Appointment]
 // @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
 // @Router /v1/appointmentsdate [get]
 func (h *handlerV1) GetAppointmentsWithDate(c *gin.Context) {
 	integer := c.Query("integer")
-	page := c.Query("page")
-	limit := c.Query("limit")
-	response, err := h.storage.Appointment().GetAppointmentsWithDate(cast.ToInt(integer), cast.ToInt(page), cast.ToInt(limit))
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+	response, err := h.storage.Appointment().GetAppointmentsWithDate(c.Request.Context(), cast.ToInt(integer), params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get appointments with date",
 		})
-		h.logger.Error("Failed to get appointments with date")
-		return
-	}
-	if len(response.Appointment) == 0 {
-		c.JSON(http.StatusOK, models.AllAppointments{
-			Appointments: []*models.Appointment{},
-		})
+		h.log(c).Error("Failed to get appointments with date", logger.Error(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, pagination.NewPage(response.Appointment, response.Total, response.NextCursor))
 }
 
 
@@ -218,26 +257,30 @@ func (h *handlerV1) GetAppointmentsWithDate(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param client_id query string true "client_id"
-// @Param page query string true "page"
-// @Param limit query string true "limit"
-// @Success 200 {object} models.AllAppointments
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[repo.Appointment]
 // @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
 // @Router /v1/appointmentid [get]
 func (h *handlerV1) GetAppointmentWithClientId(c *gin.Context) {
 	client_id := c.Query("client_id")
-	page := c.Query("page")
-	limit := c.Query("limit")
-	response, err := h.storage.Appointment().GetAppointmentsWithClientId(client_id, cast.ToInt(page), cast.ToInt(limit))
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+	response, err := h.storage.Appointment().GetAppointmentsWithClientId(c.Request.Context(), client_id, params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error" : "Failed to get appointment with client id",
 		})
-		h.logger.Error("Failed to get appointment with client id")
+		h.log(c).Error("Failed to get appointment with client id", logger.Error(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, pagination.NewPage(response.Appointment, response.Total, response.NextCursor))
 }
 
 // CreateAppointmentWithClient ...
@@ -256,11 +299,11 @@ func (h *handlerV1) CreateAppointmentWithClient(c *gin.Context) {
 	err := c.ShouldBindJSON(&req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, err.Error())
-		h.logger.Error("Failed to binding to json CreateAppointmentWithClient")
+		h.log(c).Error("Failed to binding to json CreateAppointmentWithClient", logger.Error(err))
 		return
 	}
 	Id := uuid.NewString()
-	respClient, err := h.storage.Client().CreateClient(&repo.Client{
+	respClient, err := h.storage.Client().CreateClient(c.Request.Context(), &repo.Client{
 		Id:          Id,
 		Name:        req.ClientName,
 		PhoneNumber: req.PhoneNumber,
@@ -269,13 +312,13 @@ func (h *handlerV1) CreateAppointmentWithClient(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create client with appointment",
 		})
-		h.logger.Error("Failed to create client with appointment")
+		h.log(c).Error("Failed to create client with appointment", logger.Error(err))
 		return
 	}
 
 	id := uuid.NewString()
 
-	respAppointment, err := h.storage.Appointment().CreateAppointment(&repo.Appointment{
+	respAppointment, err := h.storage.Appointment().CreateAppointment(c.Request.Context(), &repo.Appointment{
 		Id: id,
 		ClientId: Id,
 		Date: req.Date,
@@ -287,7 +330,7 @@ func (h *handlerV1) CreateAppointmentWithClient(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create appointment with client",
 		})
-		h.logger.Error("Failed to create appointment with client")
+		h.log(c).Error("Failed to create appointment with client", logger.Error(err))
 		return
 	}
 
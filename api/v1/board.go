@@ -0,0 +1,157 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/live"
+	"github.com/dentist/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// boardRetry is how long a browser waits before reconnecting a board
+const boardRetry = 3 * time.Second
+
+// BoardStream
+// @Summary BoardStream
+// @Description Server-Sent Events of the appointments of a day, of one doctor with doctor_id: appointment.created, appointment.updated, appointment.checked_in, appointment.cancelled and appointment.deleted, a moved appointment is sent to the board it left too. The id of an event is sent back in Last-Event-ID on reconnection to get the missed ones, a reset event asks the board to load the day again when too many were missed. Needs a board token as a bearer token or the access_token query parameter.
+// @Tags board
+// @Produce text/event-stream
+// @Param date query string false "day, YYYY-MM-DD in the clinic timezone, today by default"
+// @Param doctor_id query string false "doctor id"
+// @Param access_token query string false "board token, when the Authorization header cannot be set"
+// @Param Last-Event-ID header string false "id of the last event received"
+// @Success 200 {string} string
+// @Failure 400 {object} github_com_dentist_api_models.ErrorResponse
+// @Failure 401 {object} github_com_dentist_api_models.ErrorResponse
+// @Failure 500 {object} github_com_dentist_api_models.ErrorResponse
+// @Router /v1/board/stream [get]
+func (h *handlerV1) BoardStream(c *gin.Context) {
+	day := civil.Today(h.cfg.Location)
+	if v := c.Query("date"); v != "" {
+		d, err := civil.ParseDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "date must be YYYY-MM-DD",
+			})
+			return
+		}
+		day = d
+	}
+	doctorId := c.Query("doctor_id")
+	if doctorId != "" {
+		if _, err := uuid.Parse(doctorId); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "doctor_id must be a uuid",
+			})
+			return
+		}
+	}
+	var lastId int64
+	if v := c.GetHeader("Last-Event-ID"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Last-Event-ID must be the id of an event",
+			})
+			return
+		}
+		lastId = id
+	}
+
+	from := day.In(h.cfg.Location)
+	filter := live.Filter{
+		From:     from,
+		To:       from.AddDate(0, 0, 1),
+		DoctorId: doctorId,
+	}
+
+	// subscribing before the replay is read loses nothing in between, the
+	// events both have are skipped by their id
+	sub := h.board.Subscribe(filter)
+	defer h.board.Unsubscribe(sub)
+
+	// the stream outlives any write timeout of the server
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if err := live.WriteRetry(c.Writer, boardRetry); err != nil {
+		return
+	}
+
+	sent := lastId
+	if lastId > 0 {
+		var err error
+		if sent, err = h.replayBoard(c, filter, lastId); err != nil {
+			h.log(c).Error("Failed to replay board events", logger.Error(err))
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.cfg.BoardHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				// too far behind, the board reconnects and catches up,
+				// or the server is shutting down
+				return
+			}
+			if e.Seq <= sent {
+				continue
+			}
+			sent = e.Seq
+			err = live.WriteEvent(c.Writer, e)
+		case <-heartbeat.C:
+			err = live.WriteComment(c.Writer, "ping")
+		}
+		if err != nil {
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// replayBoard writes the events of filter after lastId, or a reset when
+// there are more than BoardReplayLimit. It returns the id written last.
+func (h *handlerV1) replayBoard(c *gin.Context, filter live.Filter, lastId int64) (int64, error) {
+	list, err := h.storage.Outbox().Events(c.Request.Context(), lastId, events.AppointmentTypes, h.cfg.BoardReplayLimit+1)
+	if err != nil {
+		return 0, err
+	}
+	if len(list) > h.cfg.BoardReplayLimit {
+		last, err := h.storage.Outbox().LastSeq(c.Request.Context())
+		if err != nil {
+			return 0, err
+		}
+		return last, live.WriteReset(c.Writer, last)
+	}
+
+	sent := lastId
+	for _, ev := range list {
+		sent = ev.Seq
+		e, err := live.Decode(ev)
+		if err != nil {
+			h.log(c).Error("Failed to decode board event", logger.String("event_id", ev.Id), logger.Error(err))
+			continue
+		}
+		if !filter.Match(e) {
+			continue
+		}
+		if err = live.WriteEvent(c.Writer, e); err != nil {
+			return 0, err
+		}
+	}
+	return sent, nil
+}
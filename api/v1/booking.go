@@ -0,0 +1,546 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/booking"
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/pkg/ratelimit"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetBookingProcedures
+// @Summary GetBookingProcedures
+// @Description The procedures clients can book on the website
+// @Tags booking
+// @Produce json
+// @Success 200 {array} models.BookingProcedure
+// @Router /public/booking/procedures [get]
+func (h *handlerV1) GetBookingProcedures(c *gin.Context) {
+	response := make([]models.BookingProcedure, 0, len(h.schedule.Procedures))
+	for _, p := range h.schedule.Procedures {
+		response = append(response, bookingProcedure(p))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetBookingSlots
+// @Summary GetBookingSlots
+// @Description The times of a day a procedure can be booked at, with the doctors free at each
+// @Tags booking
+// @Produce json
+// @Param procedure query string true "procedure name"
+// @Param date query string true "day as YYYY-MM-DD"
+// @Param doctor_id query string false "only the times of this doctor"
+// @Success 200 {object} models.BookingSlots
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /public/booking/slots [get]
+func (h *handlerV1) GetBookingSlots(c *gin.Context) {
+	procedure, ok := h.schedule.Procedure(c.Query("procedure"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "procedure is unknown",
+		})
+		return
+	}
+	day, err := civil.ParseDate(c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	doctorId := c.Query("doctor_id")
+	if _, err = uuid.Parse(doctorId); doctorId != "" && err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "doctor_id must be a uuid",
+		})
+		return
+	}
+
+	doctors, ok := h.bookingDoctors(c, doctorId)
+	if !ok {
+		return
+	}
+	from, to := h.schedule.Window(day)
+	busy, err := h.storage.Booking().BusyTimes(c.Request.Context(), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get free times",
+		})
+		h.log(c).Error("Failed to get busy times", logger.Error(err))
+		return
+	}
+
+	response := models.BookingSlots{
+		Procedure: bookingProcedure(procedure),
+		Date:      day,
+		Doctors:   make([]models.BookingDoctor, 0, len(doctors)),
+		Slots:     []models.BookingSlot{},
+	}
+	ids := make([]string, 0, len(doctors))
+	for _, d := range doctors {
+		ids = append(ids, d.Id)
+		response.Doctors = append(response.Doctors, models.BookingDoctor{
+			Id:        d.Id,
+			Name:      d.Name,
+			LastName:  d.LastName,
+			Specialty: d.Specialty,
+		})
+	}
+	for _, slot := range h.schedule.Slots(day, procedure, ids, busy, time.Now()) {
+		response.Slots = append(response.Slots, models.BookingSlot{
+			Start:     slot.Start,
+			DoctorIds: slot.DoctorIds,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateBooking
+// @Summary CreateBooking
+// @Description Books a procedure from the website. The client is matched by phone and name or created, and the time is held by a pending appointment until the booking is confirmed, with the code sent to the phone or by staff depending on the clinic. Unconfirmed bookings expire and free their time. Bookings from an address and pending ones of a phone are limited.
+// @Tags booking
+// @Accept json
+// @Produce json
+// @Param booking body models.PublicBookingRequest true "booking"
+// @Success 201 {object} models.PublicBooking
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error "the time was taken meanwhile"
+// @Failure 422 {object} models.Error "the time cannot be booked"
+// @Failure 429 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Failure 502 {object} models.Error "the code could not be sent"
+// @Router /public/booking [post]
+func (h *handlerV1) CreateBooking(c *gin.Context) {
+	var body models.PublicBookingRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	// X-Forwarded-For counts only from TRUSTED_PROXIES, the address
+	// cannot be forged to get around the limits
+	clientIp := c.ClientIP()
+	if body.Website != "" {
+		h.log(c).Warn("Booking rejected as spam", logger.String("client_ip", clientIp))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid booking",
+		})
+		return
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "name is required",
+		})
+		return
+	}
+	if !booking.ValidPhone(body.PhoneNumber) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "phone_number is not a valid phone number",
+		})
+		return
+	}
+	if _, err := uuid.Parse(body.DoctorId); body.DoctorId != "" && err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "doctor_id must be a uuid",
+		})
+		return
+	}
+	procedure, ok := h.schedule.Procedure(body.Procedure)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "procedure is unknown",
+		})
+		return
+	}
+	now := time.Now()
+	if !h.schedule.Bookable(body.Start, procedure, now) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "The time cannot be booked",
+		})
+		return
+	}
+
+	phoneDigits := booking.PhoneDigits(body.PhoneNumber)
+	if !h.bookingAllowed(c, clientIp, phoneDigits, now) {
+		return
+	}
+
+	doctors, ok := h.bookingDoctors(c, body.DoctorId)
+	if !ok {
+		return
+	}
+	from, to := h.schedule.Window(civil.DateOf(body.Start.In(h.schedule.Location)))
+	busy, err := h.storage.Booking().BusyTimes(c.Request.Context(), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to book",
+		})
+		h.log(c).Error("Failed to get busy times", logger.Error(err))
+		return
+	}
+
+	var code string
+	req := &repo.BookingRequest{
+		Procedure: procedure.Name,
+		Duration:  procedure.Duration,
+		Date:      body.Start,
+		ClientIp:  clientIp,
+		ExpiresAt: now.Add(h.bookingTTL()),
+	}
+	if h.cfg.BookingConfirmation != booking.ConfirmByStaff {
+		if code, err = booking.NewCode(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to book",
+			})
+			h.log(c).Error("Failed to make confirmation code", logger.Error(err))
+			return
+		}
+		req.CodeHash = booking.HashCode(code)
+	}
+	client := &repo.BookingClient{
+		Name:        body.Name,
+		LastName:    strings.TrimSpace(body.LastName),
+		PhoneNumber: strings.TrimSpace(body.PhoneNumber),
+		PhoneDigits: phoneDigits,
+	}
+
+	// the first doctor free at the time gets the booking, the next one is
+	// tried when another booking took the time meanwhile
+	var created *repo.BookingRequest
+	err = repo.ErrSlotTaken
+	for _, d := range doctors {
+		if !errors.Is(err, repo.ErrSlotTaken) {
+			break
+		}
+		if !h.schedule.Free(d.Id, body.Start, procedure, busy) {
+			continue
+		}
+		req.DoctorId = d.Id
+		created, err = h.storage.Booking().CreateRequest(c.Request.Context(), req, client, h.schedule.MinDuration)
+	}
+	if errors.Is(err, repo.ErrSlotTaken) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The time is not free any more",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to book",
+		})
+		h.log(c).Error("Failed to create booking request", logger.Error(err))
+		return
+	}
+	h.log(c).Info("Booking requested",
+		logger.String("booking_id", created.Id),
+		logger.String("appointment_id", created.AppointmentId),
+		logger.Bool("new_client", created.NewClient),
+	)
+
+	if code != "" {
+		if err = h.codes.SendCode(c.Request.Context(), client.PhoneNumber, code); err != nil {
+			h.log(c).Error("Failed to send booking code", logger.String("booking_id", created.Id), logger.Error(err))
+			// nobody could confirm it, its time is freed at once
+			if _, rejectErr := h.storage.Booking().Reject(c.Request.Context(), created.Id); rejectErr != nil {
+				h.log(c).Error("Failed to reject booking request", logger.String("booking_id", created.Id), logger.Error(rejectErr))
+			}
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error": "Failed to send the confirmation code",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, h.publicBooking(created))
+}
+
+// ConfirmBooking
+// @Summary ConfirmBooking
+// @Description Confirms a booking with the code sent to the phone. A booking is rejected and its time freed after too many wrong codes.
+// @Tags booking
+// @Accept json
+// @Produce json
+// @Param id path string true "booking id"
+// @Param code body models.BookingConfirmRequest true "code"
+// @Success 200 {object} models.PublicBooking
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 410 {object} models.Error "the booking expired or is closed"
+// @Failure 422 {object} models.Error "wrong code"
+// @Failure 500 {object} models.Error
+// @Router /public/booking/{id}/confirm [post]
+func (h *handlerV1) ConfirmBooking(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	var body models.BookingConfirmRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	confirmed, err := h.storage.Booking().ConfirmWithCode(c.Request.Context(), id, booking.HashCode(body.Code), h.cfg.BookingOtpAttempts)
+	if errors.Is(err, repo.ErrWrongCode) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "Wrong code",
+		})
+		return
+	}
+	if !h.bookingDecided(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, h.publicBooking(confirmed))
+}
+
+// GetBookingRequests
+// @Summary GetBookingRequests
+// @Description Api for get the bookings made on the website, the oldest first
+// @Tags booking
+// @Produce json
+// @Param status query string false "pending, confirmed, rejected or expired"
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.BookingRequest]
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/booking-requests [get]
+func (h *handlerV1) GetBookingRequests(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", repo.BookingPending, repo.BookingConfirmed, repo.BookingRejected, repo.BookingExpired:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "status must be pending, confirmed, rejected or expired",
+		})
+		return
+	}
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+
+	requests, err := h.storage.Booking().GetRequests(c.Request.Context(), status, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get booking requests",
+		})
+		h.log(c).Error("Failed to get booking requests", logger.Error(err))
+		return
+	}
+
+	items := make([]models.BookingRequest, 0, len(requests.Requests))
+	for _, r := range requests.Requests {
+		items = append(items, bookingRequest(r))
+	}
+	c.JSON(http.StatusOK, pagination.NewPage(items, requests.Total, requests.NextCursor))
+}
+
+// ConfirmBookingRequest
+// @Summary ConfirmBookingRequest
+// @Description Api for confirm a pending booking, its appointment is scheduled
+// @Tags booking
+// @Produce json
+// @Param id path string true "booking id"
+// @Success 200 {object} models.BookingRequest
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 410 {object} models.Error "the booking expired or is closed"
+// @Failure 500 {object} models.Error
+// @Router /v1/booking-requests/{id}/confirm [post]
+func (h *handlerV1) ConfirmBookingRequest(c *gin.Context) {
+	h.decideBooking(c, h.storage.Booking().Confirm)
+}
+
+// RejectBookingRequest
+// @Summary RejectBookingRequest
+// @Description Api for reject a pending booking, its appointment is cancelled
+// @Tags booking
+// @Produce json
+// @Param id path string true "booking id"
+// @Success 200 {object} models.BookingRequest
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 410 {object} models.Error "the booking expired or is closed"
+// @Failure 500 {object} models.Error
+// @Router /v1/booking-requests/{id}/reject [post]
+func (h *handlerV1) RejectBookingRequest(c *gin.Context) {
+	h.decideBooking(c, h.storage.Booking().Reject)
+}
+
+func (h *handlerV1) decideBooking(c *gin.Context, decide func(ctx context.Context, id string) (*repo.BookingRequest, error)) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	decided, err := decide(c.Request.Context(), id)
+	if !h.bookingDecided(c, err) {
+		return
+	}
+	h.log(c).Info("Booking decided by staff",
+		logger.String("booking_id", decided.Id),
+		logger.String("status", decided.Status),
+	)
+	c.JSON(http.StatusOK, bookingRequest(decided))
+}
+
+// bookingDecided answers the error of deciding a booking, if any
+func (h *handlerV1) bookingDecided(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Booking not found",
+		})
+		return false
+	case errors.Is(err, repo.ErrBookingClosed):
+		c.JSON(http.StatusGone, gin.H{
+			"error": "The booking expired or is closed",
+		})
+		return false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to decide booking",
+		})
+		h.log(c).Error("Failed to decide booking", logger.Error(err))
+		return false
+	}
+	return true
+}
+
+// bookingAllowed checks the limits of bookings from the client address
+// and of the phone, answering 429 itself when one is reached
+func (h *handlerV1) bookingAllowed(c *gin.Context, clientIp, phoneDigits string, now time.Time) bool {
+	recent, err := h.storage.Booking().CountRecent(c.Request.Context(), clientIp, now.Add(-time.Hour))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to book",
+		})
+		h.log(c).Error("Failed to count bookings", logger.Error(err))
+		return false
+	}
+	if recent >= h.cfg.BookingMaxPerIp {
+		h.log(c).Warn("Booking limit of address reached", logger.String("client_ip", clientIp))
+		c.Header("Retry-After", ratelimit.RetryAfter(time.Hour))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many bookings, try again later",
+		})
+		return false
+	}
+
+	pending, err := h.storage.Booking().CountPending(c.Request.Context(), phoneDigits)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to book",
+		})
+		h.log(c).Error("Failed to count bookings", logger.Error(err))
+		return false
+	}
+	if pending >= h.cfg.BookingMaxPendingPerPhone {
+		c.Header("Retry-After", ratelimit.RetryAfter(h.bookingTTL()))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "This phone has bookings waiting for confirmation",
+		})
+		return false
+	}
+	return true
+}
+
+// bookingDoctors returns the doctors that can be booked, only the one
+// with doctorId when it is set
+func (h *handlerV1) bookingDoctors(c *gin.Context, doctorId string) ([]*repo.Doctor, bool) {
+	doctors, err := h.storage.Booking().Doctors(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get doctors",
+		})
+		h.log(c).Error("Failed to get doctors", logger.Error(err))
+		return nil, false
+	}
+	if doctorId == "" {
+		return doctors, true
+	}
+	for _, d := range doctors {
+		if d.Id == doctorId {
+			return []*repo.Doctor{d}, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": "doctor does not exist",
+	})
+	return nil, false
+}
+
+func (h *handlerV1) publicBooking(r *repo.BookingRequest) models.PublicBooking {
+	confirmation := booking.ConfirmByCode
+	if r.CodeHash == "" {
+		confirmation = booking.ConfirmByStaff
+	}
+	return models.PublicBooking{
+		Id:           r.Id,
+		Procedure:    r.Procedure,
+		Start:        r.Date.In(h.schedule.Location),
+		DoctorId:     r.DoctorId,
+		Status:       r.Status,
+		Confirmation: confirmation,
+		ExpiresAt:    r.ExpiresAt,
+	}
+}
+
+// bookingTTL is how long a booking waits for its confirmation
+func (h *handlerV1) bookingTTL() time.Duration {
+	if h.cfg.BookingConfirmation == booking.ConfirmByStaff {
+		return h.cfg.BookingStaffTTL
+	}
+	return h.cfg.BookingOtpTTL
+}
+
+func bookingProcedure(p booking.Procedure) models.BookingProcedure {
+	return models.BookingProcedure{
+		Name:            p.Name,
+		DurationMinutes: int(p.Duration / time.Minute),
+	}
+}
+
+func bookingRequest(r *repo.BookingRequest) models.BookingRequest {
+	return models.BookingRequest{
+		Id:              r.Id,
+		AppointmentId:   r.AppointmentId,
+		ClientId:        r.ClientId,
+		Procedure:       r.Procedure,
+		DurationMinutes: int(r.Duration / time.Minute),
+		DoctorId:        r.DoctorId,
+		Date:            r.Date,
+		ClientIp:        r.ClientIp,
+		Attempts:        r.Attempts,
+		Status:          r.Status,
+		ConfirmedBy:     r.ConfirmedBy,
+		ExpiresAt:       r.ExpiresAt,
+		CreatedAt:       r.CreatedAt,
+		DecidedAt:       r.DecidedAt,
+	}
+}
@@ -0,0 +1,490 @@
+package v1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dentist/pkg/ical"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+)
+
+// A CalDAV account of a feed is laid out as
+//
+//	/caldav/{token}/                  principal and calendar home
+//	/caldav/{token}/schedule/         the calendar
+//	/caldav/{token}/schedule/{id}.ics an appointment
+//
+// It is read-only, only discovery, reports and GET are answered.
+
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+
+	caldavCalendar = "schedule/"
+	caldavAllow    = "OPTIONS, GET, HEAD, PROPFIND, REPORT"
+
+	// maxDavBody bounds the XML bodies of PROPFIND and REPORT
+	maxDavBody = 1 << 20
+)
+
+// CalDAV answers the read-only CalDAV methods of a calendar feed
+func (h *handlerV1) CalDAV(c *gin.Context) {
+	if c.Request.Method == http.MethodOptions {
+		c.Header("DAV", "1, calendar-access")
+		c.Header("Allow", caldavAllow)
+		c.Status(http.StatusOK)
+		return
+	}
+
+	feed, ok := h.calendarFeedOf(c, c.Param("token"))
+	if !ok {
+		return
+	}
+	dav := &davRequest{
+		h:    h,
+		c:    c,
+		feed: feed,
+		home: "/caldav/" + c.Param("token") + "/",
+		path: strings.TrimPrefix(c.Param("path"), "/"),
+	}
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead:
+		dav.get()
+	case "PROPFIND":
+		dav.propfind()
+	case "REPORT":
+		dav.report()
+	default:
+		c.Header("Allow", caldavAllow)
+		c.Status(http.StatusMethodNotAllowed)
+	}
+}
+
+type davRequest struct {
+	h    *handlerV1
+	c    *gin.Context
+	feed *repo.CalendarFeed
+	home string
+	// path is what follows home
+	path string
+}
+
+// davProp is a property of a resource, its value is inner XML
+type davProp struct {
+	name  xml.Name
+	value string
+}
+
+type davResource struct {
+	href  string
+	props []davProp
+}
+
+func (d *davRequest) calendarHref() string {
+	return d.home + caldavCalendar
+}
+
+func (d *davRequest) eventHref(ev *ical.Event) string {
+	return d.calendarHref() + strings.TrimSuffix(ev.UID, "@dentist") + ".ics"
+}
+
+// eventOf returns the appointment id a path names, "" when it names none
+func (d *davRequest) eventOf(path string) string {
+	name := strings.TrimPrefix(path, caldavCalendar)
+	if name == path || !strings.HasSuffix(name, ".ics") || strings.Contains(name, "/") {
+		return ""
+	}
+	return strings.TrimSuffix(name, ".ics")
+}
+
+func (d *davRequest) events(from, to time.Time) ([]*ical.Event, bool) {
+	events, err := d.h.calendarEvents(d.c, d.feed, from, to)
+	if err != nil {
+		d.c.Status(http.StatusInternalServerError)
+		d.h.log(d.c).Error("Failed to get calendar", logger.Error(err))
+		return nil, false
+	}
+	return events, true
+}
+
+func (d *davRequest) get() {
+	from, to := d.h.calendarWindow()
+	switch {
+	case d.path == caldavCalendar:
+		d.c.Redirect(http.StatusFound, "/calendar/"+d.c.Param("token")+".ics")
+		return
+	case d.eventOf(d.path) == "":
+		d.c.Status(http.StatusNotFound)
+		return
+	}
+
+	events, ok := d.events(from, to)
+	if !ok {
+		return
+	}
+	for _, ev := range events {
+		if d.eventHref(ev) != d.home+d.path {
+			continue
+		}
+		d.c.Header("Content-Type", "text/calendar; charset=utf-8")
+		d.c.Header("ETag", ical.ETag(ev))
+		d.c.Status(http.StatusOK)
+		if d.c.Request.Method == http.MethodHead {
+			return
+		}
+		if err := ical.WriteEvent(d.c.Writer, ev); err != nil {
+			d.h.log(d.c).Error("Failed to write event", logger.Error(err))
+		}
+		return
+	}
+	d.c.Status(http.StatusNotFound)
+}
+
+type davPropfind struct {
+	XMLName  xml.Name   `xml:"DAV: propfind"`
+	AllProp  *struct{}  `xml:"DAV: allprop"`
+	PropName *struct{}  `xml:"DAV: propname"`
+	Prop     davPropSet `xml:"DAV: prop"`
+}
+
+type davPropSet struct {
+	Names []davName `xml:",any"`
+}
+
+type davName struct {
+	XMLName xml.Name
+}
+
+func (s davPropSet) names() []xml.Name {
+	names := make([]xml.Name, 0, len(s.Names))
+	for _, n := range s.Names {
+		names = append(names, n.XMLName)
+	}
+	return names
+}
+
+func (d *davRequest) propfind() {
+	var body davPropfind
+	if !d.readBody(&body) {
+		return
+	}
+	var names []xml.Name
+	if body.AllProp == nil && body.PropName == nil {
+		names = body.Prop.names()
+	}
+	depth := d.c.GetHeader("Depth")
+	if depth == "" {
+		depth = "infinity"
+	}
+
+	var resources []davResource
+	switch {
+	case d.path == "":
+		resources = append(resources, d.homeResource())
+		if depth != "0" {
+			calendar, ok := d.calendarResource()
+			if !ok {
+				return
+			}
+			resources = append(resources, calendar)
+		}
+	case d.path == caldavCalendar:
+		calendar, ok := d.calendarResource()
+		if !ok {
+			return
+		}
+		resources = append(resources, calendar)
+		if depth != "0" {
+			events, ok := d.events(d.h.calendarWindow())
+			if !ok {
+				return
+			}
+			for _, ev := range events {
+				resources = append(resources, d.eventResource(ev, false))
+			}
+		}
+	case d.eventOf(d.path) != "":
+		events, ok := d.events(d.h.calendarWindow())
+		if !ok {
+			return
+		}
+		for _, ev := range events {
+			if d.eventHref(ev) == d.home+d.path {
+				resources = append(resources, d.eventResource(ev, false))
+			}
+		}
+		if len(resources) == 0 {
+			d.c.Status(http.StatusNotFound)
+			return
+		}
+	default:
+		d.c.Status(http.StatusNotFound)
+		return
+	}
+
+	d.multistatus(resources, names)
+}
+
+type davReport struct {
+	XMLName xml.Name
+	Prop    davPropSet `xml:"DAV: prop"`
+	Hrefs   []string   `xml:"DAV: href"`
+	Filter  struct {
+		Comps []davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+type davCompFilter struct {
+	Name      string          `xml:"name,attr"`
+	TimeRange *davTimeRange   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	Comps     []davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type davTimeRange struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+// timeRange finds the time-range of the VEVENT filter
+func (f davCompFilter) timeRange() *davTimeRange {
+	if f.TimeRange != nil {
+		return f.TimeRange
+	}
+	for _, comp := range f.Comps {
+		if r := comp.timeRange(); r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+func (d *davRequest) report() {
+	if d.path != caldavCalendar {
+		d.c.Status(http.StatusForbidden)
+		return
+	}
+	var body davReport
+	if !d.readBody(&body) {
+		return
+	}
+
+	from, to := d.h.calendarWindow()
+	var hrefs map[string]bool
+	switch body.XMLName {
+	case xml.Name{Space: nsCalDAV, Local: "calendar-query"}:
+		for _, comp := range body.Filter.Comps {
+			r := comp.timeRange()
+			if r == nil {
+				continue
+			}
+			if t, err := time.Parse("20060102T150405Z", r.Start); err == nil {
+				from = t
+			}
+			if t, err := time.Parse("20060102T150405Z", r.End); err == nil {
+				to = t
+			}
+		}
+	case xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}:
+		hrefs = map[string]bool{}
+		for _, href := range body.Hrefs {
+			// clients may send the full URL or escape the path
+			if u, err := url.Parse(strings.TrimSpace(href)); err == nil {
+				hrefs[u.Path] = true
+			}
+		}
+	default:
+		d.c.Status(http.StatusForbidden)
+		return
+	}
+
+	events, ok := d.events(from, to)
+	if !ok {
+		return
+	}
+	names := body.Prop.names()
+	withData := false
+	for _, n := range names {
+		if n == (xml.Name{Space: nsCalDAV, Local: "calendar-data"}) {
+			withData = true
+		}
+	}
+
+	var resources []davResource
+	for _, ev := range events {
+		href := d.eventHref(ev)
+		if hrefs != nil {
+			if !hrefs[href] {
+				continue
+			}
+			delete(hrefs, href)
+		}
+		resources = append(resources, d.eventResource(ev, withData))
+	}
+	// hrefs left over are gone, or out of the window of the feed
+	for href := range hrefs {
+		resources = append(resources, davResource{href: href})
+	}
+
+	d.multistatus(resources, names)
+}
+
+func (d *davRequest) homeResource() davResource {
+	principal := davHref(d.home)
+	return davResource{
+		href: d.home,
+		props: []davProp{
+			{xml.Name{Space: nsDAV, Local: "resourcetype"}, "<d:collection/><d:principal/>"},
+			{xml.Name{Space: nsDAV, Local: "displayname"}, davText(d.feed.Name)},
+			{xml.Name{Space: nsDAV, Local: "current-user-principal"}, principal},
+			{xml.Name{Space: nsDAV, Local: "principal-URL"}, principal},
+			{xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}, principal},
+		},
+	}
+}
+
+func (d *davRequest) calendarResource() (davResource, bool) {
+	events, ok := d.events(d.h.calendarWindow())
+	if !ok {
+		return davResource{}, false
+	}
+	// the ctag changes whenever an event of the calendar does
+	h := sha256.New()
+	for _, ev := range events {
+		io.WriteString(h, ical.ETag(ev))
+	}
+	ctag := `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+
+	return davResource{
+		href: d.calendarHref(),
+		props: []davProp{
+			{xml.Name{Space: nsDAV, Local: "resourcetype"}, "<d:collection/><c:calendar/>"},
+			{xml.Name{Space: nsDAV, Local: "displayname"}, davText(d.feed.Name)},
+			{xml.Name{Space: nsDAV, Local: "current-user-principal"}, davHref(d.home)},
+			{xml.Name{Space: nsDAV, Local: "current-user-privilege-set"}, "<d:privilege><d:read/></d:privilege>"},
+			{xml.Name{Space: nsDAV, Local: "supported-report-set"}, "<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>" +
+				"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>"},
+			{xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}, `<c:comp name="VEVENT"/>`},
+			{xml.Name{Space: nsCS, Local: "getctag"}, davText(ctag)},
+			{xml.Name{Space: nsDAV, Local: "getetag"}, davText(ctag)},
+		},
+	}, true
+}
+
+func (d *davRequest) eventResource(ev *ical.Event, withData bool) davResource {
+	props := []davProp{
+		{xml.Name{Space: nsDAV, Local: "resourcetype"}, ""},
+		{xml.Name{Space: nsDAV, Local: "getetag"}, davText(ical.ETag(ev))},
+		{xml.Name{Space: nsDAV, Local: "getcontenttype"}, "text/calendar; charset=utf-8"},
+	}
+	if withData {
+		var b bytes.Buffer
+		ical.WriteEvent(&b, ev)
+		props = append(props, davProp{xml.Name{Space: nsCalDAV, Local: "calendar-data"}, davText(b.String())})
+	}
+	return davResource{href: d.eventHref(ev), props: props}
+}
+
+// multistatus answers 207 with the props of resources that were asked for,
+// all of them when names is empty. A resource without props is not found.
+func (d *davRequest) multistatus(resources []davResource, names []xml.Name) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="` + nsCalDAV + `" xmlns:cs="` + nsCS + `">`)
+	for _, r := range resources {
+		b.WriteString("<d:response>" + davHref(r.href))
+		if r.props == nil {
+			b.WriteString("<d:status>HTTP/1.1 404 Not Found</d:status></d:response>")
+			continue
+		}
+
+		found, missing := r.props, []xml.Name(nil)
+		if len(names) > 0 {
+			found = nil
+			for _, n := range names {
+				prop, ok := findProp(r.props, n)
+				if ok {
+					found = append(found, prop)
+				} else {
+					missing = append(missing, n)
+				}
+			}
+		}
+		if len(found) > 0 {
+			b.WriteString("<d:propstat><d:prop>")
+			for _, p := range found {
+				tag := davTag(p.name)
+				b.WriteString("<" + tag + ">" + p.value + "</" + tag + ">")
+			}
+			b.WriteString("</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>")
+		}
+		if len(missing) > 0 {
+			b.WriteString("<d:propstat><d:prop>")
+			for _, n := range missing {
+				b.WriteString(`<x:` + davText(n.Local) + ` xmlns:x="` + davText(n.Space) + `"/>`)
+			}
+			b.WriteString("</d:prop><d:status>HTTP/1.1 404 Not Found</d:status></d:propstat>")
+		}
+		b.WriteString("</d:response>")
+	}
+	b.WriteString("</d:multistatus>")
+
+	d.c.Data(http.StatusMultiStatus, "application/xml; charset=utf-8", []byte(b.String()))
+}
+
+// readBody decodes the XML body into v, an empty body leaves v as it is
+func (d *davRequest) readBody(v interface{}) bool {
+	body, err := io.ReadAll(io.LimitReader(d.c.Request.Body, maxDavBody))
+	if err != nil {
+		d.c.Status(http.StatusBadRequest)
+		return false
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return true
+	}
+	if err = xml.Unmarshal(body, v); err != nil {
+		d.c.String(http.StatusBadRequest, "malformed XML body")
+		return false
+	}
+	return true
+}
+
+func findProp(props []davProp, name xml.Name) (davProp, bool) {
+	for _, p := range props {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return davProp{}, false
+}
+
+// davTag is the prefixed name of a property in the multistatus
+func davTag(name xml.Name) string {
+	switch name.Space {
+	case nsCalDAV:
+		return "c:" + name.Local
+	case nsCS:
+		return "cs:" + name.Local
+	}
+	return "d:" + name.Local
+}
+
+func davHref(href string) string {
+	return "<d:href>" + davText(href) + "</d:href>"
+}
+
+func davText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
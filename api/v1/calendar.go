@@ -0,0 +1,348 @@
+package v1
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/ical"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateCalendarFeed
+// @Summary CreateCalendarFeed
+// @Description Api for create a secret link to the schedule of a doctor, or of the whole clinic without doctor_id, for calendar apps. The links are returned only here, a lost link is revoked and a new feed created.
+// @Tags calendar
+// @Accept json
+// @Produce json
+// @Param feed body models.CalendarFeedRequest true "feed"
+// @Success 201 {object} models.CalendarFeedCreated
+// @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/calendar-feeds [post]
+func (h *handlerV1) CreateCalendarFeed(c *gin.Context) {
+	var body models.CalendarFeedRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if body.DoctorId != "" {
+		if _, err := uuid.Parse(body.DoctorId); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "doctor_id must be a uuid",
+			})
+			return
+		}
+		doctor, err := h.storage.Doctor().GetDoctor(c.Request.Context(), body.DoctorId)
+		if errors.Is(err, repo.ErrNotFound) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "doctor does not exist",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get doctor",
+			})
+			h.log(c).Error("Failed to get doctor", logger.Error(err))
+			return
+		}
+		if body.Name == "" {
+			body.Name = strings.TrimSpace(doctor.Name + " " + doctor.LastName)
+		}
+	}
+	if body.Name == "" {
+		body.Name = "Clinic"
+	}
+
+	token, err := newFeedToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create calendar feed",
+		})
+		h.log(c).Error("Failed to create feed token", logger.Error(err))
+		return
+	}
+
+	feed, err := h.storage.Calendar().CreateFeed(c.Request.Context(), &repo.CalendarFeed{
+		TokenHash:    hashFeedToken(token),
+		DoctorId:     body.DoctorId,
+		Name:         body.Name,
+		MaskPatients: body.MaskPatients,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create calendar feed",
+		})
+		h.log(c).Error("Failed to create calendar feed", logger.Error(err))
+		return
+	}
+	h.log(c).Info("Calendar feed created",
+		logger.String("feed_id", feed.Id),
+		logger.String("doctor_id", feed.DoctorId),
+		logger.Bool("mask_patients", feed.MaskPatients),
+	)
+
+	base := h.publicUrl(c)
+	c.JSON(http.StatusCreated, models.CalendarFeedCreated{
+		CalendarFeed: calendarFeed(feed),
+		IcsUrl:       base + "/calendar/" + token + ".ics",
+		CaldavUrl:    base + "/caldav/" + token + "/",
+	})
+}
+
+// GetCalendarFeeds
+// @Summary GetCalendarFeeds
+// @Description Api for get the calendar feeds, revoked ones included. Their links are not shown again.
+// @Tags calendar
+// @Produce json
+// @Success 200 {array} models.CalendarFeed
+// @Failure 500 {object} models.Error
+// @Router /v1/calendar-feeds [get]
+func (h *handlerV1) GetCalendarFeeds(c *gin.Context) {
+	feeds, err := h.storage.Calendar().GetFeeds(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get calendar feeds",
+		})
+		h.log(c).Error("Failed to get calendar feeds", logger.Error(err))
+		return
+	}
+
+	response := make([]models.CalendarFeed, 0, len(feeds))
+	for _, feed := range feeds {
+		response = append(response, calendarFeed(feed))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeCalendarFeed
+// @Summary RevokeCalendarFeed
+// @Description Api for revoke a calendar feed, its links stop working at once
+// @Tags calendar
+// @Produce json
+// @Param id path string true "feed id"
+// @Success 204
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/calendar-feeds/{id} [delete]
+func (h *handlerV1) RevokeCalendarFeed(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	revoked, err := h.storage.Calendar().RevokeFeed(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to revoke calendar feed",
+		})
+		h.log(c).Error("Failed to revoke calendar feed", logger.Error(err))
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Calendar feed not found",
+		})
+		return
+	}
+	h.log(c).Info("Calendar feed revoked", logger.String("feed_id", id))
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetCalendar
+// @Summary GetCalendar
+// @Description iCalendar subscription of a feed, the token is the secret of its link. Patients are shown by initials only in a masked feed.
+// @Tags calendar
+// @Produce text/calendar
+// @Param token path string true "token of the feed followed by .ics"
+// @Success 200 {file} file
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /calendar/{token} [get]
+func (h *handlerV1) GetCalendar(c *gin.Context) {
+	feed, ok := h.calendarFeedOf(c, strings.TrimSuffix(c.Param("token"), ".ics"))
+	if !ok {
+		return
+	}
+
+	from, to := h.calendarWindow()
+	events, err := h.calendarEvents(c, feed, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get calendar",
+		})
+		h.log(c).Error("Failed to get calendar", logger.Error(err))
+		return
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", `inline; filename="calendar.ics"`)
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+	err = ical.Write(c.Writer, &ical.Calendar{
+		Name:            feed.Name,
+		TimeZone:        h.cfg.ClinicTimezone,
+		RefreshInterval: h.cfg.CalendarRefreshInterval,
+		Events:          events,
+	})
+	if err != nil {
+		h.log(c).Error("Failed to write calendar", logger.Error(err))
+	}
+}
+
+// calendarFeedOf finds the live feed of token, answering 404 itself for an
+// unknown or revoked one
+func (h *handlerV1) calendarFeedOf(c *gin.Context, token string) (*repo.CalendarFeed, bool) {
+	feed, err := h.storage.Calendar().GetFeedByToken(c.Request.Context(), hashFeedToken(token))
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Calendar not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get calendar",
+		})
+		h.log(c).Error("Failed to get calendar feed", logger.Error(err))
+		return nil, false
+	}
+	return feed, true
+}
+
+// calendarWindow is the span of appointments a feed has
+func (h *handlerV1) calendarWindow() (time.Time, time.Time) {
+	now := time.Now()
+	return now.AddDate(0, 0, -h.cfg.CalendarPastDays), now.AddDate(0, 0, h.cfg.CalendarFutureDays)
+}
+
+// calendarEvents returns the appointments of feed between from and to as
+// events
+func (h *handlerV1) calendarEvents(c *gin.Context, feed *repo.CalendarFeed, from, to time.Time) ([]*ical.Event, error) {
+	// an event that started before from may still be going on
+	from = from.Add(-h.cfg.AppointmentDuration)
+	filter := &repo.AppointmentFilter{
+		From:     &from,
+		To:       &to,
+		DoctorId: feed.DoctorId,
+		Sort:     repo.SortDate,
+	}
+
+	stamp := time.Now()
+	var events []*ical.Event
+	err := h.storage.Appointment().StreamAppointments(c.Request.Context(), filter, func(a *repo.AppointmentRow) error {
+		events = append(events, h.calendarEvent(feed, a, stamp))
+		return nil
+	})
+	return events, err
+}
+
+func (h *handlerV1) calendarEvent(feed *repo.CalendarFeed, a *repo.AppointmentRow, stamp time.Time) *ical.Event {
+	ev := &ical.Event{
+		UID:     a.Id + "@dentist",
+		Start:   a.Date,
+		End:     a.Date.Add(h.cfg.AppointmentDuration),
+		Summary: strings.TrimSpace(a.ClientName),
+		Status:  eventStatus(a.Status),
+		Stamp:   stamp,
+	}
+
+	var details []string
+	if feed.MaskPatients {
+		ev.Summary = initials(a.ClientName)
+	} else if a.Treatment != "" {
+		ev.Summary += " - " + a.Treatment
+	}
+	if feed.DoctorId == "" && strings.TrimSpace(a.DoctorName) != "" {
+		details = append(details, "Doctor: "+strings.TrimSpace(a.DoctorName))
+	}
+	if !feed.MaskPatients {
+		if a.ClientPhone != "" {
+			details = append(details, "Phone: "+a.ClientPhone)
+		}
+		if a.Diagnostics != "" {
+			details = append(details, "Diagnostics: "+a.Diagnostics)
+		}
+	}
+	details = append(details, "Status: "+a.Status)
+	ev.Description = strings.Join(details, "\n")
+	return ev
+}
+
+// publicUrl is the address the links of the API are built on
+func (h *handlerV1) publicUrl(c *gin.Context) string {
+	if h.cfg.PublicUrl != "" {
+		return strings.TrimSuffix(h.cfg.PublicUrl, "/")
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+func eventStatus(status string) string {
+	switch status {
+	case repo.StatusCancelled, repo.StatusNoShow:
+		return ical.StatusCancelled
+	case repo.StatusPending, repo.StatusScheduled:
+		return ical.StatusTentative
+	}
+	return ical.StatusConfirmed
+}
+
+// initials turns "Ali Karimov" into "A. K."
+func initials(name string) string {
+	var parts []string
+	for _, word := range strings.Fields(name) {
+		parts = append(parts, strings.ToUpper(string([]rune(word)[:1]))+".")
+	}
+	if len(parts) == 0 {
+		return "Patient"
+	}
+	return strings.Join(parts, " ")
+}
+
+// newFeedToken returns 32 random bytes, safe to put in a URL
+func newFeedToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func calendarFeed(feed *repo.CalendarFeed) models.CalendarFeed {
+	return models.CalendarFeed{
+		Id:           feed.Id,
+		DoctorId:     feed.DoctorId,
+		Name:         feed.Name,
+		MaskPatients: feed.MaskPatients,
+		CreatedAt:    feed.CreatedAt,
+		RevokedAt:    feed.RevokedAt,
+	}
+}
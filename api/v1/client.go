@@ -1,15 +1,14 @@
 package v1
 
 import (
-	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
 	"github.com/dentist/storage/repo"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/spf13/cast"
 )
 
 // CreateClient ...
@@ -31,7 +30,7 @@ func (h *handlerV1) CreateClient(c *gin.Context) {
 		return
 	}
 	Id := uuid.NewString()
-	response, err := h.storage.Client().CreateClient(&repo.Client{
+	response, err := h.storage.Client().CreateClient(c.Request.Context(), &repo.Client{
 		Id:          Id,
 		Name:        req.Name,
 		LastName:    req.LastName,
@@ -44,7 +43,7 @@ func (h *handlerV1) CreateClient(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to create client",
 		})
-		h.logger.Error("Failed to create client")
+		h.log(c).Error("Failed to create client", logger.Error(err))
 		return
 	}
 
@@ -64,12 +63,12 @@ func (h *handlerV1) CreateClient(c *gin.Context) {
 // @Router /v1/client [get]
 func (h *handlerV1) GetClient(c *gin.Context) {
 	id := c.Query("id")
-	response, err := h.storage.Client().GetClient(id)
+	response, err := h.storage.Client().GetClient(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get client",
 		})
-		h.logger.Error("Failed to get client")
+		h.log(c).Error("Failed to get client", logger.Error(err))
 		return
 	}
 
@@ -83,30 +82,34 @@ func (h *handlerV1) GetClient(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id query string true "id"
-// @Param page query string true "page"
-// @Param limit query string true "limit"
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Success 200 {object} models.Client
 // @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
 // @Router /v1/clientappointment [get]
 func (h *handlerV1) GetClientWithAppointments(c *gin.Context) {
 	id := c.Query("id")
-	page := c.Query("page")
-	limit := c.Query("limit")
-	respClient, err := h.storage.Client().GetClient(id)
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+	respClient, err := h.storage.Client().GetClient(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get client",
 		})
-		h.logger.Error("Failed to get client")
+		h.log(c).Error("Failed to get client", logger.Error(err))
 		return
 	}
-	respAppointment, err := h.storage.Appointment().GetAppointmentsWithClientId(id, cast.ToInt(page), cast.ToInt(limit))
+	respAppointment, err := h.storage.Appointment().GetAppointmentsWithClientId(c.Request.Context(), id, params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get client's appointment",
 		})
-		h.logger.Error("Failed to get client's appointment")
+		h.log(c).Error("Failed to get client's appointment", logger.Error(err))
 		return
 	}
 	response := models.Client{
@@ -117,7 +120,7 @@ func (h *handlerV1) GetClientWithAppointments(c *gin.Context) {
 		PhoneNumber:     respClient.PhoneNumber,
 		Address:         respClient.Address,
 		BirthDate:       respClient.BirthDate,
-		AllAppointments: respAppointment,
+		AllAppointments: pagination.NewPage(respAppointment.Appointment, respAppointment.Total, respAppointment.NextCursor),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -139,11 +142,11 @@ func (h *handlerV1) UpdateClient(c *gin.Context) {
 	err := c.ShouldBindJSON(&client)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, err.Error())
-		h.logger.Error("Error binding json update client")
+		h.log(c).Error("Error binding json update client", logger.Error(err))
 		return
 	}
 
-	response, err := h.storage.Client().UpdateClient(&repo.Client{
+	response, err := h.storage.Client().UpdateClient(c.Request.Context(), &repo.Client{
 		Id:          client.Id,
 		Name:        client.Name,
 		LastName:    client.LastName,
@@ -156,7 +159,7 @@ func (h *handlerV1) UpdateClient(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update client",
 		})
-		h.logger.Error("Failed to update client")
+		h.log(c).Error("Failed to update client", logger.Error(err))
 		return
 	}
 
@@ -176,12 +179,12 @@ func (h *handlerV1) UpdateClient(c *gin.Context) {
 // @Router /v1/client [delete]
 func (h *handlerV1) DeleteClient(c *gin.Context) {
 	id := c.Query("id")
-	response, err := h.storage.Client().DeleteClient(id)
+	response, err := h.storage.Client().DeleteClient(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete client",
 		})
-		h.logger.Error("Failed to delete client")
+		h.log(c).Error("Failed to delete client", logger.Error(err))
 		return
 	}
 
@@ -194,41 +197,37 @@ func (h *handlerV1) DeleteClient(c *gin.Context) {
 // @Tags client
 // @Accept json
 // @Produce json
-// @Param page query string true "page"
-// @Param limit query string true "limit"
-// @Success 200 {object} models.AllClients
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[repo.Client]
 // @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
 // @Router /v1/clients [get]
 func (h *handlerV1) GetAllClients(c *gin.Context) {
-	page := c.Query("page")
-	limit := c.Query("limit")
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
 
-	response, err := h.storage.Client().GetAllClients(&repo.GetAllClient{
-		Page:  cast.ToInt(page),
-		Limit: cast.ToInt(limit),
+	response, err := h.storage.Client().GetAllClients(c.Request.Context(), &repo.GetAllClient{
+		Params: params,
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get all clients",
 		})
-		h.logger.Error("Failed to get all clients")
+		h.log(c).Error("Failed to get all clients", logger.Error(err))
 		return
 	}
-	if len(response.Clients) == 0 {
-		c.JSON(http.StatusOK, models.AllClients{
-			Clients: []*models.Client{},
-		})
-		return
-	}
-	fmt.Println(response)
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, pagination.NewPage(response.Clients, response.Total, response.NextCursor))
 }
 
 // GetAllClientsCount
 // @Summary GetAllClientsCount
-// @Description Api for get all clients count
+// @Description Api for get all clients count, deprecated: lists report total themselves
 // @Tags client
 // @Accept json
 // @Produce json
@@ -237,9 +236,12 @@ func (h *handlerV1) GetAllClients(c *gin.Context) {
 // @Failure 500 {object} models.Error
 // @Router /v1/count [get]
 func (h *handlerV1) GetAllClientsCount(c *gin.Context) {
-	resp, err := h.storage.Client().GetAllClientsCount()
+	resp, err := h.storage.Client().GetAllClientsCount(c.Request.Context())
 	if err != nil {
-		log.Println("Failed to get all clietns count")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get all clients count",
+		})
+		h.log(c).Error("Failed to get all clients count", logger.Error(err))
 		return
 	}
 
@@ -253,26 +255,27 @@ func (h *handlerV1) GetAllClientsCount(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param str query string true "SearchClients"
-// @Success 200 {object} models.AllClients
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[repo.Client]
 // @Failure 400 {object} models.Error
 // @Failure 500 {object} models.Error
 // @Router /v1/search [get]
 func (h *handlerV1) SearchClients(c *gin.Context) {
 	str := c.Query("str")
-	response, err := h.storage.Client().SearchClients(str)
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+	response, err := h.storage.Client().SearchClients(c.Request.Context(), str, params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to search clients",
 		})
-		log.Println("Failed to search clients", err)
-		return
-	}
-	if len(response.Clients) == 0 {
-		c.JSON(http.StatusOK, models.AllClients{
-			Clients: []*models.Client{},
-		})
+		h.log(c).Error("Failed to search clients", logger.Error(err))
 		return
 	}
-
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, pagination.NewPage(response.Clients, response.Total, response.NextCursor))
 }
@@ -0,0 +1,476 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetRelations
+// @Summary GetRelations
+// @Description Api for get the relations of a client, the children it is the guardian of are listed as wards
+// @Tags family
+// @Produce json
+// @Param id path string true "client id"
+// @Success 200 {array} models.RelationResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/clients/{id}/relations [get]
+func (h *handlerV1) GetRelations(c *gin.Context) {
+	id, ok := h.familyClient(c)
+	if !ok {
+		return
+	}
+
+	h.relations(c, http.StatusOK, id)
+}
+
+// AddRelation
+// @Summary AddRelation
+// @Description Api for add a relation between two clients, spouse and sibling are stored for both. The first guardian of a minor without a guarantor becomes its guarantor.
+// @Tags family
+// @Accept json
+// @Produce json
+// @Param id path string true "client id"
+// @Param Relation body models.RelationRequest true "AddRelation"
+// @Success 201 {array} models.RelationResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/clients/{id}/relations [post]
+func (h *handlerV1) AddRelation(c *gin.Context) {
+	id, ok := h.familyClient(c)
+	if !ok {
+		return
+	}
+	var req models.RelationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if _, err := uuid.Parse(req.RelatedId); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "related_id must be a uuid",
+		})
+		return
+	}
+	if req.RelatedId == id {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "A client cannot be related to itself",
+		})
+		return
+	}
+	if !repo.ValidRelation(req.Kind) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "kind must be guardian, spouse or sibling",
+		})
+		return
+	}
+
+	err := h.storage.Family().AddRelation(c.Request.Context(), id, req.RelatedId, req.Kind)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Related client not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to add relation",
+		})
+		h.log(c).Error("Failed to add relation", logger.Error(err))
+		return
+	}
+
+	if req.Kind == repo.RelationGuardian {
+		if err = h.defaultGuarantor(c, id, req.RelatedId); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to set guarantor",
+			})
+			h.log(c).Error("Failed to set guarantor", logger.Error(err))
+			return
+		}
+	}
+	h.log(c).Info("Client relation added",
+		logger.String("client_id", id),
+		logger.String("related_id", req.RelatedId),
+		logger.String("kind", req.Kind),
+	)
+
+	h.relations(c, http.StatusCreated, id)
+}
+
+// DeleteRelation
+// @Summary DeleteRelation
+// @Description Api for delete a relation between two clients, the last guardian of a minor cannot be removed
+// @Tags family
+// @Produce json
+// @Param id path string true "client id"
+// @Param related_id path string true "related client id"
+// @Param kind query string true "guardian, spouse or sibling"
+// @Success 200 {object} bool
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/clients/{id}/relations/{related_id} [delete]
+func (h *handlerV1) DeleteRelation(c *gin.Context) {
+	id, ok := h.familyClient(c)
+	if !ok {
+		return
+	}
+	relatedId, kind := c.Param("related_id"), c.Query("kind")
+	if _, err := uuid.Parse(relatedId); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "related_id must be a uuid",
+		})
+		return
+	}
+	if !repo.ValidRelation(kind) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "kind must be guardian, spouse or sibling",
+		})
+		return
+	}
+
+	if kind == repo.RelationGuardian {
+		client, err := h.storage.Client().GetClient(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get client",
+			})
+			h.log(c).Error("Failed to get client", logger.Error(err))
+			return
+		}
+		if client.IsMinor(civil.Today(h.cfg.Location), h.cfg.AdultAge) {
+			guardians, err := h.storage.Family().CountGuardians(c.Request.Context(), id)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to count guardians",
+				})
+				h.log(c).Error("Failed to count guardians", logger.Error(err))
+				return
+			}
+			if guardians <= 1 {
+				c.JSON(http.StatusConflict, gin.H{
+					"error": "The last guardian of a minor cannot be removed",
+				})
+				return
+			}
+		}
+	}
+
+	deleted, err := h.storage.Family().DeleteRelation(c.Request.Context(), id, relatedId, kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete relation",
+		})
+		h.log(c).Error("Failed to delete relation", logger.Error(err))
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Relation not found",
+		})
+		return
+	}
+	h.log(c).Info("Client relation deleted",
+		logger.String("client_id", id),
+		logger.String("related_id", relatedId),
+		logger.String("kind", kind),
+	)
+
+	c.JSON(http.StatusOK, true)
+}
+
+// SetGuarantor
+// @Summary SetGuarantor
+// @Description Api for set who gets the reminders and bills of a client, it must be a guardian or spouse of the client. An empty guarantor_id makes the client answer for itself.
+// @Tags family
+// @Accept json
+// @Produce json
+// @Param id path string true "client id"
+// @Param Guarantor body models.GuarantorRequest true "SetGuarantor"
+// @Success 200 {object} models.FamilyClient
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/clients/{id}/guarantor [put]
+func (h *handlerV1) SetGuarantor(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	var req models.GuarantorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if req.GuarantorId != "" {
+		if _, err := uuid.Parse(req.GuarantorId); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "guarantor_id must be a uuid",
+			})
+			return
+		}
+	}
+
+	err := h.storage.Family().SetGuarantor(c.Request.Context(), id, req.GuarantorId)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Client not found",
+		})
+		return
+	}
+	if errors.Is(err, repo.ErrNotRelated) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to set guarantor",
+		})
+		h.log(c).Error("Failed to set guarantor", logger.Error(err))
+		return
+	}
+	h.log(c).Info("Client guarantor set",
+		logger.String("client_id", id),
+		logger.String("guarantor_id", req.GuarantorId),
+	)
+
+	h.contact(c, id)
+}
+
+// GetContact
+// @Summary GetContact
+// @Description Api for get who is contacted about a client: its guarantor, or the client itself
+// @Tags family
+// @Produce json
+// @Param id path string true "client id"
+// @Success 200 {object} models.FamilyClient
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/clients/{id}/contact [get]
+func (h *handlerV1) GetContact(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	h.contact(c, id)
+}
+
+// GetFamily
+// @Summary GetFamily
+// @Description Api for get everyone related to a client, their upcoming appointments and the combined balance
+// @Tags family
+// @Produce json
+// @Param id path string true "client id"
+// @Success 200 {object} models.FamilyResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/clients/{id}/family [get]
+func (h *handlerV1) GetFamily(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	family, err := h.storage.Family().GetFamily(c.Request.Context(), id, time.Now())
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Client not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get family",
+		})
+		h.log(c).Error("Failed to get family", logger.Error(err))
+		return
+	}
+
+	today := civil.Today(h.cfg.Location)
+	response := models.FamilyResponse{
+		Members:              make([]models.FamilyMember, 0, len(family.Members)),
+		UpcomingAppointments: make([]models.FamilyAppointment, 0, len(family.Upcoming)),
+		Balance:              family.Balance,
+	}
+	for _, m := range family.Members {
+		member := models.FamilyMember{
+			FamilyClient: familyClient(&m.Client),
+			Minor:        m.IsMinor(today, h.cfg.AdultAge),
+			GuarantorId:  m.GuarantorId,
+		}
+		if !m.BirthDate.IsZero() {
+			age := m.BirthDate.YearsUntil(today)
+			member.Age = &age
+		}
+		response.Members = append(response.Members, member)
+	}
+	for _, a := range family.Upcoming {
+		response.UpcomingAppointments = append(response.UpcomingAppointments, models.FamilyAppointment{
+			Id:        a.Id,
+			ClientId:  a.ClientId,
+			DoctorId:  a.DoctorId,
+			Date:      a.Date,
+			Treatment: a.Treatment,
+			Amount:    a.Amount,
+			Status:    a.Status,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// familyClient returns the :id path parameter of an active client,
+// answering 400 or 404 itself
+func (h *handlerV1) familyClient(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return "", false
+	}
+
+	_, err := h.storage.Client().GetClient(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Client not found",
+		})
+		return "", false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get client",
+		})
+		h.log(c).Error("Failed to get client", logger.Error(err))
+		return "", false
+	}
+	return id, true
+}
+
+// relations answers with the relations of the client
+func (h *handlerV1) relations(c *gin.Context, status int, id string) {
+	relations, err := h.storage.Family().GetRelations(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get relations",
+		})
+		h.log(c).Error("Failed to get relations", logger.Error(err))
+		return
+	}
+
+	response := make([]models.RelationResponse, 0, len(relations))
+	for _, r := range relations {
+		response = append(response, models.RelationResponse{
+			Kind:      r.Kind,
+			Client:    familyClient(&r.Related),
+			CreatedAt: r.CreatedAt,
+		})
+	}
+	c.JSON(status, response)
+}
+
+// contact answers with the client contacted about the given one
+func (h *handlerV1) contact(c *gin.Context, id string) {
+	contact, err := h.storage.Family().GetContact(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Client not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get contact",
+		})
+		h.log(c).Error("Failed to get contact", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, familyClient(contact))
+}
+
+// defaultGuarantor makes the new guardian the guarantor of a minor that
+// has none yet
+func (h *handlerV1) defaultGuarantor(c *gin.Context, id, guardianId string) error {
+	contact, err := h.storage.Family().GetContact(c.Request.Context(), id)
+	if err != nil {
+		return err
+	}
+	if contact.Id != id || !contact.IsMinor(civil.Today(h.cfg.Location), h.cfg.AdultAge) {
+		return nil
+	}
+	return h.storage.Family().SetGuarantor(c.Request.Context(), id, guardianId)
+}
+
+// guardianPresent answers 422 when the client is a minor without a
+// guardian, unknown clients are left to the insert to reject
+func (h *handlerV1) guardianPresent(c *gin.Context, clientId string) bool {
+	client, err := h.storage.Client().GetClient(c.Request.Context(), clientId)
+	if errors.Is(err, repo.ErrNotFound) {
+		return true
+	}
+	if err == nil && !client.IsMinor(civil.Today(h.cfg.Location), h.cfg.AdultAge) {
+		return true
+	}
+	var guardians int
+	if err == nil {
+		guardians, err = h.storage.Family().CountGuardians(c.Request.Context(), clientId)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get client",
+		})
+		h.log(c).Error("Failed to check guardian", logger.Error(err))
+		return false
+	}
+	if guardians == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "A minor client needs a guardian",
+		})
+		return false
+	}
+	return true
+}
+
+func familyClient(c *repo.Client) models.FamilyClient {
+	return models.FamilyClient{
+		Id:          c.Id,
+		Name:        c.Name,
+		LastName:    c.LastName,
+		FatherName:  c.FatherName,
+		PhoneNumber: c.PhoneNumber,
+		Address:     c.Address,
+		BirthDate:   c.BirthDate,
+	}
+}
@@ -1,7 +1,13 @@
 package v1
 
 import (
+	"net/http"
+
 	"github.com/dentist/config"
+	"github.com/dentist/pkg/booking"
+	"github.com/dentist/pkg/live"
+	"github.com/dentist/pkg/pagination"
+	"github.com/gin-gonic/gin"
 	"github.com/dentist/pkg/logger"
 	"github.com/dentist/storage"
 )
@@ -10,12 +16,20 @@ type handlerV1 struct {
 	cfg     *config.Config
 	storage storage.StorageI
 	logger logger.Logger
+	board  *live.Hub
+	schedule *booking.Schedule
+	codes    booking.Sender
 }
 
 type HandlerV1Options struct {
 	Cfg     *config.Config
 	Storage storage.StorageI
 	Logger logger.Logger
+	Board  *live.Hub
+	// BookingSchedule and BookingSender serve the bookings from the
+	// website
+	BookingSchedule *booking.Schedule
+	BookingSender   booking.Sender
 }
 
 func New(options *HandlerV1Options) *handlerV1 {
@@ -23,5 +37,26 @@ func New(options *HandlerV1Options) *handlerV1 {
 		cfg: options.Cfg,
 		storage: options.Storage,
 		logger: options.Logger,
+		board: options.Board,
+		schedule: options.BookingSchedule,
+		codes: options.BookingSender,
 	}
-}
\ No newline at end of file
+}
+
+// log returns the handler logger tagged with the request id of c
+func (h *handlerV1) log(c *gin.Context) logger.Logger {
+	return logger.FromContext(c.Request.Context(), h.logger)
+}
+
+// pagination parses the list parameters of c, answering 400 itself when
+// they are invalid
+func (h *handlerV1) pagination(c *gin.Context) (pagination.Params, bool) {
+	params, err := pagination.Parse(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return params, false
+	}
+	return params, true
+}
@@ -0,0 +1,224 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/importer"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/spf13/cast"
+)
+
+// maxListedProblems bounds the problems in the import response, the error
+// report has all of them
+const maxListedProblems = 100
+
+// Import
+// @Summary Import
+// @Description Api for import clients or appointments from a CSV or XLSX file. Columns are found by their title, mapping names the column of a field otherwise. Every row is checked first, with dry_run nothing is saved. Appointment rows find their client by client_id, phone number, or name and birth date, and create it when not found.
+// @Tags import
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "the .csv or .xlsx file, the first row is the header"
+// @Param kind formData string true "clients or appointments"
+// @Param dry_run formData bool false "only check the rows"
+// @Param mapping formData string false "JSON object from field to column title, e.g. phone_number: Tel"
+// @Success 200 {object} models.ImportResponse "dry run"
+// @Success 201 {object} models.ImportResponse
+// @Failure 400 {object} models.Error
+// @Failure 413 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/import [post]
+func (h *handlerV1) Import(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.ImportMaxBytes)
+	file, header, err := c.Request.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "The file is too large",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "file is required",
+		})
+		return
+	}
+	defer file.Close()
+
+	opts := importer.Options{
+		Kind:      c.PostForm("kind"),
+		FileName:  header.Filename,
+		DryRun:    cast.ToBool(c.PostForm("dry_run")),
+		BatchSize: h.cfg.ImportBatchSize,
+		Location:  h.cfg.Location,
+	}
+	if mapping := c.PostForm("mapping"); mapping != "" {
+		if err = json.Unmarshal([]byte(mapping), &opts.Mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "mapping must be a JSON object from field to column title",
+			})
+			return
+		}
+	}
+
+	sheet, err := importer.Read(file, importer.FormatOf(header.Filename))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read the file: " + err.Error(),
+		})
+		return
+	}
+
+	run, err := importer.Run(c.Request.Context(), h.storage.Import(), sheet, opts)
+	var mappingErr *importer.MappingError
+	if errors.As(err, &mappingErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": mappingErr.Error(),
+		})
+		return
+	}
+	if run == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to import",
+		})
+		h.log(c).Error("Failed to import", logger.Error(err))
+		return
+	}
+	// a stopped import is recorded as well, its batches before the error
+	// are saved
+	if saveErr := h.storage.Import().SaveImport(c.Request.Context(), run); saveErr != nil {
+		h.log(c).Error("Failed to save import", logger.Error(saveErr))
+		if err == nil {
+			err = saveErr
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to import, the first " + cast.ToString(run.Imported) + " rows were saved",
+		})
+		h.log(c).Error("Failed to import", logger.Error(err))
+		return
+	}
+	h.log(c).Info("Import done",
+		logger.String("import_id", run.Id),
+		logger.String("kind", run.Kind),
+		logger.Bool("dry_run", run.DryRun),
+		logger.Int("imported", run.Imported),
+		logger.Int("duplicates", run.Duplicates),
+		logger.Int("failed", run.Failed),
+	)
+
+	status := http.StatusCreated
+	if run.DryRun {
+		status = http.StatusOK
+	}
+	c.JSON(status, importResponse(run))
+}
+
+// GetImport
+// @Summary GetImport
+// @Description Api for get the outcome of an import
+// @Tags import
+// @Produce json
+// @Param id path string true "import id"
+// @Success 200 {object} models.ImportResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/imports/{id} [get]
+func (h *handlerV1) GetImport(c *gin.Context) {
+	run, ok := h.importRun(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, importResponse(run))
+}
+
+// GetImportErrors
+// @Summary GetImportErrors
+// @Description Api for download the rows of an import that were not imported as CSV, with the reason and the original cells. It can be fixed and imported again.
+// @Tags import
+// @Produce text/csv
+// @Param id path string true "import id"
+// @Success 200 {file} file
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/imports/{id}/errors [get]
+func (h *handlerV1) GetImportErrors(c *gin.Context) {
+	run, ok := h.importRun(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="import-`+run.Id+`-errors.csv"`)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := importer.WriteProblems(c.Writer, run); err != nil {
+		h.log(c).Error("Failed to write import errors", logger.Error(err))
+	}
+}
+
+func (h *handlerV1) importRun(c *gin.Context) (*repo.ImportRun, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return nil, false
+	}
+
+	run, err := h.storage.Import().GetImport(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Import not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get import",
+		})
+		h.log(c).Error("Failed to get import", logger.Error(err))
+		return nil, false
+	}
+	return run, true
+}
+
+func importResponse(run *repo.ImportRun) models.ImportResponse {
+	response := models.ImportResponse{
+		Id:             run.Id,
+		Kind:           run.Kind,
+		FileName:       run.FileName,
+		DryRun:         run.DryRun,
+		Total:          run.Total,
+		Imported:       run.Imported,
+		CreatedClients: run.CreatedClients,
+		Duplicates:     run.Duplicates,
+		Failed:         run.Failed,
+		CreatedAt:      run.CreatedAt,
+		Problems:       make([]models.ImportProblem, 0, len(run.Problems)),
+	}
+	for _, p := range run.Problems {
+		if len(response.Problems) == maxListedProblems {
+			break
+		}
+		response.Problems = append(response.Problems, models.ImportProblem{
+			Row:    p.Row,
+			Status: p.Status,
+			Errors: p.Errors,
+		})
+	}
+	if len(run.Problems) > 0 {
+		response.ErrorReport = "/v1/imports/" + run.Id + "/errors"
+	}
+	return response
+}
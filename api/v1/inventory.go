@@ -0,0 +1,724 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateInventoryItem
+// @Summary CreateInventoryItem
+// @Description Api for add a material to the inventory, its stock is received in batches
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param item body models.InventoryItemRequest true "item"
+// @Success 201 {object} models.InventoryItem
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error "another item has the name"
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/items [post]
+func (h *handlerV1) CreateInventoryItem(c *gin.Context) {
+	item, ok := inventoryItemOf(c)
+	if !ok {
+		return
+	}
+
+	created, err := h.storage.Inventory().CreateItem(c.Request.Context(), item)
+	if errors.Is(err, repo.ErrNameTaken) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "An item with this name exists",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create inventory item",
+		})
+		h.log(c).Error("Failed to create inventory item", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, inventoryItem(created))
+}
+
+// GetInventoryItems
+// @Summary GetInventoryItems
+// @Description Api for get the inventory items with their stock, by name
+// @Tags inventory
+// @Produce json
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.InventoryItem]
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/items [get]
+func (h *handlerV1) GetInventoryItems(c *gin.Context) {
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+
+	items, err := h.storage.Inventory().GetItems(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get inventory items",
+		})
+		h.log(c).Error("Failed to get inventory items", logger.Error(err))
+		return
+	}
+
+	response := make([]models.InventoryItem, 0, len(items.Items))
+	for _, i := range items.Items {
+		response = append(response, inventoryItem(i))
+	}
+	c.JSON(http.StatusOK, pagination.NewPage(response, items.Total, items.NextCursor))
+}
+
+// GetInventoryItem
+// @Summary GetInventoryItem
+// @Description Api for get an inventory item with its stock per location and its batches, first expiring first
+// @Tags inventory
+// @Produce json
+// @Param id path string true "item id"
+// @Success 200 {object} models.InventoryItemStock
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/items/{id} [get]
+func (h *handlerV1) GetInventoryItem(c *gin.Context) {
+	item, ok := h.inventoryItemParam(c)
+	if !ok {
+		return
+	}
+
+	batches, err := h.storage.Inventory().GetBatches(c.Request.Context(), item.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get batches",
+		})
+		h.log(c).Error("Failed to get batches", logger.Error(err))
+		return
+	}
+
+	response := models.InventoryItemStock{
+		InventoryItem: inventoryItem(item),
+		Locations:     []models.LocationStock{},
+		Batches:       make([]models.InventoryBatch, 0, len(batches)),
+	}
+	at := map[string]int{}
+	for _, b := range batches {
+		response.Batches = append(response.Batches, inventoryBatch(b))
+
+		i, ok := at[b.LocationId]
+		if !ok {
+			i = len(response.Locations)
+			at[b.LocationId] = i
+			response.Locations = append(response.Locations, models.LocationStock{
+				LocationId: b.LocationId,
+				Name:       b.LocationName,
+			})
+		}
+		response.Locations[i].Quantity += b.Quantity
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateInventoryItem
+// @Summary UpdateInventoryItem
+// @Description Api for update an inventory item, its stock is changed with batches and adjustments
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Param item body models.InventoryItemRequest true "item"
+// @Success 200 {object} models.InventoryItem
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "another item has the name"
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/items/{id} [put]
+func (h *handlerV1) UpdateInventoryItem(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	item, ok := inventoryItemOf(c)
+	if !ok {
+		return
+	}
+	item.Id = id
+
+	updated, err := h.storage.Inventory().UpdateItem(c.Request.Context(), item)
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Inventory item not found",
+		})
+		return
+	case errors.Is(err, repo.ErrNameTaken):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "An item with this name exists",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update inventory item",
+		})
+		h.log(c).Error("Failed to update inventory item", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, inventoryItem(updated))
+}
+
+// DeleteInventoryItem
+// @Summary DeleteInventoryItem
+// @Description Api for delete an inventory item, procedures stop using it and its history is kept
+// @Tags inventory
+// @Produce json
+// @Param id path string true "item id"
+// @Success 204
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/items/{id} [delete]
+func (h *handlerV1) DeleteInventoryItem(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	err := h.storage.Inventory().DeleteItem(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Inventory item not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete inventory item",
+		})
+		h.log(c).Error("Failed to delete inventory item", logger.Error(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ReceiveInventoryBatch
+// @Summary ReceiveInventoryBatch
+// @Description Api for put a delivered batch of an item into stock at a location
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "item id"
+// @Param batch body models.BatchRequest true "batch"
+// @Success 201 {object} models.InventoryBatch
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/items/{id}/batches [post]
+func (h *handlerV1) ReceiveInventoryBatch(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	var body models.BatchRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if _, err := uuid.Parse(body.LocationId); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "location_id must be a uuid",
+		})
+		return
+	}
+	if body.Quantity <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "quantity must be positive",
+		})
+		return
+	}
+
+	batch, err := h.storage.Inventory().ReceiveBatch(c.Request.Context(), &repo.InventoryBatch{
+		ItemId:     id,
+		LocationId: body.LocationId,
+		Lot:        body.Lot,
+		ExpiresOn:  body.ExpiresOn,
+		Quantity:   body.Quantity,
+	}, body.Note)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "item or location does not exist",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to receive batch",
+		})
+		h.log(c).Error("Failed to receive batch", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, inventoryBatch(batch))
+}
+
+// AdjustInventoryBatch
+// @Summary AdjustInventoryBatch
+// @Description Api for correct the quantity of a batch by hand, such as after a count or for waste. The note is kept in the stock history.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "batch id"
+// @Param adjustment body models.AdjustmentRequest true "adjustment"
+// @Success 200 {object} models.InventoryBatch
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 422 {object} models.Error "the batch holds less than taken out"
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/batches/{id}/adjustments [post]
+func (h *handlerV1) AdjustInventoryBatch(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	var body models.AdjustmentRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	batch, err := h.storage.Inventory().AdjustBatch(c.Request.Context(), id, body.Delta, strings.TrimSpace(body.Note))
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Batch not found",
+		})
+		return
+	case errors.Is(err, repo.ErrInsufficientStock):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "The batch holds less than that",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to adjust batch",
+		})
+		h.log(c).Error("Failed to adjust batch", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, inventoryBatch(batch))
+}
+
+// CreateStorageLocation
+// @Summary CreateStorageLocation
+// @Description Api for add a place stock is kept in
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param location body models.StorageLocationRequest true "location"
+// @Success 201 {object} models.StorageLocation
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error "another location has the name"
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/locations [post]
+func (h *handlerV1) CreateStorageLocation(c *gin.Context) {
+	var body models.StorageLocationRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "name is required",
+		})
+		return
+	}
+
+	location, err := h.storage.Inventory().CreateLocation(c.Request.Context(), &repo.StorageLocation{Name: name})
+	if errors.Is(err, repo.ErrNameTaken) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A location with this name exists",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create storage location",
+		})
+		h.log(c).Error("Failed to create storage location", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, storageLocation(location))
+}
+
+// GetStorageLocations
+// @Summary GetStorageLocations
+// @Description Api for get the places stock is kept in
+// @Tags inventory
+// @Produce json
+// @Success 200 {array} models.StorageLocation
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/locations [get]
+func (h *handlerV1) GetStorageLocations(c *gin.Context) {
+	locations, err := h.storage.Inventory().GetLocations(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get storage locations",
+		})
+		h.log(c).Error("Failed to get storage locations", logger.Error(err))
+		return
+	}
+
+	response := make([]models.StorageLocation, 0, len(locations))
+	for _, l := range locations {
+		response = append(response, storageLocation(l))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetProcedureMaterials
+// @Summary GetProcedureMaterials
+// @Description Api for get the bills of materials, what completing an appointment with the procedure as its treatment takes out of stock
+// @Tags inventory
+// @Produce json
+// @Param procedure query string false "only this procedure"
+// @Success 200 {array} models.ProcedureMaterial
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/materials [get]
+func (h *handlerV1) GetProcedureMaterials(c *gin.Context) {
+	materials, err := h.storage.Inventory().GetMaterials(c.Request.Context(), c.Query("procedure"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get procedure materials",
+		})
+		h.log(c).Error("Failed to get procedure materials", logger.Error(err))
+		return
+	}
+
+	response := make([]models.ProcedureMaterial, 0, len(materials))
+	for _, m := range materials {
+		response = append(response, models.ProcedureMaterial{
+			Procedure: m.Procedure,
+			ItemId:    m.ItemId,
+			ItemName:  m.ItemName,
+			Unit:      m.Unit,
+			Quantity:  m.Quantity,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetProcedureMaterials
+// @Summary SetProcedureMaterials
+// @Description Api for replace the bill of materials of a procedure, matched to the treatment of appointments without regard to case. An empty list clears it.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param procedure query string true "procedure"
+// @Param materials body []models.ProcedureMaterialRequest true "materials"
+// @Success 204
+// @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/materials [put]
+func (h *handlerV1) SetProcedureMaterials(c *gin.Context) {
+	procedure := strings.TrimSpace(c.Query("procedure"))
+	if procedure == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "procedure is required",
+		})
+		return
+	}
+	var body []models.ProcedureMaterialRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	materials := make([]*repo.ProcedureMaterial, 0, len(body))
+	for _, m := range body {
+		if _, err := uuid.Parse(m.ItemId); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "item_id must be a uuid",
+			})
+			return
+		}
+		if m.Quantity <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "quantity must be positive",
+			})
+			return
+		}
+		materials = append(materials, &repo.ProcedureMaterial{ItemId: m.ItemId, Quantity: m.Quantity})
+	}
+
+	err := h.storage.Inventory().SetMaterials(c.Request.Context(), procedure, materials)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "item does not exist",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to set procedure materials",
+		})
+		h.log(c).Error("Failed to set procedure materials", logger.Error(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetStockMovements
+// @Summary GetStockMovements
+// @Description Api for get the history of stock, newest first
+// @Tags inventory
+// @Produce json
+// @Param item_id query string false "item id"
+// @Param appointment_id query string false "appointment id"
+// @Param reason query string false "receipt, consumption or adjustment"
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.StockMovement]
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/movements [get]
+func (h *handlerV1) GetStockMovements(c *gin.Context) {
+	filter := &repo.MovementFilter{
+		ItemId:        c.Query("item_id"),
+		AppointmentId: c.Query("appointment_id"),
+		Reason:        c.Query("reason"),
+	}
+	switch filter.Reason {
+	case "", repo.MovementReceipt, repo.MovementConsumption, repo.MovementAdjustment:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "reason must be receipt, consumption or adjustment",
+		})
+		return
+	}
+	for name, id := range map[string]string{"item_id": filter.ItemId, "appointment_id": filter.AppointmentId} {
+		if _, err := uuid.Parse(id); id != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": name + " must be a uuid",
+			})
+			return
+		}
+	}
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+
+	movements, err := h.storage.Inventory().GetMovements(c.Request.Context(), filter, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get stock movements",
+		})
+		h.log(c).Error("Failed to get stock movements", logger.Error(err))
+		return
+	}
+
+	items := make([]models.StockMovement, 0, len(movements.Movements))
+	for _, m := range movements.Movements {
+		items = append(items, models.StockMovement{
+			Id:            m.Id,
+			ItemId:        m.ItemId,
+			BatchId:       m.BatchId,
+			LocationId:    m.LocationId,
+			Quantity:      m.Quantity,
+			Reason:        m.Reason,
+			AppointmentId: m.AppointmentId,
+			Note:          m.Note,
+			CreatedAt:     m.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, pagination.NewPage(items, movements.Total, movements.NextCursor))
+}
+
+// GetInventoryAlerts
+// @Summary GetInventoryAlerts
+// @Description Api for get the items below their minimum stock and the batches in stock that expire soon or did. An item falling below its minimum is also sent as the inventory.low_stock event.
+// @Tags inventory
+// @Produce json
+// @Success 200 {object} models.InventoryAlerts
+// @Failure 500 {object} models.Error
+// @Router /v1/inventory/alerts [get]
+func (h *handlerV1) GetInventoryAlerts(c *gin.Context) {
+	low, err := h.storage.Inventory().LowStock(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get inventory alerts",
+		})
+		h.log(c).Error("Failed to get low stock", logger.Error(err))
+		return
+	}
+	today := civil.Today(h.cfg.Location)
+	expiring, err := h.storage.Inventory().ExpiringBatches(c.Request.Context(), civil.DateOf(today.In(h.cfg.Location).AddDate(0, 0, h.cfg.InventoryExpiryDays)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get inventory alerts",
+		})
+		h.log(c).Error("Failed to get expiring batches", logger.Error(err))
+		return
+	}
+
+	response := models.InventoryAlerts{
+		LowStock: make([]models.InventoryItem, 0, len(low)),
+		Expiring: make([]models.ExpiringBatch, 0, len(expiring)),
+	}
+	for _, i := range low {
+		response.LowStock = append(response.LowStock, inventoryItem(i))
+	}
+	for _, b := range expiring {
+		response.Expiring = append(response.Expiring, models.ExpiringBatch{
+			InventoryBatch: inventoryBatch(&b.InventoryBatch),
+			ItemName:       b.ItemName,
+			Unit:           b.Unit,
+			Expired:        b.ExpiresOn.In(h.cfg.Location).Before(today.In(h.cfg.Location)),
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// inventoryItemParam returns the item of the :id path parameter,
+// answering 400 or 404 itself
+func (h *handlerV1) inventoryItemParam(c *gin.Context) (*repo.InventoryItem, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return nil, false
+	}
+
+	item, err := h.storage.Inventory().GetItem(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Inventory item not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get inventory item",
+		})
+		h.log(c).Error("Failed to get inventory item", logger.Error(err))
+		return nil, false
+	}
+	return item, true
+}
+
+// inventoryItemOf binds the item of the request body, answering 400
+// itself
+func inventoryItemOf(c *gin.Context) (*repo.InventoryItem, bool) {
+	var body models.InventoryItemRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	item := &repo.InventoryItem{
+		Name:     strings.TrimSpace(body.Name),
+		Unit:     strings.TrimSpace(body.Unit),
+		Sku:      strings.TrimSpace(body.Sku),
+		MinStock: body.MinStock,
+	}
+	if item.Name == "" || item.Unit == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "name and unit are required",
+		})
+		return nil, false
+	}
+	if len(item.Unit) > 20 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "unit is at most 20 characters",
+		})
+		return nil, false
+	}
+	if item.MinStock < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "min_stock must not be negative",
+		})
+		return nil, false
+	}
+	return item, true
+}
+
+func inventoryItem(i *repo.InventoryItem) models.InventoryItem {
+	return models.InventoryItem{
+		Id:        i.Id,
+		Name:      i.Name,
+		Unit:      i.Unit,
+		Sku:       i.Sku,
+		MinStock:  i.MinStock,
+		Stock:     i.Stock,
+		Low:       i.Stock < i.MinStock,
+		CreatedAt: i.CreatedAt,
+		UpdatedAt: i.UpdatedAt,
+	}
+}
+
+func inventoryBatch(b *repo.InventoryBatch) models.InventoryBatch {
+	return models.InventoryBatch{
+		Id:           b.Id,
+		ItemId:       b.ItemId,
+		LocationId:   b.LocationId,
+		LocationName: b.LocationName,
+		Lot:          b.Lot,
+		ExpiresOn:    b.ExpiresOn,
+		Quantity:     b.Quantity,
+		ReceivedAt:   b.ReceivedAt,
+	}
+}
+
+func storageLocation(l *repo.StorageLocation) models.StorageLocation {
+	return models.StorageLocation{
+		Id:        l.Id,
+		Name:      l.Name,
+		CreatedAt: l.CreatedAt,
+	}
+}
@@ -0,0 +1,508 @@
+package v1
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateLabCase
+// @Summary CreateLabCase
+// @Description Api for add prosthetic work such as a crown, bridge or denture made by an outside lab for a client. The lab is a supplier. While the case is not received its fitting appointment cannot be confirmed.
+// @Tags lab cases
+// @Accept json
+// @Produce json
+// @Param case body models.LabCaseRequest true "case"
+// @Success 201 {object} models.LabCase
+// @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error "the client, lab or appointment of the client does not exist"
+// @Failure 409 {object} models.Error "the appointment is confirmed already and the case not received"
+// @Failure 500 {object} models.Error
+// @Router /v1/lab-cases [post]
+func (h *handlerV1) CreateLabCase(c *gin.Context) {
+	labCase, ok := labCaseOf(c)
+	if !ok {
+		return
+	}
+
+	created, err := h.storage.LabCase().CreateCase(c.Request.Context(), labCase)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "client, lab or appointment of the client does not exist",
+		})
+		return
+	}
+	if errors.Is(err, repo.ErrLabCaseNotReceived) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The appointment is confirmed already, the lab case must be received first",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create lab case",
+		})
+		h.log(c).Error("Failed to create lab case", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.labCase(created))
+}
+
+// GetLabCases
+// @Summary GetLabCases
+// @Description Api for get the lab cases newest first
+// @Tags lab cases
+// @Produce json
+// @Param client_id query string false "client id"
+// @Param lab_id query string false "lab id"
+// @Param status query string false "created, sent, received, fitted or cancelled"
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.LabCase]
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/lab-cases [get]
+func (h *handlerV1) GetLabCases(c *gin.Context) {
+	filter := &repo.LabCaseFilter{
+		ClientId: c.Query("client_id"),
+		LabId:    c.Query("lab_id"),
+		Status:   c.Query("status"),
+	}
+	for name, id := range map[string]string{"client_id": filter.ClientId, "lab_id": filter.LabId} {
+		if _, err := uuid.Parse(id); id != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": name + " must be a uuid",
+			})
+			return
+		}
+	}
+	switch filter.Status {
+	case "", repo.LabCaseCreated, repo.LabCaseSent, repo.LabCaseReceived, repo.LabCaseFitted, repo.LabCaseCancelled:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "status must be created, sent, received, fitted or cancelled",
+		})
+		return
+	}
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+
+	cases, err := h.storage.LabCase().GetCases(c.Request.Context(), filter, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get lab cases",
+		})
+		h.log(c).Error("Failed to get lab cases", logger.Error(err))
+		return
+	}
+
+	response := make([]models.LabCase, 0, len(cases.Cases))
+	for _, lc := range cases.Cases {
+		response = append(response, h.labCase(lc))
+	}
+	c.JSON(http.StatusOK, pagination.NewPage(response, cases.Total, cases.NextCursor))
+}
+
+// GetOverdueLabCases
+// @Summary GetOverdueLabCases
+// @Description Api for get the lab cases not received that were due before today, longest overdue first
+// @Tags lab cases
+// @Produce json
+// @Success 200 {array} models.LabCase
+// @Failure 500 {object} models.Error
+// @Router /v1/lab-cases/overdue [get]
+func (h *handlerV1) GetOverdueLabCases(c *gin.Context) {
+	cases, err := h.storage.LabCase().OverdueCases(c.Request.Context(), civil.Today(h.cfg.Location))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get overdue lab cases",
+		})
+		h.log(c).Error("Failed to get overdue lab cases", logger.Error(err))
+		return
+	}
+
+	response := make([]models.LabCase, 0, len(cases))
+	for _, lc := range cases {
+		response = append(response, h.labCase(lc))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetLabCase
+// @Summary GetLabCase
+// @Description Api for get a lab case
+// @Tags lab cases
+// @Produce json
+// @Param id path string true "case id"
+// @Success 200 {object} models.LabCase
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/lab-cases/{id} [get]
+func (h *handlerV1) GetLabCase(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	labCase, err := h.storage.LabCase().GetCase(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Lab case not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get lab case",
+		})
+		h.log(c).Error("Failed to get lab case", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.labCase(labCase))
+}
+
+// UpdateLabCase
+// @Summary UpdateLabCase
+// @Description Api for update the details of a lab case not fitted or cancelled, its client cannot be changed
+// @Tags lab cases
+// @Accept json
+// @Produce json
+// @Param id path string true "case id"
+// @Param case body models.LabCaseRequest true "case"
+// @Success 200 {object} models.LabCase
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "the case is fitted or cancelled, or not received and the appointment is confirmed already"
+// @Failure 422 {object} models.Error "the lab or appointment of the client does not exist"
+// @Failure 500 {object} models.Error
+// @Router /v1/lab-cases/{id} [put]
+func (h *handlerV1) UpdateLabCase(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	labCase, ok := labCaseOf(c)
+	if !ok {
+		return
+	}
+	labCase.Id = id
+
+	// the case is looked up first, ErrNotFound of the update is then
+	// about the lab or the appointment
+	existing, err := h.storage.LabCase().GetCase(c.Request.Context(), id)
+	if err != nil {
+		h.labCaseChanged(c, nil, err, "Failed to get lab case", "")
+		return
+	}
+	if existing.ClientId != labCase.ClientId {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "client_id cannot be changed",
+		})
+		return
+	}
+
+	updated, err := h.storage.LabCase().UpdateCase(c.Request.Context(), labCase)
+	h.labCaseChanged(c, updated, err, "Failed to update lab case", "lab or appointment of the client does not exist")
+}
+
+// SendLabCase
+// @Summary SendLabCase
+// @Description Api for mark a lab case as sent to the lab
+// @Tags lab cases
+// @Accept json
+// @Produce json
+// @Param id path string true "case id"
+// @Param sent body models.LabCaseSendRequest false "sent"
+// @Success 200 {object} models.LabCase
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "the case was sent already"
+// @Failure 500 {object} models.Error
+// @Router /v1/lab-cases/{id}/send [post]
+func (h *handlerV1) SendLabCase(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	var body models.LabCaseSendRequest
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	sentOn, ok := h.labCaseDay(c, body.SentOn, "sent_on")
+	if !ok {
+		return
+	}
+	if !body.DueOn.IsZero() && body.DueOn.In(time.UTC).Before(sentOn.In(time.UTC)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "due_on must not be before sent_on",
+		})
+		return
+	}
+
+	labCase, err := h.storage.LabCase().SendCase(c.Request.Context(), id, sentOn, body.DueOn)
+	h.labCaseChanged(c, labCase, err, "Failed to send lab case", "")
+}
+
+// ReceiveLabCase
+// @Summary ReceiveLabCase
+// @Description Api for mark a sent lab case as delivered by the lab, its fitting appointment can be confirmed then
+// @Tags lab cases
+// @Accept json
+// @Produce json
+// @Param id path string true "case id"
+// @Param received body models.LabCaseDateRequest false "received"
+// @Success 200 {object} models.LabCase
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "the case is not sent"
+// @Failure 500 {object} models.Error
+// @Router /v1/lab-cases/{id}/receive [post]
+func (h *handlerV1) ReceiveLabCase(c *gin.Context) {
+	id, on, ok := h.labCaseDateOf(c)
+	if !ok {
+		return
+	}
+
+	labCase, err := h.storage.LabCase().ReceiveCase(c.Request.Context(), id, on)
+	h.labCaseChanged(c, labCase, err, "Failed to receive lab case", "")
+}
+
+// FitLabCase
+// @Summary FitLabCase
+// @Description Api for mark a received lab case as fitted to the client
+// @Tags lab cases
+// @Accept json
+// @Produce json
+// @Param id path string true "case id"
+// @Param fitted body models.LabCaseDateRequest false "fitted"
+// @Success 200 {object} models.LabCase
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "the case is not received"
+// @Failure 500 {object} models.Error
+// @Router /v1/lab-cases/{id}/fit [post]
+func (h *handlerV1) FitLabCase(c *gin.Context) {
+	id, on, ok := h.labCaseDateOf(c)
+	if !ok {
+		return
+	}
+
+	labCase, err := h.storage.LabCase().FitCase(c.Request.Context(), id, on)
+	h.labCaseChanged(c, labCase, err, "Failed to fit lab case", "")
+}
+
+// CancelLabCase
+// @Summary CancelLabCase
+// @Description Api for cancel a lab case not fitted yet, its fitting appointment is not held back by it any more
+// @Tags lab cases
+// @Produce json
+// @Param id path string true "case id"
+// @Success 200 {object} models.LabCase
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "the case is fitted or cancelled, or not received and the appointment is confirmed already"
+// @Failure 500 {object} models.Error
+// @Router /v1/lab-cases/{id}/cancel [post]
+func (h *handlerV1) CancelLabCase(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	labCase, err := h.storage.LabCase().CancelCase(c.Request.Context(), id)
+	h.labCaseChanged(c, labCase, err, "Failed to cancel lab case", "")
+}
+
+// labCaseChanged answers a change of a lab case, notFound is the 422
+// message when ErrNotFound is about something other than the case
+func (h *handlerV1) labCaseChanged(c *gin.Context, labCase *repo.LabCase, err error, failed, notFound string) {
+	switch {
+	case errors.Is(err, repo.ErrNotFound) && notFound != "":
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": notFound,
+		})
+	case errors.Is(err, repo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Lab case not found",
+		})
+	case errors.Is(err, repo.ErrLabCaseState):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The lab case status does not allow this",
+		})
+	case errors.Is(err, repo.ErrLabCaseNotReceived):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The appointment is confirmed already, the lab case must be received first",
+		})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": failed,
+		})
+		h.log(c).Error(failed, logger.Error(err))
+	default:
+		c.JSON(http.StatusOK, h.labCase(labCase))
+	}
+}
+
+// labCaseDateOf returns the :id path parameter and the day of the
+// optional body, today when not given, answering 400 itself
+func (h *handlerV1) labCaseDateOf(c *gin.Context) (string, civil.Date, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return "", civil.Date{}, false
+	}
+	var body models.LabCaseDateRequest
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return "", civil.Date{}, false
+	}
+	on, ok := h.labCaseDay(c, body.On, "on")
+	return id, on, ok
+}
+
+// labCaseDay is day, today when zero, answering 400 itself when it is
+// in the future
+func (h *handlerV1) labCaseDay(c *gin.Context, day civil.Date, name string) (civil.Date, bool) {
+	today := civil.Today(h.cfg.Location)
+	if day.IsZero() {
+		return today, true
+	}
+	if day.In(time.UTC).After(today.In(time.UTC)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": name + " must not be in the future",
+		})
+		return civil.Date{}, false
+	}
+	return day, true
+}
+
+// labCaseOf binds the case of the request body, answering 400 itself
+func labCaseOf(c *gin.Context) (*repo.LabCase, bool) {
+	var body models.LabCaseRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	for name, id := range map[string]string{"client_id": body.ClientId, "lab_id": body.LabId, "appointment_id": body.AppointmentId} {
+		if _, err := uuid.Parse(id); (id != "" || name != "appointment_id") && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": name + " must be a uuid",
+			})
+			return nil, false
+		}
+	}
+	if !repo.ValidWorkType(body.WorkType) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "work_type must be crown, bridge, denture, veneer, inlay, implant or other",
+		})
+		return nil, false
+	}
+	for _, t := range body.Teeth {
+		if !repo.ValidTooth(t) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "teeth must be in FDI notation, such as 11 or 46",
+			})
+			return nil, false
+		}
+	}
+	shade := strings.TrimSpace(body.Shade)
+	if len(shade) > 20 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "shade is at most 20 characters",
+		})
+		return nil, false
+	}
+	if body.Cost < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cost must not be negative",
+		})
+		return nil, false
+	}
+
+	if body.Teeth == nil {
+		body.Teeth = []int{}
+	}
+
+	return &repo.LabCase{
+		ClientId:      body.ClientId,
+		AppointmentId: body.AppointmentId,
+		LabId:         body.LabId,
+		WorkType:      body.WorkType,
+		Shade:         shade,
+		Teeth:         body.Teeth,
+		DueOn:         body.DueOn,
+		Cost:          body.Cost,
+		Note:          strings.TrimSpace(body.Note),
+	}, true
+}
+
+func (h *handlerV1) labCase(lc *repo.LabCase) models.LabCase {
+	response := models.LabCase{
+		Id:            lc.Id,
+		ClientId:      lc.ClientId,
+		ClientName:    strings.TrimSpace(lc.ClientName),
+		AppointmentId: lc.AppointmentId,
+		LabId:         lc.LabId,
+		LabName:       lc.LabName,
+		WorkType:      lc.WorkType,
+		Shade:         lc.Shade,
+		Teeth:         lc.Teeth,
+		Status:        lc.Status,
+		SentOn:        lc.SentOn,
+		DueOn:         lc.DueOn,
+		ReceivedOn:    lc.ReceivedOn,
+		FittedOn:      lc.FittedOn,
+		Cost:          lc.Cost,
+		Note:          lc.Note,
+		CreatedAt:     lc.CreatedAt,
+		UpdatedAt:     lc.UpdatedAt,
+	}
+	if !lc.AppointmentDate.IsZero() {
+		response.AppointmentDate = &lc.AppointmentDate
+	}
+	if (lc.Status == repo.LabCaseCreated || lc.Status == repo.LabCaseSent) && !lc.DueOn.IsZero() {
+		late := civil.Today(h.cfg.Location).In(time.UTC).Sub(lc.DueOn.In(time.UTC))
+		if late > 0 {
+			response.DaysOverdue = int(late.Hours() / 24)
+		}
+	}
+	return response
+}
@@ -0,0 +1,281 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FindClientDuplicates
+// @Summary FindClientDuplicates
+// @Description Api for get the clients that are likely the same person: same phone number, or a similar name with the same birth date
+// @Tags merge
+// @Produce json
+// @Param id path string true "client id"
+// @Success 200 {array} models.DuplicateMatch
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/clients/{id}/duplicates [get]
+func (h *handlerV1) FindClientDuplicates(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	_, err := h.storage.Client().GetClient(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Client not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get client",
+		})
+		h.log(c).Error("Failed to get client", logger.Error(err))
+		return
+	}
+
+	matches, err := h.storage.Merge().FindDuplicates(c.Request.Context(), id, h.cfg.DuplicateNameSimilarity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to find duplicates",
+		})
+		h.log(c).Error("Failed to find duplicates", logger.Error(err))
+		return
+	}
+
+	response := make([]models.DuplicateMatch, 0, len(matches))
+	for _, m := range matches {
+		response = append(response, duplicateMatch(m))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// FindDuplicates
+// @Summary FindDuplicates
+// @Description Api for get every pair of clients that are likely the same person, phone matches first
+// @Tags merge
+// @Produce json
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Success 200 {object} pagination.Page[models.DuplicateMatch]
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/duplicates [get]
+func (h *handlerV1) FindDuplicates(c *gin.Context) {
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+	if params.Cursor != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "duplicates are paged by page or offset, not cursor",
+		})
+		return
+	}
+
+	response, err := h.storage.Merge().FindAllDuplicates(c.Request.Context(), h.cfg.DuplicateNameSimilarity, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to find duplicates",
+		})
+		h.log(c).Error("Failed to find duplicates", logger.Error(err))
+		return
+	}
+
+	items := make([]models.DuplicateMatch, 0, len(response.Matches))
+	for _, m := range response.Matches {
+		items = append(items, duplicateMatch(m))
+	}
+	c.JSON(http.StatusOK, pagination.NewPage(items, response.Total, ""))
+}
+
+// MergeClients
+// @Summary MergeClients
+// @Description Api for merge a duplicate into the surviving client: appointments move over, empty fields of the survivor are filled and the duplicate is deleted. It can be undone for a while.
+// @Tags merge
+// @Accept json
+// @Produce json
+// @Param Merge body models.MergeRequest true "MergeClients"
+// @Success 201 {object} models.MergeResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/merges [post]
+func (h *handlerV1) MergeClients(c *gin.Context) {
+	var req models.MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	for _, id := range []string{req.SurvivorId, req.MergedId} {
+		if _, err := uuid.Parse(id); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "survivor_id and merged_id must be uuids",
+			})
+			return
+		}
+	}
+
+	merge, err := h.storage.Merge().MergeClients(c.Request.Context(), req.SurvivorId, req.MergedId)
+	if errors.Is(err, repo.ErrSameClient) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Both clients must exist and not be deleted",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to merge clients",
+		})
+		h.log(c).Error("Failed to merge clients", logger.Error(err))
+		return
+	}
+	h.log(c).Info("Clients merged",
+		logger.String("merge_id", merge.Id),
+		logger.String("survivor_id", merge.SurvivorId),
+		logger.String("merged_id", merge.MergedId),
+	)
+
+	c.JSON(http.StatusCreated, h.mergeResponse(merge))
+}
+
+// GetClientMerges
+// @Summary GetClientMerges
+// @Description Api for get the merge history of a client, newest first
+// @Tags merge
+// @Produce json
+// @Param id path string true "client id"
+// @Success 200 {array} models.MergeResponse
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/clients/{id}/merges [get]
+func (h *handlerV1) GetClientMerges(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	merges, err := h.storage.Merge().GetClientMerges(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get merges",
+		})
+		h.log(c).Error("Failed to get merges", logger.Error(err))
+		return
+	}
+
+	response := make([]models.MergeResponse, 0, len(merges))
+	for _, merge := range merges {
+		response = append(response, h.mergeResponse(merge))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UndoMerge
+// @Summary UndoMerge
+// @Description Api for undo a merge within the undo window, the duplicate comes back with its appointments
+// @Tags merge
+// @Produce json
+// @Param id path string true "merge id"
+// @Success 200 {object} models.MergeResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/merges/{id}/undo [post]
+func (h *handlerV1) UndoMerge(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	merge, err := h.storage.Merge().UndoMerge(c.Request.Context(), id, h.cfg.MergeUndoWindow)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Merge not found",
+		})
+		return
+	}
+	if errors.Is(err, repo.ErrMergeUndone) || errors.Is(err, repo.ErrMergeExpired) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to undo merge",
+		})
+		h.log(c).Error("Failed to undo merge", logger.Error(err))
+		return
+	}
+	h.log(c).Info("Merge undone", logger.String("merge_id", merge.Id))
+
+	c.JSON(http.StatusOK, h.mergeResponse(merge))
+}
+
+func duplicateClient(client *repo.Client) models.DuplicateClient {
+	return models.DuplicateClient{
+		Id:          client.Id,
+		Name:        client.Name,
+		LastName:    client.LastName,
+		FatherName:  client.FatherName,
+		PhoneNumber: client.PhoneNumber,
+		BirthDate:   client.BirthDate,
+		CreatedAt:   client.CreatedAt,
+	}
+}
+
+func duplicateMatch(m *repo.DuplicateMatch) models.DuplicateMatch {
+	return models.DuplicateMatch{
+		Client:         duplicateClient(&m.Client),
+		Duplicate:      duplicateClient(&m.Duplicate),
+		PhoneMatch:     m.PhoneMatch,
+		BirthDateMatch: m.BirthDateMatch,
+		NameSimilarity: m.NameSimilarity,
+	}
+}
+
+func (h *handlerV1) mergeResponse(merge *repo.ClientMerge) models.MergeResponse {
+	return models.MergeResponse{
+		Id:                   merge.Id,
+		SurvivorId:           merge.SurvivorId,
+		MergedId:             merge.MergedId,
+		MovedAppointments:    len(merge.AppointmentIds),
+		MovedLabCases:        len(merge.LabCaseIds),
+		MovedWaitlistEntries: len(merge.WaitlistEntryIds),
+		MovedBookingRequests: len(merge.BookingRequestIds),
+		FilledFields:         append([]string{}, merge.FilledFields...),
+		MergedAt:             merge.MergedAt,
+		UndoUntil:            merge.MergedAt.Add(h.cfg.MergeUndoWindow),
+		UndoneAt:             merge.UndoneAt,
+	}
+}
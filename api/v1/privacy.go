@@ -0,0 +1,179 @@
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/export"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ExportClient
+// @Summary ExportClient
+// @Description Api for get a copy of everything stored about a client, deleted appointments included
+// @Tags client
+// @Produce json
+// @Produce application/pdf
+// @Param id path string true "client id"
+// @Param format query string false "json or pdf" default(json)
+// @Success 200 {object} models.ClientExport
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/clients/{id}/export [get]
+func (h *handlerV1) ExportClient(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "format must be json or pdf",
+		})
+		return
+	}
+
+	data, err := h.storage.Client().ExportClient(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Client not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to export client",
+		})
+		h.log(c).Error("Failed to export client", logger.Error(err))
+		return
+	}
+	// who got a copy of whose record is worth keeping
+	h.log(c).Info("Client data exported", logger.String("client_id", id), logger.String("format", format))
+
+	now := time.Now()
+	filename := "client-" + id + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	if format == "json" {
+		c.JSON(http.StatusOK, clientExport(data, now))
+		return
+	}
+
+	var buf bytes.Buffer
+	err = export.ClientPDF(&buf, data, export.PDFOptions{
+		Location:   h.cfg.Location,
+		FontPath:   h.cfg.PdfFontPath,
+		ExportedAt: now,
+	})
+	if err != nil {
+		c.Header("Content-Disposition", "")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to render the pdf",
+		})
+		h.log(c).Error("Failed to render client pdf", logger.Error(err))
+		return
+	}
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+func clientExport(data *repo.ClientExport, exportedAt time.Time) models.ClientExport {
+	response := models.ClientExport{
+		ExportedAt: exportedAt,
+		Client: models.ExportedClient{
+			Id:          data.Client.Id,
+			Name:        data.Client.Name,
+			LastName:    data.Client.LastName,
+			FatherName:  data.Client.FatherName,
+			PhoneNumber: data.Client.PhoneNumber,
+			Address:     data.Client.Address,
+			BirthDate:   data.Client.BirthDate,
+			CreatedAt:   data.Client.CreatedAt,
+			DeletedAt:   data.DeletedAt,
+		},
+		Appointments: make([]models.ExportedAppointment, 0, len(data.Appointments)),
+		Charges:      models.ExportedCharges{Items: []models.Charge{}},
+	}
+	for _, a := range data.Appointments {
+		response.Appointments = append(response.Appointments, models.ExportedAppointment{
+			Id:          a.Id,
+			DoctorId:    a.DoctorId,
+			DoctorName:  a.DoctorName,
+			Date:        a.Date,
+			Diagnostics: a.Diagnostics,
+			Treatment:   a.Treatment,
+			Amount:      a.Amount,
+			Status:      a.Status,
+			DeletedAt:   a.DeletedAt,
+		})
+		if a.DeletedAt == nil {
+			response.Charges.Total += a.Amount
+			response.Charges.Items = append(response.Charges.Items, models.Charge{
+				AppointmentId: a.Id,
+				Date:          a.Date,
+				Amount:        a.Amount,
+			})
+		}
+	}
+	return response
+}
+
+// AnonymizeClient
+// @Summary AnonymizeClient
+// @Description Api for erase a client: the personal data and appointment notes are wiped for good, the appointment dates and amounts stay for statistics
+// @Tags client
+// @Accept json
+// @Produce json
+// @Param id path string true "client id"
+// @Param Confirm body models.AnonymizeRequest true "confirm must be true"
+// @Success 200 {object} models.AnonymizeResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/clients/{id}/anonymize [post]
+func (h *handlerV1) AnonymizeClient(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	var req models.AnonymizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !req.Confirm {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Anonymization cannot be undone, send {\"confirm\": true}",
+		})
+		return
+	}
+
+	anonymizedAt, err := h.storage.Client().AnonymizeClient(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Client not found or already anonymized",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to anonymize client",
+		})
+		h.log(c).Error("Failed to anonymize client", logger.Error(err))
+		return
+	}
+	h.log(c).Info("Client anonymized", logger.String("client_id", id))
+
+	c.JSON(http.StatusOK, models.AnonymizeResponse{
+		Id:           id,
+		AnonymizedAt: anonymizedAt,
+	})
+}
@@ -0,0 +1,806 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateSupplier
+// @Summary CreateSupplier
+// @Description Api for add a supplier materials are ordered from
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param supplier body models.SupplierRequest true "supplier"
+// @Success 201 {object} models.Supplier
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error "another supplier has the name"
+// @Failure 500 {object} models.Error
+// @Router /v1/suppliers [post]
+func (h *handlerV1) CreateSupplier(c *gin.Context) {
+	supplier, ok := supplierOf(c)
+	if !ok {
+		return
+	}
+
+	created, err := h.storage.Purchasing().CreateSupplier(c.Request.Context(), supplier)
+	if errors.Is(err, repo.ErrNameTaken) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A supplier with this name exists",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create supplier",
+		})
+		h.log(c).Error("Failed to create supplier", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, supplierModel(created))
+}
+
+// GetSuppliers
+// @Summary GetSuppliers
+// @Description Api for get the suppliers by name
+// @Tags purchasing
+// @Produce json
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.Supplier]
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/suppliers [get]
+func (h *handlerV1) GetSuppliers(c *gin.Context) {
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+
+	suppliers, err := h.storage.Purchasing().GetSuppliers(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get suppliers",
+		})
+		h.log(c).Error("Failed to get suppliers", logger.Error(err))
+		return
+	}
+
+	response := make([]models.Supplier, 0, len(suppliers.Suppliers))
+	for _, s := range suppliers.Suppliers {
+		response = append(response, supplierModel(s))
+	}
+	c.JSON(http.StatusOK, pagination.NewPage(response, suppliers.Total, suppliers.NextCursor))
+}
+
+// GetSupplier
+// @Summary GetSupplier
+// @Description Api for get a supplier
+// @Tags purchasing
+// @Produce json
+// @Param id path string true "supplier id"
+// @Success 200 {object} models.Supplier
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/suppliers/{id} [get]
+func (h *handlerV1) GetSupplier(c *gin.Context) {
+	supplier, ok := h.supplierParam(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, supplierModel(supplier))
+}
+
+// UpdateSupplier
+// @Summary UpdateSupplier
+// @Description Api for update the details of a supplier
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path string true "supplier id"
+// @Param supplier body models.SupplierRequest true "supplier"
+// @Success 200 {object} models.Supplier
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "another supplier has the name"
+// @Failure 500 {object} models.Error
+// @Router /v1/suppliers/{id} [put]
+func (h *handlerV1) UpdateSupplier(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	supplier, ok := supplierOf(c)
+	if !ok {
+		return
+	}
+	supplier.Id = id
+
+	updated, err := h.storage.Purchasing().UpdateSupplier(c.Request.Context(), supplier)
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Supplier not found",
+		})
+		return
+	case errors.Is(err, repo.ErrNameTaken):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A supplier with this name exists",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update supplier",
+		})
+		h.log(c).Error("Failed to update supplier", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, supplierModel(updated))
+}
+
+// DeleteSupplier
+// @Summary DeleteSupplier
+// @Description Api for delete a supplier, its orders and price history are kept
+// @Tags purchasing
+// @Produce json
+// @Param id path string true "supplier id"
+// @Success 204
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/suppliers/{id} [delete]
+func (h *handlerV1) DeleteSupplier(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	err := h.storage.Purchasing().DeleteSupplier(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Supplier not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete supplier",
+		})
+		h.log(c).Error("Failed to delete supplier", logger.Error(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetSupplierPrices
+// @Summary GetSupplierPrices
+// @Description Api for get the unit prices a supplier was ordered items at, newest first. A price is recorded when an order is placed.
+// @Tags purchasing
+// @Produce json
+// @Param id path string true "supplier id"
+// @Param item_id query string false "only this item"
+// @Success 200 {array} models.SupplierPrice
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/suppliers/{id}/prices [get]
+func (h *handlerV1) GetSupplierPrices(c *gin.Context) {
+	itemId := c.Query("item_id")
+	if _, err := uuid.Parse(itemId); itemId != "" && err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "item_id must be a uuid",
+		})
+		return
+	}
+	supplier, ok := h.supplierParam(c)
+	if !ok {
+		return
+	}
+
+	prices, err := h.storage.Purchasing().GetPrices(c.Request.Context(), supplier.Id, itemId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get supplier prices",
+		})
+		h.log(c).Error("Failed to get supplier prices", logger.Error(err))
+		return
+	}
+
+	response := make([]models.SupplierPrice, 0, len(prices))
+	for _, p := range prices {
+		response = append(response, models.SupplierPrice{
+			ItemId:     p.ItemId,
+			ItemName:   p.ItemName,
+			UnitPrice:  p.UnitPrice,
+			OrderId:    p.OrderId,
+			RecordedAt: p.RecordedAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreatePurchaseOrder
+// @Summary CreatePurchaseOrder
+// @Description Api for draft an order of items from a supplier. The draft can be edited until it is placed.
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param order body models.PurchaseOrderRequest true "order"
+// @Success 201 {object} models.PurchaseOrder
+// @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error "the supplier or an item does not exist"
+// @Failure 500 {object} models.Error
+// @Router /v1/purchase-orders [post]
+func (h *handlerV1) CreatePurchaseOrder(c *gin.Context) {
+	order, ok := purchaseOrderOf(c)
+	if !ok {
+		return
+	}
+
+	created, err := h.storage.Purchasing().CreateOrder(c.Request.Context(), order)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "supplier or item does not exist",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create purchase order",
+		})
+		h.log(c).Error("Failed to create purchase order", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.purchaseOrder(created))
+}
+
+// GetPurchaseOrders
+// @Summary GetPurchaseOrders
+// @Description Api for get the purchase orders newest first, without their lines
+// @Tags purchasing
+// @Produce json
+// @Param supplier_id query string false "supplier id"
+// @Param status query string false "draft, ordered, partially_received, received or cancelled"
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.PurchaseOrder]
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/purchase-orders [get]
+func (h *handlerV1) GetPurchaseOrders(c *gin.Context) {
+	filter := &repo.PurchaseOrderFilter{
+		SupplierId: c.Query("supplier_id"),
+		Status:     c.Query("status"),
+	}
+	if _, err := uuid.Parse(filter.SupplierId); filter.SupplierId != "" && err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "supplier_id must be a uuid",
+		})
+		return
+	}
+	switch filter.Status {
+	case "", repo.OrderDraft, repo.OrderOrdered, repo.OrderPartiallyReceived, repo.OrderReceived, repo.OrderCancelled:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "status must be draft, ordered, partially_received, received or cancelled",
+		})
+		return
+	}
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+
+	orders, err := h.storage.Purchasing().GetOrders(c.Request.Context(), filter, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get purchase orders",
+		})
+		h.log(c).Error("Failed to get purchase orders", logger.Error(err))
+		return
+	}
+
+	response := make([]models.PurchaseOrder, 0, len(orders.Orders))
+	for _, o := range orders.Orders {
+		response = append(response, h.purchaseOrder(o))
+	}
+	c.JSON(http.StatusOK, pagination.NewPage(response, orders.Total, orders.NextCursor))
+}
+
+// GetPurchaseOrder
+// @Summary GetPurchaseOrder
+// @Description Api for get a purchase order with its lines and what is still to be delivered
+// @Tags purchasing
+// @Produce json
+// @Param id path string true "order id"
+// @Success 200 {object} models.PurchaseOrder
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/purchase-orders/{id} [get]
+func (h *handlerV1) GetPurchaseOrder(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	order, err := h.storage.Purchasing().GetOrder(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Purchase order not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get purchase order",
+		})
+		h.log(c).Error("Failed to get purchase order", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, h.purchaseOrder(order))
+}
+
+// UpdatePurchaseOrder
+// @Summary UpdatePurchaseOrder
+// @Description Api for replace the supplier, delivery date, note and lines of a draft purchase order
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path string true "order id"
+// @Param order body models.PurchaseOrderRequest true "order"
+// @Success 200 {object} models.PurchaseOrder
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "the order is not a draft"
+// @Failure 422 {object} models.Error "the supplier or an item does not exist"
+// @Failure 500 {object} models.Error
+// @Router /v1/purchase-orders/{id} [put]
+func (h *handlerV1) UpdatePurchaseOrder(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	order, ok := purchaseOrderOf(c)
+	if !ok {
+		return
+	}
+	order.Id = id
+
+	// the order is looked up first, ErrNotFound of the update is then
+	// about the supplier or an item
+	if _, ok = h.purchaseOrderParam(c); !ok {
+		return
+	}
+	updated, err := h.storage.Purchasing().UpdateOrder(c.Request.Context(), order)
+	h.orderChanged(c, updated, err, "Failed to update purchase order", "supplier or item does not exist")
+}
+
+// PlacePurchaseOrder
+// @Summary PlacePurchaseOrder
+// @Description Api for mark a draft purchase order as ordered from the supplier, its unit prices are added to the price history of the supplier
+// @Tags purchasing
+// @Produce json
+// @Param id path string true "order id"
+// @Success 200 {object} models.PurchaseOrder
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "the order is not a draft"
+// @Failure 500 {object} models.Error
+// @Router /v1/purchase-orders/{id}/place [post]
+func (h *handlerV1) PlacePurchaseOrder(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	order, err := h.storage.Purchasing().PlaceOrder(c.Request.Context(), id)
+	h.orderChanged(c, order, err, "Failed to place purchase order", "")
+}
+
+// CancelPurchaseOrder
+// @Summary CancelPurchaseOrder
+// @Description Api for cancel a purchase order not fully received, what was received stays in stock
+// @Tags purchasing
+// @Produce json
+// @Param id path string true "order id"
+// @Success 200 {object} models.PurchaseOrder
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "the order is received or cancelled"
+// @Failure 500 {object} models.Error
+// @Router /v1/purchase-orders/{id}/cancel [post]
+func (h *handlerV1) CancelPurchaseOrder(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	order, err := h.storage.Purchasing().CancelOrder(c.Request.Context(), id)
+	h.orderChanged(c, order, err, "Failed to cancel purchase order", "")
+}
+
+// ReceivePurchaseOrder
+// @Summary ReceivePurchaseOrder
+// @Description Api for put a delivery of a placed purchase order into stock, a batch per line. A delivery may cover part of the order, it is received once every line is.
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path string true "order id"
+// @Param receipt body models.ReceiptRequest true "receipt"
+// @Success 200 {object} models.PurchaseOrder
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "the order is not placed, or received or cancelled"
+// @Failure 422 {object} models.Error "the location or a line does not exist, or more is received than outstanding"
+// @Failure 500 {object} models.Error
+// @Router /v1/purchase-orders/{id}/receipts [post]
+func (h *handlerV1) ReceivePurchaseOrder(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	var body models.ReceiptRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if _, err := uuid.Parse(body.LocationId); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "location_id must be a uuid",
+		})
+		return
+	}
+	if len(body.Lines) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "lines must not be empty",
+		})
+		return
+	}
+	receipt := &repo.Receipt{
+		OrderId:    id,
+		LocationId: body.LocationId,
+		Note:       strings.TrimSpace(body.Note),
+	}
+	for _, l := range body.Lines {
+		if _, err := uuid.Parse(l.LineId); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "line_id must be a uuid",
+			})
+			return
+		}
+		if l.Quantity <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "quantity must be positive",
+			})
+			return
+		}
+		receipt.Lines = append(receipt.Lines, &repo.ReceiptLine{
+			LineId:    l.LineId,
+			Quantity:  l.Quantity,
+			Lot:       strings.TrimSpace(l.Lot),
+			ExpiresOn: l.ExpiresOn,
+		})
+	}
+
+	if _, ok := h.purchaseOrderParam(c); !ok {
+		return
+	}
+	order, err := h.storage.Purchasing().Receive(c.Request.Context(), receipt)
+	if errors.Is(err, repo.ErrOverReceipt) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "more received than outstanding on the line",
+		})
+		return
+	}
+	h.orderChanged(c, order, err, "Failed to receive purchase order", "location or line does not exist")
+}
+
+// GetSupplierSpend
+// @Summary GetSupplierSpend
+// @Description Api for get what was spent per supplier per month, counted from deliveries at the unit prices ordered. Months are of the clinic time zone and default to the last twelve.
+// @Tags purchasing
+// @Produce json
+// @Param from query string false "first month, YYYY-MM"
+// @Param to query string false "last month, YYYY-MM"
+// @Success 200 {array} models.SupplierSpend
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/purchasing/spend [get]
+func (h *handlerV1) GetSupplierSpend(c *gin.Context) {
+	today := civil.Today(h.cfg.Location).In(h.cfg.Location)
+	to := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, h.cfg.Location)
+	from := to.AddDate(0, -11, 0)
+	for name, month := range map[string]*time.Time{"from": &from, "to": &to} {
+		v := c.Query(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.ParseInLocation("2006-01", v, h.cfg.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": name + " must be YYYY-MM",
+			})
+			return
+		}
+		*month = t
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from must not be after to",
+		})
+		return
+	}
+
+	spend, err := h.storage.Purchasing().GetSpend(c.Request.Context(), civil.DateOf(from), civil.DateOf(to))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get supplier spend",
+		})
+		h.log(c).Error("Failed to get supplier spend", logger.Error(err))
+		return
+	}
+
+	response := make([]models.SupplierSpend, 0, len(spend))
+	for _, s := range spend {
+		response = append(response, models.SupplierSpend{
+			SupplierId:   s.SupplierId,
+			SupplierName: s.SupplierName,
+			Month:        s.Month,
+			Amount:       s.Amount,
+			Orders:       s.Orders,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// orderChanged answers a change of a purchase order, notFound is the
+// 422 message when ErrNotFound is about something other than the order
+func (h *handlerV1) orderChanged(c *gin.Context, order *repo.PurchaseOrder, err error, failed, notFound string) {
+	switch {
+	case errors.Is(err, repo.ErrNotFound) && notFound != "":
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": notFound,
+		})
+	case errors.Is(err, repo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Purchase order not found",
+		})
+	case errors.Is(err, repo.ErrOrderState):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The purchase order status does not allow this",
+		})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": failed,
+		})
+		h.log(c).Error(failed, logger.Error(err))
+	default:
+		c.JSON(http.StatusOK, h.purchaseOrder(order))
+	}
+}
+
+// purchaseOrderParam returns the order of the :id path parameter,
+// answering 404 itself
+func (h *handlerV1) purchaseOrderParam(c *gin.Context) (*repo.PurchaseOrder, bool) {
+	order, err := h.storage.Purchasing().GetOrder(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Purchase order not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get purchase order",
+		})
+		h.log(c).Error("Failed to get purchase order", logger.Error(err))
+		return nil, false
+	}
+	return order, true
+}
+
+// supplierParam returns the supplier of the :id path parameter,
+// answering 400 or 404 itself
+func (h *handlerV1) supplierParam(c *gin.Context) (*repo.Supplier, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return nil, false
+	}
+
+	supplier, err := h.storage.Purchasing().GetSupplier(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Supplier not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get supplier",
+		})
+		h.log(c).Error("Failed to get supplier", logger.Error(err))
+		return nil, false
+	}
+	return supplier, true
+}
+
+// supplierOf binds the supplier of the request body, answering 400
+// itself
+func supplierOf(c *gin.Context) (*repo.Supplier, bool) {
+	var body models.SupplierRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	supplier := &repo.Supplier{
+		Name:    strings.TrimSpace(body.Name),
+		Contact: strings.TrimSpace(body.Contact),
+		Phone:   strings.TrimSpace(body.Phone),
+		Email:   strings.TrimSpace(body.Email),
+		Note:    strings.TrimSpace(body.Note),
+	}
+	if supplier.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "name is required",
+		})
+		return nil, false
+	}
+	if len(supplier.Phone) > 30 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "phone is at most 30 characters",
+		})
+		return nil, false
+	}
+	return supplier, true
+}
+
+// purchaseOrderOf binds the order of the request body, answering 400
+// itself
+func purchaseOrderOf(c *gin.Context) (*repo.PurchaseOrder, bool) {
+	var body models.PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	if _, err := uuid.Parse(body.SupplierId); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "supplier_id must be a uuid",
+		})
+		return nil, false
+	}
+	if len(body.Lines) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "lines must not be empty",
+		})
+		return nil, false
+	}
+
+	order := &repo.PurchaseOrder{
+		SupplierId: body.SupplierId,
+		ExpectedOn: body.ExpectedOn,
+		Note:       strings.TrimSpace(body.Note),
+	}
+	for _, l := range body.Lines {
+		if _, err := uuid.Parse(l.ItemId); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "item_id must be a uuid",
+			})
+			return nil, false
+		}
+		if l.Quantity <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "quantity must be positive",
+			})
+			return nil, false
+		}
+		if l.UnitPrice < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "unit_price must not be negative",
+			})
+			return nil, false
+		}
+		order.Lines = append(order.Lines, &repo.PurchaseOrderLine{
+			ItemId:    l.ItemId,
+			Quantity:  l.Quantity,
+			UnitPrice: l.UnitPrice,
+		})
+	}
+	return order, true
+}
+
+func (h *handlerV1) purchaseOrder(o *repo.PurchaseOrder) models.PurchaseOrder {
+	today := civil.Today(h.cfg.Location)
+	open := o.Status == repo.OrderOrdered || o.Status == repo.OrderPartiallyReceived
+
+	response := models.PurchaseOrder{
+		Id:           o.Id,
+		SupplierId:   o.SupplierId,
+		SupplierName: o.SupplierName,
+		Status:       o.Status,
+		ExpectedOn:   o.ExpectedOn,
+		Overdue:      open && !o.ExpectedOn.IsZero() && o.ExpectedOn.In(h.cfg.Location).Before(today.In(h.cfg.Location)),
+		Note:         o.Note,
+		Total:        o.Total,
+		OrderedAt:    o.OrderedAt,
+		CreatedAt:    o.CreatedAt,
+		UpdatedAt:    o.UpdatedAt,
+	}
+	for _, l := range o.Lines {
+		response.Lines = append(response.Lines, models.PurchaseOrderLine{
+			Id:          l.Id,
+			ItemId:      l.ItemId,
+			ItemName:    l.ItemName,
+			Unit:        l.Unit,
+			Quantity:    l.Quantity,
+			UnitPrice:   l.UnitPrice,
+			Received:    l.Received,
+			Outstanding: l.Quantity - l.Received,
+		})
+	}
+	return response
+}
+
+func supplierModel(s *repo.Supplier) models.Supplier {
+	return models.Supplier{
+		Id:        s.Id,
+		Name:      s.Name,
+		Contact:   s.Contact,
+		Phone:     s.Phone,
+		Email:     s.Email,
+		Note:      s.Note,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
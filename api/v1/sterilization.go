@@ -0,0 +1,560 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateAutoclave
+// @Summary CreateAutoclave
+// @Description Api for add an autoclave instruments are sterilized in
+// @Tags sterilization
+// @Accept json
+// @Produce json
+// @Param autoclave body models.AutoclaveRequest true "autoclave"
+// @Success 201 {object} models.Autoclave
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error "the name is taken"
+// @Failure 500 {object} models.Error
+// @Router /v1/sterilization/autoclaves [post]
+func (h *handlerV1) CreateAutoclave(c *gin.Context) {
+	var body models.AutoclaveRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "name is required",
+		})
+		return
+	}
+
+	autoclave, err := h.storage.Sterilization().CreateAutoclave(c.Request.Context(), &repo.Autoclave{
+		Name:         name,
+		SerialNumber: strings.TrimSpace(body.SerialNumber),
+	})
+	if errors.Is(err, repo.ErrNameTaken) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "An autoclave with this name exists",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create autoclave",
+		})
+		h.log(c).Error("Failed to create autoclave", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, autoclaveResponse(autoclave))
+}
+
+// GetAutoclaves
+// @Summary GetAutoclaves
+// @Description Api for get the autoclaves by name
+// @Tags sterilization
+// @Produce json
+// @Success 200 {array} models.Autoclave
+// @Failure 500 {object} models.Error
+// @Router /v1/sterilization/autoclaves [get]
+func (h *handlerV1) GetAutoclaves(c *gin.Context) {
+	autoclaves, err := h.storage.Sterilization().GetAutoclaves(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get autoclaves",
+		})
+		h.log(c).Error("Failed to get autoclaves", logger.Error(err))
+		return
+	}
+
+	response := make([]models.Autoclave, 0, len(autoclaves))
+	for _, a := range autoclaves {
+		response = append(response, autoclaveResponse(a))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateCycle
+// @Summary CreateCycle
+// @Description Api for log an autoclave cycle with its parameters and the instrument packs processed in it. Its result is pending until the indicator is read, its packs cannot be used before it passed.
+// @Tags sterilization
+// @Accept json
+// @Produce json
+// @Param cycle body models.CycleRequest true "cycle"
+// @Success 201 {object} models.Cycle
+// @Failure 400 {object} models.Error
+// @Failure 409 {object} models.Error "the cycle number of the autoclave or a pack code is recorded"
+// @Failure 422 {object} models.Error "the autoclave does not exist"
+// @Failure 500 {object} models.Error
+// @Router /v1/sterilization/cycles [post]
+func (h *handlerV1) CreateCycle(c *gin.Context) {
+	var body models.CycleRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if _, err := uuid.Parse(body.AutoclaveId); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "autoclave_id must be a uuid",
+		})
+		return
+	}
+	switch {
+	case body.CycleNumber <= 0:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cycle_number must be positive",
+		})
+		return
+	case body.HoldMinutes <= 0:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "hold_minutes must be positive",
+		})
+		return
+	case body.TemperatureC <= 0 || body.TemperatureC >= 1000:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "temperature_c must be between 0 and 1000",
+		})
+		return
+	case body.PressureKpa <= 0 || body.PressureKpa >= 10000:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "pressure_kpa must be between 0 and 10000",
+		})
+		return
+	case body.StartedAt.After(time.Now()):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "started_at must not be in the future",
+		})
+		return
+	}
+
+	cycle := &repo.SterilizationCycle{
+		AutoclaveId:  body.AutoclaveId,
+		CycleNumber:  body.CycleNumber,
+		Program:      strings.TrimSpace(body.Program),
+		StartedAt:    body.StartedAt,
+		TemperatureC: body.TemperatureC,
+		PressureKpa:  body.PressureKpa,
+		HoldMinutes:  body.HoldMinutes,
+		Operator:     strings.TrimSpace(body.Operator),
+		Note:         strings.TrimSpace(body.Note),
+	}
+	codes := make(map[string]bool, len(body.Packs))
+	for _, p := range body.Packs {
+		code := strings.TrimSpace(p.Code)
+		if code == "" || len(code) > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "pack code must be 1 to 50 characters",
+			})
+			return
+		}
+		if codes[code] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "pack " + code + " is listed twice",
+			})
+			return
+		}
+		codes[code] = true
+		cycle.Packs = append(cycle.Packs, &repo.SterilizationPack{
+			Code:     code,
+			Contents: strings.TrimSpace(p.Contents),
+		})
+	}
+
+	created, err := h.storage.Sterilization().CreateCycle(c.Request.Context(), cycle)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "autoclave does not exist",
+		})
+		return
+	}
+	if errors.Is(err, repo.ErrDuplicate) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The cycle number of the autoclave or a pack code is recorded already",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create sterilization cycle",
+		})
+		h.log(c).Error("Failed to create sterilization cycle", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, cycleResponse(created))
+}
+
+// GetCycles
+// @Summary GetCycles
+// @Description Api for get the autoclave cycles last started first, without their packs
+// @Tags sterilization
+// @Produce json
+// @Param autoclave_id query string false "autoclave id"
+// @Param result query string false "pending, passed or failed"
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.Cycle]
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/sterilization/cycles [get]
+func (h *handlerV1) GetCycles(c *gin.Context) {
+	filter := &repo.CycleFilter{
+		AutoclaveId: c.Query("autoclave_id"),
+		Result:      c.Query("result"),
+	}
+	if _, err := uuid.Parse(filter.AutoclaveId); filter.AutoclaveId != "" && err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "autoclave_id must be a uuid",
+		})
+		return
+	}
+	switch filter.Result {
+	case "", repo.CyclePending, repo.CyclePassed, repo.CycleFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "result must be pending, passed or failed",
+		})
+		return
+	}
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+
+	cycles, err := h.storage.Sterilization().GetCycles(c.Request.Context(), filter, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get sterilization cycles",
+		})
+		h.log(c).Error("Failed to get sterilization cycles", logger.Error(err))
+		return
+	}
+
+	response := make([]models.Cycle, 0, len(cycles.Cycles))
+	for _, cycle := range cycles.Cycles {
+		response = append(response, cycleResponse(cycle))
+	}
+	c.JSON(http.StatusOK, pagination.NewPage(response, cycles.Total, cycles.NextCursor))
+}
+
+// GetCycle
+// @Summary GetCycle
+// @Description Api for get an autoclave cycle with its packs
+// @Tags sterilization
+// @Produce json
+// @Param id path string true "cycle id"
+// @Success 200 {object} models.Cycle
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/sterilization/cycles/{id} [get]
+func (h *handlerV1) GetCycle(c *gin.Context) {
+	id, ok := cycleIdOf(c)
+	if !ok {
+		return
+	}
+
+	cycle, err := h.storage.Sterilization().GetCycle(c.Request.Context(), id)
+	h.cycleChanged(c, cycle, err, "Failed to get sterilization cycle")
+}
+
+// RecordCycleResult
+// @Summary RecordCycleResult
+// @Description Api for record the indicator result of a pending cycle, or fail a cycle that passed when its biological indicator grows. A failed cycle sends sterilization.cycle_failed with the appointments its packs were used at.
+// @Tags sterilization
+// @Accept json
+// @Produce json
+// @Param id path string true "cycle id"
+// @Param result body models.CycleResultRequest true "result"
+// @Success 200 {object} models.Cycle
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "the cycle failed already or passed already"
+// @Failure 500 {object} models.Error
+// @Router /v1/sterilization/cycles/{id}/result [post]
+func (h *handlerV1) RecordCycleResult(c *gin.Context) {
+	id, ok := cycleIdOf(c)
+	if !ok {
+		return
+	}
+	var body models.CycleResultRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if body.Indicator != repo.IndicatorChemical && body.Indicator != repo.IndicatorBiological {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "indicator must be chemical or biological",
+		})
+		return
+	}
+
+	cycle, err := h.storage.Sterilization().RecordResult(c.Request.Context(), id, *body.Passed, body.Indicator, strings.TrimSpace(body.Note))
+	h.cycleChanged(c, cycle, err, "Failed to record sterilization cycle result")
+}
+
+// GetCycleExposures
+// @Summary GetCycleExposures
+// @Description Api for trace the appointments, with their clients, the packs of a cycle were used at, earliest first
+// @Tags sterilization
+// @Produce json
+// @Param id path string true "cycle id"
+// @Success 200 {array} models.Exposure
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/sterilization/cycles/{id}/exposures [get]
+func (h *handlerV1) GetCycleExposures(c *gin.Context) {
+	id, ok := cycleIdOf(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.storage.Sterilization().GetCycle(c.Request.Context(), id); err != nil {
+		h.cycleChanged(c, nil, err, "Failed to get sterilization cycle")
+		return
+	}
+	exposures, err := h.storage.Sterilization().Exposures(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get exposures",
+		})
+		h.log(c).Error("Failed to get exposures", logger.Error(err))
+		return
+	}
+
+	response := make([]models.Exposure, 0, len(exposures))
+	for _, e := range exposures {
+		response = append(response, models.Exposure{
+			AppointmentId:   e.AppointmentId,
+			AppointmentDate: e.AppointmentDate,
+			ClientId:        e.ClientId,
+			ClientName:      strings.TrimSpace(e.ClientName),
+			PhoneNumber:     e.PhoneNumber,
+			PackCode:        e.PackCode,
+			UsedAt:          e.UsedAt,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPack
+// @Summary GetPack
+// @Description Api for get an instrument pack by the code on its label, with the result of its cycle
+// @Tags sterilization
+// @Produce json
+// @Param code path string true "pack code"
+// @Success 200 {object} models.Pack
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/sterilization/packs/{code} [get]
+func (h *handlerV1) GetPack(c *gin.Context) {
+	pack, err := h.storage.Sterilization().GetPack(c.Request.Context(), c.Param("code"))
+	h.packChanged(c, pack, err, "Failed to get sterilization pack")
+}
+
+// UsePack
+// @Summary UsePack
+// @Description Api for record that an instrument pack was opened at an appointment. Only packs of a cycle that passed can be used, and each once.
+// @Tags sterilization
+// @Accept json
+// @Produce json
+// @Param code path string true "pack code"
+// @Param use body models.PackUseRequest true "use"
+// @Success 200 {object} models.Pack
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "the pack was used or its cycle has not passed"
+// @Failure 422 {object} models.Error "the appointment does not exist"
+// @Failure 500 {object} models.Error
+// @Router /v1/sterilization/packs/{code}/use [post]
+func (h *handlerV1) UsePack(c *gin.Context) {
+	var body models.PackUseRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if _, err := uuid.Parse(body.AppointmentId); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "appointment_id must be a uuid",
+		})
+		return
+	}
+
+	// the appointment is looked up first, ErrNotFound of the use is then
+	// about the pack
+	_, err := h.storage.Appointment().GetAppointment(c.Request.Context(), body.AppointmentId)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "appointment does not exist",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get appointment",
+		})
+		h.log(c).Error("Failed to get appointment", logger.Error(err))
+		return
+	}
+
+	pack, err := h.storage.Sterilization().UsePack(c.Request.Context(), c.Param("code"), body.AppointmentId)
+	h.packChanged(c, pack, err, "Failed to use sterilization pack")
+}
+
+// GetAppointmentPacks
+// @Summary GetAppointmentPacks
+// @Description Api for get the instrument packs used at an appointment
+// @Tags sterilization
+// @Produce json
+// @Param appointment_id query string true "appointment id"
+// @Success 200 {array} models.Pack
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/sterilization/packs [get]
+func (h *handlerV1) GetAppointmentPacks(c *gin.Context) {
+	appointmentId := c.Query("appointment_id")
+	if _, err := uuid.Parse(appointmentId); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "appointment_id must be a uuid",
+		})
+		return
+	}
+
+	packs, err := h.storage.Sterilization().GetAppointmentPacks(c.Request.Context(), appointmentId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get appointment packs",
+		})
+		h.log(c).Error("Failed to get appointment packs", logger.Error(err))
+		return
+	}
+
+	response := make([]models.Pack, 0, len(packs))
+	for _, p := range packs {
+		response = append(response, packResponse(p))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// cycleChanged answers a read or change of a cycle
+func (h *handlerV1) cycleChanged(c *gin.Context, cycle *repo.SterilizationCycle, err error, failed string) {
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Sterilization cycle not found",
+		})
+	case errors.Is(err, repo.ErrCycleState):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The cycle result does not allow this",
+		})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": failed,
+		})
+		h.log(c).Error(failed, logger.Error(err))
+	default:
+		c.JSON(http.StatusOK, cycleResponse(cycle))
+	}
+}
+
+// packChanged answers a read or change of a pack
+func (h *handlerV1) packChanged(c *gin.Context, pack *repo.SterilizationPack, err error, failed string) {
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Sterilization pack not found",
+		})
+	case errors.Is(err, repo.ErrPackUnusable):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The pack was used already or its cycle has not passed",
+		})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": failed,
+		})
+		h.log(c).Error(failed, logger.Error(err))
+	default:
+		c.JSON(http.StatusOK, packResponse(pack))
+	}
+}
+
+// cycleIdOf returns the :id path parameter, answering 400 itself
+func cycleIdOf(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return "", false
+	}
+	return id, true
+}
+
+func autoclaveResponse(a *repo.Autoclave) models.Autoclave {
+	return models.Autoclave{
+		Id:           a.Id,
+		Name:         a.Name,
+		SerialNumber: a.SerialNumber,
+		CreatedAt:    a.CreatedAt,
+	}
+}
+
+func cycleResponse(cycle *repo.SterilizationCycle) models.Cycle {
+	response := models.Cycle{
+		Id:            cycle.Id,
+		AutoclaveId:   cycle.AutoclaveId,
+		AutoclaveName: cycle.AutoclaveName,
+		CycleNumber:   cycle.CycleNumber,
+		Program:       cycle.Program,
+		StartedAt:     cycle.StartedAt,
+		TemperatureC:  cycle.TemperatureC,
+		PressureKpa:   cycle.PressureKpa,
+		HoldMinutes:   cycle.HoldMinutes,
+		Operator:      cycle.Operator,
+		Note:          cycle.Note,
+		Result:        cycle.Result,
+		Indicator:     cycle.Indicator,
+		ResultNote:    cycle.ResultNote,
+		ResultAt:      cycle.ResultAt,
+		CreatedAt:     cycle.CreatedAt,
+	}
+	for _, p := range cycle.Packs {
+		response.Packs = append(response.Packs, packResponse(p))
+	}
+	return response
+}
+
+func packResponse(p *repo.SterilizationPack) models.Pack {
+	return models.Pack{
+		Id:            p.Id,
+		Code:          p.Code,
+		CycleId:       p.CycleId,
+		CycleResult:   p.CycleResult,
+		Contents:      p.Contents,
+		AppointmentId: p.AppointmentId,
+		UsedAt:        p.UsedAt,
+	}
+}
@@ -0,0 +1,240 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetTrash
+// @Summary GetTrash
+// @Description Api for get how many clients and appointments are in the trash
+// @Tags trash
+// @Produce json
+// @Success 200 {object} models.TrashSummary
+// @Failure 500 {object} models.Error
+// @Router /v1/trash [get]
+func (h *handlerV1) GetTrash(c *gin.Context) {
+	first := pagination.Params{Limit: 1}
+
+	clients, err := h.storage.Trash().GetDeletedClients(c.Request.Context(), first)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get the trash",
+		})
+		h.log(c).Error("Failed to get deleted clients", logger.Error(err))
+		return
+	}
+	appointments, err := h.storage.Trash().GetDeletedAppointments(c.Request.Context(), first)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get the trash",
+		})
+		h.log(c).Error("Failed to get deleted appointments", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TrashSummary{
+		Clients:       clients.Total,
+		Appointments:  appointments.Total,
+		RetentionDays: h.cfg.TrashRetentionDays,
+		Anonymize:     h.cfg.TrashAnonymize,
+	})
+}
+
+// GetDeletedClients
+// @Summary GetDeletedClients
+// @Description Api for get deleted clients, most recently deleted first
+// @Tags trash
+// @Produce json
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.DeletedClientResponse]
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/trash/clients [get]
+func (h *handlerV1) GetDeletedClients(c *gin.Context) {
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.storage.Trash().GetDeletedClients(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get deleted clients",
+		})
+		h.log(c).Error("Failed to get deleted clients", logger.Error(err))
+		return
+	}
+
+	items := make([]models.DeletedClientResponse, 0, len(response.Clients))
+	for _, client := range response.Clients {
+		items = append(items, models.DeletedClientResponse{
+			Id:          client.Id,
+			Name:        client.Name,
+			LastName:    client.LastName,
+			FatherName:  client.FatherName,
+			PhoneNumber: client.PhoneNumber,
+			Address:     client.Address,
+			BirthDate:   client.BirthDate,
+			CreatedAt:   client.CreatedAt,
+			DeletedAt:   client.DeletedAt,
+			PurgeAt:     h.purgeAt(client.DeletedAt),
+		})
+	}
+
+	c.JSON(http.StatusOK, pagination.NewPage(items, response.Total, response.NextCursor))
+}
+
+// GetDeletedAppointments
+// @Summary GetDeletedAppointments
+// @Description Api for get deleted appointments, most recently deleted first
+// @Tags trash
+// @Produce json
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.DeletedAppointmentResponse]
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/trash/appointments [get]
+func (h *handlerV1) GetDeletedAppointments(c *gin.Context) {
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.storage.Trash().GetDeletedAppointments(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get deleted appointments",
+		})
+		h.log(c).Error("Failed to get deleted appointments", logger.Error(err))
+		return
+	}
+
+	items := make([]models.DeletedAppointmentResponse, 0, len(response.Appointments))
+	for _, appointment := range response.Appointments {
+		items = append(items, models.DeletedAppointmentResponse{
+			Id:          appointment.Id,
+			ClientId:    appointment.ClientId,
+			DoctorId:    appointment.DoctorId,
+			Date:        appointment.Date,
+			Diagnostics: appointment.Diagnostics,
+			Treatment:   appointment.Treatment,
+			Amount:      appointment.Amount,
+			Status:      appointment.Status,
+			DeletedAt:   appointment.DeletedAt,
+			PurgeAt:     h.purgeAt(appointment.DeletedAt),
+		})
+	}
+
+	c.JSON(http.StatusOK, pagination.NewPage(items, response.Total, response.NextCursor))
+}
+
+// RestoreClient
+// @Summary RestoreClient
+// @Description Api for restore a deleted client with the appointments deleted together with it
+// @Tags trash
+// @Produce json
+// @Param id path string true "client id"
+// @Success 200 {object} models.RestoreClientResponse
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/trash/clients/{id}/restore [post]
+func (h *handlerV1) RestoreClient(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	restored, err := h.storage.Trash().RestoreClient(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Client is not in the trash",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to restore client",
+		})
+		h.log(c).Error("Failed to restore client", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RestoreClientResponse{
+		Id:                   id,
+		RestoredAppointments: restored,
+	})
+}
+
+// RestoreAppointment
+// @Summary RestoreAppointment
+// @Description Api for restore a deleted appointment, its client must not be deleted
+// @Tags trash
+// @Produce json
+// @Param id path string true "appointment id"
+// @Success 204
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/trash/appointments/{id}/restore [post]
+func (h *handlerV1) RestoreAppointment(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	err := h.storage.Trash().RestoreAppointment(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Appointment is not in the trash",
+		})
+		return
+	}
+	if errors.Is(err, repo.ErrClientDeleted) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The client of the appointment is deleted, restore the client first",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to restore appointment",
+		})
+		h.log(c).Error("Failed to restore appointment", logger.Error(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// purgeAt is when the retention job takes a row deleted at deletedAt, nil
+// when the trash is kept forever
+func (h *handlerV1) purgeAt(deletedAt time.Time) *time.Time {
+	if h.cfg.TrashRetentionDays <= 0 {
+		return nil
+	}
+	t := deletedAt.AddDate(0, 0, h.cfg.TrashRetentionDays)
+	return &t
+}
@@ -0,0 +1,415 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/pkg/waitlist"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateWaitlistEntry
+// @Summary CreateWaitlistEntry
+// @Description Api for put a client on the waitlist for an earlier appointment. When an appointment in the window is cancelled or moved, the best matching clients are offered its time and the first to accept gets it. A minor client needs a guardian.
+// @Tags waitlist
+// @Accept json
+// @Produce json
+// @Param entry body models.WaitlistRequest true "entry"
+// @Success 201 {object} models.WaitlistEntry
+// @Failure 400 {object} models.Error
+// @Failure 422 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/waitlist [post]
+func (h *handlerV1) CreateWaitlistEntry(c *gin.Context) {
+	var body models.WaitlistRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if _, err := uuid.Parse(body.ClientId); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "client_id must be a uuid",
+		})
+		return
+	}
+	if body.DoctorId != "" {
+		if _, err := uuid.Parse(body.DoctorId); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "doctor_id must be a uuid",
+			})
+			return
+		}
+	}
+	if body.DateTo.In(h.cfg.Location).Before(body.DateFrom.In(h.cfg.Location)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "date_to must not be before date_from",
+		})
+		return
+	}
+
+	_, err := h.storage.Client().GetClient(c.Request.Context(), body.ClientId)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "client does not exist",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get client",
+		})
+		h.log(c).Error("Failed to get client", logger.Error(err))
+		return
+	}
+	if body.DoctorId != "" {
+		_, err = h.storage.Doctor().GetDoctor(c.Request.Context(), body.DoctorId)
+		if errors.Is(err, repo.ErrNotFound) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error": "doctor does not exist",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get doctor",
+			})
+			h.log(c).Error("Failed to get doctor", logger.Error(err))
+			return
+		}
+	}
+	if !h.guardianPresent(c, body.ClientId) {
+		return
+	}
+
+	entry, err := h.storage.Waitlist().CreateEntry(c.Request.Context(), &repo.WaitlistEntry{
+		ClientId:  body.ClientId,
+		DoctorId:  body.DoctorId,
+		Treatment: body.Treatment,
+		DateFrom:  body.DateFrom,
+		DateTo:    body.DateTo,
+		Priority:  body.Priority,
+		Note:      body.Note,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create waitlist entry",
+		})
+		h.log(c).Error("Failed to create waitlist entry", logger.Error(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, waitlistEntry(entry))
+}
+
+// GetWaitlist
+// @Summary GetWaitlist
+// @Description Api for get the waitlist, the longest waiting first
+// @Tags waitlist
+// @Produce json
+// @Param status query string false "waiting, booked or cancelled"
+// @Param doctor_id query string false "doctor id"
+// @Param client_id query string false "client id"
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.WaitlistEntry]
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/waitlist [get]
+func (h *handlerV1) GetWaitlist(c *gin.Context) {
+	filter := &repo.WaitlistFilter{
+		Status:   c.Query("status"),
+		DoctorId: c.Query("doctor_id"),
+		ClientId: c.Query("client_id"),
+	}
+	switch filter.Status {
+	case "", repo.WaitlistWaiting, repo.WaitlistBooked, repo.WaitlistCancelled:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "status must be waiting, booked or cancelled",
+		})
+		return
+	}
+	for name, id := range map[string]string{"doctor_id": filter.DoctorId, "client_id": filter.ClientId} {
+		if _, err := uuid.Parse(id); id != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": name + " must be a uuid",
+			})
+			return
+		}
+	}
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.storage.Waitlist().GetEntries(c.Request.Context(), filter, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get waitlist",
+		})
+		h.log(c).Error("Failed to get waitlist", logger.Error(err))
+		return
+	}
+
+	items := make([]models.WaitlistEntry, 0, len(entries.Entries))
+	for _, e := range entries.Entries {
+		items = append(items, waitlistEntry(e))
+	}
+	c.JSON(http.StatusOK, pagination.NewPage(items, entries.Total, entries.NextCursor))
+}
+
+// CancelWaitlistEntry
+// @Summary CancelWaitlistEntry
+// @Description Api for take a waiting client off the waitlist, the links of the pending offers stop working
+// @Tags waitlist
+// @Produce json
+// @Param id path string true "entry id"
+// @Success 204
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/waitlist/{id} [delete]
+func (h *handlerV1) CancelWaitlistEntry(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	cancelled, err := h.storage.Waitlist().CancelEntry(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to cancel waitlist entry",
+		})
+		h.log(c).Error("Failed to cancel waitlist entry", logger.Error(err))
+		return
+	}
+	if !cancelled {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Waiting entry not found",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetWaitlistOffers
+// @Summary GetWaitlistOffers
+// @Description Api for get the slots offered to a waitlist entry, newest first
+// @Tags waitlist
+// @Produce json
+// @Param id path string true "entry id"
+// @Success 200 {array} models.WaitlistOffer
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/waitlist/{id}/offers [get]
+func (h *handlerV1) GetWaitlistOffers(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	_, err := h.storage.Waitlist().GetEntry(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Waitlist entry not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get waitlist entry",
+		})
+		h.log(c).Error("Failed to get waitlist entry", logger.Error(err))
+		return
+	}
+
+	offers, err := h.storage.Waitlist().GetEntryOffers(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get waitlist offers",
+		})
+		h.log(c).Error("Failed to get waitlist offers", logger.Error(err))
+		return
+	}
+
+	response := make([]models.WaitlistOffer, 0, len(offers))
+	for _, o := range offers {
+		response = append(response, waitlistOffer(o))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// AcceptWaitlistOffer
+// @Summary AcceptWaitlistOffer
+// @Description Api for accept an offer on behalf of the client, such as on the phone. The first offer of a slot accepted books it.
+// @Tags waitlist
+// @Produce json
+// @Param id path string true "offer id"
+// @Success 201 {object} models.WaitlistBooking
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "someone else took the slot"
+// @Failure 410 {object} models.Error "the offer expired or is closed"
+// @Failure 500 {object} models.Error
+// @Router /v1/waitlist-offers/{id}/accept [post]
+func (h *handlerV1) AcceptWaitlistOffer(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	h.bookOffer(c, id)
+}
+
+// GetPublicWaitlistOffer
+// @Summary GetPublicWaitlistOffer
+// @Description The slot offered by the link sent to a client on the waitlist, the token is the secret of the link
+// @Tags waitlist
+// @Produce json
+// @Param token path string true "token of the offer link"
+// @Success 200 {object} models.PublicWaitlistOffer
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /waitlist/offers/{token} [get]
+func (h *handlerV1) GetPublicWaitlistOffer(c *gin.Context) {
+	offer, ok := h.waitlistOfferOf(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PublicWaitlistOffer{
+		Date:      offer.Date,
+		DoctorId:  offer.DoctorId,
+		Treatment: offer.Treatment,
+		Status:    offer.Status,
+		ExpiresAt: offer.ExpiresAt,
+	})
+}
+
+// AcceptPublicWaitlistOffer
+// @Summary AcceptPublicWaitlistOffer
+// @Description Books the slot offered by the link sent to a client on the waitlist, if nobody accepted it first
+// @Tags waitlist
+// @Produce json
+// @Param token path string true "token of the offer link"
+// @Success 201 {object} models.WaitlistBooking
+// @Failure 404 {object} models.Error
+// @Failure 409 {object} models.Error "someone else took the slot"
+// @Failure 410 {object} models.Error "the offer expired or is closed"
+// @Failure 500 {object} models.Error
+// @Router /waitlist/offers/{token}/accept [post]
+func (h *handlerV1) AcceptPublicWaitlistOffer(c *gin.Context) {
+	offer, ok := h.waitlistOfferOf(c)
+	if !ok {
+		return
+	}
+	h.bookOffer(c, offer.Id)
+}
+
+// waitlistOfferOf finds the offer of the token of the link, answering
+// 404 itself for an unknown one
+func (h *handlerV1) waitlistOfferOf(c *gin.Context) (*repo.WaitlistOffer, bool) {
+	offer, err := h.storage.Waitlist().GetOfferByToken(c.Request.Context(), waitlist.HashToken(c.Param("token")))
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Offer not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get offer",
+		})
+		h.log(c).Error("Failed to get waitlist offer", logger.Error(err))
+		return nil, false
+	}
+	return offer, true
+}
+
+// bookOffer accepts the offer and answers with the appointment booked
+func (h *handlerV1) bookOffer(c *gin.Context, offerId string) {
+	appointment, err := waitlist.Book(c.Request.Context(), h.storage.Waitlist(), h.storage.Appointment(), h.logger, offerId)
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Offer not found",
+		})
+		return
+	case errors.Is(err, repo.ErrSlotTaken):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Someone else took the slot first",
+		})
+		return
+	case errors.Is(err, repo.ErrOfferClosed):
+		c.JSON(http.StatusGone, gin.H{
+			"error": "The offer expired or is closed",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to book the slot",
+		})
+		h.log(c).Error("Failed to book waitlist offer", logger.Error(err))
+		return
+	}
+	h.log(c).Info("Waitlist offer booked",
+		logger.String("offer_id", offerId),
+		logger.String("appointment_id", appointment.Id),
+	)
+
+	c.JSON(http.StatusCreated, models.WaitlistBooking{
+		AppointmentId: appointment.Id,
+		Date:          appointment.Date,
+		DoctorId:      appointment.DoctorId,
+	})
+}
+
+func waitlistEntry(e *repo.WaitlistEntry) models.WaitlistEntry {
+	return models.WaitlistEntry{
+		Id:            e.Id,
+		ClientId:      e.ClientId,
+		DoctorId:      e.DoctorId,
+		Treatment:     e.Treatment,
+		DateFrom:      e.DateFrom,
+		DateTo:        e.DateTo,
+		Priority:      e.Priority,
+		Note:          e.Note,
+		Status:        e.Status,
+		AppointmentId: e.AppointmentId,
+		CreatedAt:     e.CreatedAt,
+	}
+}
+
+func waitlistOffer(o *repo.WaitlistOffer) models.WaitlistOffer {
+	return models.WaitlistOffer{
+		Id:         o.Id,
+		EntryId:    o.EntryId,
+		Rank:       o.Rank,
+		DoctorId:   o.DoctorId,
+		Date:       o.Date,
+		Status:     o.Status,
+		ExpiresAt:  o.ExpiresAt,
+		NotifiedAt: o.NotifiedAt,
+		AcceptedAt: o.AcceptedAt,
+		CreatedAt:  o.CreatedAt,
+	}
+}
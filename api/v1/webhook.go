@@ -0,0 +1,283 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/pkg/webhook"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateWebhook
+// @Summary CreateWebhook
+// @Description Api for subscribe a URL to events: appointment.created, appointment.updated, appointment.checked_in, appointment.cancelled, appointment.deleted, appointment.restored, client.created, client.updated, client.deleted, client.restored, inventory.low_stock, sterilization.cycle_failed, or * for all. The URL must be https and must not resolve to a loopback, private or link-local address. Every event is sent as a signed JSON POST and retried with backoff until it is answered 2xx. The secret is returned only here.
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Param webhook body models.WebhookRequest true "webhook"
+// @Success 201 {object} models.WebhookCreated
+// @Failure 400 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/webhooks [post]
+func (h *handlerV1) CreateWebhook(c *gin.Context) {
+	var body models.WebhookRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	err := webhook.CheckURL(c.Request.Context(), body.Url, h.cfg.WebhookAllowPrivate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	target, _ := url.Parse(body.Url)
+	if len(body.Events) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "events must not be empty",
+		})
+		return
+	}
+	for _, event := range body.Events {
+		if !webhook.ValidEvent(event) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "unknown event " + event + ", known are * and " + strings.Join(webhook.Events, ", "),
+			})
+			return
+		}
+	}
+	if body.Secret == "" {
+		if body.Secret, err = webhook.NewSecret(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to create webhook",
+			})
+			h.log(c).Error("Failed to create webhook secret", logger.Error(err))
+			return
+		}
+	}
+
+	created, err := h.storage.Webhook().CreateWebhook(c.Request.Context(), &repo.Webhook{
+		Url:         body.Url,
+		Events:      body.Events,
+		Secret:      body.Secret,
+		Description: body.Description,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create webhook",
+		})
+		h.log(c).Error("Failed to create webhook", logger.Error(err))
+		return
+	}
+	h.log(c).Info("Webhook created",
+		logger.String("webhook_id", created.Id),
+		logger.String("host", target.Host),
+	)
+
+	c.JSON(http.StatusCreated, models.WebhookCreated{
+		Webhook: webhookResponse(created),
+		Secret:  created.Secret,
+	})
+}
+
+// GetWebhooks
+// @Summary GetWebhooks
+// @Description Api for get the webhooks, without their secrets
+// @Tags webhook
+// @Produce json
+// @Success 200 {array} models.Webhook
+// @Failure 500 {object} models.Error
+// @Router /v1/webhooks [get]
+func (h *handlerV1) GetWebhooks(c *gin.Context) {
+	webhooks, err := h.storage.Webhook().GetWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get webhooks",
+		})
+		h.log(c).Error("Failed to get webhooks", logger.Error(err))
+		return
+	}
+
+	response := make([]models.Webhook, 0, len(webhooks))
+	for _, w := range webhooks {
+		response = append(response, webhookResponse(w))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteWebhook
+// @Summary DeleteWebhook
+// @Description Api for delete a webhook, its pending deliveries are not sent. The delivery log is kept.
+// @Tags webhook
+// @Produce json
+// @Param id path string true "webhook id"
+// @Success 204
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/webhooks/{id} [delete]
+func (h *handlerV1) DeleteWebhook(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	deleted, err := h.storage.Webhook().DeleteWebhook(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete webhook",
+		})
+		h.log(c).Error("Failed to delete webhook", logger.Error(err))
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Webhook not found",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries
+// @Summary GetWebhookDeliveries
+// @Description Api for get the delivery log of a webhook, newest first
+// @Tags webhook
+// @Produce json
+// @Param id path string true "webhook id"
+// @Param page query int false "page number, starts from 1"
+// @Param offset query int false "rows to skip, instead of page"
+// @Param limit query int false "page size, at most 100"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.WebhookDelivery]
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/webhooks/{id}/deliveries [get]
+func (h *handlerV1) GetWebhookDeliveries(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+	params, ok := h.pagination(c)
+	if !ok {
+		return
+	}
+
+	_, err := h.storage.Webhook().GetWebhook(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Webhook not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get webhook",
+		})
+		h.log(c).Error("Failed to get webhook", logger.Error(err))
+		return
+	}
+
+	deliveries, err := h.storage.Webhook().GetDeliveries(c.Request.Context(), id, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get webhook deliveries",
+		})
+		h.log(c).Error("Failed to get webhook deliveries", logger.Error(err))
+		return
+	}
+
+	items := make([]models.WebhookDelivery, 0, len(deliveries.Deliveries))
+	for _, d := range deliveries.Deliveries {
+		items = append(items, webhookDelivery(d))
+	}
+	c.JSON(http.StatusOK, pagination.NewPage(items, deliveries.Total, deliveries.NextCursor))
+}
+
+// ReplayWebhookDelivery
+// @Summary ReplayWebhookDelivery
+// @Description Api for send the payload of a delivery again, as a new delivery with the same event id
+// @Tags webhook
+// @Produce json
+// @Param id path string true "delivery id"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {object} models.Error
+// @Failure 404 {object} models.Error
+// @Failure 500 {object} models.Error
+// @Router /v1/webhook-deliveries/{id}/replay [post]
+func (h *handlerV1) ReplayWebhookDelivery(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "id must be a uuid",
+		})
+		return
+	}
+
+	delivery, err := h.storage.Webhook().ReplayDelivery(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Delivery not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to replay delivery",
+		})
+		h.log(c).Error("Failed to replay delivery", logger.Error(err))
+		return
+	}
+	h.log(c).Info("Webhook delivery replayed",
+		logger.String("delivery_id", delivery.Id),
+		logger.String("replay_of", id),
+	)
+
+	c.JSON(http.StatusAccepted, webhookDelivery(delivery))
+}
+
+func webhookResponse(w *repo.Webhook) models.Webhook {
+	return models.Webhook{
+		Id:          w.Id,
+		Url:         w.Url,
+		Events:      w.Events,
+		Description: w.Description,
+		CreatedAt:   w.CreatedAt,
+	}
+}
+
+func webhookDelivery(d *repo.WebhookDelivery) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		Id:             d.Id,
+		WebhookId:      d.WebhookId,
+		EventId:        d.EventId,
+		Event:          d.Event,
+		Payload:        d.Payload,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		ReplayOf:       d.ReplayOf,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+}
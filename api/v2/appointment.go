@@ -0,0 +1,344 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateAppointment ...
+// @Summary CreateAppointment
+// @Description Api for creating a new appointment
+// @Tags v2 appointment
+// @Accept json
+// @Produce json
+// @Param Appointment body models.AppointmentRequest true "Appointment"
+// @Success 201 {object} models.AppointmentResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse "client or doctor does not exist, or a minor client has no guardian"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/appointments [post]
+func (h *handlerV2) CreateAppointment(c *gin.Context) {
+	var req models.AppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	client, ok := h.appointmentClient(c, req.ClientId)
+	if !ok {
+		return
+	}
+
+	h.createAppointment(c, client, &req)
+}
+
+// appointmentClient gets the client of an appointment, answering 400 when
+// id is not a uuid and 422 when there is no such client
+func (h *handlerV2) appointmentClient(c *gin.Context, id string) (*repo.Client, bool) {
+	if _, err := uuid.Parse(id); err != nil {
+		abort(c, http.StatusBadRequest, "client_id must be a uuid")
+		return nil, false
+	}
+
+	client, err := h.storage.Client().GetClient(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		abort(c, http.StatusUnprocessableEntity, "client does not exist")
+		return nil, false
+	}
+	if err != nil {
+		h.fail(c, err, "Failed to get client")
+		return nil, false
+	}
+	return client, true
+}
+
+func (h *handlerV2) createAppointment(c *gin.Context, client *repo.Client, req *models.AppointmentRequest) {
+	if !validAppointment(c, req) || !h.doctorExists(c, req.DoctorId) || !h.guardianPresent(c, client) {
+		return
+	}
+
+	response, err := h.storage.Appointment().CreateAppointment(c.Request.Context(), &repo.Appointment{
+		Id:          uuid.NewString(),
+		ClientId:    req.ClientId,
+		Date:        req.Date,
+		Diagnostics: req.Diagnostics,
+		Treatment:   req.Treatment,
+		Amount:      req.Amount,
+		Status:      req.Status,
+		DoctorId:    req.DoctorId,
+	})
+	if err != nil {
+		h.fail(c, err, "Failed to create appointment")
+		return
+	}
+
+	c.Header("Location", "/v2/appointments/"+response.Id)
+	c.JSON(http.StatusCreated, appointmentResponse(response))
+}
+
+// guardianPresent answers 422 when the client is a minor without a guardian
+func (h *handlerV2) guardianPresent(c *gin.Context, client *repo.Client) bool {
+	if !client.IsMinor(civil.Today(h.cfg.Location), h.cfg.AdultAge) {
+		return true
+	}
+	guardians, err := h.storage.Family().CountGuardians(c.Request.Context(), client.Id)
+	if err != nil {
+		h.fail(c, err, "Failed to count guardians")
+		return false
+	}
+	if guardians == 0 {
+		abort(c, http.StatusUnprocessableEntity, "a minor client needs a guardian")
+		return false
+	}
+	return true
+}
+
+// validAppointment checks what binding tags cannot, answering 400 itself
+func validAppointment(c *gin.Context, req *models.AppointmentRequest) bool {
+	if req.Status != "" && !repo.ValidStatus(req.Status) {
+		abort(c, http.StatusBadRequest, "unknown status")
+		return false
+	}
+	if req.DoctorId != "" {
+		if _, err := uuid.Parse(req.DoctorId); err != nil {
+			abort(c, http.StatusBadRequest, "doctor_id must be a uuid")
+			return false
+		}
+	}
+	return true
+}
+
+// doctorExists answers 422 when the optional doctor id names no doctor
+func (h *handlerV2) doctorExists(c *gin.Context, id string) bool {
+	if id == "" {
+		return true
+	}
+	_, err := h.storage.Doctor().GetDoctor(c.Request.Context(), id)
+	if errors.Is(err, repo.ErrNotFound) {
+		abort(c, http.StatusUnprocessableEntity, "doctor does not exist")
+		return false
+	}
+	if err != nil {
+		h.fail(c, err, "Failed to get doctor")
+		return false
+	}
+	return true
+}
+
+// GetAppointment ...
+// @Summary GetAppointment
+// @Description Api for get appointment
+// @Tags v2 appointment
+// @Produce json
+// @Param id path string true "appointment id"
+// @Success 200 {object} models.AppointmentResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/appointments/{id} [get]
+func (h *handlerV2) GetAppointment(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.storage.Appointment().GetAppointment(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err, "Failed to get appointment")
+		return
+	}
+
+	c.JSON(http.StatusOK, appointmentResponse(response))
+}
+
+// UpdateAppointment ...
+// @Summary UpdateAppointment
+// @Description Api for replacing appointment's data
+// @Tags v2 appointment
+// @Accept json
+// @Produce json
+// @Param id path string true "appointment id"
+// @Param Appointment body models.AppointmentRequest true "Appointment"
+// @Success 200 {object} models.AppointmentResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "confirming, checking in or completing the fitting of a lab case not received"
+// @Failure 422 {object} models.ErrorResponse "client or doctor does not exist, or a minor client has no guardian"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/appointments/{id} [put]
+func (h *handlerV2) UpdateAppointment(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		return
+	}
+	var req models.AppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	client, ok := h.appointmentClient(c, req.ClientId)
+	if !ok {
+		return
+	}
+	if !validAppointment(c, &req) || !h.doctorExists(c, req.DoctorId) || !h.guardianPresent(c, client) {
+		return
+	}
+
+	response, err := h.storage.Appointment().UpdateAppointment(c.Request.Context(), &repo.Appointment{
+		Id:          id,
+		ClientId:    req.ClientId,
+		Date:        req.Date,
+		Diagnostics: req.Diagnostics,
+		Treatment:   req.Treatment,
+		Amount:      req.Amount,
+		Status:      req.Status,
+		DoctorId:    req.DoctorId,
+	})
+	if errors.Is(err, repo.ErrLabCaseNotReceived) {
+		abort(c, http.StatusConflict, "the lab case fitted at this appointment is not received yet")
+		return
+	}
+	if err != nil {
+		h.fail(c, err, "Failed to update appointment")
+		return
+	}
+
+	c.JSON(http.StatusOK, appointmentResponse(response))
+}
+
+// DeleteAppointment ...
+// @Summary DeleteAppointment
+// @Description Api for deleting an appointment
+// @Tags v2 appointment
+// @Param id path string true "appointment id"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/appointments/{id} [delete]
+func (h *handlerV2) DeleteAppointment(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.storage.Appointment().DeleteAppointment(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err, "Failed to delete appointment")
+		return
+	}
+	if !deleted {
+		abort(c, http.StatusNotFound, "not found")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListAppointments ...
+// @Summary ListAppointments
+// @Description Api for listing appointments, every given filter must match
+// @Tags v2 appointment
+// @Produce json
+// @Param from query string false "inclusive start, RFC 3339 or YYYY-MM-DD in the clinic time zone"
+// @Param to query string false "exclusive end, RFC 3339 or YYYY-MM-DD in the clinic time zone"
+// @Param client_id query string false "only appointments of this client"
+// @Param doctor_id query string false "only appointments of this doctor"
+// @Param status query string false "comma separated statuses, e.g. scheduled,confirmed"
+// @Param min_amount query int false "inclusive lower bound of amount"
+// @Param max_amount query int false "inclusive upper bound of amount"
+// @Param q query string false "search diagnostics and treatment, web search syntax"
+// @Param sort query string false "date, -date, amount or -amount" default(date)
+// @Param limit query int false "page size, at most 100"
+// @Param offset query int false "rows to skip"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.AppointmentResponse]
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/appointments [get]
+func (h *handlerV2) ListAppointments(c *gin.Context) {
+	params, ok := pageParams(c)
+	if !ok {
+		return
+	}
+	filter, ok := h.appointmentFilter(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.storage.Appointment().GetAppointments(c.Request.Context(), filter, params)
+	if err != nil {
+		h.fail(c, err, "Failed to list appointments")
+		return
+	}
+
+	c.JSON(http.StatusOK, appointmentPage(response))
+}
+
+// appointmentFilter parses the ListAppointments query, answering 400 itself
+func (h *handlerV2) appointmentFilter(c *gin.Context) (*repo.AppointmentFilter, bool) {
+	var (
+		filter repo.AppointmentFilter
+		ok     bool
+	)
+	if filter.From, ok = h.queryTime(c, "from"); !ok {
+		return nil, false
+	}
+	if filter.To, ok = h.queryTime(c, "to"); !ok {
+		return nil, false
+	}
+	if filter.ClientId, ok = queryId(c, "client_id"); !ok {
+		return nil, false
+	}
+	if filter.DoctorId, ok = queryId(c, "doctor_id"); !ok {
+		return nil, false
+	}
+	if filter.MinAmount, ok = queryInt(c, "min_amount"); !ok {
+		return nil, false
+	}
+	if filter.MaxAmount, ok = queryInt(c, "max_amount"); !ok {
+		return nil, false
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		abort(c, http.StatusBadRequest, "min_amount must not exceed max_amount")
+		return nil, false
+	}
+
+	if v := c.Query("status"); v != "" {
+		for _, status := range strings.Split(v, ",") {
+			status = strings.TrimSpace(status)
+			if !repo.ValidStatus(status) {
+				abort(c, http.StatusBadRequest, "unknown status "+status)
+				return nil, false
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	filter.Text = strings.TrimSpace(c.Query("q"))
+
+	sort := c.DefaultQuery("sort", repo.SortDate)
+	filter.Desc = strings.HasPrefix(sort, "-")
+	filter.Sort = strings.TrimPrefix(sort, "-")
+	if filter.Sort != repo.SortDate && filter.Sort != repo.SortAmount {
+		abort(c, http.StatusBadRequest, "sort must be date, -date, amount or -amount")
+		return nil, false
+	}
+
+	return &filter, true
+}
+
+func appointmentPage(all *repo.AllAppointments) pagination.Page[models.AppointmentResponse] {
+	items := make([]models.AppointmentResponse, 0, len(all.Appointment))
+	for _, a := range all.Appointment {
+		items = append(items, appointmentResponse(a))
+	}
+	return pagination.NewPage(items, all.Total, all.NextCursor)
+}
@@ -0,0 +1,264 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateClient ...
+// @Summary CreateClient
+// @Description Api for creating a new client
+// @Tags v2 client
+// @Accept json
+// @Produce json
+// @Param Client body models.ClientRequest true "Client"
+// @Success 201 {object} models.ClientResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/clients [post]
+func (h *handlerV2) CreateClient(c *gin.Context) {
+	var req models.ClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.storage.Client().CreateClient(c.Request.Context(), &repo.Client{
+		Id:          uuid.NewString(),
+		Name:        req.Name,
+		LastName:    req.LastName,
+		FatherName:  req.FatherName,
+		PhoneNumber: req.PhoneNumber,
+		Address:     req.Address,
+		BirthDate:   req.BirthDate,
+	})
+	if err != nil {
+		h.fail(c, err, "Failed to create client")
+		return
+	}
+
+	c.Header("Location", "/v2/clients/"+response.Id)
+	c.JSON(http.StatusCreated, clientResponse(response))
+}
+
+// GetClient ...
+// @Summary GetClient
+// @Description Api for get client
+// @Tags v2 client
+// @Produce json
+// @Param id path string true "client id"
+// @Success 200 {object} models.ClientResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/clients/{id} [get]
+func (h *handlerV2) GetClient(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.storage.Client().GetClient(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err, "Failed to get client")
+		return
+	}
+
+	c.JSON(http.StatusOK, clientResponse(response))
+}
+
+// UpdateClient ...
+// @Summary UpdateClient
+// @Description Api for replacing client's data
+// @Tags v2 client
+// @Accept json
+// @Produce json
+// @Param id path string true "client id"
+// @Param Client body models.ClientRequest true "Client"
+// @Success 200 {object} models.ClientResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/clients/{id} [put]
+func (h *handlerV2) UpdateClient(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		return
+	}
+	var req models.ClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.storage.Client().UpdateClient(c.Request.Context(), &repo.Client{
+		Id:          id,
+		Name:        req.Name,
+		LastName:    req.LastName,
+		FatherName:  req.FatherName,
+		PhoneNumber: req.PhoneNumber,
+		Address:     req.Address,
+		BirthDate:   req.BirthDate,
+	})
+	if err != nil {
+		h.fail(c, err, "Failed to update client")
+		return
+	}
+
+	c.JSON(http.StatusOK, clientResponse(response))
+}
+
+// DeleteClient ...
+// @Summary DeleteClient
+// @Description Api for deleting a client together with the appointments
+// @Tags v2 client
+// @Param id path string true "client id"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/clients/{id} [delete]
+func (h *handlerV2) DeleteClient(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.storage.Client().DeleteClient(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err, "Failed to delete client")
+		return
+	}
+	if !deleted {
+		abort(c, http.StatusNotFound, "not found")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListClients ...
+// @Summary ListClients
+// @Description Api for listing clients, q searches by name or last name
+// @Tags v2 client
+// @Produce json
+// @Param q query string false "search by name or last name"
+// @Param limit query int false "page size, at most 100"
+// @Param offset query int false "rows to skip"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.ClientResponse]
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/clients [get]
+func (h *handlerV2) ListClients(c *gin.Context) {
+	params, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	var (
+		response *repo.AllClients
+		err      error
+	)
+	if q := c.Query("q"); q != "" {
+		response, err = h.storage.Client().SearchClients(c.Request.Context(), q, params)
+	} else {
+		response, err = h.storage.Client().GetAllClients(c.Request.Context(), &repo.GetAllClient{Params: params})
+	}
+	if err != nil {
+		h.fail(c, err, "Failed to list clients")
+		return
+	}
+
+	items := make([]models.ClientResponse, 0, len(response.Clients))
+	for _, client := range response.Clients {
+		items = append(items, clientResponse(client))
+	}
+
+	c.JSON(http.StatusOK, pagination.NewPage(items, response.Total, response.NextCursor))
+}
+
+// ListClientAppointments ...
+// @Summary ListClientAppointments
+// @Description Api for listing appointments of a client
+// @Tags v2 client
+// @Produce json
+// @Param id path string true "client id"
+// @Param limit query int false "page size, at most 100"
+// @Param offset query int false "rows to skip"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.AppointmentResponse]
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/clients/{id}/appointments [get]
+func (h *handlerV2) ListClientAppointments(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		return
+	}
+	params, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.storage.Client().GetClient(c.Request.Context(), id); err != nil {
+		h.fail(c, err, "Failed to get client")
+		return
+	}
+
+	response, err := h.storage.Appointment().GetAppointmentsWithClientId(c.Request.Context(), id, params)
+	if err != nil {
+		h.fail(c, err, "Failed to list client's appointments")
+		return
+	}
+
+	c.JSON(http.StatusOK, appointmentPage(response))
+}
+
+// CreateClientAppointment ...
+// @Summary CreateClientAppointment
+// @Description Api for booking an appointment for a client
+// @Tags v2 client
+// @Accept json
+// @Produce json
+// @Param id path string true "client id"
+// @Param Appointment body models.ClientAppointmentRequest true "Appointment"
+// @Success 201 {object} models.AppointmentResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/clients/{id}/appointments [post]
+func (h *handlerV2) CreateClientAppointment(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		return
+	}
+	var req models.ClientAppointmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	client, err := h.storage.Client().GetClient(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err, "Failed to get client")
+		return
+	}
+
+	h.createAppointment(c, client, &models.AppointmentRequest{
+		ClientId:    id,
+		Date:        req.Date,
+		Diagnostics: req.Diagnostics,
+		Treatment:   req.Treatment,
+		Amount:      req.Amount,
+		Status:      req.Status,
+		DoctorId:    req.DoctorId,
+	})
+}
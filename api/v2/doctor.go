@@ -0,0 +1,171 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateDoctor ...
+// @Summary CreateDoctor
+// @Description Api for creating a new doctor
+// @Tags v2 doctor
+// @Accept json
+// @Produce json
+// @Param Doctor body models.DoctorRequest true "Doctor"
+// @Success 201 {object} models.DoctorResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/doctors [post]
+func (h *handlerV2) CreateDoctor(c *gin.Context) {
+	var req models.DoctorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.storage.Doctor().CreateDoctor(c.Request.Context(), &repo.Doctor{
+		Id:          uuid.NewString(),
+		Name:        req.Name,
+		LastName:    req.LastName,
+		Specialty:   req.Specialty,
+		PhoneNumber: req.PhoneNumber,
+	})
+	if err != nil {
+		h.fail(c, err, "Failed to create doctor")
+		return
+	}
+
+	c.Header("Location", "/v2/doctors/"+response.Id)
+	c.JSON(http.StatusCreated, doctorResponse(response))
+}
+
+// GetDoctor ...
+// @Summary GetDoctor
+// @Description Api for get doctor
+// @Tags v2 doctor
+// @Produce json
+// @Param id path string true "doctor id"
+// @Success 200 {object} models.DoctorResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/doctors/{id} [get]
+func (h *handlerV2) GetDoctor(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.storage.Doctor().GetDoctor(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err, "Failed to get doctor")
+		return
+	}
+
+	c.JSON(http.StatusOK, doctorResponse(response))
+}
+
+// UpdateDoctor ...
+// @Summary UpdateDoctor
+// @Description Api for replacing doctor's data
+// @Tags v2 doctor
+// @Accept json
+// @Produce json
+// @Param id path string true "doctor id"
+// @Param Doctor body models.DoctorRequest true "Doctor"
+// @Success 200 {object} models.DoctorResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/doctors/{id} [put]
+func (h *handlerV2) UpdateDoctor(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		return
+	}
+	var req models.DoctorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abort(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.storage.Doctor().UpdateDoctor(c.Request.Context(), &repo.Doctor{
+		Id:          id,
+		Name:        req.Name,
+		LastName:    req.LastName,
+		Specialty:   req.Specialty,
+		PhoneNumber: req.PhoneNumber,
+	})
+	if err != nil {
+		h.fail(c, err, "Failed to update doctor")
+		return
+	}
+
+	c.JSON(http.StatusOK, doctorResponse(response))
+}
+
+// DeleteDoctor ...
+// @Summary DeleteDoctor
+// @Description Api for deleting a doctor, past appointments keep the reference
+// @Tags v2 doctor
+// @Param id path string true "doctor id"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/doctors/{id} [delete]
+func (h *handlerV2) DeleteDoctor(c *gin.Context) {
+	id, ok := pathId(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.storage.Doctor().DeleteDoctor(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err, "Failed to delete doctor")
+		return
+	}
+	if !deleted {
+		abort(c, http.StatusNotFound, "not found")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDoctors ...
+// @Summary ListDoctors
+// @Description Api for listing doctors
+// @Tags v2 doctor
+// @Produce json
+// @Param limit query int false "page size, at most 100"
+// @Param offset query int false "rows to skip"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} pagination.Page[models.DoctorResponse]
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /v2/doctors [get]
+func (h *handlerV2) ListDoctors(c *gin.Context) {
+	params, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.storage.Doctor().GetAllDoctors(c.Request.Context(), params)
+	if err != nil {
+		h.fail(c, err, "Failed to list doctors")
+		return
+	}
+
+	items := make([]models.DoctorResponse, 0, len(response.Doctors))
+	for _, doctor := range response.Doctors {
+		items = append(items, doctorResponse(doctor))
+	}
+
+	c.JSON(http.StatusOK, pagination.NewPage(items, response.Total, response.NextCursor))
+}
@@ -0,0 +1,222 @@
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dentist/pkg/export"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportClients ...
+// @Summary ExportClients
+// @Description Api for downloading the clients as a spreadsheet, the rows are streamed from the database
+// @Tags v2 export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv or xlsx" default(csv)
+// @Param lang query string false "language of the column titles: en, ru or uz, else Accept-Language"
+// @Param q query string false "only clients whose name or last name contains this"
+// @Success 200 {file} file
+// @Failure 400 {object} github_com_dentist_api_models.ErrorResponse
+// @Failure 500 {object} github_com_dentist_api_models.ErrorResponse
+// @Router /v2/exports/clients [get]
+func (h *handlerV2) ExportClients(c *gin.Context) {
+	table, ok := h.startExport(c, "clients",
+		export.ColumnName,
+		export.ColumnLastName,
+		export.ColumnFatherName,
+		export.ColumnPhoneNumber,
+		export.ColumnAddress,
+		export.ColumnBirthDate,
+		export.ColumnCreatedAt,
+		export.ColumnId,
+	)
+	if !ok {
+		return
+	}
+
+	err := h.storage.Client().StreamClients(c.Request.Context(), c.Query("q"), func(client *repo.Client) error {
+		return table.Write(
+			client.Name,
+			client.LastName,
+			client.FatherName,
+			client.PhoneNumber,
+			client.Address,
+			client.BirthDate,
+			client.CreatedAt,
+			client.Id,
+		)
+	})
+	h.finishExport(c, table, err)
+}
+
+// ExportAppointments ...
+// @Summary ExportAppointments
+// @Description Api for downloading the appointment journal as a spreadsheet, with the filters of ListAppointments
+// @Tags v2 export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv or xlsx" default(csv)
+// @Param lang query string false "language of the column titles: en, ru or uz, else Accept-Language"
+// @Param from query string false "inclusive start, RFC 3339 or YYYY-MM-DD in the clinic time zone"
+// @Param to query string false "exclusive end, RFC 3339 or YYYY-MM-DD in the clinic time zone"
+// @Param client_id query string false "only appointments of this client"
+// @Param doctor_id query string false "only appointments of this doctor"
+// @Param status query string false "comma separated statuses, e.g. scheduled,confirmed"
+// @Param min_amount query int false "inclusive lower bound of amount"
+// @Param max_amount query int false "inclusive upper bound of amount"
+// @Param q query string false "search diagnostics and treatment, web search syntax"
+// @Param sort query string false "date, -date, amount or -amount" default(date)
+// @Success 200 {file} file
+// @Failure 400 {object} github_com_dentist_api_models.ErrorResponse
+// @Failure 500 {object} github_com_dentist_api_models.ErrorResponse
+// @Router /v2/exports/appointments [get]
+func (h *handlerV2) ExportAppointments(c *gin.Context) {
+	filter, ok := h.appointmentFilter(c)
+	if !ok {
+		return
+	}
+	table, ok := h.startExport(c, "appointments",
+		export.ColumnDate,
+		export.ColumnClient,
+		export.ColumnPhoneNumber,
+		export.ColumnDoctor,
+		export.ColumnDiagnostics,
+		export.ColumnTreatment,
+		export.ColumnAmount,
+		export.ColumnStatus,
+		export.ColumnId,
+	)
+	if !ok {
+		return
+	}
+
+	err := h.storage.Appointment().StreamAppointments(c.Request.Context(), filter, func(a *repo.AppointmentRow) error {
+		return table.Write(
+			a.Date,
+			a.ClientName,
+			a.ClientPhone,
+			a.DoctorName,
+			a.Diagnostics,
+			a.Treatment,
+			a.Amount,
+			a.Status,
+			a.Id,
+		)
+	})
+	h.finishExport(c, table, err)
+}
+
+// ExportPayments ...
+// @Summary ExportPayments
+// @Description Api for downloading the payments as a spreadsheet ending with their total. Payments are not recorded apart from appointments, they are the completed appointments with an amount.
+// @Tags v2 export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv or xlsx" default(csv)
+// @Param lang query string false "language of the column titles: en, ru or uz, else Accept-Language"
+// @Param from query string false "inclusive start, RFC 3339 or YYYY-MM-DD in the clinic time zone"
+// @Param to query string false "exclusive end, RFC 3339 or YYYY-MM-DD in the clinic time zone"
+// @Param client_id query string false "only payments of this client"
+// @Param doctor_id query string false "only payments for this doctor"
+// @Param min_amount query int false "inclusive lower bound of amount"
+// @Param max_amount query int false "inclusive upper bound of amount"
+// @Param sort query string false "date, -date, amount or -amount" default(date)
+// @Success 200 {file} file
+// @Failure 400 {object} github_com_dentist_api_models.ErrorResponse
+// @Failure 500 {object} github_com_dentist_api_models.ErrorResponse
+// @Router /v2/exports/payments [get]
+func (h *handlerV2) ExportPayments(c *gin.Context) {
+	filter, ok := h.appointmentFilter(c)
+	if !ok {
+		return
+	}
+	filter.Statuses = []string{repo.StatusCompleted}
+	if filter.MinAmount == nil || *filter.MinAmount < 1 {
+		paid := 1
+		filter.MinAmount = &paid
+	}
+	table, ok := h.startExport(c, "payments",
+		export.ColumnDate,
+		export.ColumnClient,
+		export.ColumnPhoneNumber,
+		export.ColumnDoctor,
+		export.ColumnTreatment,
+		export.ColumnAmount,
+		export.ColumnId,
+	)
+	if !ok {
+		return
+	}
+
+	total := 0
+	err := h.storage.Appointment().StreamAppointments(c.Request.Context(), filter, func(a *repo.AppointmentRow) error {
+		total += a.Amount
+		return table.Write(
+			a.Date,
+			a.ClientName,
+			a.ClientPhone,
+			a.DoctorName,
+			a.Treatment,
+			a.Amount,
+			a.Id,
+		)
+	})
+	if err == nil {
+		err = table.Write(export.Titles(exportLanguage(c), export.ColumnTotal)[0], "", "", "", "", total, "")
+	}
+	h.finishExport(c, table, err)
+}
+
+// startExport checks the format, sends the headers of the download and
+// starts the table with the titles of columns
+func (h *handlerV2) startExport(c *gin.Context, name string, columns ...string) (export.Table, bool) {
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		abort(c, http.StatusBadRequest, "format must be csv or xlsx")
+		return nil, false
+	}
+
+	fileName := fmt.Sprintf("%s-%s.%s", name, time.Now().In(h.cfg.Location).Format("2006-01-02"), format)
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+
+	table, err := export.NewTable(c.Writer, format, export.Titles(exportLanguage(c), columns...), h.cfg.Location)
+	if err != nil {
+		h.fail(c, err, "Failed to start export")
+		return nil, false
+	}
+	return table, true
+}
+
+// finishExport closes the table. The status is sent with the first row of
+// a CSV file, a failure after it can only cut the download short.
+func (h *handlerV2) finishExport(c *gin.Context, table export.Table, err error) {
+	if err == nil {
+		err = table.Close()
+	} else {
+		table.Discard()
+	}
+	if err == nil {
+		return
+	}
+	if c.Writer.Written() {
+		h.log(c).Error("Export cut short", logger.Error(err))
+		c.Abort()
+		return
+	}
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Content-Disposition")
+	h.fail(c, err, "Failed to export")
+}
+
+func exportLanguage(c *gin.Context) string {
+	if lang := c.Query("lang"); lang != "" {
+		return export.Language(lang)
+	}
+	return export.Language(c.GetHeader("Accept-Language"))
+}
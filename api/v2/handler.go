@@ -0,0 +1,160 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dentist/api/models"
+	"github.com/dentist/config"
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type handlerV2 struct {
+	cfg     *config.Config
+	storage storage.StorageI
+	logger  logger.Logger
+}
+
+type HandlerV2Options struct {
+	Cfg     *config.Config
+	Storage storage.StorageI
+	Logger  logger.Logger
+}
+
+func New(options *HandlerV2Options) *handlerV2 {
+	return &handlerV2{
+		cfg:     options.Cfg,
+		storage: options.Storage,
+		logger:  options.Logger,
+	}
+}
+
+// log returns the handler logger tagged with the request id of c
+func (h *handlerV2) log(c *gin.Context) logger.Logger {
+	return logger.FromContext(c.Request.Context(), h.logger)
+}
+
+// abort answers with the v2 error body
+func abort(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, models.ErrorResponse{Error: message})
+}
+
+// fail maps a storage error to 404 or logs it and answers 500
+func (h *handlerV2) fail(c *gin.Context, err error, message string) {
+	if errors.Is(err, repo.ErrNotFound) {
+		abort(c, http.StatusNotFound, "not found")
+		return
+	}
+	h.log(c).Error(message, logger.Error(err))
+	abort(c, http.StatusInternalServerError, message)
+}
+
+// pathId returns the :id path parameter, answering 400 when it is not a uuid
+func pathId(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		abort(c, http.StatusBadRequest, "id must be a uuid")
+		return "", false
+	}
+	return id, true
+}
+
+// pageParams parses the list parameters, answering 400 when they are invalid
+func pageParams(c *gin.Context) (pagination.Params, bool) {
+	params, err := pagination.Parse(c)
+	if err != nil {
+		abort(c, http.StatusBadRequest, err.Error())
+		return params, false
+	}
+	return params, true
+}
+
+// queryTime parses an optional time from the query string, a bare
+// YYYY-MM-DD means the midnight starting that day in the clinic
+func (h *handlerV2) queryTime(c *gin.Context, key string) (*time.Time, bool) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, true
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, true
+	}
+	if d, err := civil.ParseDate(v); err == nil {
+		t := d.In(h.cfg.Location)
+		return &t, true
+	}
+	abort(c, http.StatusBadRequest, key+" must be RFC 3339 or YYYY-MM-DD")
+	return nil, false
+}
+
+// queryId parses an optional uuid from the query string
+func queryId(c *gin.Context, key string) (string, bool) {
+	v := c.Query(key)
+	if v == "" {
+		return "", true
+	}
+	if _, err := uuid.Parse(v); err != nil {
+		abort(c, http.StatusBadRequest, key+" must be a uuid")
+		return "", false
+	}
+	return v, true
+}
+
+// queryInt parses an optional integer from the query string
+func queryInt(c *gin.Context, key string) (*int, bool) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		abort(c, http.StatusBadRequest, key+" must be an integer")
+		return nil, false
+	}
+	return &n, true
+}
+
+func clientResponse(c *repo.Client) models.ClientResponse {
+	return models.ClientResponse{
+		Id:          c.Id,
+		Name:        c.Name,
+		LastName:    c.LastName,
+		FatherName:  c.FatherName,
+		PhoneNumber: c.PhoneNumber,
+		Address:     c.Address,
+		BirthDate:   c.BirthDate,
+		CreatedAt:   c.CreatedAt,
+	}
+}
+
+func appointmentResponse(a *repo.Appointment) models.AppointmentResponse {
+	return models.AppointmentResponse{
+		Id:          a.Id,
+		ClientId:    a.ClientId,
+		Date:        a.Date,
+		Diagnostics: a.Diagnostics,
+		Treatment:   a.Treatment,
+		Amount:      a.Amount,
+		Status:      a.Status,
+		DoctorId:    a.DoctorId,
+	}
+}
+
+func doctorResponse(d *repo.Doctor) models.DoctorResponse {
+	return models.DoctorResponse{
+		Id:          d.Id,
+		Name:        d.Name,
+		LastName:    d.LastName,
+		Specialty:   d.Specialty,
+		PhoneNumber: d.PhoneNumber,
+		CreatedAt:   d.CreatedAt,
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dentist/config"
+	"github.com/dentist/pkg/db"
+	"github.com/dentist/pkg/importer"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage"
+	"github.com/dentist/storage/repo"
+)
+
+const importUsage = `usage: dentist import [flags] <file.csv|file.xlsx>
+
+flags:
+  -kind K        clients or appointments (default clients)
+  -dry-run       only check the rows, save nothing
+  -map F=Title   column title of field F, can be repeated
+  -batch N       rows saved per transaction (default IMPORT_BATCH_SIZE)
+  -errors PATH   write the rows that were not imported to PATH as CSV`
+
+// mapping collects the -map flags
+type mapping map[string]string
+
+func (m mapping) String() string {
+	return fmt.Sprint(map[string]string(m))
+}
+
+func (m mapping) Set(value string) error {
+	field, title, ok := strings.Cut(value, "=")
+	if !ok {
+		return errors.New("want field=Column title")
+	}
+	m[strings.TrimSpace(field)] = strings.TrimSpace(title)
+	return nil
+}
+
+// runImport implements `dentist import ...`
+func runImport(ctx context.Context, cfg config.Config, log logger.Logger, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, importUsage) }
+	columns := mapping{}
+	kind := flags.String("kind", "clients", "")
+	dryRun := flags.Bool("dry-run", false, "")
+	batch := flags.Int("batch", cfg.ImportBatchSize, "")
+	errorsPath := flags.String("errors", "", "")
+	flags.Var(columns, "map", "")
+	if err := flags.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	path := flags.Arg(0)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	sheet, err := importer.Read(file, importer.FormatOf(path))
+	if err != nil {
+		return err
+	}
+
+	psql, cleanUp, err := db.ConnectToDB(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+	defer cleanUp()
+	stor := storage.NewStoragePg(psql, cfg.Location, log)
+
+	run, err := importer.Run(ctx, stor.Import(), sheet, importer.Options{
+		Kind:      *kind,
+		FileName:  filepath.Base(path),
+		Mapping:   columns,
+		DryRun:    *dryRun,
+		BatchSize: *batch,
+		Location:  cfg.Location,
+	})
+	if run == nil {
+		return err
+	}
+	if saveErr := stor.Import().SaveImport(ctx, run); saveErr != nil {
+		log.Error("Failed to save import", logger.Error(saveErr))
+	}
+
+	fmt.Printf("import %s of %s, dry run: %t\n", run.Id, run.Kind, run.DryRun)
+	fmt.Printf("  rows:            %d\n", run.Total)
+	fmt.Printf("  imported:        %d\n", run.Imported)
+	if run.Kind == repo.ImportAppointments {
+		fmt.Printf("  created clients: %d\n", run.CreatedClients)
+	}
+	fmt.Printf("  duplicates:      %d\n", run.Duplicates)
+	fmt.Printf("  failed:          %d\n", run.Failed)
+
+	if *errorsPath != "" && len(run.Problems) > 0 {
+		report, createErr := os.Create(*errorsPath)
+		if createErr != nil {
+			return createErr
+		}
+		defer report.Close()
+		if writeErr := importer.WriteProblems(report, run); writeErr != nil {
+			return writeErr
+		}
+		fmt.Printf("  error report:    %s\n", *errorsPath)
+	}
+
+	return err
+}
@@ -1,32 +1,153 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // the zone database for images that ship without one
 
 	"github.com/dentist/api"
 	"github.com/dentist/config"
+	"github.com/dentist/pkg/booking"
 	"github.com/dentist/pkg/db"
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/inventory"
+	"github.com/dentist/pkg/live"
 	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/metrics"
+	"github.com/dentist/pkg/ratelimit"
+	"github.com/dentist/pkg/retention"
+	"github.com/dentist/pkg/tracing"
+	"github.com/dentist/pkg/waitlist"
+	"github.com/dentist/pkg/webhook"
 	"github.com/dentist/storage"
+	"github.com/dentist/storage/repo"
 )
 
 func main() {
 	cfg := config.Load()
 
-	psql, _, err := db.ConnectToDB(cfg)
+	log := logger.New(cfg.LogLevel, "dentist")
+	defer logger.Cleanup(log)
+
+	var err error
+	cfg.Location, err = time.LoadLocation(cfg.ClinicTimezone)
+	if err != nil {
+		log.Fatal("Unknown clinic time zone", logger.String("zone", cfg.ClinicTimezone), logger.Error(err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, cfg, log, os.Args[2:]); err != nil {
+			log.Fatal("Migration failed", logger.Error(err))
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(ctx, cfg, log, os.Args[2:]); err != nil {
+			log.Fatal("Import failed", logger.Error(err))
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, cfg)
+	if err != nil {
+		log.Fatal("Error initializing tracing", logger.Error(err))
+	}
+
+	psql, cleanUp, err := db.ConnectToDB(ctx, cfg, log)
+	if err != nil {
+		log.Fatal("Error connecting to database", logger.Error(err))
+	}
+	defer cleanUp()
+
+	stor := storage.NewStoragePg(psql, cfg.Location, log)
+
+	go retention.Run(ctx, &cfg, stor.Trash(), log)
+	go webhook.Run(ctx, &cfg, stor.Webhook(), log)
+
+	bus := events.NewBus(&cfg, stor.Outbox(), log)
+	bus.Subscribe("webhooks", webhook.Subscriber(stor.Webhook()))
+	bus.Subscribe("waitlist", waitlist.Subscriber(&cfg, stor.Waitlist(), waitlist.LogSender(log), log))
+	bus.Subscribe("inventory", inventory.Subscriber(stor.Inventory(), log))
+	go bus.Run(ctx)
+
+	schedule, err := booking.NewSchedule(&cfg)
 	if err != nil {
-		log.Println("Error connecting to database", logger.Error(err))
+		log.Fatal("Invalid booking settings", logger.Error(err))
 	}
-	
-	stor := storage.NewStoragePg(psql)
-	
-	apiServ := api.New(api.RoutOptions{
+	go booking.Run(ctx, &cfg, stor.Booking(), log)
+
+	var limits repo.NewRateLimitI
+	switch cfg.RateLimitStore {
+	case ratelimit.StoreMemory:
+		limits = ratelimit.MemoryStore()
+	case ratelimit.StorePostgres:
+		limits = stor.RateLimit()
+	default:
+		log.Fatal("RATE_LIMIT_STORE must be memory or postgres", logger.String("store", cfg.RateLimitStore))
+	}
+	limiter, err := ratelimit.New(&cfg, limits, log)
+	if err != nil {
+		log.Fatal("Invalid rate limit settings", logger.Error(err))
+	}
+	go limiter.Run(ctx)
+
+	board := live.NewHub(&cfg, stor.Outbox(), db.DSN(cfg), log)
+	go board.Run(ctx)
+
+	apiServ, err := api.New(api.RoutOptions{
 		Cfg: &cfg,
 		Storage: stor,
+		Logger: log,
+		Metrics: metrics.New(psql.DB, stor.Appointment(), cfg.Location, log),
+		Board: board,
+		BookingSchedule: schedule,
+		BookingSender: booking.LogSender(&cfg, log),
+		Limiter: limiter,
 	})
-
-	err = apiServ.Run(cfg.HttpPort)
 	if err != nil {
-		log.Fatalf("Failed to run server: %v", err)
+		log.Fatal("Invalid router settings", logger.Error(err))
+	}
+
+	server := &http.Server{
+		Addr:    cfg.HttpPort,
+		Handler: apiServ,
+	}
+	// Shutdown waits for the requests to finish, the board streams only
+	// do when the hub closes them
+	server.RegisterOnShutdown(board.Close)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info("Listening", logger.String("addr", cfg.HttpPort))
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to run server", logger.Error(err))
+		}
+		return
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Info("Shutting down, draining requests", logger.String("timeout", cfg.ShutdownTimeout.String()))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		log.Error("Failed to shut down server gracefully", logger.Error(err))
+	}
+	if err = shutdownTracing(shutdownCtx); err != nil {
+		log.Error("Failed to flush traces", logger.Error(err))
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/dentist/config"
+	"github.com/dentist/migrations"
+	"github.com/dentist/pkg/db"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/migrate"
+)
+
+const migrateUsage = `usage: dentist migrate <command>
+
+commands:
+  up           apply all pending migrations
+  down [N]     revert the last N migrations (default 1)
+  status       show the current version and pending migrations
+  force V      set the version to V and clear the dirty flag`
+
+// runMigrate implements the `dentist migrate ...` subcommands.
+func runMigrate(ctx context.Context, cfg config.Config, log logger.Logger, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	psql, cleanUp, err := db.ConnectToDB(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+	defer cleanUp()
+
+	migrator, err := migrate.New(psql.DB, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Info("applied", logger.Int("version", m.Version), logger.String("name", m.Name))
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Info("no pending migrations")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			log.Info("reverted", logger.Int("version", m.Version), logger.String("name", m.Name))
+		}
+		if err != nil {
+			return err
+		}
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d, dirty: %t\n", status.Version, status.Dirty)
+		for _, m := range status.Applied {
+			fmt.Printf("  applied  %06d_%s\n", m.Version, m.Name)
+		}
+		for _, m := range status.Pending {
+			fmt.Printf("  pending  %06d_%s\n", m.Version, m.Name)
+		}
+	case "force":
+		if len(args) < 2 {
+			return fmt.Errorf("force needs a version")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err = migrator.Force(ctx, version); err != nil {
+			return err
+		}
+		log.Info("forced version", logger.Int("version", version))
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	return nil
+}
@@ -1,22 +1,291 @@
 package config
 
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
 type Config struct {
+	Environment string
+	LogLevel string
 	HttpPort string
 	PostgresHost string
 	PostgresPort int
 	PostgresUser string
 	PostgresPassword string
 	PostgresDatabase string
+
+	// PostgresConnectAttempts is how many times startup tries to reach
+	// the database before giving up, PostgresConnectBackoff is the delay
+	// before the second attempt and doubles after every failure.
+	PostgresConnectAttempts int
+	PostgresConnectBackoff time.Duration
+
+	// ShutdownTimeout bounds how long in-flight requests may drain
+	// after SIGTERM before the server is closed forcibly.
+	ShutdownTimeout time.Duration
+
+	// OtelEnabled turns on tracing exported over OTLP/HTTP to
+	// OtelEndpoint (host:port of a collector).
+	OtelEnabled bool
+	OtelEndpoint string
+	OtelInsecure bool
+	OtelServiceName string
+	OtelSampleRatio float64
+
+	// ClinicTimezone is the IANA zone the clinic works in. Days such as
+	// "today" are cut in it and it is the session zone of the database
+	// connections, instants are stored as TIMESTAMPTZ regardless.
+	ClinicTimezone string
+	// Location is ClinicTimezone resolved at startup
+	Location *time.Location
+
+	// TrashRetentionDays is how long deleted clients and appointments stay
+	// restorable, 0 keeps them forever. Every TrashPurgeInterval the older
+	// ones are deleted for good, or only stripped of personal data when
+	// TrashAnonymize is set.
+	TrashRetentionDays int
+	TrashPurgeInterval time.Duration
+	TrashAnonymize bool
+
+	// PdfFontPath is a TrueType font for the generated PDFs, needed for
+	// Cyrillic text. Without it only Latin-1 characters are printed.
+	PdfFontPath string
+
+	// DuplicateNameSimilarity is the trigram similarity (0..1) from which
+	// two clients with the same birth date are reported as duplicates.
+	// MergeUndoWindow is how long a merge of duplicates can be undone.
+	DuplicateNameSimilarity float64
+	MergeUndoWindow time.Duration
+
+	// AdultAge is the age from which a client needs no guardian to book
+	// appointments.
+	AdultAge int
+
+	// ImportBatchSize is how many rows of an import are saved in one
+	// transaction. ImportMaxBytes limits the size of an uploaded file.
+	ImportBatchSize int
+	ImportMaxBytes int64
+
+	// AppointmentDuration is how long an appointment lasts in calendar
+	// apps, appointments have no end of their own. A calendar feed has the
+	// appointments from CalendarPastDays ago to CalendarFutureDays ahead
+	// and asks to be fetched again every CalendarRefreshInterval.
+	AppointmentDuration time.Duration
+	CalendarPastDays int
+	CalendarFutureDays int
+	CalendarRefreshInterval time.Duration
+
+	// PublicUrl is the address clients reach the API at, used for links
+	// handed out such as calendar feeds. The host of the request is used
+	// when it is empty.
+	PublicUrl string
+	// TrustedProxies are the comma-separated addresses or CIDR ranges of
+	// the reverse proxies in front of the API. X-Forwarded-For is believed
+	// only from them, so the client address the rate limits, lockouts and
+	// booking caps count cannot be forged. Empty trusts none.
+	TrustedProxies string
+
+	// Webhook deliveries that are due are looked for every
+	// WebhookPollInterval and get WebhookTimeout to be answered. A failed
+	// one is retried after WebhookRetryBase, doubling up to
+	// WebhookRetryMax, until WebhookMaxAttempts were made.
+	WebhookPollInterval time.Duration
+	WebhookTimeout time.Duration
+	WebhookRetryBase time.Duration
+	WebhookRetryMax time.Duration
+	WebhookMaxAttempts int
+	// WebhookAllowPrivate lets webhooks use http and target loopback and
+	// private addresses, for development only
+	WebhookAllowPrivate bool
+
+	// The outbox of domain events is read every OutboxPollInterval,
+	// OutboxBatchSize events at a time. An event a subscriber fails is
+	// retried after OutboxRetryBase, doubling up to OutboxRetryMax, and
+	// put aside as dead after OutboxMaxAttempts. Events every subscriber
+	// has handled are deleted once older than OutboxRetention, 0 keeps
+	// them, the latest BoardReplayLimit are kept for the boards.
+	OutboxPollInterval time.Duration
+	OutboxBatchSize int
+	OutboxRetryBase time.Duration
+	OutboxRetryMax time.Duration
+	OutboxMaxAttempts int
+	OutboxRetention time.Duration
+
+	// BoardTokens gives access to the live schedule board, as
+	// comma-separated name:token pairs, the name is logged as the user.
+	// Without any the board is closed. The outbox is read for the boards
+	// every BoardPollInterval besides on notification, and an idle stream
+	// gets a heartbeat every BoardHeartbeat. A board reconnecting more
+	// than BoardReplayLimit events behind is told to reload instead.
+	BoardTokens string
+	BoardPollInterval time.Duration
+	BoardHeartbeat time.Duration
+	BoardReplayLimit int
+
+	// A slot freed at least WaitlistMinNotice ahead is offered to the
+	// WaitlistOfferCount best matching clients on the waitlist, an offer
+	// can be accepted for WaitlistOfferTTL.
+	WaitlistOfferCount int
+	WaitlistOfferTTL time.Duration
+	WaitlistMinNotice time.Duration
+
+	// BookingProcedures are the procedures clients book on the website,
+	// as comma-separated name=duration pairs such as "cleaning=30m". They
+	// are booked with any doctor from BookingOpen to BookingClose on
+	// BookingWorkdays, at least BookingMinNotice and at most
+	// BookingMaxDays ahead.
+	BookingProcedures string
+	BookingOpen string
+	BookingClose string
+	BookingWorkdays string
+	BookingMinNotice time.Duration
+	BookingMaxDays int
+	// BookingConfirmation is "otp" to confirm bookings with a code sent
+	// to the phone, valid for BookingOtpTTL and BookingOtpAttempts tries,
+	// or "staff" to have staff confirm them within BookingStaffTTL. An
+	// unconfirmed booking expires and frees its time, pending ones are
+	// swept every BookingSweepInterval.
+	BookingConfirmation string
+	BookingOtpTTL time.Duration
+	BookingOtpAttempts int
+	BookingStaffTTL time.Duration
+	BookingSweepInterval time.Duration
+	// An address makes BookingMaxPerIp bookings an hour at most, and a
+	// phone has BookingMaxPendingPerPhone pending at a time.
+	BookingMaxPerIp int
+	BookingMaxPendingPerPhone int
+
+	// RateLimitStore keeps the rate limits in the "memory" of each
+	// instance, or in "postgres" to share them between instances.
+	// RateLimitIp, RateLimitUser and RateLimitKey limit each client
+	// address, authenticated user and API key, as requests/period such as
+	// "300/1m", empty for no limit. RateLimitRoutes limit each address on
+	// some routes, as comma-separated "METHOD /path=requests/period" pairs
+	// with the paths as routed. Expired limits are swept every
+	// RateLimitSweepInterval.
+	RateLimitStore string
+	RateLimitIp string
+	RateLimitUser string
+	RateLimitKey string
+	RateLimitRoutes string
+	RateLimitSweepInterval time.Duration
+	// An address failing LockoutAttempts times within LockoutWindow, such
+	// as with wrong board tokens or booking codes, is locked out for
+	// LockoutDuration. No attempts turns lockouts off.
+	LockoutAttempts int
+	LockoutWindow time.Duration
+	LockoutDuration time.Duration
+
+	// A batch in stock is reported among the inventory alerts
+	// InventoryExpiryDays before it expires.
+	InventoryExpiryDays int
 }
 
 func Load() Config {
 	var config Config
-    config.HttpPort = ":7070"
-    config.PostgresHost = "localhost"
-    config.PostgresPort = 5432
-    config.PostgresUser = "postgres"
-    config.PostgresPassword = "0"
-    config.PostgresDatabase = "doctordb"
-	
+	config.Environment = cast.ToString(getOrReturnDefault("ENVIRONMENT", "develop"))
+	config.LogLevel = cast.ToString(getOrReturnDefault("LOG_LEVEL", "debug"))
+	config.HttpPort = cast.ToString(getOrReturnDefault("HTTP_PORT", ":7070"))
+	config.PostgresHost = cast.ToString(getOrReturnDefault("POSTGRES_HOST", "localhost"))
+	config.PostgresPort = cast.ToInt(getOrReturnDefault("POSTGRES_PORT", 5432))
+	config.PostgresUser = cast.ToString(getOrReturnDefault("POSTGRES_USER", "postgres"))
+	config.PostgresPassword = cast.ToString(getOrReturnDefault("POSTGRES_PASSWORD", "0"))
+	config.PostgresDatabase = cast.ToString(getOrReturnDefault("POSTGRES_DATABASE", "doctordb"))
+
+	config.PostgresConnectAttempts = cast.ToInt(getOrReturnDefault("POSTGRES_CONNECT_ATTEMPTS", 10))
+	config.PostgresConnectBackoff = cast.ToDuration(getOrReturnDefault("POSTGRES_CONNECT_BACKOFF", "500ms"))
+
+	config.ShutdownTimeout = cast.ToDuration(getOrReturnDefault("SHUTDOWN_TIMEOUT", "15s"))
+
+	config.OtelEnabled = cast.ToBool(getOrReturnDefault("OTEL_ENABLED", false))
+	config.OtelEndpoint = cast.ToString(getOrReturnDefault("OTEL_ENDPOINT", "localhost:4318"))
+	config.OtelInsecure = cast.ToBool(getOrReturnDefault("OTEL_INSECURE", true))
+	config.OtelServiceName = cast.ToString(getOrReturnDefault("OTEL_SERVICE_NAME", "dentist"))
+	config.OtelSampleRatio = cast.ToFloat64(getOrReturnDefault("OTEL_SAMPLE_RATIO", 1.0))
+
+	config.ClinicTimezone = cast.ToString(getOrReturnDefault("CLINIC_TIMEZONE", "Asia/Tashkent"))
+
+	config.TrashRetentionDays = cast.ToInt(getOrReturnDefault("TRASH_RETENTION_DAYS", 0))
+	config.TrashPurgeInterval = cast.ToDuration(getOrReturnDefault("TRASH_PURGE_INTERVAL", "1h"))
+	config.TrashAnonymize = cast.ToBool(getOrReturnDefault("TRASH_ANONYMIZE", true))
+
+	config.PdfFontPath = cast.ToString(getOrReturnDefault("PDF_FONT_PATH", ""))
+
+	config.DuplicateNameSimilarity = cast.ToFloat64(getOrReturnDefault("DUPLICATE_NAME_SIMILARITY", 0.5))
+	config.MergeUndoWindow = cast.ToDuration(getOrReturnDefault("MERGE_UNDO_WINDOW", "168h"))
+
+	config.AdultAge = cast.ToInt(getOrReturnDefault("ADULT_AGE", 18))
+
+	config.ImportBatchSize = cast.ToInt(getOrReturnDefault("IMPORT_BATCH_SIZE", 500))
+	config.ImportMaxBytes = cast.ToInt64(getOrReturnDefault("IMPORT_MAX_BYTES", 20<<20))
+
+	config.AppointmentDuration = cast.ToDuration(getOrReturnDefault("APPOINTMENT_DURATION", "30m"))
+	config.CalendarPastDays = cast.ToInt(getOrReturnDefault("CALENDAR_PAST_DAYS", 30))
+	config.CalendarFutureDays = cast.ToInt(getOrReturnDefault("CALENDAR_FUTURE_DAYS", 365))
+	config.CalendarRefreshInterval = cast.ToDuration(getOrReturnDefault("CALENDAR_REFRESH_INTERVAL", "15m"))
+
+	config.PublicUrl = cast.ToString(getOrReturnDefault("PUBLIC_URL", ""))
+
+	config.WebhookPollInterval = cast.ToDuration(getOrReturnDefault("WEBHOOK_POLL_INTERVAL", "2s"))
+	config.WebhookTimeout = cast.ToDuration(getOrReturnDefault("WEBHOOK_TIMEOUT", "10s"))
+	config.WebhookRetryBase = cast.ToDuration(getOrReturnDefault("WEBHOOK_RETRY_BASE", "30s"))
+	config.WebhookRetryMax = cast.ToDuration(getOrReturnDefault("WEBHOOK_RETRY_MAX", "6h"))
+	config.WebhookMaxAttempts = cast.ToInt(getOrReturnDefault("WEBHOOK_MAX_ATTEMPTS", 10))
+	config.WebhookAllowPrivate = cast.ToBool(getOrReturnDefault("WEBHOOK_ALLOW_PRIVATE", false))
+
+	config.OutboxPollInterval = cast.ToDuration(getOrReturnDefault("OUTBOX_POLL_INTERVAL", "1s"))
+	config.OutboxBatchSize = cast.ToInt(getOrReturnDefault("OUTBOX_BATCH_SIZE", 100))
+	config.OutboxRetryBase = cast.ToDuration(getOrReturnDefault("OUTBOX_RETRY_BASE", "1s"))
+	config.OutboxRetryMax = cast.ToDuration(getOrReturnDefault("OUTBOX_RETRY_MAX", "5m"))
+	config.OutboxMaxAttempts = cast.ToInt(getOrReturnDefault("OUTBOX_MAX_ATTEMPTS", 10))
+	config.OutboxRetention = cast.ToDuration(getOrReturnDefault("OUTBOX_RETENTION", "168h"))
+
+	config.BoardTokens = cast.ToString(getOrReturnDefault("BOARD_TOKENS", ""))
+	config.BoardPollInterval = cast.ToDuration(getOrReturnDefault("BOARD_POLL_INTERVAL", "5s"))
+	config.BoardHeartbeat = cast.ToDuration(getOrReturnDefault("BOARD_HEARTBEAT", "15s"))
+	config.BoardReplayLimit = cast.ToInt(getOrReturnDefault("BOARD_REPLAY_LIMIT", 1000))
+
+	config.WaitlistOfferCount = cast.ToInt(getOrReturnDefault("WAITLIST_OFFER_COUNT", 3))
+	config.WaitlistOfferTTL = cast.ToDuration(getOrReturnDefault("WAITLIST_OFFER_TTL", "2h"))
+	config.WaitlistMinNotice = cast.ToDuration(getOrReturnDefault("WAITLIST_MIN_NOTICE", "1h"))
+
+	config.BookingProcedures = cast.ToString(getOrReturnDefault("BOOKING_PROCEDURES", "consultation=30m,cleaning=60m"))
+	config.BookingOpen = cast.ToString(getOrReturnDefault("BOOKING_OPEN", "09:00"))
+	config.BookingClose = cast.ToString(getOrReturnDefault("BOOKING_CLOSE", "18:00"))
+	config.BookingWorkdays = cast.ToString(getOrReturnDefault("BOOKING_WORKDAYS", "mon,tue,wed,thu,fri,sat"))
+	config.BookingMinNotice = cast.ToDuration(getOrReturnDefault("BOOKING_MIN_NOTICE", "2h"))
+	config.BookingMaxDays = cast.ToInt(getOrReturnDefault("BOOKING_MAX_DAYS", 60))
+	config.BookingConfirmation = cast.ToString(getOrReturnDefault("BOOKING_CONFIRMATION", "otp"))
+	config.BookingOtpTTL = cast.ToDuration(getOrReturnDefault("BOOKING_OTP_TTL", "15m"))
+	config.BookingOtpAttempts = cast.ToInt(getOrReturnDefault("BOOKING_OTP_ATTEMPTS", 5))
+	config.BookingStaffTTL = cast.ToDuration(getOrReturnDefault("BOOKING_STAFF_TTL", "24h"))
+	config.BookingSweepInterval = cast.ToDuration(getOrReturnDefault("BOOKING_SWEEP_INTERVAL", "1m"))
+	config.BookingMaxPerIp = cast.ToInt(getOrReturnDefault("BOOKING_MAX_PER_IP", 5))
+	config.BookingMaxPendingPerPhone = cast.ToInt(getOrReturnDefault("BOOKING_MAX_PENDING_PER_PHONE", 2))
+
+	config.TrustedProxies = cast.ToString(getOrReturnDefault("TRUSTED_PROXIES", ""))
+	config.RateLimitStore = cast.ToString(getOrReturnDefault("RATE_LIMIT_STORE", "memory"))
+	config.RateLimitIp = cast.ToString(getOrReturnDefault("RATE_LIMIT_IP", "600/1m"))
+	config.RateLimitUser = cast.ToString(getOrReturnDefault("RATE_LIMIT_USER", "1200/1m"))
+	config.RateLimitKey = cast.ToString(getOrReturnDefault("RATE_LIMIT_KEY", "1200/1m"))
+	config.RateLimitRoutes = cast.ToString(getOrReturnDefault("RATE_LIMIT_ROUTES", "GET /v1/search=30/1m,GET /v1/duplicates=10/1m,POST /public/booking=10/1h,GET /public/booking/slots=60/1m"))
+	config.RateLimitSweepInterval = cast.ToDuration(getOrReturnDefault("RATE_LIMIT_SWEEP_INTERVAL", "5m"))
+	config.LockoutAttempts = cast.ToInt(getOrReturnDefault("LOCKOUT_ATTEMPTS", 5))
+	config.LockoutWindow = cast.ToDuration(getOrReturnDefault("LOCKOUT_WINDOW", "15m"))
+	config.LockoutDuration = cast.ToDuration(getOrReturnDefault("LOCKOUT_DURATION", "15m"))
+
+	config.InventoryExpiryDays = cast.ToInt(getOrReturnDefault("INVENTORY_EXPIRY_DAYS", 30))
+
 	return config
-}
\ No newline at end of file
+}
+
+func getOrReturnDefault(key string, defaultValue interface{}) interface{} {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+
+	return defaultValue
+}
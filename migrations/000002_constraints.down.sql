@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS clients_deleted_at_idx;
+DROP INDEX IF EXISTS appointments_deleted_at_idx;
+DROP INDEX IF EXISTS appointments_client_id_idx;
+DROP INDEX IF EXISTS appointments_date_idx;
+
+-- values that were not a date come back from birth_date_raw
+ALTER TABLE clients ALTER COLUMN birth_date TYPE VARCHAR(50) USING COALESCE(to_char(birth_date, 'YYYY-MM-DD'), birth_date_raw);
+ALTER TABLE clients DROP COLUMN IF EXISTS birth_date_raw;
+
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_client_id_fkey;
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_pkey;
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_pkey;
@@ -0,0 +1,55 @@
+ALTER TABLE clients ADD CONSTRAINT clients_pkey PRIMARY KEY (id);
+ALTER TABLE appointments ADD CONSTRAINT appointments_pkey PRIMARY KEY (id);
+
+-- Appointments were created without any check on client_id, so legacy rows
+-- may point to clients that never existed. NOT VALID enforces the key for
+-- every new or updated row without failing on that history; run
+-- VALIDATE CONSTRAINT once the orphans are cleaned up.
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_client_id_fkey
+    FOREIGN KEY (client_id) REFERENCES clients (id) NOT VALID;
+
+-- birth_date was free text. Values that read as a date in either format
+-- the forms used are converted; read_birth_date answers NULL instead of
+-- failing for ones like 2020-13-45 or 31.02.2020. Anything else is kept
+-- in birth_date_raw, reported as a warning in the server log, and put
+-- back by the down migration; clean them up by hand looking for
+-- birth_date_raw IS NOT NULL.
+CREATE FUNCTION pg_temp.read_birth_date(raw TEXT) RETURNS DATE AS $$
+BEGIN
+    IF raw ~ '^\d{4}-\d{2}-\d{2}$' THEN
+        RETURN raw::DATE;
+    ELSIF raw ~ '^\d{2}\.\d{2}\.\d{4}$' THEN
+        RETURN to_date(raw, 'DD.MM.YYYY');
+    END IF;
+    RETURN NULL;
+EXCEPTION WHEN OTHERS THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS birth_date_raw VARCHAR(50);
+
+UPDATE clients
+SET birth_date_raw = birth_date
+WHERE btrim(birth_date) <> '' AND pg_temp.read_birth_date(birth_date) IS NULL;
+
+DO $$
+DECLARE
+    unreadable INT;
+BEGIN
+    SELECT COUNT(*) INTO unreadable FROM clients WHERE birth_date_raw IS NOT NULL;
+    IF unreadable > 0 THEN
+        RAISE WARNING '% clients have a birth_date that is not a date, kept in clients.birth_date_raw', unreadable;
+    END IF;
+END
+$$;
+
+ALTER TABLE clients ALTER COLUMN birth_date TYPE DATE USING pg_temp.read_birth_date(birth_date);
+
+DROP FUNCTION pg_temp.read_birth_date(TEXT);
+
+CREATE INDEX IF NOT EXISTS appointments_date_idx ON appointments (date);
+CREATE INDEX IF NOT EXISTS appointments_client_id_idx ON appointments (client_id);
+CREATE INDEX IF NOT EXISTS appointments_deleted_at_idx ON appointments (deleted_at);
+CREATE INDEX IF NOT EXISTS clients_deleted_at_idx ON clients (deleted_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS appointments_date_status_idx;
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_status_check;
+ALTER TABLE appointments DROP COLUMN IF EXISTS status;
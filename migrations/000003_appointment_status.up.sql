@@ -0,0 +1,8 @@
+ALTER TABLE appointments
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'scheduled';
+
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_status_check
+    CHECK (status IN ('scheduled', 'confirmed', 'checked_in', 'completed', 'cancelled', 'no_show'));
+
+CREATE INDEX IF NOT EXISTS appointments_date_status_idx ON appointments (date, status);
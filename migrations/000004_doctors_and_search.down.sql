@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS appointments_text_search_idx;
+DROP INDEX IF EXISTS appointments_amount_idx;
+DROP INDEX IF EXISTS appointments_doctor_id_date_idx;
+
+ALTER TABLE appointments DROP COLUMN IF EXISTS doctor_id;
+
+DROP TABLE IF EXISTS doctors;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS doctors (
+    id UUID PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    last_name VARCHAR(50) NOT NULL DEFAULT '',
+    specialty VARCHAR(100) NOT NULL DEFAULT '',
+    phone_number VARCHAR(20) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP NULL
+);
+
+ALTER TABLE appointments ADD COLUMN IF NOT EXISTS doctor_id UUID NULL REFERENCES doctors (id);
+
+CREATE INDEX IF NOT EXISTS appointments_doctor_id_date_idx ON appointments (doctor_id, date);
+CREATE INDEX IF NOT EXISTS appointments_amount_idx ON appointments (amount);
+
+-- 'simple' because records are written in Uzbek and Russian, for which
+-- postgres has no stemmer; the expression must match the one in
+-- storage/postgres for the index to be used.
+CREATE INDEX IF NOT EXISTS appointments_text_search_idx ON appointments
+    USING GIN (to_tsvector('simple', COALESCE(diagnostics, '') || ' ' || COALESCE(treatment, '')));
@@ -0,0 +1,23 @@
+-- appointments.date was written as the clinic's wall clock time without a
+-- zone, so it is read in the clinic zone (the CLINIC_TIMEZONE default;
+-- change it here before running if the clinic is elsewhere).
+ALTER TABLE appointments
+    ALTER COLUMN date TYPE TIMESTAMPTZ USING date AT TIME ZONE 'Asia/Tashkent';
+
+-- The audit columns were filled by CURRENT_TIMESTAMP in the server's zone,
+-- UTC in the stock postgres image. The session zone cannot be relied on
+-- here because the application now connects in the clinic zone.
+ALTER TABLE appointments
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC',
+    ALTER COLUMN deleted_at TYPE TIMESTAMPTZ USING deleted_at AT TIME ZONE 'UTC';
+
+ALTER TABLE clients
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC',
+    ALTER COLUMN deleted_at TYPE TIMESTAMPTZ USING deleted_at AT TIME ZONE 'UTC';
+
+ALTER TABLE doctors
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC',
+    ALTER COLUMN deleted_at TYPE TIMESTAMPTZ USING deleted_at AT TIME ZONE 'UTC';
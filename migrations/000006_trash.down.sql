@@ -0,0 +1,2 @@
+ALTER TABLE appointments DROP COLUMN IF EXISTS anonymized_at;
+ALTER TABLE clients DROP COLUMN IF EXISTS anonymized_at;
@@ -0,0 +1,4 @@
+-- Set when the retention job anonymized a deleted row instead of purging
+-- it; such rows are kept for statistics but can no longer be restored.
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMPTZ NULL;
+ALTER TABLE appointments ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMPTZ NULL;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS clients_birth_date_idx;
+DROP INDEX IF EXISTS clients_phone_digits_idx;
+DROP INDEX IF EXISTS clients_full_name_trgm_idx;
+
+DROP TABLE IF EXISTS client_merges;
+
+ALTER TABLE clients DROP COLUMN IF EXISTS merged_into;
+
+-- pg_trgm is left installed, other objects may depend on it
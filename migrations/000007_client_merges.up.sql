@@ -0,0 +1,28 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- The merged duplicate stays as a deleted row pointing at the client it
+-- was merged into, so the merge can be undone. No foreign keys here: the
+-- trash may purge either client while the history is kept.
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS merged_into UUID NULL;
+
+CREATE TABLE IF NOT EXISTS client_merges (
+    id UUID PRIMARY KEY,
+    survivor_id UUID NOT NULL,
+    merged_id UUID NOT NULL,
+    -- the appointments moved to the survivor, moved back on undo
+    appointment_ids UUID[] NOT NULL DEFAULT '{}',
+    -- survivor fields that were empty and were filled from the duplicate
+    filled JSONB NOT NULL DEFAULT '{}',
+    merged_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    undone_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS client_merges_survivor_id_idx ON client_merges (survivor_id);
+CREATE INDEX IF NOT EXISTS client_merges_merged_id_idx ON client_merges (merged_id);
+
+-- the expressions must match the duplicate finder in storage/postgres
+CREATE INDEX IF NOT EXISTS clients_full_name_trgm_idx ON clients
+    USING GIN ((name || ' ' || COALESCE(last_name, '')) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS clients_phone_digits_idx ON clients
+    (right(regexp_replace(phone_number, '\D', '', 'g'), 9));
+CREATE INDEX IF NOT EXISTS clients_birth_date_idx ON clients (birth_date);
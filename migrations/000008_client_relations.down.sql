@@ -0,0 +1,6 @@
+ALTER TABLE client_merges DROP COLUMN IF EXISTS guaranteed_ids;
+ALTER TABLE client_merges DROP COLUMN IF EXISTS relations;
+
+ALTER TABLE clients DROP COLUMN IF EXISTS guarantor_id;
+
+DROP TABLE IF EXISTS client_relations;
@@ -0,0 +1,19 @@
+-- A row reads "related_id is the <kind> of client_id". Spouse and sibling
+-- are stored in both directions, guardian only from the child's side.
+CREATE TABLE IF NOT EXISTS client_relations (
+    client_id UUID NOT NULL REFERENCES clients (id) ON DELETE CASCADE,
+    related_id UUID NOT NULL REFERENCES clients (id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('guardian', 'spouse', 'sibling')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (client_id, related_id, kind),
+    CHECK (client_id <> related_id)
+);
+
+CREATE INDEX IF NOT EXISTS client_relations_related_id_idx ON client_relations (related_id);
+
+-- who gets the reminders and the bills, NULL is the client itself
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS guarantor_id UUID NULL REFERENCES clients (id) ON DELETE SET NULL;
+
+-- what a merge of duplicates changed here, to be reverted on undo
+ALTER TABLE client_merges ADD COLUMN IF NOT EXISTS relations JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE client_merges ADD COLUMN IF NOT EXISTS guaranteed_ids UUID[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,17 @@
+-- One row per import run, dry runs included. problems keeps the rows that
+-- were not imported together with their original cells, so the error
+-- report can be downloaded, fixed and uploaded again.
+CREATE TABLE IF NOT EXISTS imports (
+    id UUID PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('clients', 'appointments')),
+    file_name TEXT NOT NULL DEFAULT '',
+    dry_run BOOLEAN NOT NULL,
+    total INT NOT NULL,
+    imported INT NOT NULL,
+    created_clients INT NOT NULL,
+    duplicates INT NOT NULL,
+    failed INT NOT NULL,
+    header TEXT[] NOT NULL DEFAULT '{}',
+    problems JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,12 @@
+-- Secret links to the schedule for calendar apps. Only the SHA-256 of the
+-- token is kept, the link is shown once when the feed is created. A feed
+-- without doctor_id has the whole clinic.
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    id UUID PRIMARY KEY,
+    token_hash TEXT NOT NULL UNIQUE,
+    doctor_id UUID REFERENCES doctors(id) ON DELETE CASCADE,
+    name TEXT NOT NULL DEFAULT '',
+    mask_patients BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMPTZ
+);
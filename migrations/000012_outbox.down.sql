@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS outbox_attempts;
+DROP TABLE IF EXISTS outbox_offsets;
+DROP TABLE IF EXISTS outbox;
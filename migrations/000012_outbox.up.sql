@@ -0,0 +1,33 @@
+-- Domain events, written in the transaction of the change they describe.
+-- Writers hold an advisory lock from the insert to the commit, so seq
+-- follows the commit order and no event shows up behind one already read.
+CREATE TABLE IF NOT EXISTS outbox (
+    seq BIGSERIAL PRIMARY KEY,
+    id UUID NOT NULL UNIQUE,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every event up to seq is handled by the subscriber. The row is locked
+-- while an instance processes for the subscriber.
+CREATE TABLE IF NOT EXISTS outbox_offsets (
+    subscriber TEXT PRIMARY KEY,
+    seq BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Events after the offset that were handled out of order, or failed and
+-- wait for a retry. Dead ones are kept for inspection.
+CREATE TABLE IF NOT EXISTS outbox_attempts (
+    subscriber TEXT NOT NULL,
+    seq BIGINT NOT NULL REFERENCES outbox(seq) ON DELETE CASCADE,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('done', 'retry', 'dead')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    last_error TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (subscriber, seq)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS waitlist_offers;
+DROP TABLE IF EXISTS waitlist_slots;
+DROP TABLE IF EXISTS waitlist_entries;
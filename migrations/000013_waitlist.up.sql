@@ -0,0 +1,50 @@
+-- Clients waiting for an earlier appointment, with a doctor and a
+-- treatment or with any when empty, on a day from date_from to date_to.
+CREATE TABLE IF NOT EXISTS waitlist_entries (
+    id UUID PRIMARY KEY,
+    client_id UUID NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    doctor_id UUID REFERENCES doctors(id) ON DELETE SET NULL,
+    treatment TEXT NOT NULL DEFAULT '',
+    date_from DATE NOT NULL,
+    date_to DATE NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    note TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'booked', 'cancelled')),
+    appointment_id UUID REFERENCES appointments(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (date_from <= date_to)
+);
+
+CREATE INDEX IF NOT EXISTS waitlist_entries_waiting_idx ON waitlist_entries (date_from, date_to) WHERE status = 'waiting';
+
+-- A slot freed by the cancellation or move of an appointment. Its id is
+-- derived from the event that freed it, so it is offered only once. The
+-- first offer accepted takes it.
+CREATE TABLE IF NOT EXISTS waitlist_slots (
+    id UUID PRIMARY KEY,
+    doctor_id UUID REFERENCES doctors(id) ON DELETE SET NULL,
+    date TIMESTAMPTZ NOT NULL,
+    treatment TEXT NOT NULL DEFAULT '',
+    source_appointment_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'taken')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The offer of a slot to an entry. The link sent to the client carries a
+-- token of which only the hash is kept.
+CREATE TABLE IF NOT EXISTS waitlist_offers (
+    id UUID PRIMARY KEY,
+    slot_id UUID NOT NULL REFERENCES waitlist_slots(id) ON DELETE CASCADE,
+    entry_id UUID NOT NULL REFERENCES waitlist_entries(id) ON DELETE CASCADE,
+    rank INT NOT NULL,
+    token_hash TEXT UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'lost', 'withdrawn')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    notified_at TIMESTAMPTZ,
+    accepted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (slot_id, entry_id)
+);
+
+CREATE INDEX IF NOT EXISTS waitlist_offers_entry_id_idx ON waitlist_offers (entry_id, created_at);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS booking_requests;
+
+UPDATE appointments SET status = 'cancelled' WHERE status = 'pending';
+
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_status_check;
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_status_check
+    CHECK (status IN ('scheduled', 'confirmed', 'checked_in', 'completed', 'cancelled', 'no_show'));
@@ -0,0 +1,31 @@
+-- An appointment booked online holds its time as pending until it is
+-- confirmed.
+ALTER TABLE appointments DROP CONSTRAINT IF EXISTS appointments_status_check;
+ALTER TABLE appointments
+    ADD CONSTRAINT appointments_status_check
+    CHECK (status IN ('pending', 'scheduled', 'confirmed', 'checked_in', 'completed', 'cancelled', 'no_show'));
+
+-- A booking made on the website. It is confirmed with the code sent to
+-- the phone, of which only the hash is kept, or by staff. client_ip and
+-- phone_digits count the requests of a caller to stop abuse.
+CREATE TABLE IF NOT EXISTS booking_requests (
+    id UUID PRIMARY KEY,
+    appointment_id UUID NOT NULL REFERENCES appointments(id) ON DELETE CASCADE,
+    client_id UUID NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    procedure TEXT NOT NULL,
+    duration_minutes INT NOT NULL,
+    phone_digits TEXT NOT NULL,
+    client_ip TEXT NOT NULL,
+    code_hash TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'rejected', 'expired')),
+    confirmed_by VARCHAR(10) CHECK (confirmed_by IN ('code', 'staff')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS booking_requests_client_ip_idx ON booking_requests (client_ip, created_at);
+CREATE INDEX IF NOT EXISTS booking_requests_pending_idx ON booking_requests (expires_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS booking_requests_phone_idx ON booking_requests (phone_digits) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS booking_requests_appointment_id_idx ON booking_requests (appointment_id);
@@ -0,0 +1,23 @@
+-- Token buckets of the rate limits shared by every instance. A bucket
+-- idle past expires_at is full again and can be swept.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_expires_idx ON rate_limit_buckets (expires_at);
+
+-- Failed attempts, such as wrong tokens, counted in a window that starts
+-- at the first failure. Too many lock the key out until locked_until.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_lockouts (
+    key TEXT PRIMARY KEY,
+    failures INT NOT NULL,
+    window_start TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_lockouts_expires_idx ON rate_limit_lockouts (expires_at);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS inventory_consumptions;
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS procedure_materials;
+DROP TABLE IF EXISTS inventory_batches;
+DROP TABLE IF EXISTS storage_locations;
+DROP TABLE IF EXISTS inventory_items;
@@ -0,0 +1,77 @@
+-- Materials the clinic keeps in stock, counted in unit. An item below
+-- min_stock is reported as low.
+CREATE TABLE IF NOT EXISTS inventory_items (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    unit VARCHAR(20) NOT NULL,
+    sku TEXT NOT NULL DEFAULT '',
+    min_stock NUMERIC(12, 3) NOT NULL DEFAULT 0 CHECK (min_stock >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS inventory_items_name_idx ON inventory_items (LOWER(name)) WHERE deleted_at IS NULL;
+
+-- Where stock is kept, such as a cabinet or a fridge.
+CREATE TABLE IF NOT EXISTS storage_locations (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS storage_locations_name_idx ON storage_locations (LOWER(name));
+
+-- A batch of an item received into a location, used up first expiring
+-- first. The stock of an item is the sum of its batches.
+CREATE TABLE IF NOT EXISTS inventory_batches (
+    id UUID PRIMARY KEY,
+    item_id UUID NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+    location_id UUID NOT NULL REFERENCES storage_locations(id),
+    lot TEXT NOT NULL DEFAULT '',
+    expires_on DATE,
+    quantity NUMERIC(12, 3) NOT NULL CHECK (quantity >= 0),
+    received_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS inventory_batches_item_idx ON inventory_batches (item_id, expires_on) WHERE quantity > 0;
+
+-- The bill of materials of a procedure, matched to the treatment of
+-- appointments without regard to case.
+CREATE TABLE IF NOT EXISTS procedure_materials (
+    procedure TEXT NOT NULL,
+    item_id UUID NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+    quantity NUMERIC(12, 3) NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (procedure, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS procedure_materials_procedure_idx ON procedure_materials (LOWER(procedure));
+
+-- Every change of stock. A consumption not covered by stock is written
+-- without a batch.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id UUID PRIMARY KEY,
+    item_id UUID NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+    batch_id UUID REFERENCES inventory_batches(id) ON DELETE SET NULL,
+    location_id UUID REFERENCES storage_locations(id),
+    quantity NUMERIC(12, 3) NOT NULL,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('receipt', 'consumption', 'adjustment')),
+    appointment_id UUID REFERENCES appointments(id) ON DELETE SET NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS stock_movements_item_idx ON stock_movements (item_id, created_at);
+CREATE INDEX IF NOT EXISTS stock_movements_appointment_idx ON stock_movements (appointment_id) WHERE appointment_id IS NOT NULL;
+
+-- Appointments whose materials were taken out of stock, each only once.
+CREATE TABLE IF NOT EXISTS inventory_consumptions (
+    appointment_id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Appointments completed before inventory was kept used nothing from it,
+-- the inventory subscriber reads the outbox from its start.
+INSERT INTO inventory_consumptions (appointment_id)
+SELECT id FROM appointments WHERE status = 'completed'
+ON CONFLICT DO NOTHING;
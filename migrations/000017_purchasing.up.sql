@@ -0,0 +1,67 @@
+-- Suppliers materials are ordered from.
+CREATE TABLE IF NOT EXISTS suppliers (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    contact TEXT NOT NULL DEFAULT '',
+    phone VARCHAR(30) NOT NULL DEFAULT '',
+    email TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS suppliers_name_idx ON suppliers (LOWER(name)) WHERE deleted_at IS NULL;
+
+-- An order of items from a supplier. It is edited as a draft, placed and
+-- then received in one or more deliveries.
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id UUID PRIMARY KEY,
+    supplier_id UUID NOT NULL REFERENCES suppliers(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'ordered', 'partially_received', 'received', 'cancelled')),
+    expected_on DATE,
+    note TEXT NOT NULL DEFAULT '',
+    ordered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS purchase_orders_supplier_idx ON purchase_orders (supplier_id, created_at);
+
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    item_id UUID NOT NULL REFERENCES inventory_items(id),
+    quantity NUMERIC(12, 3) NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(12, 2) NOT NULL CHECK (unit_price >= 0),
+    received NUMERIC(12, 3) NOT NULL DEFAULT 0 CHECK (received >= 0 AND received <= quantity)
+);
+
+CREATE INDEX IF NOT EXISTS purchase_order_lines_order_idx ON purchase_order_lines (order_id, position);
+
+-- Every delivered quantity of an order line, what spend is counted from.
+CREATE TABLE IF NOT EXISTS purchase_receipts (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    line_id UUID NOT NULL REFERENCES purchase_order_lines(id) ON DELETE CASCADE,
+    batch_id UUID REFERENCES inventory_batches(id) ON DELETE SET NULL,
+    quantity NUMERIC(12, 3) NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(12, 2) NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS purchase_receipts_received_idx ON purchase_receipts (received_at);
+
+-- The unit prices a supplier was ordered items at, written when an order
+-- is placed.
+CREATE TABLE IF NOT EXISTS supplier_prices (
+    id UUID PRIMARY KEY,
+    supplier_id UUID NOT NULL REFERENCES suppliers(id),
+    item_id UUID NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+    unit_price NUMERIC(12, 2) NOT NULL,
+    order_id UUID REFERENCES purchase_orders(id) ON DELETE SET NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS supplier_prices_supplier_idx ON supplier_prices (supplier_id, item_id, recorded_at);
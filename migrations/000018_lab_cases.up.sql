@@ -0,0 +1,24 @@
+-- Prosthetic work made by an outside lab, a supplier, for a client. The
+-- fitting appointment cannot be confirmed until the case is received.
+CREATE TABLE IF NOT EXISTS lab_cases (
+    id UUID PRIMARY KEY,
+    client_id UUID NOT NULL REFERENCES clients(id),
+    appointment_id UUID REFERENCES appointments(id) ON DELETE SET NULL,
+    lab_id UUID NOT NULL REFERENCES suppliers(id),
+    work_type VARCHAR(20) NOT NULL CHECK (work_type IN ('crown', 'bridge', 'denture', 'veneer', 'inlay', 'implant', 'other')),
+    shade VARCHAR(20) NOT NULL DEFAULT '',
+    teeth SMALLINT[] NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'created' CHECK (status IN ('created', 'sent', 'received', 'fitted', 'cancelled')),
+    sent_on DATE,
+    due_on DATE,
+    received_on DATE,
+    fitted_on DATE,
+    cost NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (cost >= 0),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS lab_cases_client_idx ON lab_cases (client_id, created_at);
+CREATE INDEX IF NOT EXISTS lab_cases_appointment_idx ON lab_cases (appointment_id) WHERE appointment_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS lab_cases_due_idx ON lab_cases (due_on) WHERE status IN ('created', 'sent');
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sterilization_packs;
+DROP TABLE IF EXISTS sterilization_cycles;
+DROP TABLE IF EXISTS autoclaves;
\ No newline at end of file
@@ -0,0 +1,47 @@
+-- Sterilizers instruments are processed in.
+CREATE TABLE IF NOT EXISTS autoclaves (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    serial_number TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS autoclaves_name_idx ON autoclaves (LOWER(name));
+
+-- A run of an autoclave with its parameters. The result comes from its
+-- chemical or biological indicator, a cycle that passed can still fail
+-- later when a biological indicator grows.
+CREATE TABLE IF NOT EXISTS sterilization_cycles (
+    id UUID PRIMARY KEY,
+    autoclave_id UUID NOT NULL REFERENCES autoclaves(id),
+    cycle_number INT NOT NULL CHECK (cycle_number > 0),
+    program TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL,
+    temperature_c NUMERIC(5, 1) NOT NULL,
+    pressure_kpa NUMERIC(6, 1) NOT NULL,
+    hold_minutes INT NOT NULL CHECK (hold_minutes > 0),
+    operator TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    result VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (result IN ('pending', 'passed', 'failed')),
+    indicator VARCHAR(20) NOT NULL DEFAULT '' CHECK (indicator IN ('', 'chemical', 'biological')),
+    result_note TEXT NOT NULL DEFAULT '',
+    result_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (autoclave_id, cycle_number)
+);
+
+CREATE INDEX IF NOT EXISTS sterilization_cycles_started_idx ON sterilization_cycles (started_at);
+
+-- An instrument pack processed in a cycle, identified by the code on its
+-- label. It is opened once, at the appointment it is used at.
+CREATE TABLE IF NOT EXISTS sterilization_packs (
+    id UUID PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    cycle_id UUID NOT NULL REFERENCES sterilization_cycles(id) ON DELETE CASCADE,
+    contents TEXT NOT NULL DEFAULT '',
+    appointment_id UUID REFERENCES appointments(id),
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS sterilization_packs_cycle_idx ON sterilization_packs (cycle_id);
+CREATE INDEX IF NOT EXISTS sterilization_packs_appointment_idx ON sterilization_packs (appointment_id) WHERE appointment_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- 000020 only checks the data, there is nothing to undo.
+SELECT 1;
@@ -0,0 +1,17 @@
+-- 000005 read the legacy appointments.date values as Asia/Tashkent wall
+-- clock time. In a clinic elsewhere the appointments written before it ran
+-- were moved by the difference between the zones, and only the operator
+-- can tell them from the ones written since. The connection runs in
+-- CLINIC_TIMEZONE, so stop here when it reads any appointment differently.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM appointments
+        WHERE date AT TIME ZONE current_setting('TimeZone') <> date AT TIME ZONE 'Asia/Tashkent'
+    ) THEN
+        RAISE EXCEPTION 'CLINIC_TIMEZONE is % but 000005 read the legacy appointment times as Asia/Tashkent: '
+            'move the appointments created before it ran with SET date = (date AT TIME ZONE ''Asia/Tashkent'') AT TIME ZONE ''%'', '
+            'then run `dentist migrate force 20`', current_setting('TimeZone'), current_setting('TimeZone');
+    END IF;
+END
+$$;
@@ -0,0 +1,2 @@
+-- The down of 000002 reads birth_date_raw back and drops it.
+SELECT 1;
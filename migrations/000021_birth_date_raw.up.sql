@@ -0,0 +1,4 @@
+-- 000002 keeps the birth dates that are not a date in birth_date_raw.
+-- Databases that ran it before it did have no such column, add it so
+-- every database has the same clients table.
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS birth_date_raw VARCHAR(50);
@@ -0,0 +1,3 @@
+ALTER TABLE client_merges DROP COLUMN IF EXISTS booking_request_ids;
+ALTER TABLE client_merges DROP COLUMN IF EXISTS waitlist_entry_ids;
+ALTER TABLE client_merges DROP COLUMN IF EXISTS lab_case_ids;
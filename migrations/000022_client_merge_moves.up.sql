@@ -0,0 +1,5 @@
+-- the lab cases, waitlist entries and online bookings a merge of
+-- duplicates moved to the survivor, moved back on undo
+ALTER TABLE client_merges ADD COLUMN IF NOT EXISTS lab_case_ids UUID[] NOT NULL DEFAULT '{}';
+ALTER TABLE client_merges ADD COLUMN IF NOT EXISTS waitlist_entry_ids UUID[] NOT NULL DEFAULT '{}';
+ALTER TABLE client_merges ADD COLUMN IF NOT EXISTS booking_request_ids UUID[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL migrations into the binary,
+// so `dentist migrate` does not need the files or the migrate CLI at hand.
+package migrations
+
+import "embed"
+
+// FS holds every NNNNNN_name.{up,down}.sql file of this directory.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,123 @@
+package booking
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/dentist/config"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+)
+
+// Ways bookings are confirmed
+const (
+	ConfirmByCode  = "otp"
+	ConfirmByStaff = "staff"
+)
+
+// Sender delivers confirmation codes to phones, such as by SMS
+type Sender interface {
+	SendCode(ctx context.Context, phoneNumber, code string) error
+}
+
+type logSender struct {
+	cfg *config.Config
+	log logger.Logger
+}
+
+// LogSender only logs that a code was sent, for clinics without an SMS
+// gateway. The code itself is logged outside production so bookings can
+// be tried out.
+func LogSender(cfg *config.Config, log logger.Logger) Sender {
+	return &logSender{cfg: cfg, log: log}
+}
+
+func (s *logSender) SendCode(ctx context.Context, phoneNumber, code string) error {
+	fields := []logger.Field{logger.String("phone_digits", PhoneDigits(phoneNumber))}
+	if s.cfg.Environment != "production" {
+		fields = append(fields, logger.String("code", code))
+	}
+	logger.FromContext(ctx, s.log).Info("booking: confirmation code sent", fields...)
+	return nil
+}
+
+// PhoneDigits returns the last 9 digits of a phone number, the key
+// clients are matched by
+func PhoneDigits(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	s := b.String()
+	if len(s) > 9 {
+		s = s[len(s)-9:]
+	}
+	return s
+}
+
+// ValidPhone reports whether phone looks like a number that can get a
+// code: digits with an optional leading + and separators, 9 to 15 digits
+func ValidPhone(phone string) bool {
+	digits := 0
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '(' || r == ')':
+		default:
+			return false
+		}
+	}
+	return digits >= 9 && digits <= 15
+}
+
+// NewCode returns a random 6 digit code
+func NewCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// HashCode is what is kept of a confirmation code
+func HashCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Run expires the bookings not confirmed in time, once right away and
+// then every cfg.BookingSweepInterval until ctx is cancelled
+func Run(ctx context.Context, cfg *config.Config, store repo.NewBookingI, log logger.Logger) {
+	if cfg.BookingSweepInterval <= 0 {
+		log.Error("booking: BOOKING_SWEEP_INTERVAL must be positive, bookings do not expire")
+		return
+	}
+
+	ticker := time.NewTicker(cfg.BookingSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := store.Expire(ctx)
+		if err != nil {
+			log.Error("booking: failed to expire bookings", logger.Error(err))
+		} else if expired > 0 {
+			log.Info("booking: expired unconfirmed bookings", logger.Int("bookings", expired))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Package booking lets clients book appointments on the website. Schedule
+// tells which times are free for a procedure, a booking holds its time as
+// a pending appointment until it is confirmed with a code sent to the
+// phone or by staff, and Run expires the ones never confirmed.
+package booking
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dentist/config"
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/storage/repo"
+)
+
+// Procedure is what a client books, it takes Duration of a doctor's time
+type Procedure struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Slot is a time a procedure can start at, with the doctors free for it
+type Slot struct {
+	Start     time.Time
+	DoctorIds []string
+}
+
+// Schedule is when the clinic takes bookings
+type Schedule struct {
+	Procedures []Procedure
+	// Open and Close are the working hours as time since midnight
+	Open  time.Duration
+	Close time.Duration
+	// Step is the time between two slots, every booking starts on it
+	Step      time.Duration
+	Workdays  map[time.Weekday]bool
+	MinNotice time.Duration
+	MaxDays   int
+	Location  *time.Location
+	// MinDuration is how long an appointment that does not tell is taken
+	// to last
+	MinDuration time.Duration
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// NewSchedule reads the schedule of bookings from cfg
+func NewSchedule(cfg *config.Config) (*Schedule, error) {
+	s := &Schedule{
+		Step:        cfg.AppointmentDuration,
+		Workdays:    map[time.Weekday]bool{},
+		MinNotice:   cfg.BookingMinNotice,
+		MaxDays:     cfg.BookingMaxDays,
+		Location:    cfg.Location,
+		MinDuration: cfg.AppointmentDuration,
+	}
+	if s.Location == nil {
+		s.Location = time.UTC
+	}
+	if s.Step <= 0 {
+		return nil, fmt.Errorf("booking: APPOINTMENT_DURATION must be positive")
+	}
+
+	for _, pair := range strings.Split(cfg.BookingProcedures, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, duration, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("booking: procedure %q is not name=duration", pair)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("booking: procedure %q has no valid duration", pair)
+		}
+		s.Procedures = append(s.Procedures, Procedure{Name: strings.TrimSpace(name), Duration: d})
+	}
+
+	var err error
+	if s.Open, err = clock(cfg.BookingOpen); err != nil {
+		return nil, err
+	}
+	if s.Close, err = clock(cfg.BookingClose); err != nil {
+		return nil, err
+	}
+	if s.Close <= s.Open {
+		return nil, fmt.Errorf("booking: BOOKING_CLOSE must be after BOOKING_OPEN")
+	}
+
+	for _, day := range strings.Split(cfg.BookingWorkdays, ",") {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if day == "" {
+			continue
+		}
+		weekday, ok := weekdays[day]
+		if !ok {
+			return nil, fmt.Errorf("booking: %q is not a weekday", day)
+		}
+		s.Workdays[weekday] = true
+	}
+	return s, nil
+}
+
+// clock parses an HH:MM time of day
+func clock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("booking: %q is not an HH:MM time", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Procedure returns the procedure called name
+func (s *Schedule) Procedure(name string) (Procedure, bool) {
+	for _, p := range s.Procedures {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return Procedure{}, false
+}
+
+// Window is the time to look for busy doctors in to know the slots of
+// day, appointments started before it are taken to last the longest
+// procedure
+func (s *Schedule) Window(day civil.Date) (from, to time.Time) {
+	longest := s.MinDuration
+	for _, p := range s.Procedures {
+		if p.Duration > longest {
+			longest = p.Duration
+		}
+	}
+	midnight := day.In(s.Location)
+	return midnight.Add(s.Open - longest), midnight.Add(s.Close)
+}
+
+// Bookable reports whether p can be booked to start at start as now: on a
+// workday within the working hours, on a step and neither too early nor
+// too far ahead
+func (s *Schedule) Bookable(start time.Time, p Procedure, now time.Time) bool {
+	start = start.In(s.Location)
+	day := civil.DateOf(start)
+	if !s.Workdays[start.Weekday()] {
+		return false
+	}
+	offset := start.Sub(day.In(s.Location))
+	if offset < s.Open || offset+p.Duration > s.Close || (offset-s.Open)%s.Step != 0 {
+		return false
+	}
+	if start.Before(now.Add(s.MinNotice)) {
+		return false
+	}
+	return !start.After(now.AddDate(0, 0, s.MaxDays))
+}
+
+// Free reports whether the doctor has none of busy overlapping p started
+// at start
+func (s *Schedule) Free(doctorId string, start time.Time, p Procedure, busy []repo.BusyTime) bool {
+	end := start.Add(p.Duration)
+	for _, b := range busy {
+		if b.DoctorId != doctorId {
+			continue
+		}
+		duration := b.Duration
+		if duration < s.MinDuration {
+			duration = s.MinDuration
+		}
+		if b.Start.Before(end) && b.Start.Add(duration).After(start) {
+			return false
+		}
+	}
+	return true
+}
+
+// Slots lists the times of day p can be booked at as now, with the
+// doctors free for each. Times nobody is free at are left out.
+func (s *Schedule) Slots(day civil.Date, p Procedure, doctorIds []string, busy []repo.BusyTime, now time.Time) []Slot {
+	slots := []Slot{}
+	midnight := day.In(s.Location)
+	for offset := s.Open; offset+p.Duration <= s.Close; offset += s.Step {
+		start := midnight.Add(offset)
+		if !s.Bookable(start, p, now) {
+			continue
+		}
+		slot := Slot{Start: start}
+		for _, id := range doctorIds {
+			if s.Free(id, start, p, busy) {
+				slot.DoctorIds = append(slot.DoctorIds, id)
+			}
+		}
+		if len(slot.DoctorIds) > 0 {
+			sort.Strings(slot.DoctorIds)
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
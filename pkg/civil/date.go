@@ -0,0 +1,118 @@
+// Package civil provides a calendar date without a time of day or time
+// zone, for values such as birth dates that must not shift when they are
+// converted between zones.
+package civil
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const layout = "2006-01-02"
+
+// Date is a day of the calendar, the zero value means no date and is
+// stored as NULL and sent as an empty string.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the date t falls on in its own location
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// ParseDate parses a YYYY-MM-DD string
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("civil: %q is not a YYYY-MM-DD date", s)
+	}
+	return DateOf(t), nil
+}
+
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// In returns the midnight starting d in loc
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*d = Date{}
+		return nil
+	}
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value stores the date in a DATE column
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.String(), nil
+}
+
+// Scan reads a DATE column, the driver hands it over as a time at UTC
+// midnight so the calendar fields are taken as they are
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = DateOf(v)
+	case []byte:
+		return d.Scan(string(v))
+	case string:
+		parsed, err := ParseDate(v)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	default:
+		return fmt.Errorf("civil: cannot scan %T into Date", src)
+	}
+	return nil
+}
+
+// Today returns the current date in loc
+func Today(loc *time.Location) Date {
+	return DateOf(time.Now().In(loc))
+}
+
+// YearsUntil returns the whole years from d to day, an age when d is a
+// birth date
+func (d Date) YearsUntil(day Date) int {
+	years := day.Year - d.Year
+	if day.Month < d.Month || (day.Month == d.Month && day.Day < d.Day) {
+		years--
+	}
+	return years
+}
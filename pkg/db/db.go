@@ -1,29 +1,96 @@
 package db
 
 import (
+    "context"
     "fmt"
+    "time"
+
+    "github.com/XSAM/otelsql"
     "github.com/jmoiron/sqlx"
     _ "github.com/lib/pq" //postgres drivers
+    semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
     "github.com/dentist/config"
+    "github.com/dentist/pkg/logger"
 )
 
-func ConnectToDB(cfg config.Config) (*sqlx.DB, func(), error) {
-    psqlString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+// ConnectToDB opens the postgres pool, retrying with exponential backoff
+// while the database is not reachable yet (e.g. the container is still
+// starting). It gives up after cfg.PostgresConnectAttempts or when ctx is
+// cancelled.
+func ConnectToDB(ctx context.Context, cfg config.Config, log logger.Logger) (*sqlx.DB, func(), error) {
+    psqlString := DSN(cfg)
+
+    attempts := cfg.PostgresConnectAttempts
+    if attempts < 1 {
+        attempts = 1
+    }
+    backoff := cfg.PostgresConnectBackoff
+
+    var (
+        connDb *sqlx.DB
+        err    error
+    )
+    for attempt := 1; attempt <= attempts; attempt++ {
+        connDb, err = connect(ctx, psqlString)
+        if err == nil {
+            break
+        }
+        if attempt == attempts {
+            return nil, nil, fmt.Errorf("connect to postgres after %d attempts: %w", attempts, err)
+        }
+        log.Warn("postgres is not ready, retrying",
+            logger.Int("attempt", attempt),
+            logger.Int("attempts", attempts),
+            logger.String("backoff", backoff.String()),
+            logger.Error(err),
+        )
+
+        select {
+        case <-ctx.Done():
+            return nil, nil, ctx.Err()
+        case <-time.After(backoff):
+        }
+        backoff *= 2
+    }
+
+    cleanUpFunc := func ()  {
+        connDb.Close()
+    }
+
+    return connDb, cleanUpFunc, nil
+}
+
+// DSN is the connection string of the database, also for connections
+// opened outside the pool such as LISTEN
+func DSN(cfg config.Config) string {
+    // TimeZone makes postgres cut ::DATE and to_char in the clinic zone
+    // and return TIMESTAMPTZ values with the clinic offset
+    return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable TimeZone=%s",
         cfg.PostgresHost,
         cfg.PostgresPort,
         cfg.PostgresUser,
         cfg.PostgresPassword,
         cfg.PostgresDatabase,
+        cfg.ClinicTimezone,
     )
+}
 
-    connDb, err := sqlx.Connect("postgres", psqlString)
+// connect opens the pool through otelsql, so every query becomes a span
+// under the span of the request that issued it.
+func connect(ctx context.Context, dsn string) (*sqlx.DB, error) {
+    sqlDb, err := otelsql.Open("postgres", dsn,
+        otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+        otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true}),
+    )
     if err != nil {
-        return nil, nil, err
+        return nil, err
     }
 
-    cleanUpFunc := func ()  {
+    connDb := sqlx.NewDb(sqlDb, "postgres")
+    if err = connDb.PingContext(ctx); err != nil {
         connDb.Close()
+        return nil, err
     }
 
-    return connDb, cleanUpFunc, nil
+    return connDb, nil
 }
\ No newline at end of file
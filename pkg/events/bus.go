@@ -0,0 +1,141 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/config"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+)
+
+// Handler does the side effect of an event. An event may be handed more
+// than once, handlers must tolerate that.
+type Handler func(ctx context.Context, event *repo.DomainEvent) error
+
+type subscription struct {
+	name    string
+	handler Handler
+}
+
+// Bus delivers the outbox to subscribers at least once. The events of an
+// aggregate reach a subscriber in order, an event it fails holds back the
+// later ones of that aggregate until it succeeds or is given up.
+type Bus struct {
+	cfg   *config.Config
+	store repo.NewOutboxI
+	log   logger.Logger
+	subs  []subscription
+}
+
+func NewBus(cfg *config.Config, store repo.NewOutboxI, log logger.Logger) *Bus {
+	return &Bus{
+		cfg:   cfg,
+		store: store,
+		log:   log,
+	}
+}
+
+// Subscribe registers handler under name before Run. The name keeps the
+// progress of the subscriber in the database, renaming it hands all the
+// events again.
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.subs = append(b.subs, subscription{name: name, handler: handler})
+}
+
+// Run delivers the events to every subscriber until ctx is cancelled.
+// Every instance may run it, one at a time processes for a subscriber.
+func (b *Bus) Run(ctx context.Context) {
+	if b.cfg.OutboxPollInterval <= 0 {
+		b.log.Error("events: OUTBOX_POLL_INTERVAL must be positive, events are not delivered")
+		return
+	}
+	for _, sub := range b.subs {
+		go b.run(ctx, sub)
+	}
+	if b.cfg.OutboxRetention > 0 {
+		go b.prune(ctx)
+	}
+}
+
+// pruneInterval is how often the handled events are deleted
+const pruneInterval = time.Hour
+
+// prune deletes the events older than OutboxRetention that every
+// subscriber is past, until ctx is cancelled
+func (b *Bus) prune(ctx context.Context) {
+	names := make([]string, 0, len(b.subs))
+	for _, sub := range b.subs {
+		names = append(names, sub.name)
+	}
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := b.store.Prune(ctx, names, b.cfg.BoardReplayLimit, time.Now().Add(-b.cfg.OutboxRetention))
+		if err != nil && ctx.Err() == nil {
+			b.log.Error("events: failed to prune the outbox", logger.Error(err))
+		} else if n > 0 {
+			b.log.Info("events: pruned the outbox", logger.Int("events", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *Bus) run(ctx context.Context, sub subscription) {
+	retry := repo.OutboxRetry{
+		MaxAttempts: b.cfg.OutboxMaxAttempts,
+		Backoff:     b.backoff,
+	}
+	handle := func(ctx context.Context, event *repo.DomainEvent) error {
+		err := sub.handler(ctx, event)
+		if err != nil && ctx.Err() == nil {
+			b.log.Warn("events: subscriber failed an event",
+				logger.String("subscriber", sub.name),
+				logger.String("event_id", event.Id),
+				logger.String("event", event.Type),
+				logger.Error(err),
+			)
+		}
+		return err
+	}
+
+	ticker := time.NewTicker(b.cfg.OutboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := b.store.Process(ctx, sub.name, b.cfg.OutboxBatchSize, retry, handle)
+		if err != nil && ctx.Err() == nil {
+			b.log.Error("events: failed to process the outbox", logger.String("subscriber", sub.name), logger.Error(err))
+		}
+		// a full batch handed means more may be waiting, events held back
+		// by a backoff are not counted so they do not keep it spinning
+		if err == nil && n > 0 && n == b.cfg.OutboxBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backoff doubles from OutboxRetryBase up to OutboxRetryMax
+func (b *Bus) backoff(attempts int) time.Duration {
+	delay := b.cfg.OutboxRetryBase
+	for i := 1; i < attempts && delay < b.cfg.OutboxRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > b.cfg.OutboxRetryMax {
+		delay = b.cfg.OutboxRetryMax
+	}
+	return delay
+}
@@ -0,0 +1,177 @@
+// Package events are the domain events of the clinic. Storage writes them
+// to the outbox in the transaction of the change they describe, and Bus
+// hands them to the subscribers registered in-process, such as webhooks.
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+)
+
+// Aggregates, the events of one aggregate reach a subscriber in the order
+// they were committed
+const (
+	AggregateAppointment = "appointment"
+	AggregateClient      = "client"
+	AggregateItem        = "inventory_item"
+	AggregateCycle       = "sterilization_cycle"
+)
+
+// Event types
+const (
+	AppointmentCreated   = "appointment.created"
+	AppointmentUpdated   = "appointment.updated"
+	AppointmentCheckedIn = "appointment.checked_in"
+	AppointmentCancelled = "appointment.cancelled"
+	AppointmentDeleted   = "appointment.deleted"
+	AppointmentRestored  = "appointment.restored"
+	ClientCreated        = "client.created"
+	ClientUpdated        = "client.updated"
+	ClientDeleted        = "client.deleted"
+	ClientRestored       = "client.restored"
+	InventoryLowStock    = "inventory.low_stock"
+	CycleFailed          = "sterilization.cycle_failed"
+)
+
+// AppointmentTypes are the event types of the appointment aggregate
+var AppointmentTypes = []string{
+	AppointmentCreated,
+	AppointmentUpdated,
+	AppointmentCheckedIn,
+	AppointmentCancelled,
+	AppointmentDeleted,
+	AppointmentRestored,
+}
+
+// Types lists the event types in the order they are documented
+var Types = []string{
+	AppointmentCreated,
+	AppointmentUpdated,
+	AppointmentCheckedIn,
+	AppointmentCancelled,
+	AppointmentDeleted,
+	AppointmentRestored,
+	ClientCreated,
+	ClientUpdated,
+	ClientDeleted,
+	ClientRestored,
+	InventoryLowStock,
+	CycleFailed,
+}
+
+// AppointmentData is the payload of appointment events, a deleted
+// appointment is described as it was
+type AppointmentData struct {
+	Id          string    `json:"id"`
+	ClientId    string    `json:"client_id"`
+	DoctorId    string    `json:"doctor_id,omitempty"`
+	Date        time.Time `json:"date"`
+	Diagnostics string    `json:"diagnostics"`
+	Treatment   string    `json:"treatment"`
+	Amount      int       `json:"amount"`
+	Status      string    `json:"status"`
+	// PreviousStatus, PreviousDate and PreviousDoctorId are set when an
+	// update changed them
+	PreviousStatus   string     `json:"previous_status,omitempty"`
+	PreviousDate     *time.Time `json:"previous_date,omitempty"`
+	PreviousDoctorId string     `json:"previous_doctor_id,omitempty"`
+}
+
+// ClientData is the payload of client.created, client.updated and
+// client.restored
+type ClientData struct {
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	LastName    string     `json:"last_name"`
+	FatherName  string     `json:"father_name"`
+	PhoneNumber string     `json:"phone_number"`
+	Address     string     `json:"address"`
+	BirthDate   civil.Date `json:"birth_date"`
+}
+
+// LowStockData is the payload of inventory.low_stock, sent when the stock
+// of an item falls below its minimum
+type LowStockData struct {
+	ItemId   string  `json:"item_id"`
+	Name     string  `json:"name"`
+	Unit     string  `json:"unit"`
+	Stock    float64 `json:"stock"`
+	MinStock float64 `json:"min_stock"`
+}
+
+// CycleFailedData is the payload of sterilization.cycle_failed, sent when
+// a sterilization cycle fails. ExposedAppointments are the appointments
+// its packs were used at.
+type CycleFailedData struct {
+	CycleId             string   `json:"cycle_id"`
+	AutoclaveId         string   `json:"autoclave_id"`
+	Autoclave           string   `json:"autoclave"`
+	CycleNumber         int      `json:"cycle_number"`
+	Indicator           string   `json:"indicator"`
+	ExposedAppointments []string `json:"exposed_appointments"`
+}
+
+// DeletedData is the payload of client.deleted
+type DeletedData struct {
+	Id string `json:"id"`
+}
+
+func Appointment(a *repo.Appointment) AppointmentData {
+	return AppointmentData{
+		Id:          a.Id,
+		ClientId:    a.ClientId,
+		DoctorId:    a.DoctorId,
+		Date:        a.Date,
+		Diagnostics: a.Diagnostics,
+		Treatment:   a.Treatment,
+		Amount:      a.Amount,
+		Status:      a.Status,
+	}
+}
+
+func Client(c *repo.Client) ClientData {
+	return ClientData{
+		Id:          c.Id,
+		Name:        c.Name,
+		LastName:    c.LastName,
+		FatherName:  c.FatherName,
+		PhoneNumber: c.PhoneNumber,
+		Address:     c.Address,
+		BirthDate:   c.BirthDate,
+	}
+}
+
+// AppointmentChanged is the event of an update from one status to the
+// other, check-ins and cancellations are told apart
+func AppointmentChanged(previous, status string) string {
+	if previous == status {
+		return AppointmentUpdated
+	}
+	switch status {
+	case repo.StatusCheckedIn:
+		return AppointmentCheckedIn
+	case repo.StatusCancelled:
+		return AppointmentCancelled
+	}
+	return AppointmentUpdated
+}
+
+// New builds an event of the aggregate with data as its JSON payload
+func New(aggregateType, aggregateId, eventType string, data interface{}) (*repo.DomainEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &repo.DomainEvent{
+		Id:            uuid.NewString(),
+		AggregateType: aggregateType,
+		AggregateId:   aggregateId,
+		Type:          eventType,
+		Payload:       payload,
+		CreatedAt:     time.Now().UTC(),
+	}, nil
+}
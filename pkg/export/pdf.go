@@ -0,0 +1,120 @@
+// Package export renders stored data into files handed out to people,
+// such as the copy of the record a patient asks for.
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dentist/storage/repo"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// PDFOptions tune ClientPDF. FontPath is a TrueType font covering the
+// alphabets the records are written in (e.g. DejaVuSans.ttf for Cyrillic);
+// without it the built-in Helvetica is used, which only knows Latin-1.
+type PDFOptions struct {
+	Location   *time.Location
+	FontPath   string
+	ExportedAt time.Time
+}
+
+const (
+	dateTimeLayout = "02.01.2006 15:04"
+	dateLayout     = "02.01.2006"
+)
+
+// ClientPDF writes export to w as a human readable document
+func ClientPDF(w io.Writer, export *repo.ClientExport, opts PDFOptions) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	family, tr := "Helvetica", pdf.UnicodeTranslatorFromDescriptor("")
+	if opts.FontPath != "" {
+		family, tr = "body", func(s string) string { return s }
+		pdf.AddUTF8Font(family, "", opts.FontPath)
+		pdf.AddUTF8Font(family, "B", opts.FontPath)
+	}
+	pdf.SetTitle("Patient record", true)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(family, "", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("%d / {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	local := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.In(opts.Location).Format(dateTimeLayout)
+	}
+
+	pdf.SetFont(family, "B", 16)
+	pdf.CellFormat(0, 10, tr("Patient record"), "", 1, "L", false, 0, "")
+	pdf.SetFont(family, "", 9)
+	pdf.CellFormat(0, 5, tr("Exported "+local(opts.ExportedAt)), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	client := export.Client
+	birthDate := "-"
+	if !client.BirthDate.IsZero() {
+		birthDate = client.BirthDate.In(time.UTC).Format(dateLayout)
+	}
+	fields := [][2]string{
+		{"Name", client.LastName + " " + client.Name + " " + client.FatherName},
+		{"Birth date", birthDate},
+		{"Phone number", client.PhoneNumber},
+		{"Address", client.Address},
+		{"Client since", local(client.CreatedAt)},
+		{"Client id", client.Id},
+	}
+	if export.DeletedAt != nil {
+		fields = append(fields, [2]string{"Deleted", local(*export.DeletedAt)})
+	}
+	section(pdf, family, tr, "Personal data")
+	for _, f := range fields {
+		pdf.SetFont(family, "B", 10)
+		pdf.CellFormat(40, 6, tr(f[0]), "", 0, "L", false, 0, "")
+		pdf.SetFont(family, "", 10)
+		pdf.MultiCell(0, 6, tr(f[1]), "", "L", false)
+	}
+	pdf.Ln(4)
+
+	section(pdf, family, tr, fmt.Sprintf("Appointments (%d)", len(export.Appointments)))
+	total := 0
+	for _, a := range export.Appointments {
+		if a.DeletedAt == nil {
+			total += a.Amount
+		}
+		pdf.SetFont(family, "B", 10)
+		pdf.CellFormat(0, 6, tr(fmt.Sprintf("%s   %s   %d", local(a.Date), a.Status, a.Amount)), "", 1, "L", false, 0, "")
+		pdf.SetFont(family, "", 10)
+		if a.DoctorName != "" {
+			pdf.MultiCell(0, 5, tr("Doctor: "+a.DoctorName), "", "L", false)
+		}
+		if a.Diagnostics != "" {
+			pdf.MultiCell(0, 5, tr("Diagnostics: "+a.Diagnostics), "", "L", false)
+		}
+		if a.Treatment != "" {
+			pdf.MultiCell(0, 5, tr("Treatment: "+a.Treatment), "", "L", false)
+		}
+		if a.DeletedAt != nil {
+			pdf.MultiCell(0, 5, tr("Deleted "+local(*a.DeletedAt)), "", "L", false)
+		}
+		pdf.Ln(2)
+	}
+	pdf.Ln(2)
+
+	section(pdf, family, tr, "Charges")
+	pdf.SetFont(family, "", 10)
+	pdf.CellFormat(0, 6, tr(fmt.Sprintf("Total, deleted appointments excluded: %d", total)), "", 1, "L", false, 0, "")
+
+	return pdf.Output(w)
+}
+
+func section(pdf *gofpdf.Fpdf, family string, tr func(string) string, title string) {
+	pdf.SetFont(family, "B", 12)
+	pdf.CellFormat(0, 8, tr(title), "B", 1, "L", false, 0, "")
+	pdf.Ln(2)
+}
@@ -0,0 +1,192 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/xuri/excelize/v2"
+)
+
+// Formats of the tables
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ContentType returns the media type of a table format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Table writes rows of cells one by one. A cell is a string, an int, a
+// time.Time shown in the clinic time zone or a civil.Date, zero times and
+// dates are left empty.
+type Table interface {
+	Write(cells ...interface{}) error
+	// Close finishes the file, an XLSX file is only written to the
+	// destination then
+	Close() error
+	// Discard drops a table that cannot be finished and its temporary
+	// files
+	Discard()
+}
+
+// NewTable starts a table with the given header, times are shown in loc
+func NewTable(w io.Writer, format string, header []string, loc *time.Location) (Table, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVTable(w, header, loc)
+	case FormatXLSX:
+		return newXLSXTable(w, header, loc)
+	}
+	return nil, errors.New("format must be csv or xlsx")
+}
+
+type csvTable struct {
+	writer *csv.Writer
+	loc    *time.Location
+	record []string
+}
+
+func newCSVTable(w io.Writer, header []string, loc *time.Location) (*csvTable, error) {
+	// the byte order mark makes Excel read the file as UTF-8
+	if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+		return nil, err
+	}
+	t := &csvTable{writer: csv.NewWriter(w), loc: loc}
+	if err := t.writer.Write(header); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *csvTable) Write(cells ...interface{}) error {
+	t.record = t.record[:0]
+	for _, cell := range cells {
+		var value string
+		switch v := cell.(type) {
+		case string:
+			value = v
+		case int:
+			value = strconv.Itoa(v)
+		case time.Time:
+			if !v.IsZero() {
+				value = v.In(t.loc).Format("2006-01-02 15:04")
+			}
+		case civil.Date:
+			if !v.IsZero() {
+				value = v.String()
+			}
+		default:
+			value = fmt.Sprint(v)
+		}
+		t.record = append(t.record, value)
+	}
+	return t.writer.Write(t.record)
+}
+
+func (t *csvTable) Close() error {
+	t.writer.Flush()
+	return t.writer.Error()
+}
+
+func (t *csvTable) Discard() {}
+
+const sheetName = "Sheet1"
+
+// xlsxTable keeps the rows in the stream writer of excelize, which moves
+// them to a temporary file once they get many
+type xlsxTable struct {
+	w        io.Writer
+	file     *excelize.File
+	stream   *excelize.StreamWriter
+	loc      *time.Location
+	row      int
+	dateTime int
+	date     int
+}
+
+func newXLSXTable(w io.Writer, header []string, loc *time.Location) (*xlsxTable, error) {
+	file := excelize.NewFile()
+	t := &xlsxTable{w: w, file: file, loc: loc, row: 1}
+
+	var err error
+	dateTime, date := "dd.mm.yyyy hh:mm", "dd.mm.yyyy"
+	if t.dateTime, err = file.NewStyle(&excelize.Style{CustomNumFmt: &dateTime}); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if t.date, err = file.NewStyle(&excelize.Style{CustomNumFmt: &date}); err != nil {
+		file.Close()
+		return nil, err
+	}
+	bold, err := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if t.stream, err = file.NewStreamWriter(sheetName); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	cells := make([]interface{}, len(header))
+	for i, title := range header {
+		cells[i] = excelize.Cell{StyleID: bold, Value: title}
+	}
+	if err = t.stream.SetRow("A1", cells, excelize.RowOpts{StyleID: bold}); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *xlsxTable) Write(cells ...interface{}) error {
+	values := make([]interface{}, len(cells))
+	for i, cell := range cells {
+		switch v := cell.(type) {
+		case time.Time:
+			if !v.IsZero() {
+				values[i] = excelize.Cell{StyleID: t.dateTime, Value: wallClock(v.In(t.loc))}
+			}
+		case civil.Date:
+			if !v.IsZero() {
+				values[i] = excelize.Cell{StyleID: t.date, Value: v.In(time.UTC)}
+			}
+		default:
+			values[i] = v
+		}
+	}
+	t.row++
+	axis, err := excelize.CoordinatesToCellName(1, t.row)
+	if err != nil {
+		return err
+	}
+	return t.stream.SetRow(axis, values)
+}
+
+func (t *xlsxTable) Close() error {
+	defer t.file.Close()
+	if err := t.stream.Flush(); err != nil {
+		return err
+	}
+	return t.file.Write(t.w)
+}
+
+func (t *xlsxTable) Discard() {
+	t.file.Close()
+}
+
+// wallClock returns t with its clock reading kept and the zone dropped,
+// Excel cells have no time zone
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
@@ -0,0 +1,110 @@
+package export
+
+import "strings"
+
+// Columns of the exported tables
+const (
+	ColumnId          = "id"
+	ColumnName        = "name"
+	ColumnLastName    = "last_name"
+	ColumnFatherName  = "father_name"
+	ColumnPhoneNumber = "phone_number"
+	ColumnAddress     = "address"
+	ColumnBirthDate   = "birth_date"
+	ColumnCreatedAt   = "created_at"
+	ColumnDate        = "date"
+	ColumnClient      = "client"
+	ColumnDoctor      = "doctor"
+	ColumnDiagnostics = "diagnostics"
+	ColumnTreatment   = "treatment"
+	ColumnAmount      = "amount"
+	ColumnStatus      = "status"
+	ColumnTotal       = "total"
+)
+
+// DefaultLanguage is used for languages without titles
+const DefaultLanguage = "en"
+
+var titles = map[string]map[string]string{
+	"en": {
+		ColumnId:          "Id",
+		ColumnName:        "Name",
+		ColumnLastName:    "Last name",
+		ColumnFatherName:  "Father name",
+		ColumnPhoneNumber: "Phone number",
+		ColumnAddress:     "Address",
+		ColumnBirthDate:   "Birth date",
+		ColumnCreatedAt:   "Added",
+		ColumnDate:        "Date",
+		ColumnClient:      "Client",
+		ColumnDoctor:      "Doctor",
+		ColumnDiagnostics: "Diagnostics",
+		ColumnTreatment:   "Treatment",
+		ColumnAmount:      "Amount",
+		ColumnStatus:      "Status",
+		ColumnTotal:       "Total",
+	},
+	"ru": {
+		ColumnId:          "Id",
+		ColumnName:        "Имя",
+		ColumnLastName:    "Фамилия",
+		ColumnFatherName:  "Отчество",
+		ColumnPhoneNumber: "Телефон",
+		ColumnAddress:     "Адрес",
+		ColumnBirthDate:   "Дата рождения",
+		ColumnCreatedAt:   "Добавлен",
+		ColumnDate:        "Дата",
+		ColumnClient:      "Пациент",
+		ColumnDoctor:      "Врач",
+		ColumnDiagnostics: "Диагноз",
+		ColumnTreatment:   "Лечение",
+		ColumnAmount:      "Сумма",
+		ColumnStatus:      "Статус",
+		ColumnTotal:       "Итого",
+	},
+	"uz": {
+		ColumnId:          "Id",
+		ColumnName:        "Ism",
+		ColumnLastName:    "Familiya",
+		ColumnFatherName:  "Otasining ismi",
+		ColumnPhoneNumber: "Telefon",
+		ColumnAddress:     "Manzil",
+		ColumnBirthDate:   "Tug'ilgan sana",
+		ColumnCreatedAt:   "Qo'shilgan",
+		ColumnDate:        "Sana",
+		ColumnClient:      "Bemor",
+		ColumnDoctor:      "Shifokor",
+		ColumnDiagnostics: "Tashxis",
+		ColumnTreatment:   "Davolash",
+		ColumnAmount:      "Summa",
+		ColumnStatus:      "Holat",
+		ColumnTotal:       "Jami",
+	},
+}
+
+// Language picks the first known language of an Accept-Language header
+// or of a plain language code
+func Language(accept string) string {
+	for _, tag := range strings.Split(accept, ",") {
+		tag, _, _ = strings.Cut(tag, ";")
+		tag, _, _ = strings.Cut(strings.TrimSpace(tag), "-")
+		tag = strings.ToLower(tag)
+		if _, ok := titles[tag]; ok {
+			return tag
+		}
+	}
+	return DefaultLanguage
+}
+
+// Titles returns the titles of columns in lang
+func Titles(lang string, columns ...string) []string {
+	dict, ok := titles[lang]
+	if !ok {
+		dict = titles[DefaultLanguage]
+	}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = dict[column]
+	}
+	return header
+}
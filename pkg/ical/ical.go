@@ -0,0 +1,166 @@
+// Package ical writes iCalendar (RFC 5545) data for calendar apps that
+// subscribe to or sync the appointment schedule.
+package ical
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Event statuses
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+const (
+	prodId    = "-//dentist//schedule//EN"
+	utcLayout = "20060102T150405Z"
+)
+
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Status      string
+	// Stamp is when the data was produced, DTSTAMP
+	Stamp time.Time
+}
+
+// Calendar is a whole feed. RefreshInterval tells subscribers how often to
+// fetch it again.
+type Calendar struct {
+	Name            string
+	TimeZone        string
+	RefreshInterval time.Duration
+	Events          []*Event
+}
+
+// Write writes cal as one VCALENDAR
+func Write(w io.Writer, cal *Calendar) error {
+	l := &lineWriter{w: w}
+	l.line("BEGIN:VCALENDAR")
+	l.line("VERSION:2.0")
+	l.line("PRODID:" + prodId)
+	l.line("CALSCALE:GREGORIAN")
+	l.line("METHOD:PUBLISH")
+	if cal.Name != "" {
+		l.line("X-WR-CALNAME:" + escape(cal.Name))
+	}
+	if cal.TimeZone != "" {
+		l.line("X-WR-TIMEZONE:" + cal.TimeZone)
+	}
+	if cal.RefreshInterval > 0 {
+		interval := duration(cal.RefreshInterval)
+		l.line("REFRESH-INTERVAL;VALUE=DURATION:" + interval)
+		l.line("X-PUBLISHED-TTL:" + interval)
+	}
+	for _, ev := range cal.Events {
+		writeEvent(l, ev)
+	}
+	l.line("END:VCALENDAR")
+	return l.err
+}
+
+// WriteEvent writes ev alone in a VCALENDAR, as CalDAV serves it
+func WriteEvent(w io.Writer, ev *Event) error {
+	l := &lineWriter{w: w}
+	l.line("BEGIN:VCALENDAR")
+	l.line("VERSION:2.0")
+	l.line("PRODID:" + prodId)
+	writeEvent(l, ev)
+	l.line("END:VCALENDAR")
+	return l.err
+}
+
+// ETag returns a strong entity tag of ev that changes with its content
+func ETag(ev *Event) string {
+	h := sha256.New()
+	stamp := ev.Stamp
+	ev.Stamp = time.Time{}
+	var b strings.Builder
+	writeEvent(&lineWriter{w: &b}, ev)
+	ev.Stamp = stamp
+	h.Write([]byte(b.String()))
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+func writeEvent(l *lineWriter, ev *Event) {
+	l.line("BEGIN:VEVENT")
+	l.line("UID:" + ev.UID)
+	if !ev.Stamp.IsZero() {
+		l.line("DTSTAMP:" + ev.Stamp.UTC().Format(utcLayout))
+	}
+	l.line("DTSTART:" + ev.Start.UTC().Format(utcLayout))
+	l.line("DTEND:" + ev.End.UTC().Format(utcLayout))
+	l.line("SUMMARY:" + escape(ev.Summary))
+	if ev.Description != "" {
+		l.line("DESCRIPTION:" + escape(ev.Description))
+	}
+	if ev.Status != "" {
+		l.line("STATUS:" + ev.Status)
+	}
+	l.line("TRANSP:OPAQUE")
+	l.line("END:VEVENT")
+}
+
+// escape escapes a TEXT value
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// duration formats d as an RFC 5545 duration, in whole minutes
+func duration(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	return "PT" + itoa(minutes) + "M"
+}
+
+func itoa(n int) string {
+	if n == 0 {
+		return "0"
+	}
+	var b []byte
+	for ; n > 0; n /= 10 {
+		b = append([]byte{byte('0' + n%10)}, b...)
+	}
+	return string(b)
+}
+
+// lineWriter ends lines with CRLF and folds them at 75 octets without
+// splitting a character
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (l *lineWriter) line(s string) {
+	if l.err != nil {
+		return
+	}
+	var b strings.Builder
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// the leading space of a continuation line counts
+		limit = 74
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+	_, l.err = io.WriteString(l.w, b.String())
+}
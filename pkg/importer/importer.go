@@ -0,0 +1,500 @@
+// Package importer loads clients and appointments kept in spreadsheets.
+// Every row is validated and matched against the stored clients first, the
+// valid ones are then inserted in batches, one transaction each.
+package importer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+)
+
+// Fields the columns of a sheet are mapped to
+const (
+	FieldClientId    = "client_id"
+	FieldName        = "name"
+	FieldLastName    = "last_name"
+	FieldFatherName  = "father_name"
+	FieldPhoneNumber = "phone_number"
+	FieldAddress     = "address"
+	FieldBirthDate   = "birth_date"
+	FieldDate        = "date"
+	FieldTime        = "time"
+	FieldDiagnostics = "diagnostics"
+	FieldTreatment   = "treatment"
+	FieldAmount      = "amount"
+	FieldStatus      = "status"
+)
+
+// DefaultBatchSize is used when Options.BatchSize is not set
+const DefaultBatchSize = 500
+
+// maxProblems bounds the problems kept of one run
+const maxProblems = 10000
+
+var clientFields = []string{
+	FieldName,
+	FieldLastName,
+	FieldFatherName,
+	FieldPhoneNumber,
+	FieldAddress,
+	FieldBirthDate,
+}
+
+var appointmentFields = append([]string{
+	FieldClientId,
+	FieldDate,
+	FieldTime,
+	FieldDiagnostics,
+	FieldTreatment,
+	FieldAmount,
+	FieldStatus,
+}, clientFields...)
+
+// headers are the column titles recognized without a mapping, compared
+// after normalize
+var headers = map[string][]string{
+	FieldClientId:    {"client id", "patient id"},
+	FieldName:        {"name", "first name", "имя", "ism"},
+	FieldLastName:    {"last name", "surname", "фамилия", "familiya"},
+	FieldFatherName:  {"father name", "patronymic", "middle name", "отчество", "otasining ismi"},
+	FieldPhoneNumber: {"phone number", "phone", "telephone", "телефон", "номер телефона", "telefon"},
+	FieldAddress:     {"address", "адрес", "manzil"},
+	FieldBirthDate:   {"birth date", "birthday", "date of birth", "дата рождения", "tug'ilgan sana"},
+	FieldDate:        {"date", "appointment date", "visit date", "дата", "дата приема", "дата приёма", "sana"},
+	FieldTime:        {"time", "время", "vaqt"},
+	FieldDiagnostics: {"diagnostics", "diagnosis", "диагноз", "tashxis"},
+	FieldTreatment:   {"treatment", "лечение", "davolash"},
+	FieldAmount:      {"amount", "sum", "price", "сумма", "стоимость", "summa", "narx"},
+	FieldStatus:      {"status", "статус", "holat"},
+}
+
+// Options of a run. Mapping gives the column title of a field when the
+// sheet does not use one of the known titles.
+type Options struct {
+	Kind      string
+	FileName  string
+	Mapping   map[string]string
+	DryRun    bool
+	BatchSize int
+	Location  *time.Location
+}
+
+// MappingError is returned when the columns of the sheet cannot be mapped,
+// no row is looked at then
+type MappingError struct {
+	Message string
+}
+
+func (e *MappingError) Error() string {
+	return e.Message
+}
+
+// Run imports the rows of sheet, or only checks them when opts.DryRun is
+// set. The run is returned even with an error, it then tells how far the
+// import got: the batches counted as imported are committed.
+func Run(ctx context.Context, store repo.NewImportI, sheet *Sheet, opts Options) (*repo.ImportRun, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	columns, err := mapColumns(sheet.Header, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := store.ClientKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &run{
+		store:   store,
+		sheet:   sheet,
+		opts:    opts,
+		columns: columns,
+		clients: newClientIndex(keys),
+		rows:    map[string]int{},
+		booked:  map[string]bool{},
+		now:     time.Now(),
+		result: &repo.ImportRun{
+			Id:       uuid.NewString(),
+			Kind:     opts.Kind,
+			FileName: opts.FileName,
+			DryRun:   opts.DryRun,
+			Header:   sheet.Header,
+		},
+	}
+
+	for i, cells := range sheet.Rows {
+		if blank(cells) {
+			continue
+		}
+		r.result.Total++
+		r.add(i+2, cells)
+		if len(r.batch) >= opts.BatchSize {
+			if err = r.flush(ctx); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = r.flush(ctx)
+	}
+	sort.Slice(r.result.Problems, func(i, j int) bool {
+		return r.result.Problems[i].Row < r.result.Problems[j].Row
+	})
+
+	return r.result, err
+}
+
+// mapColumns returns the column index of every field found in header
+func mapColumns(header []string, opts Options) (map[string]int, error) {
+	var fields []string
+	switch opts.Kind {
+	case repo.ImportClients:
+		fields = clientFields
+	case repo.ImportAppointments:
+		fields = appointmentFields
+	default:
+		return nil, &MappingError{Message: "kind must be clients or appointments"}
+	}
+
+	titles := map[string]int{}
+	for i, title := range header {
+		if _, ok := titles[normalize(title)]; !ok {
+			titles[normalize(title)] = i
+		}
+	}
+
+	columns := map[string]int{}
+	for field, title := range opts.Mapping {
+		if !contains(fields, field) {
+			return nil, &MappingError{Message: fmt.Sprintf("unknown field %q for %s", field, opts.Kind)}
+		}
+		i, ok := titles[normalize(title)]
+		if !ok {
+			return nil, &MappingError{Message: fmt.Sprintf("column %q of field %s is not in the file", title, field)}
+		}
+		columns[field] = i
+	}
+	for _, field := range fields {
+		if _, ok := columns[field]; ok {
+			continue
+		}
+		for _, title := range append([]string{normalize(field)}, headers[field]...) {
+			if i, ok := titles[title]; ok {
+				columns[field] = i
+				break
+			}
+		}
+	}
+
+	_, hasName := columns[FieldName]
+	switch {
+	case opts.Kind == repo.ImportClients && !hasName:
+		return nil, &MappingError{Message: "no column for the name"}
+	case opts.Kind == repo.ImportAppointments:
+		if _, ok := columns[FieldDate]; !ok {
+			return nil, &MappingError{Message: "no column for the date"}
+		}
+		_, hasId := columns[FieldClientId]
+		_, hasPhone := columns[FieldPhoneNumber]
+		if !hasId && !hasName && !hasPhone {
+			return nil, &MappingError{Message: "no column to find the client by: client_id, name or phone_number"}
+		}
+	}
+
+	return columns, nil
+}
+
+// normalize makes column titles comparable
+func normalize(title string) string {
+	title = strings.ToLower(strings.TrimSpace(title))
+	title = strings.NewReplacer("_", " ", "-", " ", ".", " ").Replace(title)
+	return strings.Join(strings.Fields(title), " ")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func blank(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// row is a valid row waiting in the batch
+type row struct {
+	number      int
+	cells       []string
+	client      *repo.Client
+	appointment *repo.Appointment
+}
+
+type run struct {
+	store   repo.NewImportI
+	sheet   *Sheet
+	opts    Options
+	columns map[string]int
+	clients *clientIndex
+	// rows holds the row of every client created from the file
+	rows map[string]int
+	// booked holds the appointments of the file so far, by bookingKey
+	booked map[string]bool
+	now    time.Time
+	batch  []*row
+	result *repo.ImportRun
+}
+
+func (r *run) cell(cells []string, field string) string {
+	i, ok := r.columns[field]
+	if !ok || i >= len(cells) {
+		return ""
+	}
+	return strings.TrimSpace(cells[i])
+}
+
+// add validates a row and queues it, or records why it is left out
+func (r *run) add(number int, cells []string) {
+	var errs []string
+	client, clientErrs := r.parseClient(cells)
+	errs = append(errs, clientErrs...)
+
+	if r.opts.Kind == repo.ImportClients {
+		if len(errs) > 0 {
+			r.problem(number, cells, repo.RowError, errs)
+			return
+		}
+		if match := r.clients.match(client); match != "" {
+			duplicateOf := "client " + match
+			if row, ok := r.rows[match]; ok {
+				duplicateOf = fmt.Sprintf("row %d", row)
+			}
+			r.problem(number, cells, repo.RowDuplicate, []string{"same phone number, or name and birth date, as " + duplicateOf})
+			return
+		}
+		client.Id = uuid.NewString()
+		r.clients.add(client)
+		r.rows[client.Id] = number
+		r.batch = append(r.batch, &row{number: number, cells: cells, client: client})
+		return
+	}
+
+	appointment, appointmentErrs := r.parseAppointment(cells)
+	errs = append(errs, appointmentErrs...)
+
+	clientId := r.cell(cells, FieldClientId)
+	var newClient *repo.Client
+	switch {
+	case clientId != "":
+		if _, err := uuid.Parse(clientId); err != nil {
+			errs = append(errs, "client_id must be a uuid")
+		} else if !r.clients.exists(clientId) {
+			errs = append(errs, "no client with this client_id")
+		}
+	case client == nil:
+		errs = append(errs, "the row has no client_id, name or phone number of the client")
+	default:
+		clientId = r.clients.match(client)
+		if clientId == "" {
+			if client.Name == "" {
+				errs = append(errs, "the client is not found by phone number, or name and birth date, and there is no name to create it")
+			} else {
+				newClient = client
+			}
+		}
+	}
+	if len(errs) > 0 {
+		r.problem(number, cells, repo.RowError, errs)
+		return
+	}
+
+	if newClient != nil {
+		newClient.Id = uuid.NewString()
+		clientId = newClient.Id
+	}
+	appointment.Id = uuid.NewString()
+	appointment.ClientId = clientId
+	key := bookingKey(clientId, appointment.Date)
+	if r.booked[key] {
+		r.problem(number, cells, repo.RowDuplicate, []string{"the client has another appointment at this time in the file"})
+		return
+	}
+	r.booked[key] = true
+	if newClient != nil {
+		r.clients.add(newClient)
+	}
+	r.batch = append(r.batch, &row{number: number, cells: cells, client: newClient, appointment: appointment})
+}
+
+// parseClient reads the client fields, a row without any of them gives a
+// nil client
+func (r *run) parseClient(cells []string) (*repo.Client, []string) {
+	client := &repo.Client{
+		Name:        r.cell(cells, FieldName),
+		LastName:    r.cell(cells, FieldLastName),
+		FatherName:  r.cell(cells, FieldFatherName),
+		PhoneNumber: r.cell(cells, FieldPhoneNumber),
+		Address:     r.cell(cells, FieldAddress),
+	}
+
+	var errs []string
+	if birthDate := r.cell(cells, FieldBirthDate); birthDate != "" {
+		date, err := parseDate(birthDate, r.sheet.Excel)
+		switch {
+		case err != nil:
+			errs = append(errs, "birth_date: "+err.Error())
+		case date.In(r.opts.Location).After(r.now):
+			errs = append(errs, "birth_date is in the future")
+		default:
+			client.BirthDate = date
+		}
+	}
+	if client.PhoneNumber != "" && len(digits(client.PhoneNumber)) < 7 {
+		errs = append(errs, "phone_number has less than 7 digits")
+	}
+
+	if r.opts.Kind == repo.ImportClients {
+		if client.Name == "" {
+			errs = append(errs, "name is required")
+		}
+		return client, errs
+	}
+	if client.Name == "" && client.PhoneNumber == "" {
+		return nil, errs
+	}
+	return client, errs
+}
+
+func (r *run) parseAppointment(cells []string) (*repo.Appointment, []string) {
+	var (
+		appointment repo.Appointment
+		errs        []string
+	)
+
+	date, err := parseDateTime(r.cell(cells, FieldDate), r.cell(cells, FieldTime), r.sheet.Excel, r.opts.Location)
+	if err != nil {
+		errs = append(errs, "date: "+err.Error())
+	}
+	appointment.Date = date
+
+	if amount := r.cell(cells, FieldAmount); amount != "" {
+		if appointment.Amount, err = parseAmount(amount); err != nil {
+			errs = append(errs, "amount: "+err.Error())
+		}
+	}
+
+	appointment.Status = strings.ToLower(r.cell(cells, FieldStatus))
+	switch {
+	case appointment.Status == "" && date.Before(r.now):
+		appointment.Status = repo.StatusCompleted
+	case appointment.Status == "":
+		appointment.Status = repo.StatusScheduled
+	case !repo.ValidStatus(appointment.Status):
+		errs = append(errs, "unknown status "+appointment.Status)
+	}
+
+	appointment.Diagnostics = r.cell(cells, FieldDiagnostics)
+	appointment.Treatment = r.cell(cells, FieldTreatment)
+
+	return &appointment, errs
+}
+
+// flush checks the queued appointments against the stored ones and saves
+// the batch
+func (r *run) flush(ctx context.Context) error {
+	if len(r.batch) == 0 {
+		return nil
+	}
+	batch := r.batch
+	r.batch = nil
+
+	var keys []repo.AppointmentKey
+	for _, row := range batch {
+		if row.appointment != nil && row.client == nil {
+			keys = append(keys, repo.AppointmentKey{ClientId: row.appointment.ClientId, Date: row.appointment.Date})
+		}
+	}
+	existing, err := r.store.ExistingAppointments(ctx, keys)
+	if err != nil {
+		return err
+	}
+	stored := map[string]bool{}
+	for _, key := range existing {
+		stored[bookingKey(key.ClientId, key.Date)] = true
+	}
+
+	var (
+		rows         []*row
+		clients      []*repo.Client
+		appointments []*repo.Appointment
+	)
+	for _, row := range batch {
+		if row.appointment != nil && stored[bookingKey(row.appointment.ClientId, row.appointment.Date)] {
+			r.problem(row.number, row.cells, repo.RowDuplicate, []string{"the client already has an appointment at this time"})
+			continue
+		}
+		rows = append(rows, row)
+		if row.client != nil {
+			clients = append(clients, row.client)
+		}
+		if row.appointment != nil {
+			appointments = append(appointments, row.appointment)
+		}
+	}
+
+	if !r.opts.DryRun {
+		if err = r.store.ImportBatch(ctx, clients, appointments); err != nil {
+			for _, row := range rows {
+				r.problem(row.number, row.cells, repo.RowError, []string{"not saved, the import stopped here"})
+			}
+			return err
+		}
+	}
+	r.result.Imported += len(rows)
+	if r.opts.Kind == repo.ImportAppointments {
+		r.result.CreatedClients += len(clients)
+	}
+
+	return nil
+}
+
+func (r *run) problem(number int, cells []string, status string, errs []string) {
+	if status == repo.RowDuplicate {
+		r.result.Duplicates++
+	} else {
+		r.result.Failed++
+	}
+	if len(r.result.Problems) >= maxProblems {
+		return
+	}
+	values := make([]string, len(r.sheet.Header))
+	copy(values, cells)
+	r.result.Problems = append(r.result.Problems, &repo.ImportProblem{
+		Row:    number,
+		Status: status,
+		Errors: errs,
+		Values: values,
+	})
+}
+
+func bookingKey(clientId string, date time.Time) string {
+	return clientId + "|" + date.UTC().Format(time.RFC3339)
+}
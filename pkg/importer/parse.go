@@ -0,0 +1,195 @@
+package importer
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/storage/repo"
+	"github.com/xuri/excelize/v2"
+)
+
+// layouts are the date and time formats accepted in text cells, the day
+// comes before the month as written in the clinic
+var (
+	dateLayouts = []string{"2006-01-02", "02.01.2006", "2.1.2006", "02/01/2006", "2/1/2006", "02-01-2006"}
+	timeLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM"}
+)
+
+var errDate = errors.New("not a date, use YYYY-MM-DD or DD.MM.YYYY")
+
+// parseDate reads a date, in Excel files also a serial number
+func parseDate(value string, excel bool) (civil.Date, error) {
+	if excel {
+		if serial, err := strconv.ParseFloat(value, 64); err == nil {
+			t, err := excelize.ExcelDateToTime(serial, false)
+			if err != nil {
+				return civil.Date{}, errDate
+			}
+			return civil.DateOf(t), nil
+		}
+	}
+	date, _ := splitTime(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return civil.DateOf(t), nil
+		}
+	}
+	return civil.Date{}, errDate
+}
+
+// parseDateTime reads the date of an appointment as a wall clock time in
+// loc. The time is taken from the time column when there is one, else from
+// the date cell itself, else it is midnight.
+func parseDateTime(date, clock string, excel bool, loc *time.Location) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, errors.New("is required")
+	}
+	day, err := parseDate(date, excel)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if clock == "" {
+		if serial, err := strconv.ParseFloat(date, 64); excel && err == nil {
+			clock = strconv.FormatFloat(serial-math.Floor(serial), 'f', -1, 64)
+		} else {
+			_, clock = splitTime(date)
+		}
+	}
+	offset, err := parseClock(clock, excel)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Date(day.Year, day.Month, day.Day, 0, 0, 0, 0, loc).Add(offset), nil
+}
+
+// splitTime cuts "02.01.2006 15:04" into the date and the time
+func splitTime(value string) (string, string) {
+	value = strings.Replace(value, "T", " ", 1)
+	date, clock, _ := strings.Cut(value, " ")
+	return date, strings.TrimSpace(clock)
+}
+
+// parseClock returns the time of the day, in Excel files also a fraction
+// of the day
+func parseClock(clock string, excel bool) (time.Duration, error) {
+	if clock == "" {
+		return 0, nil
+	}
+	if fraction, err := strconv.ParseFloat(clock, 64); excel && err == nil && fraction >= 0 && fraction < 1 {
+		return (time.Duration(math.Round(fraction*24*60)) * time.Minute), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, strings.ToUpper(clock)); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+		}
+	}
+	return 0, errors.New("not a time, use HH:MM")
+}
+
+var grouped = regexp.MustCompile(`^\d{1,3}([,.]\d{3})+$`)
+
+// parseAmount reads a whole amount written as "150000", "150 000",
+// "150,000", "1.500.000" or "150000.00"
+func parseAmount(value string) (int, error) {
+	value = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\u00a0' || r == '\u202f' || r == '\'' {
+			return -1
+		}
+		return r
+	}, value)
+	if grouped.MatchString(value) {
+		value = strings.NewReplacer(",", "", ".", "").Replace(value)
+	}
+	value = strings.Replace(value, ",", ".", 1)
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, errors.New("not a number")
+	}
+	if amount < 0 || amount != math.Trunc(amount) || amount > math.MaxInt32 {
+		return 0, errors.New("must be a whole positive number")
+	}
+	return int(amount), nil
+}
+
+// digits returns the last 9 digits of a phone number, the same key as
+// clients_phone_digits_idx
+func digits(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	s := b.String()
+	if len(s) > 9 {
+		s = s[len(s)-9:]
+	}
+	return s
+}
+
+// clientIndex finds active clients the way the duplicate finder does:
+// by phone number, or by name and birth date
+type clientIndex struct {
+	ids    map[string]bool
+	phones map[string]string
+	names  map[string]string
+}
+
+func newClientIndex(keys []*repo.ClientKey) *clientIndex {
+	index := &clientIndex{
+		ids:    map[string]bool{},
+		phones: map[string]string{},
+		names:  map[string]string{},
+	}
+	for _, key := range keys {
+		index.ids[key.Id] = true
+		if len(key.Phone) >= 7 {
+			if _, ok := index.phones[key.Phone]; !ok {
+				index.phones[key.Phone] = key.Id
+			}
+		}
+		if !key.BirthDate.IsZero() {
+			index.names[nameKey(key.Name, key.BirthDate)] = key.Id
+		}
+	}
+	return index
+}
+
+func (x *clientIndex) exists(id string) bool {
+	return x.ids[id]
+}
+
+// match returns the id of the client c is, or ""
+func (x *clientIndex) match(c *repo.Client) string {
+	if phone := digits(c.PhoneNumber); len(phone) >= 7 {
+		if id, ok := x.phones[phone]; ok {
+			return id
+		}
+	}
+	if !c.BirthDate.IsZero() {
+		return x.names[nameKey(c.Name+" "+c.LastName, c.BirthDate)]
+	}
+	return ""
+}
+
+func (x *clientIndex) add(c *repo.Client) {
+	x.ids[c.Id] = true
+	if phone := digits(c.PhoneNumber); len(phone) >= 7 {
+		x.phones[phone] = c.Id
+	}
+	if !c.BirthDate.IsZero() {
+		x.names[nameKey(c.Name+" "+c.LastName, c.BirthDate)] = c.Id
+	}
+}
+
+func nameKey(name string, birthDate civil.Date) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ") + "|" + birthDate.String()
+}
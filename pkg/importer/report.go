@@ -0,0 +1,31 @@
+package importer
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/dentist/storage/repo"
+)
+
+// WriteProblems writes the rows of run that were not imported as CSV: the
+// row number, the status and the errors, then the cells as they were. It
+// starts with a byte order mark so that Excel reads it as UTF-8.
+func WriteProblems(w io.Writer, run *repo.ImportRun) error {
+	if _, err := io.WriteString(w, "\xef\xbb\xbf"); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append([]string{"row", "status", "errors"}, run.Header...)); err != nil {
+		return err
+	}
+	for _, p := range run.Problems {
+		record := append([]string{strconv.Itoa(p.Row), p.Status, strings.Join(p.Errors, "; ")}, p.Values...)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Formats of the files that can be imported
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ErrUnknownFormat is returned for files that are neither CSV nor XLSX
+var ErrUnknownFormat = errors.New("only .csv and .xlsx files can be imported")
+
+// Sheet is a spreadsheet read into memory. Rows[i] is the spreadsheet row
+// i+2, the first one being the header. Excel tells that numeric cells are
+// raw values, so dates come as serial numbers.
+type Sheet struct {
+	Header []string
+	Rows   [][]string
+	Excel  bool
+}
+
+// FormatOf returns the format of a file by its name
+func FormatOf(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv", ".txt":
+		return FormatCSV
+	case ".xlsx", ".xlsm":
+		return FormatXLSX
+	}
+	return ""
+}
+
+// Read reads the first sheet of an XLSX file or a CSV file separated by
+// commas, semicolons or tabs, whichever the header uses most
+func Read(r io.Reader, format string) (*Sheet, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatXLSX:
+		return readXLSX(r)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func readCSV(r io.Reader) (*Sheet, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = ','
+	for _, sep := range []rune{';', '\t'} {
+		if bytes.Count(firstLine, []byte(string(sep))) > bytes.Count(firstLine, []byte(string(reader.Comma))) {
+			reader.Comma = sep
+		}
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return newSheet(records, false)
+}
+
+func readXLSX(r io.Reader) (*Sheet, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("the workbook has no sheets")
+	}
+	records, err := f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, err
+	}
+	return newSheet(records, true)
+}
+
+func newSheet(records [][]string, excel bool) (*Sheet, error) {
+	if len(records) == 0 {
+		return nil, errors.New("the file is empty")
+	}
+	return &Sheet{
+		Header: records[0],
+		Rows:   records[1:],
+		Excel:  excel,
+	}, nil
+}
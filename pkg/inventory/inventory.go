@@ -0,0 +1,43 @@
+// Package inventory takes the materials of procedures out of stock. When
+// an appointment is completed, Subscriber consumes the bill of materials
+// of its treatment, once however often the event is handed.
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+)
+
+// Subscriber consumes the materials of completed appointments. An
+// appointment completed and reopened keeps its consumption, corrections
+// are made with adjustments.
+func Subscriber(store repo.NewInventoryI, log logger.Logger) events.Handler {
+	return func(ctx context.Context, ev *repo.DomainEvent) error {
+		if ev.AggregateType != events.AggregateAppointment || ev.Type == events.AppointmentDeleted {
+			return nil
+		}
+		var data events.AppointmentData
+		if err := json.Unmarshal(ev.Payload, &data); err != nil {
+			return err
+		}
+		if data.Status != repo.StatusCompleted {
+			return nil
+		}
+
+		consumed, err := store.Consume(ctx, data.Id, data.Treatment)
+		if err != nil {
+			return err
+		}
+		if consumed {
+			log.Info("inventory: materials consumed",
+				logger.String("appointment_id", data.Id),
+				logger.String("treatment", data.Treatment),
+			)
+		}
+		return nil
+	}
+}
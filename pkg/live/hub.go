@@ -0,0 +1,230 @@
+// Package live pushes the changes of appointments to the open schedule
+// boards. Every instance LISTENs for the events written to the outbox, so
+// a change made on any instance reaches the boards connected to all of
+// them.
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/dentist/config"
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/lib/pq"
+)
+
+const (
+	// batchSize is how many events are read from the outbox at a time
+	batchSize = 100
+	// buffer is how many events a board may be behind before it is
+	// dropped, it reconnects and catches up from its last event id
+	buffer = 64
+)
+
+// Event is an appointment event of the outbox
+type Event struct {
+	*repo.DomainEvent
+	Appointment events.AppointmentData
+}
+
+// Decode reads the appointment of an outbox event
+func Decode(ev *repo.DomainEvent) (*Event, error) {
+	e := &Event{DomainEvent: ev}
+	if err := json.Unmarshal(ev.Payload, &e.Appointment); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Filter is what a board shows, the appointments of a day, of one doctor
+// when DoctorId is set
+type Filter struct {
+	From     time.Time
+	To       time.Time
+	DoctorId string
+}
+
+// Match reports whether the event concerns the board, an appointment
+// moved away from it included
+func (f Filter) Match(e *Event) bool {
+	a := e.Appointment
+	if f.shows(a.Date, a.DoctorId) {
+		return true
+	}
+	date, doctor := a.Date, a.DoctorId
+	if a.PreviousDate != nil {
+		date = *a.PreviousDate
+	}
+	if a.PreviousDoctorId != "" {
+		doctor = a.PreviousDoctorId
+	}
+	return f.shows(date, doctor)
+}
+
+func (f Filter) shows(date time.Time, doctor string) bool {
+	if date.Before(f.From) || !date.Before(f.To) {
+		return false
+	}
+	return f.DoctorId == "" || f.DoctorId == doctor
+}
+
+// Subscription gets the events that match its filter on C. C is closed
+// when the board fell too far behind or the hub stopped.
+type Subscription struct {
+	C      <-chan *Event
+	c      chan *Event
+	filter Filter
+}
+
+// Hub fans the events of the outbox out to the subscriptions
+type Hub struct {
+	cfg   *config.Config
+	store repo.NewOutboxI
+	dsn   string
+	log   logger.Logger
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+	last   int64
+}
+
+func NewHub(cfg *config.Config, store repo.NewOutboxI, dsn string, log logger.Logger) *Hub {
+	return &Hub{
+		cfg:   cfg,
+		store: store,
+		dsn:   dsn,
+		log:   log,
+		subs:  map[*Subscription]struct{}{},
+	}
+}
+
+// Subscribe starts sending the events that match filter, from the next
+// one the hub reads
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	c := make(chan *Event, buffer)
+	sub := &Subscription{C: c, c: c, filter: filter}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.c)
+		return sub
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe stops sending to sub
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.c)
+	}
+}
+
+// Run reads the outbox whenever an event is notified, and every
+// BoardPollInterval in case a notification was lost while the listening
+// connection was down, until ctx is cancelled
+func (h *Hub) Run(ctx context.Context) {
+	if h.cfg.BoardPollInterval <= 0 {
+		h.log.Error("live: BOARD_POLL_INTERVAL must be positive, boards get no events")
+		return
+	}
+	defer h.Close()
+
+	listener := pq.NewListener(h.dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			h.log.Warn("live: listening connection failed", logger.Error(err))
+		}
+	})
+	defer listener.Close()
+	if err := listener.Listen(repo.OutboxChannel); err != nil {
+		// the listener keeps reconnecting and listens once it is back
+		h.log.Warn("live: failed to listen, polling meanwhile", logger.Error(err))
+	}
+
+	ticker := time.NewTicker(h.cfg.BoardPollInterval)
+	defer ticker.Stop()
+
+	started := false
+	for {
+		if !started {
+			last, err := h.store.LastSeq(ctx)
+			if err == nil {
+				h.last, started = last, true
+			} else if ctx.Err() == nil {
+				h.log.Error("live: failed to read the outbox", logger.Error(err))
+			}
+		} else {
+			h.read(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		// a nil notification tells the connection was re-established
+		case <-listener.Notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// read sends the events after the last one read
+func (h *Hub) read(ctx context.Context) {
+	for {
+		list, err := h.store.Events(ctx, h.last, events.AppointmentTypes, batchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				h.log.Error("live: failed to read the outbox", logger.Error(err))
+			}
+			return
+		}
+		for _, ev := range list {
+			h.last = ev.Seq
+			e, err := Decode(ev)
+			if err != nil {
+				h.log.Error("live: failed to decode event", logger.String("event_id", ev.Id), logger.Error(err))
+				continue
+			}
+			h.send(e)
+		}
+		if len(list) < batchSize {
+			return
+		}
+	}
+}
+
+func (h *Hub) send(e *Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.c <- e:
+		default:
+			delete(h.subs, sub)
+			close(sub.c)
+		}
+	}
+}
+
+// Close ends every subscription, and the ones made later at once, so the
+// streams end when the server shuts down
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subs {
+		delete(h.subs, sub)
+		close(sub.c)
+	}
+}
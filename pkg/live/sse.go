@@ -0,0 +1,52 @@
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// envelope is the data of an event on the stream, shaped as the body of
+// a webhook delivery
+type envelope struct {
+	Id        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WriteEvent writes e as a Server-Sent Event, its seq is the event id a
+// reconnecting board sends back in Last-Event-ID
+func WriteEvent(w io.Writer, e *Event) error {
+	data, err := json.Marshal(envelope{
+		Id:        e.Id,
+		Type:      e.Type,
+		CreatedAt: e.CreatedAt,
+		Data:      json.RawMessage(e.Payload),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data)
+	return err
+}
+
+// WriteReset tells the board it missed events and has to load the day
+// again, id is where the stream goes on from
+func WriteReset(w io.Writer, id int64) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: reset\ndata: {}\n\n", id)
+	return err
+}
+
+// WriteRetry sets how long the browser waits before reconnecting
+func WriteRetry(w io.Writer, retry time.Duration) error {
+	_, err := fmt.Fprintf(w, "retry: %d\n\n", retry.Milliseconds())
+	return err
+}
+
+// WriteComment keeps the connection from being closed as idle by proxies
+func WriteComment(w io.Writer, comment string) error {
+	_, err := fmt.Fprintf(w, ": %s\n\n", comment)
+	return err
+}
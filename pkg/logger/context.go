@@ -0,0 +1,25 @@
+package logger
+
+import "context"
+
+type requestIDKey struct{}
+
+// ContextWithRequestID stores the request id so code below the http layer
+// can tag its log lines with it.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id stored in ctx, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// FromContext returns l tagged with the request id carried by ctx.
+func FromContext(ctx context.Context, l Logger) Logger {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return WithFields(l, String("request_id", id))
+	}
+	return l
+}
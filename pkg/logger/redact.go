@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// sensitiveKeys are field keys whose values are medical or personal data
+// and must never reach the logs in clear text.
+var sensitiveKeys = map[string]bool{
+	"diagnostics":  true,
+	"treatment":    true,
+	"phone":        true,
+	"phone_number": true,
+}
+
+// redactCore masks sensitive fields before they are encoded, so a careless
+// logger.String("phone_number", ...) anywhere in the code stays safe.
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c redactCore) With(fields []Field) zapcore.Core {
+	return redactCore{c.Core.With(redactFields(fields))}
+}
+
+func (c redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c redactCore) Write(entry zapcore.Entry, fields []Field) error {
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []Field) []Field {
+	var out []Field
+	for i, f := range fields {
+		if !sensitiveKeys[strings.ToLower(f.Key)] {
+			continue
+		}
+		if out == nil {
+			out = append([]Field(nil), fields...)
+		}
+		out[i] = String(f.Key, redact(f))
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// redact keeps the last two characters of phone numbers, which is enough
+// to tell records apart while debugging, and hides everything else.
+func redact(f Field) string {
+	if f.Type == zapcore.StringType && strings.Contains(strings.ToLower(f.Key), "phone") && len(f.String) > 2 {
+		return strings.Repeat("*", len(f.String)-2) + f.String[len(f.String)-2:]
+	}
+	return "[REDACTED]"
+}
@@ -33,8 +33,8 @@ func newZapLogger(level, timeFormat string) *zap.Logger {
 	consoleEncoder := zapcore.NewJSONEncoder(encoderCfg)
 
 	core := zapcore.NewTee(
-		zapcore.NewCore(consoleEncoder, consoleErrors, highPriority),
-		zapcore.NewCore(consoleEncoder, consoleInfos, lowPriority),
+		redactCore{zapcore.NewCore(consoleEncoder, consoleErrors, highPriority)},
+		redactCore{zapcore.NewCore(consoleEncoder, consoleInfos, lowPriority)},
 	)
 
 	logger := zap.New(core)
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeTimeout bounds the queries run on every scrape.
+const scrapeTimeout = 3 * time.Second
+
+// noShowWindow is how far back the no-show gauge looks.
+const noShowWindow = 30 * 24 * time.Hour
+
+// businessCollector queries the database at scrape time, so the gauges
+// are always current and nothing has to be updated from the handlers.
+type businessCollector struct {
+	appointments AppointmentCounter
+	loc          *time.Location
+	log          logger.Logger
+
+	today   *prometheus.Desc
+	noShows *prometheus.Desc
+}
+
+func newBusinessCollector(appointments AppointmentCounter, loc *time.Location, log logger.Logger) *businessCollector {
+	return &businessCollector{
+		appointments: appointments,
+		loc:          loc,
+		log:          log,
+		today: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "appointments", "today"),
+			"Appointments scheduled for today by status.",
+			[]string{"status"}, nil,
+		),
+		noShows: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "appointments", "no_shows_30d"),
+			"Appointments marked as no-show in the last 30 days.",
+			nil, nil,
+		),
+	}
+}
+
+func (b *businessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- b.today
+	ch <- b.noShows
+}
+
+func (b *businessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	// today is the clinic's day, not the server's
+	now := time.Now().In(b.loc)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, b.loc)
+
+	today, err := b.appointments.CountAppointmentsByStatus(ctx, dayStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		// skip the gauge instead of failing the whole scrape
+		b.log.Error("metrics: failed to count today's appointments", logger.Error(err))
+	} else {
+		for status, count := range today {
+			ch <- prometheus.MustNewConstMetric(b.today, prometheus.GaugeValue, float64(count), status)
+		}
+	}
+
+	recent, err := b.appointments.CountAppointmentsByStatus(ctx, now.Add(-noShowWindow), now)
+	if err != nil {
+		b.log.Error("metrics: failed to count no-shows", logger.Error(err))
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(b.noShows, prometheus.GaugeValue, float64(recent[repo.StatusNoShow]))
+}
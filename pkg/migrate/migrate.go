@@ -0,0 +1,274 @@
+// Package migrate applies the SQL migrations embedded in the binary.
+//
+// It keeps its state in the same schema_migrations table (one row with
+// version and dirty) that the golang-migrate CLI used before, so databases
+// migrated with the CLI continue from where they are.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// lockKey is the pg_advisory_lock id that keeps two instances from
+// migrating the same database at once.
+const lockKey = 4242_0001
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is one versioned step with both of its directions.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes the database against the known migrations.
+type Status struct {
+	Version int
+	Dirty   bool
+	Applied []Migration
+	Pending []Migration
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New reads every migration from fsys, which is usually migrations.FS.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, path.Join(".", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrator := &Migrator{db: db}
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrator.migrations = append(migrator.migrations, *m)
+	}
+	sort.Slice(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].Version < migrator.migrations[j].Version
+	})
+
+	return migrator, nil
+}
+
+// Up applies every pending migration, each in its own transaction.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		version, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if err = m.apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		version, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+			previous := 0
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err = m.apply(ctx, conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Force sets the recorded version and clears the dirty flag without
+// running any SQL, for recovering from a migration that failed halfway
+// under the old CLI.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err = setVersion(ctx, tx, version); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// Status reports the recorded version and which migrations are pending.
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	if err := ensureTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+	version, dirty, err := readVersion(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := Status{Version: version, Dirty: dirty}
+	for _, migration := range m.migrations {
+		if migration.Version <= version {
+			status.Applied = append(status.Applied, migration)
+		} else {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+
+	return &status, nil
+}
+
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey)
+
+	if err = ensureTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) current(ctx context.Context, conn *sql.Conn) (int, error) {
+	version, dirty, err := readVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("database is dirty at version %d, fix it by hand and run `migrate force %d`", version, version)
+	}
+
+	return version, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, query string, version int) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = setVersion(ctx, tx, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func ensureTable(ctx context.Context, db execQuerier) error {
+	_, err := db.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		dirty BOOLEAN NOT NULL
+	)`)
+
+	return err
+}
+
+func readVersion(ctx context.Context, db execQuerier) (int, bool, error) {
+	var (
+		version int
+		dirty   bool
+	)
+	err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
+
+func setVersion(ctx context.Context, tx *sql.Tx, version int) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if version <= 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)`, version)
+
+	return err
+}
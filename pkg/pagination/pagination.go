@@ -0,0 +1,131 @@
+// Package pagination parses list parameters and shapes list responses the
+// same way for every list endpoint.
+//
+// Two modes are supported: offset (page/offset + limit) for jumping to a
+// page number, and keyset (cursor + limit) for scrolling through large or
+// changing lists without skipped or repeated rows. The cursor is opaque to
+// clients; it holds the sort key and id of the last row returned.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidLimit  = errors.New("limit must be a positive integer")
+	ErrInvalidPage   = errors.New("page must be a positive integer")
+	ErrInvalidOffset = errors.New("offset must be a non-negative integer")
+	ErrInvalidCursor = errors.New("cursor is malformed")
+)
+
+// Cursor points right after the last row of the previous page. Key is the
+// sort column value of that row (empty when it was NULL), Id breaks ties.
+type Cursor struct {
+	Key string `json:"k"`
+	Id  string `json:"i"`
+}
+
+// Params is what a repository needs to fetch one page. Cursor is nil in
+// offset mode.
+type Params struct {
+	Limit  int
+	Offset int
+	Cursor *Cursor
+}
+
+// Page is the response envelope of every list endpoint.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPage builds the envelope, an empty list is sent as [] and not null.
+func NewPage[T any](items []T, total int, nextCursor string) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return Page[T]{Items: items, Total: total, NextCursor: nextCursor}
+}
+
+// Parse reads limit, page, offset and cursor from the query string.
+// Missing values fall back to the first page of DefaultLimit rows, a limit
+// above MaxLimit is lowered to MaxLimit.
+func Parse(c *gin.Context) (Params, error) {
+	params := Params{Limit: DefaultLimit}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return params, ErrInvalidLimit
+		}
+		params.Limit = min(limit, MaxLimit)
+	}
+
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := Decode(v)
+		if err != nil {
+			return params, err
+		}
+		params.Cursor = cursor
+		return params, nil
+	}
+
+	if v := c.Query("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return params, ErrInvalidOffset
+		}
+		params.Offset = offset
+	} else if v := c.Query("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return params, ErrInvalidPage
+		}
+		params.Offset = (page - 1) * params.Limit
+	}
+
+	return params, nil
+}
+
+// Encode turns a cursor into the opaque string handed to clients.
+func Encode(c Cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Decode parses a string produced by Encode.
+func Decode(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err = json.Unmarshal(raw, &c); err != nil || c.Id == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// Trim cuts the extra row a repository fetched (Limit+1) to learn whether
+// another page exists, and returns the cursor of the next page built with
+// key, or "" when this is the last one.
+func Trim[T any](items []T, limit int, key func(T) Cursor) ([]T, string) {
+	if len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+
+	return items, Encode(key(items[limit-1]))
+}
@@ -0,0 +1,269 @@
+// Package ratelimit throttles the API: token buckets limit the requests
+// of each client address, user, API key and route, and lockouts shut out
+// an address that keeps failing to authenticate. The limits are kept in
+// memory or, to be shared by several instances, in Postgres.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dentist/config"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/gin-gonic/gin"
+)
+
+// Stores of the limits
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+type Limiter struct {
+	store repo.NewRateLimitI
+	log   logger.Logger
+
+	// ip, user and key are nil when not limited
+	ip   *repo.RateLimit
+	user *repo.RateLimit
+	key  *repo.RateLimit
+	// routes are limited per address, by "METHOD /path" as routed
+	routes  map[string]repo.RateLimit
+	lockout repo.LockoutPolicy
+	sweep   time.Duration
+}
+
+// New reads the limits from cfg, store keeps them
+func New(cfg *config.Config, store repo.NewRateLimitI, log logger.Logger) (*Limiter, error) {
+	l := &Limiter{
+		store:  store,
+		log:    log,
+		routes: map[string]repo.RateLimit{},
+		lockout: repo.LockoutPolicy{
+			Attempts: cfg.LockoutAttempts,
+			Window:   cfg.LockoutWindow,
+			Duration: cfg.LockoutDuration,
+		},
+		sweep: cfg.RateLimitSweepInterval,
+	}
+
+	var err error
+	if l.ip, err = ParseRate(cfg.RateLimitIp); err != nil {
+		return nil, err
+	}
+	if l.user, err = ParseRate(cfg.RateLimitUser); err != nil {
+		return nil, err
+	}
+	if l.key, err = ParseRate(cfg.RateLimitKey); err != nil {
+		return nil, err
+	}
+
+	for _, pair := range strings.Split(cfg.RateLimitRoutes, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		route, rate, ok := strings.Cut(pair, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath {
+			return nil, fmt.Errorf("ratelimit: route limit %q is not METHOD /path=requests/period", pair)
+		}
+		limit, err := ParseRate(rate)
+		if err != nil {
+			return nil, err
+		}
+		if limit != nil {
+			l.routes[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = *limit
+		}
+	}
+
+	if l.lockout.Attempts > 0 && (l.lockout.Window <= 0 || l.lockout.Duration <= 0) {
+		return nil, fmt.Errorf("ratelimit: LOCKOUT_WINDOW and LOCKOUT_DURATION must be positive")
+	}
+	return l, nil
+}
+
+// ParseRate parses a limit such as "300/1m", 300 requests a minute. It
+// returns nil for an empty one, nothing is limited then.
+func ParseRate(s string) (*repo.RateLimit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return nil, fmt.Errorf("ratelimit: %q is not requests/period", s)
+	}
+	burst, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || burst < 1 {
+		return nil, fmt.Errorf("ratelimit: %q has no valid number of requests", s)
+	}
+	per, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || per <= 0 {
+		return nil, fmt.Errorf("ratelimit: %q has no valid period", s)
+	}
+	return &repo.RateLimit{Burst: burst, Per: per}, nil
+}
+
+// Middleware limits the requests of each client address, of each API
+// key and, on the routes given a limit, of each address on the route
+func (l *Limiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		if l.ip != nil && !l.take(c, "ip", "ip:"+ip, *l.ip) {
+			return
+		}
+		if credential := credentialOf(c); l.key != nil && credential != "" {
+			// the bucket is named after a hash, the credential itself is
+			// not kept
+			sum := sha256.Sum256([]byte(credential))
+			if !l.take(c, "key", "key:"+hex.EncodeToString(sum[:16]), *l.key) {
+				return
+			}
+		}
+		route := c.Request.Method + " " + c.FullPath()
+		if limit, ok := l.routes[route]; ok && !l.take(c, "route", "route:"+route+":"+ip, limit) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// User limits the requests of each authenticated user, it goes after the
+// authentication that sets the user under contextKey
+func (l *Limiter) User(contextKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user := c.GetString(contextKey); l.user != nil && user != "" && !l.take(c, "user", "user:"+user, *l.user) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// Lockout shuts out an address whose requests keep failing with one of
+// the failed statuses, such as 401 for a wrong token. The attempts of
+// each scope are counted apart, a request that succeeds forgets them.
+func (l *Limiter) Lockout(scope string, failed ...int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l.lockout.Attempts <= 0 {
+			c.Next()
+			return
+		}
+		key := "lockout:" + scope + ":" + c.ClientIP()
+
+		until, err := l.store.Locked(c.Request.Context(), key)
+		if err != nil {
+			// better let a request through than lock everyone out
+			logger.FromContext(c.Request.Context(), l.log).Error("ratelimit: failed to check lockout", logger.Error(err))
+		}
+		if !until.IsZero() {
+			l.deny(c, scope, time.Until(until))
+			return
+		}
+
+		c.Next()
+
+		// the request may be over, its answer counts all the same
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := c.Writer.Status()
+		for _, f := range failed {
+			if status != f {
+				continue
+			}
+			until, err = l.store.Fail(ctx, key, l.lockout)
+			if err != nil {
+				logger.FromContext(ctx, l.log).Error("ratelimit: failed to count failed attempt", logger.Error(err))
+			} else if !until.IsZero() {
+				logger.FromContext(ctx, l.log).Warn("ratelimit: address locked out",
+					logger.String("scope", scope),
+					logger.String("client_ip", c.ClientIP()),
+					logger.String("until", until.Format(time.RFC3339)),
+				)
+			}
+			return
+		}
+		if status < http.StatusBadRequest {
+			if err = l.store.Clear(ctx, key); err != nil {
+				logger.FromContext(ctx, l.log).Error("ratelimit: failed to clear failed attempts", logger.Error(err))
+			}
+		}
+	}
+}
+
+// Run drops the expired limits every sweep interval until ctx is
+// cancelled
+func (l *Limiter) Run(ctx context.Context) {
+	if l.sweep <= 0 {
+		l.log.Error("ratelimit: RATE_LIMIT_SWEEP_INTERVAL must be positive, expired limits are kept")
+		return
+	}
+
+	ticker := time.NewTicker(l.sweep)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := l.store.Sweep(ctx); err != nil {
+			l.log.Error("ratelimit: failed to sweep expired limits", logger.Error(err))
+		}
+	}
+}
+
+// take takes a request from the bucket of key, answering 429 itself when
+// it is empty. A store that fails lets the request through.
+func (l *Limiter) take(c *gin.Context, scope, key string, limit repo.RateLimit) bool {
+	result, err := l.store.Take(c.Request.Context(), key, limit)
+	if err != nil {
+		logger.FromContext(c.Request.Context(), l.log).Error("ratelimit: failed to take from bucket", logger.Error(err))
+		return true
+	}
+	if result.Allowed {
+		return true
+	}
+	l.deny(c, scope, result.RetryAfter)
+	return false
+}
+
+func (l *Limiter) deny(c *gin.Context, scope string, retryAfter time.Duration) {
+	logger.FromContext(c.Request.Context(), l.log).Warn("ratelimit: request limited",
+		logger.String("scope", scope),
+		logger.String("client_ip", c.ClientIP()),
+	)
+	c.Header("Retry-After", RetryAfter(retryAfter))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error": "too many requests, retry later",
+	})
+}
+
+// credentialOf is the API key or token the request authenticates with,
+// empty when none
+func credentialOf(c *gin.Context) string {
+	if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		return bearer
+	}
+	if key := c.GetHeader("X-Api-Key"); key != "" {
+		return key
+	}
+	return c.Query("access_token")
+}
+
+// RetryAfter is d in whole seconds for a Retry-After header, 1 at least
+func RetryAfter(d time.Duration) string {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
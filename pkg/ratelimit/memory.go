@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dentist/storage/repo"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	expires time.Time
+}
+
+type lockout struct {
+	failures    int
+	windowStart time.Time
+	lockedUntil time.Time
+	expires     time.Time
+}
+
+type memoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	lockouts map[string]*lockout
+}
+
+// MemoryStore keeps the limits in the memory of the instance, each
+// instance behind a load balancer then limits on its own
+func MemoryStore() repo.NewRateLimitI {
+	return &memoryStore{
+		buckets:  map[string]*bucket{},
+		lockouts: map[string]*lockout{},
+	}
+}
+
+func (s *memoryStore) Take(_ context.Context, key string, limit repo.RateLimit) (*repo.RateResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	rate := float64(limit.Burst) / limit.Per.Seconds()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+	b.expires = now.Add(limit.Per)
+
+	var result repo.RateResult
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	return &result, nil
+}
+
+func (s *memoryStore) Locked(_ context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.lockouts[key]; ok && l.lockedUntil.After(time.Now()) {
+		return l.lockedUntil, nil
+	}
+	return time.Time{}, nil
+}
+
+func (s *memoryStore) Fail(_ context.Context, key string, policy repo.LockoutPolicy) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	l, ok := s.lockouts[key]
+	if !ok {
+		l = &lockout{}
+		s.lockouts[key] = l
+	}
+	if !l.windowStart.Add(policy.Window).After(now) {
+		l.failures = 0
+		l.windowStart = now
+	}
+	l.failures++
+	if end := now.Add(policy.Window); end.After(l.expires) {
+		l.expires = end
+	}
+
+	if l.failures < policy.Attempts {
+		return time.Time{}, nil
+	}
+	l.failures = 0
+	l.windowStart = now
+	l.lockedUntil = now.Add(policy.Duration)
+	if l.lockedUntil.After(l.expires) {
+		l.expires = l.lockedUntil
+	}
+	return l.lockedUntil, nil
+}
+
+func (s *memoryStore) Clear(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.lockouts[key]; ok && !l.lockedUntil.After(time.Now()) {
+		delete(s.lockouts, key)
+	}
+	return nil
+}
+
+func (s *memoryStore) Sweep(_ context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	swept := 0
+	for key, b := range s.buckets {
+		if !b.expires.After(now) {
+			delete(s.buckets, key)
+			swept++
+		}
+	}
+	for key, l := range s.lockouts {
+		if !l.expires.After(now) {
+			delete(s.lockouts, key)
+			swept++
+		}
+	}
+	return swept, nil
+}
@@ -0,0 +1,48 @@
+// Package retention runs the job that empties the trash: clients and
+// appointments deleted longer than the retention period ago are purged
+// or anonymized.
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/config"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+)
+
+// Run purges once right away and then every cfg.TrashPurgeInterval until
+// ctx is cancelled. It returns at once when retention is disabled.
+func Run(ctx context.Context, cfg *config.Config, trash repo.NewTrashI, log logger.Logger) {
+	if cfg.TrashRetentionDays <= 0 {
+		return
+	}
+	if cfg.TrashPurgeInterval <= 0 {
+		log.Error("retention: TRASH_PURGE_INTERVAL must be positive, the trash is not purged")
+		return
+	}
+	retention := time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour
+
+	ticker := time.NewTicker(cfg.TrashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		result, err := trash.Purge(ctx, time.Now().Add(-retention), cfg.TrashAnonymize)
+		if err != nil {
+			log.Error("retention: failed to purge the trash", logger.Error(err))
+		} else if result.Clients > 0 || result.Appointments > 0 {
+			log.Info("retention: purged the trash",
+				logger.Int("clients", result.Clients),
+				logger.Int("appointments", result.Appointments),
+				logger.Bool("anonymized", cfg.TrashAnonymize),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package waitlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/logger"
+)
+
+// Offer is what the client on the waitlist is told about a freed slot
+type Offer struct {
+	OfferId     string
+	ClientId    string
+	ClientName  string
+	PhoneNumber string
+	DoctorId    string
+	Date        time.Time
+	Treatment   string
+	ExpiresAt   time.Time
+	// AcceptUrl is the secret link that books the slot, it works until
+	// ExpiresAt or until someone else takes the slot
+	AcceptUrl string
+}
+
+// Sender delivers offers to clients, such as by SMS. An error makes the
+// offer be sent again later with a new link.
+type Sender interface {
+	SendOffer(ctx context.Context, offer *Offer) error
+}
+
+type logSender struct {
+	log logger.Logger
+}
+
+// LogSender only logs the offers, for clinics without a gateway to their
+// clients. Staff see the offers of an entry and accept them on the phone.
+func LogSender(log logger.Logger) Sender {
+	return &logSender{log: log}
+}
+
+func (s *logSender) SendOffer(ctx context.Context, offer *Offer) error {
+	// the link is a secret and stays out of the logs
+	logger.FromContext(ctx, s.log).Info("waitlist: slot offered",
+		logger.String("offer_id", offer.OfferId),
+		logger.String("client_id", offer.ClientId),
+		logger.String("date", offer.Date.Format(time.RFC3339)),
+	)
+	return nil
+}
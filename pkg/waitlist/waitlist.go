@@ -0,0 +1,186 @@
+// Package waitlist offers the slots freed by cancelled and moved
+// appointments to the clients waiting for one. Subscriber takes the
+// events off the bus and sends the offers, Book books the first one
+// accepted.
+package waitlist
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/dentist/config"
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+)
+
+// Subscriber offers the slot an appointment event freed to the waitlist
+// and sends the offers. The slot of an event is offered once however
+// often the event is handed, offers that were not sent are sent again.
+func Subscriber(cfg *config.Config, store repo.NewWaitlistI, sender Sender, log logger.Logger) events.Handler {
+	return func(ctx context.Context, ev *repo.DomainEvent) error {
+		if ev.AggregateType != events.AggregateAppointment {
+			return nil
+		}
+		var data events.AppointmentData
+		if err := json.Unmarshal(ev.Payload, &data); err != nil {
+			return err
+		}
+		slot := FreedSlot(ev.Id, ev.Type, &data)
+		if slot == nil || slot.Date.Before(time.Now().Add(cfg.WaitlistMinNotice)) {
+			return nil
+		}
+
+		offers, err := store.OfferSlot(ctx, slot, cfg.WaitlistOfferCount, cfg.WaitlistOfferTTL)
+		if err != nil {
+			return err
+		}
+		for _, offer := range offers {
+			if err = send(ctx, cfg, store, sender, offer); err != nil {
+				return err
+			}
+		}
+		if len(offers) > 0 {
+			log.Info("waitlist: freed slot offered",
+				logger.String("appointment_id", data.Id),
+				logger.Int("offers", len(offers)),
+			)
+		}
+		return nil
+	}
+}
+
+// FreedSlot is the slot an appointment event frees, nil when it frees
+// none. A cancelled or deleted appointment frees its time, a moved one
+// the time it had before.
+func FreedSlot(eventId, eventType string, a *events.AppointmentData) *repo.WaitlistSlot {
+	slot := &repo.WaitlistSlot{
+		Id:                  eventId,
+		DoctorId:            a.DoctorId,
+		Date:                a.Date,
+		Treatment:           a.Treatment,
+		SourceAppointmentId: a.Id,
+		SourceClientId:      a.ClientId,
+	}
+	if a.PreviousDate != nil {
+		slot.Date = *a.PreviousDate
+	}
+	if a.PreviousDoctorId != "" {
+		slot.DoctorId = a.PreviousDoctorId
+	}
+
+	// the status before the event, the slot was free already when it did
+	// not hold the time
+	before := a.Status
+	if a.PreviousStatus != "" {
+		before = a.PreviousStatus
+	}
+	if !holdsTime(before) {
+		return nil
+	}
+
+	switch eventType {
+	case events.AppointmentCancelled, events.AppointmentDeleted:
+		return slot
+	case events.AppointmentUpdated, events.AppointmentCheckedIn:
+		if a.PreviousDate != nil || a.PreviousDoctorId != "" {
+			return slot
+		}
+	}
+	return nil
+}
+
+// holdsTime reports whether an appointment in status keeps its time from
+// others
+func holdsTime(status string) bool {
+	switch status {
+	case repo.StatusCancelled, repo.StatusNoShow, repo.StatusCompleted:
+		return false
+	}
+	return true
+}
+
+// send gives the offer a new link and sends it
+func send(ctx context.Context, cfg *config.Config, store repo.NewWaitlistI, sender Sender, offer *repo.WaitlistOffer) error {
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	if err = store.SetOfferToken(ctx, offer.Id, HashToken(token)); err != nil {
+		return err
+	}
+
+	err = sender.SendOffer(ctx, &Offer{
+		OfferId:     offer.Id,
+		ClientId:    offer.ClientId,
+		ClientName:  offer.ClientName,
+		PhoneNumber: offer.PhoneNumber,
+		DoctorId:    offer.DoctorId,
+		Date:        offer.Date,
+		Treatment:   offer.Treatment,
+		ExpiresAt:   offer.ExpiresAt,
+		AcceptUrl:   strings.TrimSuffix(cfg.PublicUrl, "/") + "/waitlist/offers/" + token,
+	})
+	if err != nil {
+		return err
+	}
+	return store.MarkNotified(ctx, offer.Id)
+}
+
+// Book accepts an offer: the slot is claimed for it, the first claim
+// wins, and an appointment is booked in it. It fails with
+// repo.ErrOfferClosed or repo.ErrSlotTaken when the offer cannot be
+// accepted any more.
+func Book(ctx context.Context, store repo.NewWaitlistI, appointments repo.NewAppointmentI, log logger.Logger, offerId string) (*repo.Appointment, error) {
+	offer, err := store.ClaimSlot(ctx, offerId)
+	if err != nil {
+		return nil, err
+	}
+
+	appointment, err := appointments.CreateAppointment(ctx, &repo.Appointment{
+		Id:        uuid.NewString(),
+		ClientId:  offer.ClientId,
+		DoctorId:  offer.DoctorId,
+		Date:      offer.Date,
+		Treatment: offer.Treatment,
+	})
+	if err != nil {
+		// the slot goes to the next one accepting
+		if releaseErr := store.ReleaseSlot(context.WithoutCancel(ctx), offerId); releaseErr != nil {
+			logger.FromContext(ctx, log).Error("waitlist: failed to release slot", logger.String("offer_id", offerId), logger.Error(releaseErr))
+		}
+		return nil, err
+	}
+
+	// the appointment is booked whatever happens to the waitlist now
+	if err = store.CompleteOffer(context.WithoutCancel(ctx), offerId, appointment.Id); err != nil {
+		logger.FromContext(ctx, log).Error("waitlist: failed to complete offer",
+			logger.String("offer_id", offerId),
+			logger.String("appointment_id", appointment.Id),
+			logger.Error(err),
+		)
+	}
+	return appointment, nil
+}
+
+// HashToken is what is kept of the token of an offer link
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken returns 32 random bytes, safe to put in a URL
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
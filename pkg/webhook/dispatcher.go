@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dentist/config"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+)
+
+// batchSize is how many deliveries are claimed and sent at once
+const batchSize = 20
+
+// Run sends the due deliveries every cfg.WebhookPollInterval until ctx is
+// cancelled. A delivery that does not get a 2xx answer is tried again
+// after a backoff that doubles from cfg.WebhookRetryBase up to
+// cfg.WebhookRetryMax, and fails for good after cfg.WebhookMaxAttempts.
+// Several instances may run it, each claims different deliveries.
+func Run(ctx context.Context, cfg *config.Config, store repo.NewWebhookI, log logger.Logger) {
+	if cfg.WebhookPollInterval <= 0 {
+		log.Error("webhook: WEBHOOK_POLL_INTERVAL must be positive, webhooks are not sent")
+		return
+	}
+	d := &dispatcher{
+		cfg:   cfg,
+		store: store,
+		log:   log,
+		client: &http.Client{
+			Timeout:   cfg.WebhookTimeout,
+			Transport: transport(cfg),
+			// a redirect is answered as a failure, the subscription should
+			// name the final URL
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+
+	ticker := time.NewTicker(cfg.WebhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// a full batch means more may be due already
+		for d.sendDue(ctx) == batchSize && ctx.Err() == nil {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type dispatcher struct {
+	cfg    *config.Config
+	store  repo.NewWebhookI
+	log    logger.Logger
+	client *http.Client
+}
+
+// transport dials the endpoints directly, refusing internal addresses
+// unless cfg.WebhookAllowPrivate. The check is made on the address
+// dialed, so a host that resolves elsewhere after registering is caught.
+func transport(cfg *config.Config) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if !cfg.WebhookAllowPrivate {
+		dialer.Control = dialControl
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would be the address checked instead of the endpoint
+	t.Proxy = nil
+	t.DialContext = dialer.DialContext
+	return t
+}
+
+// sendDue sends a batch of due deliveries and returns its size
+func (d *dispatcher) sendDue(ctx context.Context) int {
+	// the lease outlives an attempt, so a delivery is claimed again only
+	// when the instance sending it died
+	deliveries, err := d.store.ClaimDeliveries(ctx, batchSize, d.cfg.WebhookTimeout+time.Minute)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.log.Error("webhook: failed to claim deliveries", logger.Error(err))
+		}
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *repo.WebhookDelivery) {
+			defer wg.Done()
+			d.attempt(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries)
+}
+
+func (d *dispatcher) attempt(ctx context.Context, delivery *repo.WebhookDelivery) {
+	status, err := d.send(ctx, delivery)
+	now := time.Now()
+
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.LastError = ""
+	switch {
+	case err == nil:
+		delivery.Status = repo.DeliveryDelivered
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= d.cfg.WebhookMaxAttempts:
+		delivery.Status = repo.DeliveryFailed
+		delivery.LastError = err.Error()
+	default:
+		delivery.Status = repo.DeliveryPending
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+	}
+	if len(delivery.LastError) > 500 {
+		delivery.LastError = delivery.LastError[:500]
+	}
+
+	// the outcome is saved even when shutting down, the request was sent
+	if err := d.store.RecordAttempt(context.WithoutCancel(ctx), delivery); err != nil {
+		d.log.Error("webhook: failed to record delivery", logger.String("delivery_id", delivery.Id), logger.Error(err))
+		return
+	}
+	if delivery.Status == repo.DeliveryFailed {
+		d.log.Warn("webhook: delivery failed for good",
+			logger.String("delivery_id", delivery.Id),
+			logger.String("webhook_id", delivery.WebhookId),
+			logger.String("event", delivery.Event),
+			logger.Int("attempts", delivery.Attempts),
+			logger.String("error", delivery.LastError),
+		)
+	}
+}
+
+// send posts the payload and returns the status of the answer, the error
+// is not nil unless it is 2xx
+func (d *dispatcher) send(ctx context.Context, delivery *repo.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dentist-webhooks/1")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderEventId, delivery.EventId)
+	req.Header.Set(HeaderDelivery, delivery.Id)
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, time.Now(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, &statusError{resp.StatusCode}
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff is the delay after the attempts so far, doubling with each and
+// spread by up to a tenth so that retries of a burst do not come together
+func (d *dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.WebhookRetryBase
+	for i := 1; i < attempts && delay < d.cfg.WebhookRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > d.cfg.WebhookRetryMax {
+		delay = d.cfg.WebhookRetryMax
+	}
+	if delay > 0 {
+		delay += time.Duration(rand.Int63n(int64(delay)/10 + 1))
+	}
+	return delay
+}
+
+type statusError struct {
+	status int
+}
+
+func (e *statusError) Error() string {
+	return "the endpoint answered " + strconv.Itoa(e.status) + " " + http.StatusText(e.status)
+}
@@ -0,0 +1,67 @@
+// Package webhook tells outside systems about changes of appointments and
+// clients. Subscriber takes the domain events off the bus, every webhook
+// subscribed to one gets a delivery that Run sends, signed and retried
+// with backoff.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/storage/repo"
+)
+
+// All subscribes a webhook to every event
+const All = "*"
+
+// Events lists the event types a webhook can subscribe to, in the order
+// they are documented
+var Events = events.Types
+
+// ValidEvent reports whether a webhook can subscribe to s
+func ValidEvent(s string) bool {
+	if s == All {
+		return true
+	}
+	for _, event := range Events {
+		if event == s {
+			return true
+		}
+	}
+	return false
+}
+
+// envelope is the body of every delivery, Id is the id of the domain
+// event and stays the same when a delivery is retried or replayed so that
+// receivers can drop repeats
+type envelope struct {
+	Id        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Subscriber queues a delivery of every domain event for the webhooks
+// subscribed to it. An event handed again is not queued twice.
+func Subscriber(store repo.NewWebhookI) events.Handler {
+	return func(ctx context.Context, ev *repo.DomainEvent) error {
+		payload, err := json.Marshal(envelope{
+			Id:        ev.Id,
+			Type:      ev.Type,
+			CreatedAt: ev.CreatedAt,
+			Data:      json.RawMessage(ev.Payload),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = store.Enqueue(ctx, &repo.WebhookEvent{
+			Id:        ev.Id,
+			Type:      ev.Type,
+			CreatedAt: ev.CreatedAt,
+			Payload:   payload,
+		})
+		return err
+	}
+}
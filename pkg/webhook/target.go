@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// ErrInternalAddress is returned when a webhook would be sent to a
+// loopback, private, link-local or otherwise internal address. Payloads
+// carry personal data and the API is open, so they only go out.
+var ErrInternalAddress = errors.New("webhook target is an internal address")
+
+// internal are the ranges a webhook is not sent to, besides what
+// netip.Addr reports as loopback, private, link-local or multicast
+var internal = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// PublicAddress reports whether a webhook may be sent to addr
+func PublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range internal {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL reports why raw cannot be the URL of a webhook. It must be
+// https and every address its host resolves to public, unless
+// allowPrivate. The addresses are checked again when a delivery is sent,
+// the host may resolve elsewhere by then.
+func CheckURL(ctx context.Context, raw string, allowPrivate bool) error {
+	target, err := url.Parse(raw)
+	if err != nil || target.Hostname() == "" || target.User != nil {
+		return errors.New("url must be an absolute https URL without credentials")
+	}
+	if target.Scheme != "https" && (!allowPrivate || target.Scheme != "http") {
+		return errors.New("url must be an https URL")
+	}
+	if allowPrivate {
+		return nil
+	}
+
+	host := target.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !PublicAddress(addr) {
+			return ErrInternalAddress
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("url host %s does not resolve", host)
+	}
+	for _, addr := range addrs {
+		if !PublicAddress(addr) {
+			return ErrInternalAddress
+		}
+	}
+	return nil
+}
+
+// dialControl refuses connections to internal addresses, it sees the
+// address a host name resolved to right before the connection is made
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !PublicAddress(addr) {
+		return ErrInternalAddress
+	}
+	return nil
+}
@@ -1,26 +1,38 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
 	"github.com/dentist/storage/repo"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type appoinmentRepo struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	loc    *time.Location
+	logger logger.Logger
 }
 
-func NewAppointmentRepo(db *sqlx.DB) repo.NewAppointmentI {
+func NewAppointmentRepo(db *sqlx.DB, loc *time.Location, log logger.Logger) repo.NewAppointmentI {
 	return &appoinmentRepo{
-		db: db,
+		db:     db,
+		loc:    loc,
+		logger: log,
 	}
 }
 
 //This method create a new appointment
-func (h *appoinmentRepo) CreateAppointment(req *repo.Appointment) (*repo.Appointment, error) {
+func (h *appoinmentRepo) CreateAppointment(ctx context.Context, req *repo.Appointment) (*repo.Appointment, error) {
 	query := `
 	INSERT INTO 
 		appointments(
@@ -29,18 +41,21 @@ func (h *appoinmentRepo) CreateAppointment(req *repo.Appointment) (*repo.Appoint
 			date,
 			diagnostics,
 			treatment,
-			amount
-	) VALUES ($1, $2, $3, $4, $5, $6)
-	RETURNING id, client_id, date, diagnostics, treatment, amount
+			amount,
+			status,
+			doctor_id
+	) VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'scheduled'), NULLIF($8, '')::UUID)
+	RETURNING id, client_id, COALESCE(doctor_id::TEXT, ''), date, diagnostics, treatment, amount, status
 	`
 	var nullTime sql.NullTime
-	tx, err := h.db.Begin()
+	tx, err := h.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Println("Error creating transaction create appointment: ", err)
+		h.log(ctx).Error("Error creating transaction create appointment", logger.Error(err))
 		return nil, err
 	}
 	var user repo.Appointment
-	err = tx.QueryRow(
+	err = tx.QueryRowContext(
+		ctx,
 		query,
 		req.Id,
 		req.ClientId,
@@ -48,33 +63,43 @@ func (h *appoinmentRepo) CreateAppointment(req *repo.Appointment) (*repo.Appoint
 		req.Diagnostics,
 		req.Treatment,
 		req.Amount,
+		req.Status,
+		req.DoctorId,
 	).Scan(
 		&user.Id,
 		&user.ClientId,
+		&user.DoctorId,
 		&nullTime,
 		&user.Diagnostics,
 		&user.Treatment,
 		&user.Amount,
+		&user.Status,
 	)
 	if err != nil {
-		log.Println("Error to create appointment in database: ", err)
+		h.log(ctx).Error("Error to create appointment in database", logger.Error(err))
 		tx.Rollback()
 		return nil, err
 	}
-	err = tx.Commit()
+	if nullTime.Valid {
+		user.Date = nullTime.Time
+	}
+	err = writeEvent(ctx, tx, events.AggregateAppointment, user.Id, events.AppointmentCreated, events.Appointment(&user))
 	if err != nil {
+		h.log(ctx).Error("Error to write appointment event", logger.Error(err))
 		tx.Rollback()
 		return nil, err
 	}
-	if nullTime.Valid {
-		user.Date = nullTime.Time.Format("2006-01-02 15:04:05")
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 
 	return &user, nil
 }
 
 //This method delete appointment with id
-func (h *appoinmentRepo) DeleteAppointment(id string) (bool, error) {
+func (h *appoinmentRepo) DeleteAppointment(ctx context.Context, id string) (bool, error) {
 	query := `
 	UPDATE 
 	    appointments
@@ -83,16 +108,26 @@ func (h *appoinmentRepo) DeleteAppointment(id string) (bool, error) {
 	WHERE 
 		id = $1
 	AND
-	    deleted_at IS NULL`
+	    deleted_at IS NULL
+	RETURNING id, client_id, COALESCE(doctor_id::TEXT, ''), date, diagnostics, treatment, amount, status`
 
-	tx, err := h.db.Begin()
+	tx, err := h.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Println("Error creating transaction to delete appointment")
+		h.log(ctx).Error("Error creating transaction to delete appointment", logger.Error(err))
 		return false, err
 	}
-	_, err = tx.Exec(query, id)
+	deleted, err := scanAppointments(tx.QueryContext(ctx, query, id))
 	if err != nil {
-		log.Println("Error to deleting appointment in database: ", err)
+		h.log(ctx).Error("Error to deleting appointment in database", logger.Error(err))
+		tx.Rollback()
+		return false, err
+	}
+	if len(deleted) == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+	if err = writeDeletedAppointments(ctx, tx, deleted); err != nil {
+		h.log(ctx).Error("Error to write appointment event", logger.Error(err))
 		tx.Rollback()
 		return false, err
 	}
@@ -106,41 +141,66 @@ func (h *appoinmentRepo) DeleteAppointment(id string) (bool, error) {
 }
 
 //This method get appointment with id
-func (h *appoinmentRepo) GetAppointment(id string) (*repo.Appointment, error) {
+func (h *appoinmentRepo) GetAppointment(ctx context.Context, id string) (*repo.Appointment, error) {
 	query := `
 	SELECT 
 	    id,
 		client_id,
+		COALESCE(doctor_id::TEXT, ''),
         date,
         diagnostics,
         treatment,
-		amount
+		amount,
+		status
 	FROM 
 	    appointments
 	WHERE 
 		id = $1
 	AND 
 	    deleted_at IS NULL`
-	var appointment repo.Appointment
-	err := h.db.QueryRow(query, id).Scan(
+	var (
+		appointment repo.Appointment
+		date        sql.NullTime
+	)
+	err := h.db.QueryRowContext(ctx, query, id).Scan(
 		&appointment.Id,
 		&appointment.ClientId,
-		&appointment.Date,
+		&appointment.DoctorId,
+		&date,
 		&appointment.Diagnostics,
 		&appointment.Treatment,
 		&appointment.Amount,
+		&appointment.Status,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
 	if err != nil {
-		log.Println("Error to get appointment in database: ", err)
+		h.log(ctx).Error("Error to get appointment in database", logger.Error(err))
 		return nil, err
 	}
+	appointment.Date = date.Time
 
 	return &appointment, nil
 }
 
 //This method update appointment with id
-func (h *appoinmentRepo) UpdateAppointment(req *repo.Appointment) (*repo.Appointment, error) {
+func (h *appoinmentRepo) UpdateAppointment(ctx context.Context, req *repo.Appointment) (*repo.Appointment, error) {
 	query := `
+	WITH old AS (
+		SELECT
+			id AS old_id,
+			status AS old_status,
+			date AS old_date,
+			COALESCE(doctor_id::TEXT, '') AS old_doctor_id
+		FROM
+			appointments
+		WHERE
+			id = $8
+		AND
+			deleted_at IS NULL
+		FOR UPDATE
+	)
 	UPDATE 
 		appointments
 	SET
@@ -148,179 +208,390 @@ func (h *appoinmentRepo) UpdateAppointment(req *repo.Appointment) (*repo.Appoint
 		date = $2,
         diagnostics = $3,
         treatment = $4,
-        amount = $5
+        amount = $5,
+        status = COALESCE(NULLIF($6, ''), status),
+        doctor_id = COALESCE(NULLIF($7, '')::UUID, doctor_id),
+        updated_at = CURRENT_TIMESTAMP
+	FROM
+		old
 	WHERE
-		id = $6
-	AND 
-		deleted_at IS NULL
-	RETURNING id, client_id, date, diagnostics, treatment, amount`
-	var user repo.Appointment
-	err := h.db.QueryRow(
+		id = old_id
+	RETURNING id, client_id, COALESCE(doctor_id::TEXT, ''), date, diagnostics, treatment, amount, status, old_status, old_date, old_doctor_id`
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Error("Error creating transaction update appointment", logger.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var (
+		user           repo.Appointment
+		date           sql.NullTime
+		previous       string
+		previousDate   sql.NullTime
+		previousDoctor string
+	)
+	err = tx.QueryRowContext(
+		ctx,
 		query,
 		req.ClientId,
 		req.Date,
 		req.Diagnostics,
 		req.Treatment,
 		req.Amount,
+		req.Status,
+		req.DoctorId,
 		req.Id,
 	).Scan(
 		&user.Id,
 		&user.ClientId,
-		&user.Date,
+		&user.DoctorId,
+		&date,
 		&user.Diagnostics,
 		&user.Treatment,
 		&user.Amount,
+		&user.Status,
+		&previous,
+		&previousDate,
+		&previousDoctor,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error updating appointment in database", logger.Error(err))
+		return nil, err
+	}
+	user.Date = date.Time
+	// a fitting cannot be confirmed, or held, before the lab delivered
+	// the work
+	if fittingStatus(user.Status) && previous != user.Status {
+		if err = checkFitting(ctx, tx, user.Id); err != nil {
+			if !errors.Is(err, repo.ErrLabCaseNotReceived) {
+				h.log(ctx).Error("Error to check lab cases of appointment", logger.Error(err))
+			}
+			return nil, err
+		}
+	}
+
+	data := events.Appointment(&user)
+	if previous != user.Status {
+		data.PreviousStatus = previous
+	}
+	// an appointment without a date held no time to free
+	if previousDate.Valid && !previousDate.Time.Equal(user.Date) {
+		data.PreviousDate = &previousDate.Time
+	}
+	if previousDoctor != user.DoctorId {
+		data.PreviousDoctorId = previousDoctor
+	}
+	err = writeEvent(ctx, tx, events.AggregateAppointment, user.Id, events.AppointmentChanged(previous, user.Status), data)
 	if err != nil {
-		log.Println("Error updating appointment in database: ", err)
+		h.log(ctx).Error("Error to write appointment event", logger.Error(err))
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
 	}
 
 	return &user, nil
 }
 
 //This method get all appointments with page and limit
-func (h *appoinmentRepo) GetAllAppointments(req *repo.GetAllAppointment) (*repo.AllAppointments, error) {
-	query := `
+func (h *appoinmentRepo) GetAllAppointments(ctx context.Context, req *repo.GetAllAppointment) (*repo.AllAppointments, error) {
+	return h.listAppointments(ctx, newQuery(), byDate, req.Params)
+}
+
+//this method takes appointments by number, that is, if a positive number is entered, 
+// it will take the data of that number of days from today, if it is negative, 
+// it will take the data of the previous day. Days are the clinic's days.
+func (h *appoinmentRepo) GetAppointmentsWithDate(ctx context.Context, req int, params pagination.Params) (*repo.AllAppointments, error) {
+	today := civil.DateOf(time.Now().In(h.loc)).In(h.loc)
+	now, to := today, today.AddDate(0, 0, req)
+	if req < 0 {
+		now, to = to, now
+	}
+
+	q := newQuery().where("date >= ?", now).where("date < ?", to)
+	return h.listAppointments(ctx, q, byDate, params)
+}
+
+//This method get appointments with client id
+func (h *appoinmentRepo) GetAppointmentsWithClientId(ctx context.Context, id string, params pagination.Params) (*repo.AllAppointments, error) {
+	return h.listAppointments(ctx, newQuery().where("client_id = ?", id), byDate, params)
+}
+
+// textSearch must stay identical to the appointments_text_search_idx
+// expression, otherwise the index is not used
+const textSearch = `to_tsvector('simple', COALESCE(diagnostics, '') || ' ' || COALESCE(treatment, ''))`
+
+//This method get appointments matching every set field of the filter
+func (h *appoinmentRepo) GetAppointments(ctx context.Context, filter *repo.AppointmentFilter, params pagination.Params) (*repo.AllAppointments, error) {
+	q, order := appointmentQuery(filter)
+	return h.listAppointments(ctx, q, order, params)
+}
+
+// appointmentQuery returns the conditions and the order of filter
+func appointmentQuery(filter *repo.AppointmentFilter) (*queryBuilder, ordering) {
+	q := newQuery()
+	if filter.From != nil {
+		q.where("date >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q.where("date < ?", *filter.To)
+	}
+	if filter.ClientId != "" {
+		q.where("client_id = ?", filter.ClientId)
+	}
+	if filter.DoctorId != "" {
+		q.where("doctor_id = ?", filter.DoctorId)
+	}
+	if len(filter.Statuses) > 0 {
+		q.where("status = ANY(?)", pq.Array(filter.Statuses))
+	}
+	if filter.MinAmount != nil {
+		q.where("amount >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		q.where("amount <= ?", *filter.MaxAmount)
+	}
+	if filter.Text != "" {
+		q.where(textSearch+" @@ websearch_to_tsquery('simple', ?)", filter.Text)
+	}
+
+	order := byDate
+	if filter.Sort == repo.SortAmount {
+		order = byAmount
+	}
+	order.desc = filter.Desc
+
+	return q, order
+}
+
+//This method streams the appointments matching the filter with the names
+//of their client and doctor
+func (h *appoinmentRepo) StreamAppointments(ctx context.Context, filter *repo.AppointmentFilter, fn func(*repo.AppointmentRow) error) error {
+	q, order := appointmentQuery(filter)
+	q.where("deleted_at IS NULL")
+
+	query := fmt.Sprintf(`
 	SELECT
 		id,
 		client_id,
+		COALESCE(doctor_id::TEXT, ''),
 		date,
 		diagnostics,
-        treatment,
-        amount
-	FROM 
-	    appointments
-	WHERE 
-	    deleted_at IS NULL
-	ORDER BY date
-	LIMIT $1
-	OFFSET $2`
-	offset := req.Limit * (req.Page - 1)
-	rows, err := h.db.Query(query, req.Limit, offset)
+		treatment,
+		amount,
+		status,
+		COALESCE((SELECT c.name || ' ' || COALESCE(c.last_name, '') FROM clients c WHERE c.id = appointments.client_id), ''),
+		COALESCE((SELECT c.phone_number FROM clients c WHERE c.id = appointments.client_id), ''),
+		COALESCE((SELECT d.name || ' ' || COALESCE(d.last_name, '') FROM doctors d WHERE d.id = appointments.doctor_id), '')
+	FROM
+		appointments
+	WHERE
+		%s
+	ORDER BY %s`, q.sql(), order.sql())
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
-		log.Println("Error to get all appointments in database: ", err)
-		return nil, err
+		h.log(ctx).Error("Error to stream appointments", logger.Error(err))
+		return err
 	}
-	var AllAppointments = repo.AllAppointments{}
+	defer rows.Close()
+
 	for rows.Next() {
-		var appointment repo.Appointment
+		var (
+			row  repo.AppointmentRow
+			date sql.NullTime
+		)
 		err = rows.Scan(
-			&appointment.Id,
-			&appointment.ClientId,
-			&appointment.Date,
-			&appointment.Diagnostics,
-			&appointment.Treatment,
-			&appointment.Amount,
+			&row.Id,
+			&row.ClientId,
+			&row.DoctorId,
+			&date,
+			&row.Diagnostics,
+			&row.Treatment,
+			&row.Amount,
+			&row.Status,
+			&row.ClientName,
+			&row.ClientPhone,
+			&row.DoctorName,
 		)
 		if err != nil {
-			log.Println("Error to get all appointments: ", err)
-			return nil, err
+			h.log(ctx).Error("Error to stream appointments", logger.Error(err))
+			return err
+		}
+		row.Date = date.Time
+		if err = fn(&row); err != nil {
+			return err
 		}
-		defer rows.Close()
-		AllAppointments.Appointment = append(AllAppointments.Appointment, &appointment)
 	}
 
-	return &AllAppointments, nil
+	return rows.Err()
 }
 
-//this method takes appointments by number, that is, if a positive number is entered, 
-// it will take the data of that number of days from today, if it is negative, 
-// it will take the data of the previous day
-func (h *appoinmentRepo) GetAppointmentsWithDate(req, page, limit int) (*repo.AllAppointments, error) {
-	now := time.Now().Format("2006-01-02")
-	to := time.Now().AddDate(0, 0, req).Format("2006-01-02")
-	if req < 0 {
-		now, to = to, now
+var (
+	byDate   = ordering{column: "date"}
+	byAmount = ordering{column: "amount"}
+)
+
+// listAppointments returns one page of not deleted appointments matching
+// q in the given order, together with the total count.
+func (h *appoinmentRepo) listAppointments(ctx context.Context, q *queryBuilder, order ordering, params pagination.Params) (*repo.AllAppointments, error) {
+	q.where("deleted_at IS NULL")
+
+	var response repo.AllAppointments
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM appointments WHERE `+q.sql(), q.args...).Scan(&response.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count appointments", logger.Error(err))
+		return nil, err
 	}
 
-	offset := limit * (page - 1)
-	query := `
+	q.after(order, params.Cursor)
+	query := fmt.Sprintf(`
 	SELECT 
 		id,
 		client_id,
+		COALESCE(doctor_id::TEXT, ''),
 		date,
 		diagnostics,
 		treatment,
-		amount
+		amount,
+		status
 	FROM 
 		appointments
 	WHERE 
-		date >= $1 AND date < $2 AND deleted_at IS NULL
-	ORDER BY date
-	LIMIT $3
-	OFFSET $4`
+		%s
+	ORDER BY %s
+	LIMIT %s
+	OFFSET %s`, q.sql(), order.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
 
-	rows, err := h.db.Query(query, now, to, limit, offset)
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
-		log.Println("Error get appointments with date", err)
+		h.log(ctx).Error("Error to get appointments in database", logger.Error(err))
 		return nil, err
 	}
-	var appointments repo.AllAppointments
+	defer rows.Close()
+
 	for rows.Next() {
-		var appointment repo.Appointment
+		var (
+			appointment repo.Appointment
+			date        sql.NullTime
+		)
 		err = rows.Scan(
 			&appointment.Id,
 			&appointment.ClientId,
-			&appointment.Date,
+			&appointment.DoctorId,
+			&date,
 			&appointment.Diagnostics,
 			&appointment.Treatment,
 			&appointment.Amount,
+			&appointment.Status,
 		)
 		if err != nil {
-			log.Println("Error get appointments with date", err)
+			h.log(ctx).Error("Error to get appointments in database", logger.Error(err))
 			return nil, err
 		}
-		appointments.Appointment = append(appointments.Appointment, &appointment)
-		defer rows.Close()
+		appointment.Date = date.Time
+		response.Appointment = append(response.Appointment, &appointment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return &appointments, nil
+	response.Appointment, response.NextCursor = pagination.Trim(response.Appointment, params.Limit, func(a *repo.Appointment) pagination.Cursor {
+		if order.column == byAmount.column {
+			return pagination.Cursor{Key: strconv.Itoa(a.Amount), Id: a.Id}
+		}
+		if a.Date.IsZero() {
+			return pagination.Cursor{Id: a.Id}
+		}
+		return pagination.Cursor{Key: a.Date.Format(time.RFC3339Nano), Id: a.Id}
+	})
+
+	return &response, nil
 }
 
-//This method get appointments with client id
-func (h *appoinmentRepo) GetAppointmentsWithClientId(id string, page, limit int) ([]repo.Appointment, error) {
+//This method counts appointments in [from, to) grouped by status
+func (h *appoinmentRepo) CountAppointmentsByStatus(ctx context.Context, from, to time.Time) (map[string]int, error) {
 	query := `
-	SELECT 
-		id,
-		client_id,
-		date,
-		diagnostics,
-		treatment,
-		amount
-	FROM 
+	SELECT
+		status,
+		COUNT(*)
+	FROM
 		appointments
 	WHERE
-		client_id = $1
-	AND 
-		deleted_at IS NULL 
-	ORDER BY date
-	LIMIT $2
-	OFFSET $3 `
-	offset := limit * (page - 1)
-	rows, err := h.db.Query(query, id, limit, offset)
+		date >= $1 AND date < $2 AND deleted_at IS NULL
+	GROUP BY status`
+
+	rows, err := h.db.QueryContext(ctx, query, from, to)
 	if err != nil {
-		log.Println("Error to get appointment with course_id", err)
+		h.log(ctx).Error("Error to count appointments by status", logger.Error(err))
 		return nil, err
 	}
+	defer rows.Close()
 
-	var appointments []repo.Appointment
+	counts := map[string]int{}
 	for rows.Next() {
-		var appointment repo.Appointment
+		var (
+			status string
+			count  int
+		)
+		if err = rows.Scan(&status, &count); err != nil {
+			h.log(ctx).Error("Error to count appointments by status", logger.Error(err))
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// scanAppointments reads the rows of a RETURNING id, client_id,
+// doctor_id, date, diagnostics, treatment, amount, status
+func scanAppointments(rows *sql.Rows, err error) ([]*repo.Appointment, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var appointments []*repo.Appointment
+	for rows.Next() {
+		var (
+			a    repo.Appointment
+			date sql.NullTime
+		)
 		err = rows.Scan(
-			&appointment.Id,
-			&appointment.ClientId,
-			&appointment.Date,
-			&appointment.Diagnostics,
-			&appointment.Treatment,
-			&appointment.Amount,
+			&a.Id,
+			&a.ClientId,
+			&a.DoctorId,
+			&date,
+			&a.Diagnostics,
+			&a.Treatment,
+			&a.Amount,
+			&a.Status,
 		)
 		if err != nil {
-			log.Println("Error to get appointment with course_id", err)
 			return nil, err
 		}
-		defer rows.Close()
-		appointments = append(appointments, appointment)
+		a.Date = date.Time
+		appointments = append(appointments, &a)
+	}
+	return appointments, rows.Err()
+}
+
+// writeDeletedAppointments adds appointment.deleted of each to the outbox
+func writeDeletedAppointments(ctx context.Context, tx *sql.Tx, deleted []*repo.Appointment) error {
+	for _, a := range deleted {
+		err := writeEvent(ctx, tx, events.AggregateAppointment, a.Id, events.AppointmentDeleted, events.Appointment(a))
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	return appointments, nil
+func (h *appoinmentRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
 }
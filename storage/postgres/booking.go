@@ -0,0 +1,632 @@
+package postgres
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type bookingRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewBookingRepo(db *sqlx.DB, log logger.Logger) repo.NewBookingI {
+	return &bookingRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+// bookingRequests are the requests with the time of their appointments
+const bookingRequests = `(
+		SELECT
+			r.*,
+			COALESCE(a.doctor_id::TEXT, '') AS doctor_id,
+			a.date
+		FROM
+			booking_requests r
+		JOIN appointments a ON a.id = r.appointment_id
+	) r`
+
+const bookingColumns = `
+		r.id,
+		r.appointment_id,
+		r.client_id,
+		r.procedure,
+		r.duration_minutes,
+		r.doctor_id,
+		r.date,
+		r.phone_digits,
+		r.client_ip,
+		r.code_hash,
+		r.attempts,
+		r.status,
+		COALESCE(r.confirmed_by, ''),
+		r.expires_at,
+		r.created_at,
+		r.decided_at`
+
+// This function is get the doctors that can be booked
+func (h *bookingRepo) Doctors(ctx context.Context) ([]*repo.Doctor, error) {
+	query := `
+	SELECT
+		id,
+		name,
+		last_name,
+		specialty,
+		phone_number,
+		created_at
+	FROM
+		doctors
+	WHERE
+		deleted_at IS NULL
+	ORDER BY last_name, name, id`
+
+	rows, err := h.db.QueryContext(ctx, query)
+	if err != nil {
+		h.log(ctx).Error("Error to get doctors", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var doctors []*repo.Doctor
+	for rows.Next() {
+		var d repo.Doctor
+		if err = rows.Scan(&d.Id, &d.Name, &d.LastName, &d.Specialty, &d.PhoneNumber, &d.CreatedAt); err != nil {
+			h.log(ctx).Error("Error to get doctors", logger.Error(err))
+			return nil, err
+		}
+		doctors = append(doctors, &d)
+	}
+
+	return doctors, rows.Err()
+}
+
+// This function is get the appointments of doctors holding their time,
+// pending ones included
+func (h *bookingRepo) BusyTimes(ctx context.Context, from, to time.Time) ([]repo.BusyTime, error) {
+	query := `
+	SELECT
+		a.doctor_id::TEXT,
+		a.date,
+		COALESCE(r.duration_minutes, 0)
+	FROM
+		appointments a
+	LEFT JOIN booking_requests r ON r.appointment_id = a.id
+	WHERE
+		a.doctor_id IS NOT NULL
+	AND
+		a.deleted_at IS NULL
+	AND
+		a.status NOT IN ('cancelled', 'no_show')
+	AND
+		a.date >= $1
+	AND
+		a.date < $2`
+
+	rows, err := h.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		h.log(ctx).Error("Error to get busy times", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var busy []repo.BusyTime
+	for rows.Next() {
+		var (
+			b       repo.BusyTime
+			minutes int
+		)
+		if err = rows.Scan(&b.DoctorId, &b.Start, &minutes); err != nil {
+			h.log(ctx).Error("Error to get busy times", logger.Error(err))
+			return nil, err
+		}
+		b.Duration = time.Duration(minutes) * time.Minute
+		busy = append(busy, b)
+	}
+
+	return busy, rows.Err()
+}
+
+// This function is book the time of a request as a pending appointment.
+// Bookings of a doctor are made one at a time, so two requests cannot
+// take the same time.
+func (h *bookingRepo) CreateRequest(ctx context.Context, req *repo.BookingRequest, client *repo.BookingClient, minDuration time.Duration) (*repo.BookingRequest, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "booking:"+req.DoctorId); err != nil {
+		h.log(ctx).Error("Error to lock doctor for booking", logger.Error(err))
+		return nil, err
+	}
+
+	var taken bool
+	err = tx.QueryRowContext(ctx, `
+	SELECT EXISTS (
+		SELECT
+			1
+		FROM
+			appointments a
+		LEFT JOIN booking_requests r ON r.appointment_id = a.id
+		WHERE
+			a.doctor_id = $1
+		AND
+			a.deleted_at IS NULL
+		AND
+			a.status NOT IN ('cancelled', 'no_show')
+		AND
+			a.date < $3
+		AND
+			a.date + GREATEST($4, COALESCE(r.duration_minutes, 0)) * INTERVAL '1 minute' > $2
+	)`, req.DoctorId, req.Date, req.Date.Add(req.Duration), int(minDuration.Minutes())).Scan(&taken)
+	if err != nil {
+		h.log(ctx).Error("Error to check booking time", logger.Error(err))
+		return nil, err
+	}
+	if taken {
+		return nil, repo.ErrSlotTaken
+	}
+
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		c.id
+	FROM
+		clients c
+	WHERE
+		c.deleted_at IS NULL
+	AND
+		`+phoneDigits("c")+` = $1
+	AND
+		LOWER(TRIM(c.name)) = LOWER(TRIM($2))
+	ORDER BY c.created_at
+	LIMIT 1`, client.PhoneDigits, client.Name).Scan(&req.ClientId)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = h.createClient(ctx, tx, req, client)
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to find client for booking", logger.Error(err))
+		return nil, err
+	}
+
+	booked, err := scanAppointments(tx.QueryContext(ctx, `
+	INSERT INTO
+		appointments(
+			id,
+			client_id,
+			date,
+			diagnostics,
+			treatment,
+			amount,
+			status,
+			doctor_id
+		) VALUES ($1, $2, $3, '', $4, 0, 'pending', $5)
+	RETURNING id, client_id, COALESCE(doctor_id::TEXT, ''), date, diagnostics, treatment, amount, status`,
+		uuid.NewString(),
+		req.ClientId,
+		req.Date,
+		req.Procedure,
+		req.DoctorId,
+	))
+	if err != nil {
+		h.log(ctx).Error("Error to create pending appointment", logger.Error(err))
+		return nil, err
+	}
+	appointment := booked[0]
+	err = writeEvent(ctx, tx, events.AggregateAppointment, appointment.Id, events.AppointmentCreated, events.Appointment(appointment))
+	if err != nil {
+		h.log(ctx).Error("Error to write appointment event", logger.Error(err))
+		return nil, err
+	}
+
+	req.Id = uuid.NewString()
+	req.AppointmentId = appointment.Id
+	req.Status = repo.BookingPending
+	err = tx.QueryRowContext(ctx, `
+	INSERT INTO
+		booking_requests(
+			id,
+			appointment_id,
+			client_id,
+			procedure,
+			duration_minutes,
+			phone_digits,
+			client_ip,
+			code_hash,
+			expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING created_at`,
+		req.Id,
+		req.AppointmentId,
+		req.ClientId,
+		req.Procedure,
+		int(req.Duration.Minutes()),
+		client.PhoneDigits,
+		req.ClientIp,
+		req.CodeHash,
+		req.ExpiresAt,
+	).Scan(&req.CreatedAt)
+	if err != nil {
+		h.log(ctx).Error("Error to create booking request", logger.Error(err))
+		return nil, err
+	}
+	req.PhoneDigits = client.PhoneDigits
+
+	return req, tx.Commit()
+}
+
+// createClient adds the client of a booking nobody was matched to
+func (h *bookingRepo) createClient(ctx context.Context, tx *sql.Tx, req *repo.BookingRequest, client *repo.BookingClient) error {
+	var c repo.Client
+	err := tx.QueryRowContext(ctx, `
+	INSERT INTO
+		clients(
+			id,
+			name,
+			last_name,
+			father_name,
+			phone_number,
+			address
+		) VALUES ($1, $2, $3, '', $4, '')
+	RETURNING id, name, last_name, father_name, phone_number, address, birth_date, created_at`,
+		uuid.NewString(),
+		client.Name,
+		client.LastName,
+		client.PhoneNumber,
+	).Scan(
+		&c.Id,
+		&c.Name,
+		&c.LastName,
+		&c.FatherName,
+		&c.PhoneNumber,
+		&c.Address,
+		&c.BirthDate,
+		&c.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err = writeEvent(ctx, tx, events.AggregateClient, c.Id, events.ClientCreated, events.Client(&c)); err != nil {
+		return err
+	}
+	req.ClientId = c.Id
+	req.NewClient = true
+	return nil
+}
+
+// This function is get a booking request
+func (h *bookingRepo) GetRequest(ctx context.Context, id string) (*repo.BookingRequest, error) {
+	query := `
+	SELECT` + bookingColumns + `
+	FROM
+		` + bookingRequests + `
+	WHERE
+		r.id = $1`
+
+	r, err := scanBookingRequest(h.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get booking request", logger.Error(err))
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// This function is get the booking requests, the oldest first
+func (h *bookingRepo) GetRequests(ctx context.Context, status string, params pagination.Params) (*repo.AllBookingRequests, error) {
+	q := newQuery()
+	if status != "" {
+		q.where("status = ?", status)
+	}
+
+	var requests repo.AllBookingRequests
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM booking_requests WHERE `+q.sql(), q.args...).Scan(&requests.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count booking requests", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byCreatedAt, params.Cursor)
+	query := fmt.Sprintf(`
+	SELECT`+bookingColumns+`
+	FROM
+		`+bookingRequests+`
+	WHERE
+		%s
+	ORDER BY %s
+	LIMIT %s
+	OFFSET %s`, q.sql(), byCreatedAt.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get booking requests", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scanBookingRequest(rows)
+		if err != nil {
+			h.log(ctx).Error("Error to get booking requests", logger.Error(err))
+			return nil, err
+		}
+		requests.Requests = append(requests.Requests, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	requests.Requests, requests.NextCursor = pagination.Trim(requests.Requests, params.Limit, func(r *repo.BookingRequest) pagination.Cursor {
+		return pagination.Cursor{Key: r.CreatedAt.Format(time.RFC3339Nano), Id: r.Id}
+	})
+
+	return &requests, nil
+}
+
+// This function is count the requests made from an address since a time
+func (h *bookingRepo) CountRecent(ctx context.Context, ip string, since time.Time) (int, error) {
+	var count int
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM booking_requests WHERE client_ip = $1 AND created_at >= $2`, ip, since).Scan(&count)
+	if err != nil {
+		h.log(ctx).Error("Error to count booking requests", logger.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
+
+// This function is count the pending requests of a phone
+func (h *bookingRepo) CountPending(ctx context.Context, phoneDigits string) (int, error) {
+	var count int
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM booking_requests WHERE phone_digits = $1 AND status = 'pending'`, phoneDigits).Scan(&count)
+	if err != nil {
+		h.log(ctx).Error("Error to count booking requests", logger.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
+
+// This function is confirm a request with the code sent to the phone
+func (h *bookingRepo) ConfirmWithCode(ctx context.Context, id, codeHash string, maxAttempts int) (*repo.BookingRequest, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	r, err := h.lockPending(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.CodeHash == "" || subtle.ConstantTimeCompare([]byte(r.CodeHash), []byte(codeHash)) != 1 {
+		_, err = tx.ExecContext(ctx, `UPDATE booking_requests SET attempts = attempts + 1 WHERE id = $1`, id)
+		if err != nil {
+			h.log(ctx).Error("Error to count booking attempt", logger.Error(err))
+			return nil, err
+		}
+		if r.Attempts+1 >= maxAttempts {
+			if err = h.decide(ctx, tx, id, repo.BookingRejected, "", repo.StatusCancelled); err != nil {
+				return nil, err
+			}
+		}
+		if err = tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, repo.ErrWrongCode
+	}
+
+	if err = h.decide(ctx, tx, id, repo.BookingConfirmed, "code", repo.StatusScheduled); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetRequest(ctx, id)
+}
+
+// This function is confirm a request on behalf of staff
+func (h *bookingRepo) Confirm(ctx context.Context, id string) (*repo.BookingRequest, error) {
+	return h.decideNow(ctx, id, repo.BookingConfirmed, "staff", repo.StatusScheduled)
+}
+
+// This function is reject a request, its appointment is cancelled
+func (h *bookingRepo) Reject(ctx context.Context, id string) (*repo.BookingRequest, error) {
+	return h.decideNow(ctx, id, repo.BookingRejected, "", repo.StatusCancelled)
+}
+
+// This function is expire the pending requests past their time, their
+// appointments are cancelled and free the time again
+func (h *bookingRepo) Expire(ctx context.Context) (int, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+	UPDATE
+		booking_requests
+	SET
+		status = 'expired',
+		decided_at = CURRENT_TIMESTAMP
+	WHERE
+		status = 'pending'
+	AND
+		expires_at <= CURRENT_TIMESTAMP
+	RETURNING appointment_id`)
+	if err != nil {
+		h.log(ctx).Error("Error to expire booking requests", logger.Error(err))
+		return 0, err
+	}
+	var appointmentIds []string
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		appointmentIds = append(appointmentIds, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range appointmentIds {
+		if err = h.moveAppointment(ctx, tx, id, repo.StatusCancelled); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(appointmentIds), tx.Commit()
+}
+
+func (h *bookingRepo) decideNow(ctx context.Context, id, status, confirmedBy, appointmentStatus string) (*repo.BookingRequest, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err = h.lockPending(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	if err = h.decide(ctx, tx, id, status, confirmedBy, appointmentStatus); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetRequest(ctx, id)
+}
+
+// lockPending locks a request that can still be decided, ErrBookingClosed
+// otherwise
+func (h *bookingRepo) lockPending(ctx context.Context, tx *sql.Tx, id string) (*repo.BookingRequest, error) {
+	var r repo.BookingRequest
+	err := tx.QueryRowContext(ctx, `
+	SELECT
+		status,
+		code_hash,
+		attempts,
+		expires_at
+	FROM
+		booking_requests
+	WHERE
+		id = $1
+	FOR UPDATE`, id).Scan(&r.Status, &r.CodeHash, &r.Attempts, &r.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get booking request", logger.Error(err))
+		return nil, err
+	}
+	if r.Status != repo.BookingPending || !r.ExpiresAt.After(time.Now()) {
+		return nil, repo.ErrBookingClosed
+	}
+	return &r, nil
+}
+
+// decide closes a request with status and moves its appointment from
+// pending to appointmentStatus
+func (h *bookingRepo) decide(ctx context.Context, tx *sql.Tx, id, status, confirmedBy, appointmentStatus string) error {
+	var appointmentId string
+	err := tx.QueryRowContext(ctx, `
+	UPDATE
+		booking_requests
+	SET
+		status = $2,
+		confirmed_by = NULLIF($3, ''),
+		decided_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	RETURNING appointment_id`, id, status, confirmedBy).Scan(&appointmentId)
+	if err != nil {
+		h.log(ctx).Error("Error to decide booking request", logger.Error(err))
+		return err
+	}
+	return h.moveAppointment(ctx, tx, appointmentId, appointmentStatus)
+}
+
+// moveAppointment sets the status of a pending appointment, one staff
+// changed meanwhile is left alone
+func (h *bookingRepo) moveAppointment(ctx context.Context, tx *sql.Tx, id, status string) error {
+	moved, err := scanAppointments(tx.QueryContext(ctx, `
+	UPDATE
+		appointments
+	SET
+		status = $2,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	AND
+		status = 'pending'
+	AND
+		deleted_at IS NULL
+	RETURNING id, client_id, COALESCE(doctor_id::TEXT, ''), date, diagnostics, treatment, amount, status`, id, status))
+	if err != nil {
+		h.log(ctx).Error("Error to update pending appointment", logger.Error(err))
+		return err
+	}
+	for _, a := range moved {
+		data := events.Appointment(a)
+		data.PreviousStatus = repo.StatusPending
+		err = writeEvent(ctx, tx, events.AggregateAppointment, a.Id, events.AppointmentChanged(repo.StatusPending, a.Status), data)
+		if err != nil {
+			h.log(ctx).Error("Error to write appointment event", logger.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+func scanBookingRequest(row interface{ Scan(...interface{}) error }) (*repo.BookingRequest, error) {
+	var (
+		r       repo.BookingRequest
+		minutes int
+	)
+	err := row.Scan(
+		&r.Id,
+		&r.AppointmentId,
+		&r.ClientId,
+		&r.Procedure,
+		&minutes,
+		&r.DoctorId,
+		&r.Date,
+		&r.PhoneDigits,
+		&r.ClientIp,
+		&r.CodeHash,
+		&r.Attempts,
+		&r.Status,
+		&r.ConfirmedBy,
+		&r.ExpiresAt,
+		&r.CreatedAt,
+		&r.DecidedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	r.Duration = time.Duration(minutes) * time.Minute
+	return &r, nil
+}
+
+func (h *bookingRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
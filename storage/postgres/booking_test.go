@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dentist/storage/repo"
+)
+
+func TestCreateRequestOverlap(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	booking := NewBookingRepo(db, testLogger())
+	doctorId := insertDoctor(t, db)
+	day := time.Now().AddDate(0, 0, 3).Truncate(24 * time.Hour)
+
+	client := &repo.BookingClient{
+		Name:        "Test",
+		PhoneNumber: "+998 90 000 00 00",
+		PhoneDigits: "998900000000",
+	}
+	request := func(at time.Duration) error {
+		_, err := booking.CreateRequest(ctx, &repo.BookingRequest{
+			DoctorId:  doctorId,
+			Date:      day.Add(at),
+			Duration:  30 * time.Minute,
+			Procedure: "Checkup",
+			ClientIp:  "203.0.113.9",
+			ExpiresAt: time.Now().Add(15 * time.Minute),
+		}, client, 15*time.Minute)
+		return err
+	}
+
+	tests := []struct {
+		name string
+		at   time.Duration
+		want error
+	}{
+		{"free", 10 * time.Hour, nil},
+		{"overlapping", 10*time.Hour + 15*time.Minute, repo.ErrSlotTaken},
+		{"starting before", 9*time.Hour + 45*time.Minute, repo.ErrSlotTaken},
+		{"right after", 10*time.Hour + 30*time.Minute, nil},
+	}
+	for _, tt := range tests {
+		if err := request(tt.at); !errors.Is(err, tt.want) {
+			t.Fatalf("%s: CreateRequest err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
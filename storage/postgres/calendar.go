@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type calendarRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewCalendarRepo(db *sqlx.DB, log logger.Logger) repo.NewCalendarI {
+	return &calendarRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+const calendarFeedColumns = `
+		f.id,
+		f.token_hash,
+		COALESCE(f.doctor_id::TEXT, ''),
+		f.name,
+		f.mask_patients,
+		f.created_at,
+		f.revoked_at`
+
+// This function is create a calendar feed, only the hash of its token is
+// stored
+func (h *calendarRepo) CreateFeed(ctx context.Context, feed *repo.CalendarFeed) (*repo.CalendarFeed, error) {
+	query := `
+	INSERT INTO
+		calendar_feeds(
+			id,
+			token_hash,
+			doctor_id,
+			name,
+			mask_patients
+		) VALUES ($1, $2, NULLIF($3, '')::UUID, $4, $5)
+	RETURNING created_at`
+
+	feed.Id = uuid.NewString()
+	err := h.db.QueryRowContext(ctx, query,
+		feed.Id,
+		feed.TokenHash,
+		feed.DoctorId,
+		feed.Name,
+		feed.MaskPatients,
+	).Scan(&feed.CreatedAt)
+	if err != nil {
+		h.log(ctx).Error("Error to create calendar feed", logger.Error(err))
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// This function is get every calendar feed, the revoked ones included
+func (h *calendarRepo) GetFeeds(ctx context.Context) ([]*repo.CalendarFeed, error) {
+	query := `
+	SELECT` + calendarFeedColumns + `
+	FROM
+		calendar_feeds f
+	ORDER BY f.created_at DESC`
+
+	rows, err := h.db.QueryContext(ctx, query)
+	if err != nil {
+		h.log(ctx).Error("Error to get calendar feeds", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	feeds := []*repo.CalendarFeed{}
+	for rows.Next() {
+		feed, err := scanCalendarFeed(rows)
+		if err != nil {
+			h.log(ctx).Error("Error to get calendar feeds", logger.Error(err))
+			return nil, err
+		}
+		feeds = append(feeds, feed)
+	}
+
+	return feeds, rows.Err()
+}
+
+// This function is get the live feed of a token, a feed of a deleted
+// doctor is not served
+func (h *calendarRepo) GetFeedByToken(ctx context.Context, tokenHash string) (*repo.CalendarFeed, error) {
+	query := `
+	SELECT` + calendarFeedColumns + `
+	FROM
+		calendar_feeds f
+	LEFT JOIN doctors d ON d.id = f.doctor_id
+	WHERE
+		f.token_hash = $1
+	AND
+		f.revoked_at IS NULL
+	AND
+		(f.doctor_id IS NULL OR d.deleted_at IS NULL)`
+
+	feed, err := scanCalendarFeed(h.db.QueryRowContext(ctx, query, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get calendar feed", logger.Error(err))
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// This function is revoke a calendar feed, its link stops working
+func (h *calendarRepo) RevokeFeed(ctx context.Context, id string) (bool, error) {
+	query := `
+	UPDATE
+		calendar_feeds
+	SET
+		revoked_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	AND
+		revoked_at IS NULL`
+
+	result, err := h.db.ExecContext(ctx, query, id)
+	if err != nil {
+		h.log(ctx).Error("Error to revoke calendar feed", logger.Error(err))
+		return false, err
+	}
+	revoked, _ := result.RowsAffected()
+
+	return revoked > 0, nil
+}
+
+func scanCalendarFeed(row interface{ Scan(...interface{}) error }) (*repo.CalendarFeed, error) {
+	var (
+		feed      repo.CalendarFeed
+		revokedAt sql.NullTime
+	)
+	err := row.Scan(
+		&feed.Id,
+		&feed.TokenHash,
+		&feed.DoctorId,
+		&feed.Name,
+		&feed.MaskPatients,
+		&feed.CreatedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		feed.RevokedAt = &revokedAt.Time
+	}
+	return &feed, nil
+}
+
+func (h *calendarRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
@@ -1,24 +1,34 @@
 package postgres
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
 	"github.com/dentist/storage/repo"
 	"github.com/jmoiron/sqlx"
 )
 
 type clientRepo struct {
-	db *sqlx.DB
+	db     *sqlx.DB
+	logger logger.Logger
 }
 
-func NewClientRepo(db *sqlx.DB) repo.NewClientI {
+func NewClientRepo(db *sqlx.DB, log logger.Logger) repo.NewClientI {
 	return &clientRepo{
-		db: db,
+		db:     db,
+		logger: log,
 	}
 }
 
 // This function is create a client
-func (h *clientRepo) CreateClient(c *repo.Client) (*repo.Client, error) {
+func (h *clientRepo) CreateClient(ctx context.Context, c *repo.Client) (*repo.Client, error) {
 	query := `
 	INSERT INTO
 		clients(
@@ -29,15 +39,16 @@ func (h *clientRepo) CreateClient(c *repo.Client) (*repo.Client, error) {
             phone_number,
             address,
 			birth_date
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
-	RETURNING id, name, last_name, father_name, phone_number, address, birth_date`
-	tx, err := h.db.Begin()
+		) VALUES ($1, $2, $3, $4, $5, $6, $7::DATE)
+	RETURNING id, name, last_name, father_name, phone_number, address, birth_date, created_at`
+	tx, err := h.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Println("Error creating transaction create client: ", err)
+		h.log(ctx).Error("Error creating transaction create client", logger.Error(err))
 		return nil, err
 	}
 	var user repo.Client
-	err = tx.QueryRow(
+	err = tx.QueryRowContext(
+		ctx,
 		query,
 		c.Id,
 		c.Name,
@@ -54,9 +65,16 @@ func (h *clientRepo) CreateClient(c *repo.Client) (*repo.Client, error) {
 		&user.PhoneNumber,
 		&user.Address,
 		&user.BirthDate,
+		&user.CreatedAt,
 	)
 	if err != nil {
-		log.Println("Error to creating client in database: ", err)
+		h.log(ctx).Error("Error to creating client in database", logger.Error(err))
+		tx.Rollback()
+		return nil, err
+	}
+	err = writeEvent(ctx, tx, events.AggregateClient, user.Id, events.ClientCreated, events.Client(&user))
+	if err != nil {
+		h.log(ctx).Error("Error to write client event", logger.Error(err))
 		tx.Rollback()
 		return nil, err
 	}
@@ -69,8 +87,10 @@ func (h *clientRepo) CreateClient(c *repo.Client) (*repo.Client, error) {
 	return &user, nil
 }
 
-// This function is delete a client with client id
-func (h *clientRepo) DeleteClient(id string) (bool, error) {
+// This function is delete a client with client id. The client and its
+// appointments get the same deleted_at (the transaction time), which is
+// how the trash knows which appointments to restore with the client.
+func (h *clientRepo) DeleteClient(ctx context.Context, id string) (bool, error) {
 	query := `
 	UPDATE 
 		clients
@@ -80,18 +100,22 @@ func (h *clientRepo) DeleteClient(id string) (bool, error) {
 		id = $1
 	AND
 		deleted_at IS NULL`
-	tx, err := h.db.Begin()
+	tx, err := h.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Println("Error creating transaction delete client: ", err)
+		h.log(ctx).Error("Error creating transaction delete client", logger.Error(err))
 		return false, err
 	}
 
-	_, err = tx.Exec(query, id)
+	result, err := tx.ExecContext(ctx, query, id)
 	if err != nil {
-		log.Println("Error to delete client in database: ", err)
+		h.log(ctx).Error("Error to delete client in database", logger.Error(err))
 		tx.Rollback()
 		return false, err
 	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		tx.Rollback()
+		return false, nil
+	}
 	query2 := `
 	UPDATE
 		appointments
@@ -100,10 +124,20 @@ func (h *clientRepo) DeleteClient(id string) (bool, error) {
 	WHERE 
 		client_id = $1
 	AND
-		deleted_at IS NULL`
-	_, err = tx.Exec(query2, id)
+		deleted_at IS NULL
+	RETURNING id, client_id, COALESCE(doctor_id::TEXT, ''), date, diagnostics, treatment, amount, status`
+	appointments, err := scanAppointments(tx.QueryContext(ctx, query2, id))
 	if err != nil {
-		log.Println("Error to delete client's appointments in database: ", err)
+		h.log(ctx).Error("Error to delete client's appointments in database", logger.Error(err))
+		tx.Rollback()
+		return false, err
+	}
+	err = writeDeletedAppointments(ctx, tx, appointments)
+	if err == nil {
+		err = writeEvent(ctx, tx, events.AggregateClient, id, events.ClientDeleted, events.DeletedData{Id: id})
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to write client event", logger.Error(err))
 		tx.Rollback()
 		return false, err
 	}
@@ -118,7 +152,7 @@ func (h *clientRepo) DeleteClient(id string) (bool, error) {
 }
 
 // This function is get a client with client id
-func (h *clientRepo) GetClient(id string) (*repo.Client, error) {
+func (h *clientRepo) GetClient(ctx context.Context, id string) (*repo.Client, error) {
 	query := `
 	SELECT 
 	    id,
@@ -127,7 +161,8 @@ func (h *clientRepo) GetClient(id string) (*repo.Client, error) {
         father_name,
         phone_number,
 		address,
-        birth_date
+        birth_date,
+        created_at
 	FROM 
 	    clients
 	WHERE 
@@ -136,7 +171,7 @@ func (h *clientRepo) GetClient(id string) (*repo.Client, error) {
 	    deleted_at IS NULL`
 	
 	var user repo.Client
-	err := h.db.QueryRow(query, id).Scan(
+	err := h.db.QueryRowContext(ctx, query, id).Scan(
 		&user.Id,
 		&user.Name,
 		&user.LastName,
@@ -144,9 +179,13 @@ func (h *clientRepo) GetClient(id string) (*repo.Client, error) {
 		&user.PhoneNumber,
 		&user.Address,
 		&user.BirthDate,
+		&user.CreatedAt,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
 	if err != nil {
-		log.Println("Error to get client in database: ", err)
+		h.log(ctx).Error("Error to get client in database", logger.Error(err))
 		return nil, err
 	}
 
@@ -154,7 +193,7 @@ func (h *clientRepo) GetClient(id string) (*repo.Client, error) {
 }
 
 // This function is update a client with client id
-func (h *clientRepo) UpdateClient(c *repo.Client) (*repo.Client, error) {
+func (h *clientRepo) UpdateClient(ctx context.Context, c *repo.Client) (*repo.Client, error) {
 	query := `
 	UPDATE 
 		clients
@@ -164,21 +203,22 @@ func (h *clientRepo) UpdateClient(c *repo.Client) (*repo.Client, error) {
 		father_name = $3,
         phone_number = $4,
         address = $5,
-        birth_date = $6
+        birth_date = $6::DATE
 	WHERE 
 	    id = $7
 	AND 
 	    deleted_at IS NULL
 	RETURNING
-		id, name, last_name, father_name, phone_number, address, birth_date`
+		id, name, last_name, father_name, phone_number, address, birth_date, created_at`
 
-	tx, err := h.db.Begin()
+	tx, err := h.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Println("Error creating transaction update client: ", err)
+		h.log(ctx).Error("Error creating transaction update client", logger.Error(err))
 		return nil, err
 	}
 	var user repo.Client
-	err = tx.QueryRow(
+	err = tx.QueryRowContext(
+		ctx,
 		query,
 		c.Name,
 		c.LastName,
@@ -195,9 +235,20 @@ func (h *clientRepo) UpdateClient(c *repo.Client) (*repo.Client, error) {
 		&user.PhoneNumber,
 		&user.Address,
 		&user.BirthDate,
+		&user.CreatedAt,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return nil, repo.ErrNotFound
+	}
 	if err != nil {
-		log.Println("Error to updating client: ", err)
+		h.log(ctx).Error("Error to updating client", logger.Error(err))
+		tx.Rollback()
+		return nil, err
+	}
+	err = writeEvent(ctx, tx, events.AggregateClient, user.Id, events.ClientUpdated, events.Client(&user))
+	if err != nil {
+		h.log(ctx).Error("Error to write client event", logger.Error(err))
 		tx.Rollback()
 		return nil, err
 	}
@@ -211,8 +262,46 @@ func (h *clientRepo) UpdateClient(c *repo.Client) (*repo.Client, error) {
 }
 
 // This function is get all clients with given page and limit
-func (h *clientRepo) GetAllClients(req *repo.GetAllClient) (*repo.AllClients, error) {
-	query := `
+func (h *clientRepo) GetAllClients(ctx context.Context, req *repo.GetAllClient) (*repo.AllClients, error) {
+	return h.listClients(ctx, newQuery(), req.Params)
+}
+
+// This function is get all clients count
+func (h *clientRepo) GetAllClientsCount(ctx context.Context) (int, error) {
+	query := `SELECT COUNT(*) FROM clients WHERE deleted_at IS NULL`
+	var resp int
+	err := h.db.QueryRowContext(ctx, query).Scan(&resp)
+	if err != nil {
+		h.log(ctx).Error("Error to get all clients count", logger.Error(err))
+		return 0, err
+	}
+
+	return resp, nil
+}
+
+// This function is searching clients with name or last_name
+func (h *clientRepo) SearchClients(ctx context.Context, str string, params pagination.Params) (*repo.AllClients, error) {
+	pattern := "%" + escapeLike(str) + "%"
+
+	return h.listClients(ctx, newQuery().where("(name ILIKE ? OR last_name ILIKE ?)", pattern, pattern), params)
+}
+
+var byCreatedAt = ordering{column: "created_at"}
+
+// listClients returns one page of not deleted clients matching q,
+// ordered by creation time and id, together with the total count.
+func (h *clientRepo) listClients(ctx context.Context, q *queryBuilder, params pagination.Params) (*repo.AllClients, error) {
+	q.where("deleted_at IS NULL")
+
+	var clients repo.AllClients
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM clients WHERE `+q.sql(), q.args...).Scan(&clients.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count clients", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byCreatedAt, params.Cursor)
+	query := fmt.Sprintf(`
 	SELECT
 		id,
 		name,
@@ -220,21 +309,23 @@ func (h *clientRepo) GetAllClients(req *repo.GetAllClient) (*repo.AllClients, er
         father_name,
         phone_number,
         address,
-		birth_date
+		birth_date,
+		created_at
 	FROM 
 		clients
 	WHERE 
-		deleted_at IS NULL
-	LIMIT $1
-	OFFSET $2`
-	offset := req.Limit * (req.Page - 1)
-	rows, err := h.db.Query(query, req.Limit, offset)
+		%s
+	ORDER BY %s
+	LIMIT %s
+	OFFSET %s`, q.sql(), byCreatedAt.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
-		log.Println("Error to get all clients: ", err)
+		h.log(ctx).Error("Error to get clients", logger.Error(err))
 		return nil, err
 	}
 	defer rows.Close()
-	var clients repo.AllClients
+
 	for rows.Next() {
 		var client repo.Client
 		err = rows.Scan(
@@ -245,74 +336,89 @@ func (h *clientRepo) GetAllClients(req *repo.GetAllClient) (*repo.AllClients, er
 			&client.PhoneNumber,
 			&client.Address,
 			&client.BirthDate,
+			&client.CreatedAt,
 		)
 		if err != nil {
-			log.Println("Error to get all clients: ", err)
+			h.log(ctx).Error("Error to get clients", logger.Error(err))
 			return nil, err
 		}
 		clients.Clients = append(clients.Clients, &client)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	clients.Clients, clients.NextCursor = pagination.Trim(clients.Clients, params.Limit, func(c *repo.Client) pagination.Cursor {
+		return pagination.Cursor{Key: c.CreatedAt.Format(time.RFC3339Nano), Id: c.Id}
+	})
 
 	return &clients, nil
 }
 
-// This function is get all clients count
-func (h *clientRepo) GetAllClientsCount() (int, error) {
-	query := `SELECT COUNT(*) FROM clients WHERE deleted_at IS NULL`
-	var resp int
-	err := h.db.QueryRow(query).Scan(&resp)
-	if err != nil {
-		log.Println("Error to get all clients count")
-		return 0, err
+// This function is stream the active clients in the order they were added
+func (h *clientRepo) StreamClients(ctx context.Context, search string, fn func(*repo.Client) error) error {
+	q := newQuery().where("c.deleted_at IS NULL")
+	if search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		q.where("(c.name ILIKE ? OR c.last_name ILIKE ?)", pattern, pattern)
 	}
 
-	return resp, nil
-}
-
-// This function is searching clients with name or last_name
-func (h *clientRepo) SearchClients(str string) (*repo.AllClients, error) {
-	query := `
-	SELECT 
-		id, 
-		name, 
-		last_name, 
-		father_name, 
-		phone_number, 
-		address, 
-		birth_date
-	FROM 
-		clients
-	WHERE 
-		deleted_at IS NULL 
-	AND
-		name 
-	ILIKE 
-		'%` + str + `%' OR last_name ILIKE '%` + str + `%'`
-
-	var Clients repo.AllClients
-	rows, err := h.db.Query(query)
+	rows, err := h.db.QueryContext(ctx, `
+	SELECT`+clientColumns+`
+	FROM
+		clients c
+	WHERE
+		`+q.sql()+`
+	ORDER BY c.created_at, c.id`, q.args...)
 	if err != nil {
-		log.Println("Error search clients in database", err)
-		return nil, err
+		h.log(ctx).Error("Error to stream clients", logger.Error(err))
+		return err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var client repo.Client
-		err = rows.Scan(
-			&client.Id,
-			&client.Name,
-			&client.LastName,
-			&client.FatherName,
-			&client.PhoneNumber,
-			&client.Address,
-			&client.BirthDate,
-		)
-		if err != nil {
-			log.Println("Error search clients in database", err)
-			return nil, err
+		if err = rows.Scan(clientFields(&client)...); err != nil {
+			h.log(ctx).Error("Error to stream clients", logger.Error(err))
+			return err
+		}
+		if err = fn(&client); err != nil {
+			return err
 		}
-		Clients.Clients = append(Clients.Clients, &client)
 	}
 
-	return &Clients, nil
+	return rows.Err()
+}
+
+// clientColumns selects a client row aliased c in the order of clientFields
+const clientColumns = `
+		c.id,
+		c.name,
+		COALESCE(c.last_name, ''),
+		COALESCE(c.father_name, ''),
+		COALESCE(c.phone_number, ''),
+		COALESCE(c.address, ''),
+		c.birth_date,
+		c.created_at`
+
+func clientFields(c *repo.Client) []interface{} {
+	return []interface{}{
+		&c.Id,
+		&c.Name,
+		&c.LastName,
+		&c.FatherName,
+		&c.PhoneNumber,
+		&c.Address,
+		&c.BirthDate,
+		&c.CreatedAt,
+	}
+}
+
+// escapeLike makes % and _ typed by the user match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (h *clientRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
 }
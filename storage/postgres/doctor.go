@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/jmoiron/sqlx"
+)
+
+type doctorRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewDoctorRepo(db *sqlx.DB, log logger.Logger) repo.NewDoctorI {
+	return &doctorRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+// This function is create a doctor
+func (h *doctorRepo) CreateDoctor(ctx context.Context, d *repo.Doctor) (*repo.Doctor, error) {
+	query := `
+	INSERT INTO
+		doctors(
+			id,
+			name,
+			last_name,
+			specialty,
+			phone_number
+		) VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, name, last_name, specialty, phone_number, created_at`
+
+	var doctor repo.Doctor
+	err := h.db.QueryRowContext(ctx, query, d.Id, d.Name, d.LastName, d.Specialty, d.PhoneNumber).Scan(
+		&doctor.Id,
+		&doctor.Name,
+		&doctor.LastName,
+		&doctor.Specialty,
+		&doctor.PhoneNumber,
+		&doctor.CreatedAt,
+	)
+	if err != nil {
+		h.log(ctx).Error("Error to creating doctor in database", logger.Error(err))
+		return nil, err
+	}
+
+	return &doctor, nil
+}
+
+// This function is get a doctor with doctor id
+func (h *doctorRepo) GetDoctor(ctx context.Context, id string) (*repo.Doctor, error) {
+	query := `
+	SELECT
+		id,
+		name,
+		last_name,
+		specialty,
+		phone_number,
+		created_at
+	FROM
+		doctors
+	WHERE
+		id = $1
+	AND
+		deleted_at IS NULL`
+
+	var doctor repo.Doctor
+	err := h.db.QueryRowContext(ctx, query, id).Scan(
+		&doctor.Id,
+		&doctor.Name,
+		&doctor.LastName,
+		&doctor.Specialty,
+		&doctor.PhoneNumber,
+		&doctor.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get doctor in database", logger.Error(err))
+		return nil, err
+	}
+
+	return &doctor, nil
+}
+
+// This function is update a doctor with doctor id
+func (h *doctorRepo) UpdateDoctor(ctx context.Context, d *repo.Doctor) (*repo.Doctor, error) {
+	query := `
+	UPDATE
+		doctors
+	SET
+		name = $1,
+		last_name = $2,
+		specialty = $3,
+		phone_number = $4,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $5
+	AND
+		deleted_at IS NULL
+	RETURNING id, name, last_name, specialty, phone_number, created_at`
+
+	var doctor repo.Doctor
+	err := h.db.QueryRowContext(ctx, query, d.Name, d.LastName, d.Specialty, d.PhoneNumber, d.Id).Scan(
+		&doctor.Id,
+		&doctor.Name,
+		&doctor.LastName,
+		&doctor.Specialty,
+		&doctor.PhoneNumber,
+		&doctor.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to updating doctor", logger.Error(err))
+		return nil, err
+	}
+
+	return &doctor, nil
+}
+
+// This function is delete a doctor with doctor id, the appointments keep
+// pointing at the deleted doctor
+func (h *doctorRepo) DeleteDoctor(ctx context.Context, id string) (bool, error) {
+	query := `
+	UPDATE
+		doctors
+	SET
+		deleted_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	AND
+		deleted_at IS NULL`
+
+	result, err := h.db.ExecContext(ctx, query, id)
+	if err != nil {
+		h.log(ctx).Error("Error to delete doctor in database", logger.Error(err))
+		return false, err
+	}
+	deleted, _ := result.RowsAffected()
+
+	return deleted > 0, nil
+}
+
+// This function is get all doctors with given page and limit
+func (h *doctorRepo) GetAllDoctors(ctx context.Context, params pagination.Params) (*repo.AllDoctors, error) {
+	q := newQuery().where("deleted_at IS NULL")
+
+	var doctors repo.AllDoctors
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM doctors WHERE `+q.sql(), q.args...).Scan(&doctors.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count doctors", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byCreatedAt, params.Cursor)
+	query := fmt.Sprintf(`
+	SELECT
+		id,
+		name,
+		last_name,
+		specialty,
+		phone_number,
+		created_at
+	FROM
+		doctors
+	WHERE
+		%s
+	ORDER BY %s
+	LIMIT %s
+	OFFSET %s`, q.sql(), byCreatedAt.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get doctors", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var doctor repo.Doctor
+		err = rows.Scan(
+			&doctor.Id,
+			&doctor.Name,
+			&doctor.LastName,
+			&doctor.Specialty,
+			&doctor.PhoneNumber,
+			&doctor.CreatedAt,
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to get doctors", logger.Error(err))
+			return nil, err
+		}
+		doctors.Doctors = append(doctors.Doctors, &doctor)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	doctors.Doctors, doctors.NextCursor = pagination.Trim(doctors.Doctors, params.Limit, func(d *repo.Doctor) pagination.Cursor {
+		return pagination.Cursor{Key: d.CreatedAt.Format(time.RFC3339Nano), Id: d.Id}
+	})
+
+	return &doctors, nil
+}
+
+func (h *doctorRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
@@ -0,0 +1,429 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// maxUpcoming bounds the appointments of the family view
+const maxUpcoming = 100
+
+type familyRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewFamilyRepo(db *sqlx.DB, log logger.Logger) repo.NewFamilyI {
+	return &familyRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+// symmetric relations are stored from both sides
+func symmetric(kind string) bool {
+	return kind == repo.RelationSpouse || kind == repo.RelationSibling
+}
+
+// This function is add a relation between two active clients, adding an
+// existing one again changes nothing
+func (h *familyRepo) AddRelation(ctx context.Context, clientId, relatedId, kind string) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Error("Error creating transaction add relation", logger.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	var active int
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		COUNT(*)
+	FROM
+		clients
+	WHERE
+		id IN ($1, $2)
+	AND
+		deleted_at IS NULL`, clientId, relatedId).Scan(&active)
+	if err != nil {
+		h.log(ctx).Error("Error to get related clients", logger.Error(err))
+		return err
+	}
+	if active != 2 {
+		return repo.ErrNotFound
+	}
+
+	query := `
+	INSERT INTO
+		client_relations(
+			client_id,
+			related_id,
+			kind
+		) VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING`
+	if _, err = tx.ExecContext(ctx, query, clientId, relatedId, kind); err != nil {
+		h.log(ctx).Error("Error to add relation", logger.Error(err))
+		return err
+	}
+	if symmetric(kind) {
+		if _, err = tx.ExecContext(ctx, query, relatedId, clientId, kind); err != nil {
+			h.log(ctx).Error("Error to add relation", logger.Error(err))
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// This function is delete a relation
+func (h *familyRepo) DeleteRelation(ctx context.Context, clientId, relatedId, kind string) (bool, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Error("Error creating transaction delete relation", logger.Error(err))
+		return false, err
+	}
+	defer tx.Rollback()
+
+	pairs := [][2]string{{clientId, relatedId}}
+	if symmetric(kind) {
+		pairs = append(pairs, [2]string{relatedId, clientId})
+	}
+
+	var deleted int64
+	for _, p := range pairs {
+		result, err := tx.ExecContext(ctx, `
+		DELETE FROM
+			client_relations
+		WHERE
+			client_id = $1
+		AND
+			related_id = $2
+		AND
+			kind = $3`, p[0], p[1], kind)
+		if err != nil {
+			h.log(ctx).Error("Error to delete relation", logger.Error(err))
+			return false, err
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+
+		_, err = tx.ExecContext(ctx, `
+		UPDATE
+			clients
+		SET
+			guarantor_id = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			id = $1
+		AND
+			guarantor_id = $2
+		AND
+			NOT EXISTS (
+				SELECT 1 FROM client_relations
+				WHERE client_id = $1 AND related_id = $2 AND kind IN ('guardian', 'spouse')
+			)`, p[0], p[1])
+		if err != nil {
+			h.log(ctx).Error("Error to clear guarantor", logger.Error(err))
+			return false, err
+		}
+	}
+	if deleted == 0 {
+		return false, nil
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// This function is get the relations of a client, the children it is the
+// guardian of are listed as wards
+func (h *familyRepo) GetRelations(ctx context.Context, clientId string) ([]*repo.Relation, error) {
+	query := `
+	SELECT
+		r.kind,
+		r.created_at,` + clientColumns + `
+	FROM
+		client_relations r
+	JOIN
+		clients c ON c.id = r.related_id
+	WHERE
+		r.client_id = $1
+	AND
+		c.deleted_at IS NULL
+	UNION ALL
+	SELECT
+		'` + repo.RelationWard + `',
+		r.created_at,` + clientColumns + `
+	FROM
+		client_relations r
+	JOIN
+		clients c ON c.id = r.client_id
+	WHERE
+		r.related_id = $1
+	AND
+		r.kind = '` + repo.RelationGuardian + `'
+	AND
+		c.deleted_at IS NULL
+	ORDER BY 1, 2`
+
+	rows, err := h.db.QueryContext(ctx, query, clientId)
+	if err != nil {
+		h.log(ctx).Error("Error to get relations", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var relations []*repo.Relation
+	for rows.Next() {
+		var relation repo.Relation
+		dest := append([]interface{}{&relation.Kind, &relation.CreatedAt}, clientFields(&relation.Related)...)
+		if err = rows.Scan(dest...); err != nil {
+			h.log(ctx).Error("Error to get relations", logger.Error(err))
+			return nil, err
+		}
+		relations = append(relations, &relation)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return relations, nil
+}
+
+// This function is count the active guardians of a client
+func (h *familyRepo) CountGuardians(ctx context.Context, clientId string) (int, error) {
+	query := `
+	SELECT
+		COUNT(*)
+	FROM
+		client_relations r
+	JOIN
+		clients g ON g.id = r.related_id
+	WHERE
+		r.client_id = $1
+	AND
+		r.kind = '` + repo.RelationGuardian + `'
+	AND
+		g.deleted_at IS NULL`
+
+	var count int
+	if err := h.db.QueryRowContext(ctx, query, clientId).Scan(&count); err != nil {
+		h.log(ctx).Error("Error to count guardians", logger.Error(err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// This function is set the guarantor of a client, it must be an active
+// guardian or spouse of the client
+func (h *familyRepo) SetGuarantor(ctx context.Context, clientId, guarantorId string) error {
+	var exists bool
+	err := h.db.QueryRowContext(ctx, `
+	SELECT EXISTS (SELECT 1 FROM clients WHERE id = $1 AND deleted_at IS NULL)`, clientId).Scan(&exists)
+	if err != nil {
+		h.log(ctx).Error("Error to get client", logger.Error(err))
+		return err
+	}
+	if !exists {
+		return repo.ErrNotFound
+	}
+
+	if guarantorId == "" {
+		_, err = h.db.ExecContext(ctx, `
+		UPDATE
+			clients
+		SET
+			guarantor_id = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			id = $1`, clientId)
+		if err != nil {
+			h.log(ctx).Error("Error to clear guarantor", logger.Error(err))
+		}
+		return err
+	}
+
+	result, err := h.db.ExecContext(ctx, `
+	UPDATE
+		clients
+	SET
+		guarantor_id = $2,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	AND
+		EXISTS (
+			SELECT 1
+			FROM client_relations r
+			JOIN clients g ON g.id = r.related_id AND g.deleted_at IS NULL
+			WHERE r.client_id = $1 AND r.related_id = $2 AND r.kind IN ('guardian', 'spouse')
+		)`, clientId, guarantorId)
+	if err != nil {
+		h.log(ctx).Error("Error to set guarantor", logger.Error(err))
+		return err
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		return repo.ErrNotRelated
+	}
+
+	return nil
+}
+
+// This function is get who should be contacted about the client
+func (h *familyRepo) GetContact(ctx context.Context, clientId string) (*repo.Client, error) {
+	query := `
+	SELECT` + clientColumns + `
+	FROM
+		clients c
+	WHERE
+		c.id = (
+			SELECT COALESCE(g.id, x.id)
+			FROM clients x
+			LEFT JOIN clients g ON g.id = x.guarantor_id AND g.deleted_at IS NULL
+			WHERE x.id = $1 AND x.deleted_at IS NULL
+		)`
+
+	var contact repo.Client
+	err := h.db.QueryRowContext(ctx, query, clientId).Scan(clientFields(&contact)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get contact", logger.Error(err))
+		return nil, err
+	}
+
+	return &contact, nil
+}
+
+// This function is get the family of a client: every active client
+// reachable through relations in any direction
+func (h *familyRepo) GetFamily(ctx context.Context, clientId string, from time.Time) (*repo.Family, error) {
+	query := `
+	WITH RECURSIVE family(id) AS (
+		SELECT id FROM clients WHERE id = $1 AND deleted_at IS NULL
+		UNION
+		SELECT CASE WHEN r.client_id = f.id THEN r.related_id ELSE r.client_id END
+		FROM family f
+		JOIN client_relations r ON r.client_id = f.id OR r.related_id = f.id
+	)
+	SELECT` + clientColumns + `,
+		COALESCE(c.guarantor_id::TEXT, '')
+	FROM
+		clients c
+	JOIN
+		family f ON f.id = c.id
+	WHERE
+		c.deleted_at IS NULL
+	ORDER BY c.birth_date NULLS LAST, c.name`
+
+	rows, err := h.db.QueryContext(ctx, query, clientId)
+	if err != nil {
+		h.log(ctx).Error("Error to get family", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		family repo.Family
+		ids    []string
+	)
+	for rows.Next() {
+		var member repo.FamilyMember
+		if err = rows.Scan(append(clientFields(&member.Client), &member.GuarantorId)...); err != nil {
+			h.log(ctx).Error("Error to get family", logger.Error(err))
+			return nil, err
+		}
+		family.Members = append(family.Members, &member)
+		ids = append(ids, member.Id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, repo.ErrNotFound
+	}
+
+	rows, err = h.db.QueryContext(ctx, `
+	SELECT
+		id,
+		client_id,
+		COALESCE(doctor_id::TEXT, ''),
+		date,
+		diagnostics,
+		treatment,
+		amount,
+		status
+	FROM
+		appointments
+	WHERE
+		client_id = ANY($1)
+	AND
+		deleted_at IS NULL
+	AND
+		date >= $2
+	AND
+		status IN ('pending', 'scheduled', 'confirmed')
+	ORDER BY date, id
+	LIMIT $3`, pq.Array(ids), from, maxUpcoming)
+	if err != nil {
+		h.log(ctx).Error("Error to get family appointments", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var appointment repo.Appointment
+		err = rows.Scan(
+			&appointment.Id,
+			&appointment.ClientId,
+			&appointment.DoctorId,
+			&appointment.Date,
+			&appointment.Diagnostics,
+			&appointment.Treatment,
+			&appointment.Amount,
+			&appointment.Status,
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to get family appointments", logger.Error(err))
+			return nil, err
+		}
+		family.Upcoming = append(family.Upcoming, &appointment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = h.db.QueryRowContext(ctx, `
+	SELECT
+		COALESCE(SUM(amount), 0)
+	FROM
+		appointments
+	WHERE
+		client_id = ANY($1)
+	AND
+		deleted_at IS NULL
+	AND
+		status = 'completed'`, pq.Array(ids)).Scan(&family.Balance)
+	if err != nil {
+		h.log(ctx).Error("Error to get family balance", logger.Error(err))
+		return nil, err
+	}
+
+	return &family, nil
+}
+
+func (h *familyRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
@@ -0,0 +1,303 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type importRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewImportRepo(db *sqlx.DB, log logger.Logger) repo.NewImportI {
+	return &importRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+// This function is get the keys of every active client, the importer
+// matches rows against them in memory
+func (h *importRepo) ClientKeys(ctx context.Context) ([]*repo.ClientKey, error) {
+	query := `
+	SELECT
+		c.id,
+		COALESCE(` + phoneDigits("c") + `, ''),
+		lower(` + fullName("c") + `),
+		c.birth_date
+	FROM
+		clients c
+	WHERE
+		c.deleted_at IS NULL`
+
+	rows, err := h.db.QueryContext(ctx, query)
+	if err != nil {
+		h.log(ctx).Error("Error to get client keys", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*repo.ClientKey
+	for rows.Next() {
+		var key repo.ClientKey
+		if err = rows.Scan(&key.Id, &key.Phone, &key.Name, &key.BirthDate); err != nil {
+			h.log(ctx).Error("Error to get client keys", logger.Error(err))
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+
+	return keys, rows.Err()
+}
+
+// This function is get which of the keys already have an active appointment
+func (h *importRepo) ExistingAppointments(ctx context.Context, keys []repo.AppointmentKey) ([]repo.AppointmentKey, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	clientIds := make([]string, len(keys))
+	dates := make([]string, len(keys))
+	for i, key := range keys {
+		clientIds[i] = key.ClientId
+		dates[i] = key.Date.Format(time.RFC3339Nano)
+	}
+
+	query := `
+	SELECT
+		k.client_id,
+		k.date
+	FROM
+		unnest($1::UUID[], $2::TIMESTAMPTZ[]) AS k(client_id, date)
+	WHERE
+		EXISTS (
+			SELECT 1 FROM appointments a
+			WHERE a.client_id = k.client_id AND a.date = k.date AND a.deleted_at IS NULL
+		)`
+
+	rows, err := h.db.QueryContext(ctx, query, pq.Array(clientIds), pq.Array(dates))
+	if err != nil {
+		h.log(ctx).Error("Error to get existing appointments", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var existing []repo.AppointmentKey
+	for rows.Next() {
+		var key repo.AppointmentKey
+		if err = rows.Scan(&key.ClientId, &key.Date); err != nil {
+			h.log(ctx).Error("Error to get existing appointments", logger.Error(err))
+			return nil, err
+		}
+		existing = append(existing, key)
+	}
+
+	return existing, rows.Err()
+}
+
+// This function is insert a batch of imported clients and appointments,
+// all of it or nothing
+func (h *importRepo) ImportBatch(ctx context.Context, clients []*repo.Client, appointments []*repo.Appointment) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Error("Error creating transaction import batch", logger.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(clients) > 0 {
+		var (
+			ids, names, lastNames, fatherNames, phones, addresses []string
+			birthDates                                            []civil.Date
+		)
+		for _, c := range clients {
+			ids = append(ids, c.Id)
+			names = append(names, c.Name)
+			lastNames = append(lastNames, c.LastName)
+			fatherNames = append(fatherNames, c.FatherName)
+			phones = append(phones, c.PhoneNumber)
+			addresses = append(addresses, c.Address)
+			birthDates = append(birthDates, c.BirthDate)
+		}
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO
+			clients(
+				id,
+				name,
+				last_name,
+				father_name,
+				phone_number,
+				address,
+				birth_date
+			)
+		SELECT * FROM unnest($1::UUID[], $2::TEXT[], $3::TEXT[], $4::TEXT[], $5::TEXT[], $6::TEXT[], $7::DATE[])`,
+			pq.Array(ids),
+			pq.Array(names),
+			pq.Array(lastNames),
+			pq.Array(fatherNames),
+			pq.Array(phones),
+			pq.Array(addresses),
+			pq.Array(birthDates),
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to import clients", logger.Error(err))
+			return err
+		}
+	}
+
+	if len(appointments) > 0 {
+		var (
+			ids, clientIds, dates, diagnostics, treatments, statuses []string
+			amounts                                                  []int64
+		)
+		for _, a := range appointments {
+			ids = append(ids, a.Id)
+			clientIds = append(clientIds, a.ClientId)
+			dates = append(dates, a.Date.Format(time.RFC3339Nano))
+			diagnostics = append(diagnostics, a.Diagnostics)
+			treatments = append(treatments, a.Treatment)
+			amounts = append(amounts, int64(a.Amount))
+			statuses = append(statuses, a.Status)
+		}
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO
+			appointments(
+				id,
+				client_id,
+				date,
+				diagnostics,
+				treatment,
+				amount,
+				status
+			)
+		SELECT * FROM unnest($1::UUID[], $2::UUID[], $3::TIMESTAMPTZ[], $4::TEXT[], $5::TEXT[], $6::INT[], $7::TEXT[])`,
+			pq.Array(ids),
+			pq.Array(clientIds),
+			pq.Array(dates),
+			pq.Array(diagnostics),
+			pq.Array(treatments),
+			pq.Array(amounts),
+			pq.Array(statuses),
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to import appointments", logger.Error(err))
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// This function is record an import run
+func (h *importRepo) SaveImport(ctx context.Context, run *repo.ImportRun) error {
+	problems := run.Problems
+	if problems == nil {
+		problems = []*repo.ImportProblem{}
+	}
+	problemsJSON, err := json.Marshal(problems)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO
+		imports(
+			id,
+			kind,
+			file_name,
+			dry_run,
+			total,
+			imported,
+			created_clients,
+			duplicates,
+			failed,
+			header,
+			problems
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	RETURNING created_at`
+	err = h.db.QueryRowContext(ctx, query,
+		run.Id,
+		run.Kind,
+		run.FileName,
+		run.DryRun,
+		run.Total,
+		run.Imported,
+		run.CreatedClients,
+		run.Duplicates,
+		run.Failed,
+		pq.Array(run.Header),
+		string(problemsJSON),
+	).Scan(&run.CreatedAt)
+	if err != nil {
+		h.log(ctx).Error("Error to save import", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// This function is get an import run with its problems
+func (h *importRepo) GetImport(ctx context.Context, id string) (*repo.ImportRun, error) {
+	query := `
+	SELECT
+		id,
+		kind,
+		file_name,
+		dry_run,
+		total,
+		imported,
+		created_clients,
+		duplicates,
+		failed,
+		header,
+		problems,
+		created_at
+	FROM
+		imports
+	WHERE
+		id = $1`
+
+	var (
+		run          repo.ImportRun
+		problemsJSON []byte
+	)
+	err := h.db.QueryRowContext(ctx, query, id).Scan(
+		&run.Id,
+		&run.Kind,
+		&run.FileName,
+		&run.DryRun,
+		&run.Total,
+		&run.Imported,
+		&run.CreatedClients,
+		&run.Duplicates,
+		&run.Failed,
+		pq.Array(&run.Header),
+		&problemsJSON,
+		&run.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get import", logger.Error(err))
+		return nil, err
+	}
+	if err = json.Unmarshal(problemsJSON, &run.Problems); err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+func (h *importRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
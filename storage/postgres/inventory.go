@@ -0,0 +1,916 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type inventoryRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewInventoryRepo(db *sqlx.DB, log logger.Logger) repo.NewInventoryI {
+	return &inventoryRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+var (
+	byName              = ordering{column: "name"}
+	byMovementCreatedAt = ordering{column: "created_at", desc: true}
+)
+
+const itemColumns = `
+		i.id,
+		i.name,
+		i.unit,
+		i.sku,
+		i.min_stock,
+		COALESCE((SELECT SUM(b.quantity) FROM inventory_batches b WHERE b.item_id = i.id), 0),
+		i.created_at,
+		i.updated_at`
+
+const batchColumns = `
+		b.id,
+		b.item_id,
+		b.location_id,
+		l.name,
+		b.lot,
+		b.expires_on,
+		b.quantity,
+		b.received_at`
+
+const movementColumns = `
+		id,
+		item_id,
+		COALESCE(batch_id::TEXT, ''),
+		COALESCE(location_id::TEXT, ''),
+		quantity,
+		reason,
+		COALESCE(appointment_id::TEXT, ''),
+		note,
+		created_at`
+
+// This function is create an inventory item
+func (h *inventoryRepo) CreateItem(ctx context.Context, item *repo.InventoryItem) (*repo.InventoryItem, error) {
+	query := `
+	INSERT INTO
+		inventory_items AS i(
+			id,
+			name,
+			unit,
+			sku,
+			min_stock
+		) VALUES ($1, $2, $3, $4, $5)
+	RETURNING` + itemColumns
+
+	created, err := scanItem(h.db.QueryRowContext(ctx, query, uuid.NewString(), item.Name, item.Unit, item.Sku, item.MinStock))
+	if isUniqueViolation(err) {
+		return nil, repo.ErrNameTaken
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to create inventory item", logger.Error(err))
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// This function is get an inventory item with its stock
+func (h *inventoryRepo) GetItem(ctx context.Context, id string) (*repo.InventoryItem, error) {
+	query := `
+	SELECT` + itemColumns + `
+	FROM
+		inventory_items i
+	WHERE
+		i.id = $1
+	AND
+		i.deleted_at IS NULL`
+
+	item, err := scanItem(h.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get inventory item", logger.Error(err))
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// This function is get the inventory items by name
+func (h *inventoryRepo) GetItems(ctx context.Context, params pagination.Params) (*repo.AllInventoryItems, error) {
+	q := newQuery().where("deleted_at IS NULL")
+
+	var items repo.AllInventoryItems
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM inventory_items WHERE `+q.sql(), q.args...).Scan(&items.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count inventory items", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byName, params.Cursor)
+	query := fmt.Sprintf(`
+	SELECT`+itemColumns+`
+	FROM
+		inventory_items i
+	WHERE
+		%s
+	ORDER BY %s
+	LIMIT %s
+	OFFSET %s`, q.sql(), byName.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get inventory items", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			h.log(ctx).Error("Error to get inventory items", logger.Error(err))
+			return nil, err
+		}
+		items.Items = append(items.Items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	items.Items, items.NextCursor = pagination.Trim(items.Items, params.Limit, func(i *repo.InventoryItem) pagination.Cursor {
+		return pagination.Cursor{Key: i.Name, Id: i.Id}
+	})
+
+	return &items, nil
+}
+
+// This function is update the name, unit, sku and minimum stock of an item
+func (h *inventoryRepo) UpdateItem(ctx context.Context, item *repo.InventoryItem) (*repo.InventoryItem, error) {
+	query := `
+	UPDATE
+		inventory_items AS i
+	SET
+		name = $2,
+		unit = $3,
+		sku = $4,
+		min_stock = $5,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	AND
+		deleted_at IS NULL
+	RETURNING` + itemColumns
+
+	updated, err := scanItem(h.db.QueryRowContext(ctx, query, item.Id, item.Name, item.Unit, item.Sku, item.MinStock))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if isUniqueViolation(err) {
+		return nil, repo.ErrNameTaken
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to update inventory item", logger.Error(err))
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// This function is delete an inventory item
+func (h *inventoryRepo) DeleteItem(ctx context.Context, id string) error {
+	result, err := h.db.ExecContext(ctx, `UPDATE inventory_items SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		h.log(ctx).Error("Error to delete inventory item", logger.Error(err))
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// This function is create a storage location
+func (h *inventoryRepo) CreateLocation(ctx context.Context, location *repo.StorageLocation) (*repo.StorageLocation, error) {
+	var created repo.StorageLocation
+	err := h.db.QueryRowContext(ctx, `
+	INSERT INTO
+		storage_locations(
+			id,
+			name
+		) VALUES ($1, $2)
+	RETURNING id, name, created_at`, uuid.NewString(), location.Name).Scan(&created.Id, &created.Name, &created.CreatedAt)
+	if isUniqueViolation(err) {
+		return nil, repo.ErrNameTaken
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to create storage location", logger.Error(err))
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// This function is get the storage locations by name
+func (h *inventoryRepo) GetLocations(ctx context.Context) ([]*repo.StorageLocation, error) {
+	rows, err := h.db.QueryContext(ctx, `SELECT id, name, created_at FROM storage_locations ORDER BY name, id`)
+	if err != nil {
+		h.log(ctx).Error("Error to get storage locations", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locations []*repo.StorageLocation
+	for rows.Next() {
+		var l repo.StorageLocation
+		if err = rows.Scan(&l.Id, &l.Name, &l.CreatedAt); err != nil {
+			h.log(ctx).Error("Error to get storage locations", logger.Error(err))
+			return nil, err
+		}
+		locations = append(locations, &l)
+	}
+
+	return locations, rows.Err()
+}
+
+// This function is put a batch into stock. It fails with ErrNotFound when
+// the item or the location does not exist.
+func (h *inventoryRepo) ReceiveBatch(ctx context.Context, batch *repo.InventoryBatch, note string) (*repo.InventoryBatch, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		EXISTS (SELECT 1 FROM inventory_items WHERE id = $1 AND deleted_at IS NULL)
+	AND
+		EXISTS (SELECT 1 FROM storage_locations WHERE id = $2)`, batch.ItemId, batch.LocationId).Scan(&exists)
+	if err != nil {
+		h.log(ctx).Error("Error to check batch item", logger.Error(err))
+		return nil, err
+	}
+	if !exists {
+		return nil, repo.ErrNotFound
+	}
+
+	id, err := insertBatch(ctx, tx, batch, note)
+	if err != nil {
+		h.log(ctx).Error("Error to create batch", logger.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetBatch(ctx, id)
+}
+
+// This function is get a batch
+func (h *inventoryRepo) GetBatch(ctx context.Context, id string) (*repo.InventoryBatch, error) {
+	query := `
+	SELECT` + batchColumns + `
+	FROM
+		inventory_batches b
+	JOIN storage_locations l ON l.id = b.location_id
+	WHERE
+		b.id = $1`
+
+	batch, err := scanBatch(h.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get batch", logger.Error(err))
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// This function is get the batches of an item in stock, first expiring
+// first
+func (h *inventoryRepo) GetBatches(ctx context.Context, itemId string) ([]*repo.InventoryBatch, error) {
+	query := `
+	SELECT` + batchColumns + `
+	FROM
+		inventory_batches b
+	JOIN storage_locations l ON l.id = b.location_id
+	WHERE
+		b.item_id = $1
+	AND
+		b.quantity > 0
+	ORDER BY b.expires_on NULLS LAST, b.received_at, b.id`
+
+	rows, err := h.db.QueryContext(ctx, query, itemId)
+	if err != nil {
+		h.log(ctx).Error("Error to get batches", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []*repo.InventoryBatch
+	for rows.Next() {
+		batch, err := scanBatch(rows)
+		if err != nil {
+			h.log(ctx).Error("Error to get batches", logger.Error(err))
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, rows.Err()
+}
+
+// This function is change the quantity of a batch by delta
+func (h *inventoryRepo) AdjustBatch(ctx context.Context, id string, delta float64, note string) (*repo.InventoryBatch, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var itemId, locationId string
+	err = tx.QueryRowContext(ctx, `SELECT item_id, location_id FROM inventory_batches WHERE id = $1 FOR UPDATE`, id).Scan(&itemId, &locationId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get batch", logger.Error(err))
+		return nil, err
+	}
+	before, err := itemStock(ctx, tx, itemId)
+	if err != nil {
+		h.log(ctx).Error("Error to get stock", logger.Error(err))
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE inventory_batches SET quantity = quantity + $2 WHERE id = $1 AND quantity + $2 >= 0`, id, delta)
+	if err != nil {
+		h.log(ctx).Error("Error to adjust batch", logger.Error(err))
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, repo.ErrInsufficientStock
+	}
+	err = writeMovement(ctx, tx, &repo.StockMovement{
+		ItemId:     itemId,
+		BatchId:    id,
+		LocationId: locationId,
+		Quantity:   delta,
+		Reason:     repo.MovementAdjustment,
+		Note:       note,
+	})
+	if err != nil {
+		h.log(ctx).Error("Error to write stock movement", logger.Error(err))
+		return nil, err
+	}
+	if err = checkLowStock(ctx, tx, itemId, before); err != nil {
+		h.log(ctx).Error("Error to check low stock", logger.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetBatch(ctx, id)
+}
+
+// This function is replace the bill of materials of a procedure. It fails
+// with ErrNotFound when one of the items does not exist.
+func (h *inventoryRepo) SetMaterials(ctx context.Context, procedure string, materials []*repo.ProcedureMaterial) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	procedure = strings.TrimSpace(procedure)
+	if _, err = tx.ExecContext(ctx, `DELETE FROM procedure_materials WHERE LOWER(procedure) = LOWER($1)`, procedure); err != nil {
+		h.log(ctx).Error("Error to clear procedure materials", logger.Error(err))
+		return err
+	}
+	for _, m := range materials {
+		result, err := tx.ExecContext(ctx, `
+		INSERT INTO
+			procedure_materials(
+				procedure,
+				item_id,
+				quantity
+			)
+		SELECT
+			$1, id, $3
+		FROM
+			inventory_items
+		WHERE
+			id = $2
+		AND
+			deleted_at IS NULL
+		ON CONFLICT (procedure, item_id) DO UPDATE SET quantity = procedure_materials.quantity + EXCLUDED.quantity`,
+			procedure, m.ItemId, m.Quantity)
+		if err != nil {
+			h.log(ctx).Error("Error to set procedure materials", logger.Error(err))
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return repo.ErrNotFound
+		}
+	}
+
+	return tx.Commit()
+}
+
+// This function is get the bills of materials by procedure
+func (h *inventoryRepo) GetMaterials(ctx context.Context, procedure string) ([]*repo.ProcedureMaterial, error) {
+	q := newQuery().where("i.deleted_at IS NULL")
+	if procedure != "" {
+		q.where("LOWER(pm.procedure) = LOWER(?)", strings.TrimSpace(procedure))
+	}
+	query := `
+	SELECT
+		pm.procedure,
+		pm.item_id,
+		i.name,
+		i.unit,
+		pm.quantity
+	FROM
+		procedure_materials pm
+	JOIN inventory_items i ON i.id = pm.item_id
+	WHERE
+		` + q.sql() + `
+	ORDER BY LOWER(pm.procedure), i.name`
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get procedure materials", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var materials []*repo.ProcedureMaterial
+	for rows.Next() {
+		var m repo.ProcedureMaterial
+		if err = rows.Scan(&m.Procedure, &m.ItemId, &m.ItemName, &m.Unit, &m.Quantity); err != nil {
+			h.log(ctx).Error("Error to get procedure materials", logger.Error(err))
+			return nil, err
+		}
+		materials = append(materials, &m)
+	}
+
+	return materials, rows.Err()
+}
+
+// This function is take the materials of an appointment out of stock,
+// once. What stock does not cover is written as a movement without a
+// batch so the shortfall shows in the history.
+func (h *inventoryRepo) Consume(ctx context.Context, appointmentId, treatment string) (bool, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `INSERT INTO inventory_consumptions(appointment_id) VALUES ($1) ON CONFLICT DO NOTHING`, appointmentId)
+	if err != nil {
+		h.log(ctx).Error("Error to record consumption", logger.Error(err))
+		return false, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	// the items are consumed in id order so two appointments using the
+	// same items lock their batches in the same order
+	rows, err := tx.QueryContext(ctx, `
+	SELECT
+		pm.item_id,
+		pm.quantity
+	FROM
+		procedure_materials pm
+	JOIN inventory_items i ON i.id = pm.item_id
+	WHERE
+		i.deleted_at IS NULL
+	AND
+		LOWER(pm.procedure) = LOWER(TRIM($1))
+	ORDER BY pm.item_id`, treatment)
+	if err != nil {
+		h.log(ctx).Error("Error to get procedure materials", logger.Error(err))
+		return false, err
+	}
+	var materials []*repo.ProcedureMaterial
+	for rows.Next() {
+		var m repo.ProcedureMaterial
+		if err = rows.Scan(&m.ItemId, &m.Quantity); err != nil {
+			rows.Close()
+			return false, err
+		}
+		materials = append(materials, &m)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return false, err
+	}
+
+	for _, m := range materials {
+		if err = h.consumeItem(ctx, tx, appointmentId, m); err != nil {
+			h.log(ctx).Error("Error to consume material", logger.String("item_id", m.ItemId), logger.Error(err))
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// consumeItem takes the quantity of m out of the batches of its item that
+// did not expire, first expiring first
+func (h *inventoryRepo) consumeItem(ctx context.Context, tx *sql.Tx, appointmentId string, m *repo.ProcedureMaterial) error {
+	before, err := itemStock(ctx, tx, m.ItemId)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+	SELECT
+		id,
+		location_id,
+		quantity
+	FROM
+		inventory_batches
+	WHERE
+		item_id = $1
+	AND
+		quantity > 0
+	AND
+		(expires_on IS NULL OR expires_on >= CURRENT_DATE)
+	ORDER BY expires_on NULLS LAST, received_at, id
+	FOR UPDATE`, m.ItemId)
+	if err != nil {
+		return err
+	}
+	var batches []*repo.InventoryBatch
+	for rows.Next() {
+		var b repo.InventoryBatch
+		if err = rows.Scan(&b.Id, &b.LocationId, &b.Quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		batches = append(batches, &b)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	need := m.Quantity
+	for _, b := range batches {
+		if need <= 0 {
+			break
+		}
+		take := round3(math.Min(need, b.Quantity))
+		if _, err = tx.ExecContext(ctx, `UPDATE inventory_batches SET quantity = GREATEST(quantity - $2, 0) WHERE id = $1`, b.Id, take); err != nil {
+			return err
+		}
+		err = writeMovement(ctx, tx, &repo.StockMovement{
+			ItemId:        m.ItemId,
+			BatchId:       b.Id,
+			LocationId:    b.LocationId,
+			Quantity:      -take,
+			Reason:        repo.MovementConsumption,
+			AppointmentId: appointmentId,
+		})
+		if err != nil {
+			return err
+		}
+		need = round3(need - take)
+	}
+	if need > 0 {
+		err = writeMovement(ctx, tx, &repo.StockMovement{
+			ItemId:        m.ItemId,
+			Quantity:      -need,
+			Reason:        repo.MovementConsumption,
+			AppointmentId: appointmentId,
+			Note:          "not in stock",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return checkLowStock(ctx, tx, m.ItemId, before)
+}
+
+// This function is get the stock movements, newest first
+func (h *inventoryRepo) GetMovements(ctx context.Context, filter *repo.MovementFilter, params pagination.Params) (*repo.AllStockMovements, error) {
+	q := newQuery()
+	if filter.ItemId != "" {
+		q.where("item_id = ?", filter.ItemId)
+	}
+	if filter.AppointmentId != "" {
+		q.where("appointment_id = ?", filter.AppointmentId)
+	}
+	if filter.Reason != "" {
+		q.where("reason = ?", filter.Reason)
+	}
+
+	var movements repo.AllStockMovements
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM stock_movements WHERE `+q.sql(), q.args...).Scan(&movements.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count stock movements", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byMovementCreatedAt, params.Cursor)
+	query := fmt.Sprintf(`
+	SELECT`+movementColumns+`
+	FROM
+		stock_movements
+	WHERE
+		%s
+	ORDER BY %s
+	LIMIT %s
+	OFFSET %s`, q.sql(), byMovementCreatedAt.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get stock movements", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m repo.StockMovement
+		err = rows.Scan(
+			&m.Id,
+			&m.ItemId,
+			&m.BatchId,
+			&m.LocationId,
+			&m.Quantity,
+			&m.Reason,
+			&m.AppointmentId,
+			&m.Note,
+			&m.CreatedAt,
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to get stock movements", logger.Error(err))
+			return nil, err
+		}
+		movements.Movements = append(movements.Movements, &m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	movements.Movements, movements.NextCursor = pagination.Trim(movements.Movements, params.Limit, func(m *repo.StockMovement) pagination.Cursor {
+		return pagination.Cursor{Key: m.CreatedAt.Format(time.RFC3339Nano), Id: m.Id}
+	})
+
+	return &movements, nil
+}
+
+// This function is get the items below their minimum stock
+func (h *inventoryRepo) LowStock(ctx context.Context) ([]*repo.InventoryItem, error) {
+	query := `
+	SELECT * FROM (
+		SELECT` + itemColumns + `
+		FROM
+			inventory_items i
+		WHERE
+			i.deleted_at IS NULL
+	) s (id, name, unit, sku, min_stock, stock, created_at, updated_at)
+	WHERE
+		stock < min_stock
+	ORDER BY name, id`
+
+	rows, err := h.db.QueryContext(ctx, query)
+	if err != nil {
+		h.log(ctx).Error("Error to get low stock", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*repo.InventoryItem
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			h.log(ctx).Error("Error to get low stock", logger.Error(err))
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// This function is get the batches in stock expiring on before or earlier
+func (h *inventoryRepo) ExpiringBatches(ctx context.Context, before civil.Date) ([]*repo.ExpiringBatch, error) {
+	query := `
+	SELECT` + batchColumns + `,
+		i.name,
+		i.unit
+	FROM
+		inventory_batches b
+	JOIN storage_locations l ON l.id = b.location_id
+	JOIN inventory_items i ON i.id = b.item_id
+	WHERE
+		i.deleted_at IS NULL
+	AND
+		b.quantity > 0
+	AND
+		b.expires_on <= $1
+	ORDER BY b.expires_on, i.name, b.id`
+
+	rows, err := h.db.QueryContext(ctx, query, before)
+	if err != nil {
+		h.log(ctx).Error("Error to get expiring batches", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []*repo.ExpiringBatch
+	for rows.Next() {
+		var b repo.ExpiringBatch
+		err = rows.Scan(
+			&b.Id,
+			&b.ItemId,
+			&b.LocationId,
+			&b.LocationName,
+			&b.Lot,
+			&b.ExpiresOn,
+			&b.Quantity,
+			&b.ReceivedAt,
+			&b.ItemName,
+			&b.Unit,
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to get expiring batches", logger.Error(err))
+			return nil, err
+		}
+		batches = append(batches, &b)
+	}
+
+	return batches, rows.Err()
+}
+
+// insertBatch puts a batch into stock with a receipt movement
+func insertBatch(ctx context.Context, tx *sql.Tx, batch *repo.InventoryBatch, note string) (string, error) {
+	id := uuid.NewString()
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO
+		inventory_batches(
+			id,
+			item_id,
+			location_id,
+			lot,
+			expires_on,
+			quantity
+		) VALUES ($1, $2, $3, $4, $5, $6)`,
+		id,
+		batch.ItemId,
+		batch.LocationId,
+		batch.Lot,
+		batch.ExpiresOn,
+		batch.Quantity,
+	)
+	if err != nil {
+		return "", err
+	}
+	err = writeMovement(ctx, tx, &repo.StockMovement{
+		ItemId:     batch.ItemId,
+		BatchId:    id,
+		LocationId: batch.LocationId,
+		Quantity:   batch.Quantity,
+		Reason:     repo.MovementReceipt,
+		Note:       note,
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// writeMovement writes a change of stock to the history
+func writeMovement(ctx context.Context, tx *sql.Tx, m *repo.StockMovement) error {
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO
+		stock_movements(
+			id,
+			item_id,
+			batch_id,
+			location_id,
+			quantity,
+			reason,
+			appointment_id,
+			note
+		) VALUES ($1, $2, NULLIF($3, '')::UUID, NULLIF($4, '')::UUID, $5, $6, NULLIF($7, '')::UUID, $8)`,
+		uuid.NewString(),
+		m.ItemId,
+		m.BatchId,
+		m.LocationId,
+		m.Quantity,
+		m.Reason,
+		m.AppointmentId,
+		m.Note,
+	)
+	return err
+}
+
+func itemStock(ctx context.Context, tx *sql.Tx, itemId string) (float64, error) {
+	var stock float64
+	err := tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(quantity), 0) FROM inventory_batches WHERE item_id = $1`, itemId).Scan(&stock)
+	return stock, err
+}
+
+// checkLowStock writes inventory.low_stock when the stock of an item fell
+// below its minimum from before, and not when it was below already
+func checkLowStock(ctx context.Context, tx *sql.Tx, itemId string, before float64) error {
+	var data events.LowStockData
+	err := tx.QueryRowContext(ctx, `
+	SELECT
+		i.id,
+		i.name,
+		i.unit,
+		i.min_stock,
+		COALESCE((SELECT SUM(b.quantity) FROM inventory_batches b WHERE b.item_id = i.id), 0)
+	FROM
+		inventory_items i
+	WHERE
+		i.id = $1`, itemId).Scan(&data.ItemId, &data.Name, &data.Unit, &data.MinStock, &data.Stock)
+	if err != nil {
+		return err
+	}
+	if data.Stock >= data.MinStock || before < data.MinStock {
+		return nil
+	}
+	return writeEvent(ctx, tx, events.AggregateItem, itemId, events.InventoryLowStock, data)
+}
+
+func scanItem(row interface{ Scan(...interface{}) error }) (*repo.InventoryItem, error) {
+	var i repo.InventoryItem
+	err := row.Scan(
+		&i.Id,
+		&i.Name,
+		&i.Unit,
+		&i.Sku,
+		&i.MinStock,
+		&i.Stock,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func scanBatch(row interface{ Scan(...interface{}) error }) (*repo.InventoryBatch, error) {
+	var b repo.InventoryBatch
+	err := row.Scan(
+		&b.Id,
+		&b.ItemId,
+		&b.LocationId,
+		&b.LocationName,
+		&b.Lot,
+		&b.ExpiresOn,
+		&b.Quantity,
+		&b.ReceivedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// round3 rounds to the thousandths stock is counted in
+func round3(x float64) float64 {
+	return math.Round(x*1000) / 1000
+}
+
+func (h *inventoryRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
@@ -0,0 +1,480 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type labCaseRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewLabCaseRepo(db *sqlx.DB, log logger.Logger) repo.NewLabCaseI {
+	return &labCaseRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+const labCaseColumns = `
+		c.id,
+		c.client_id,
+		cl.name || ' ' || COALESCE(cl.last_name, ''),
+		COALESCE(c.appointment_id::TEXT, ''),
+		a.date,
+		c.lab_id,
+		s.name,
+		c.work_type,
+		c.shade,
+		c.teeth,
+		c.status,
+		c.sent_on,
+		c.due_on,
+		c.received_on,
+		c.fitted_on,
+		c.cost,
+		c.note,
+		c.created_at,
+		c.updated_at`
+
+const labCaseJoins = `
+	JOIN
+		clients cl ON cl.id = c.client_id
+	JOIN
+		suppliers s ON s.id = c.lab_id
+	LEFT JOIN
+		appointments a ON a.id = c.appointment_id AND a.deleted_at IS NULL`
+
+// This function is create a lab case not sent yet
+func (h *labCaseRepo) CreateCase(ctx context.Context, c *repo.LabCase) (*repo.LabCase, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM clients WHERE id = $1 AND deleted_at IS NULL)`, c.ClientId).Scan(&exists)
+	if err != nil {
+		h.log(ctx).Error("Error to check lab case client", logger.Error(err))
+		return nil, err
+	}
+	if !exists {
+		return nil, repo.ErrNotFound
+	}
+	if err = checkLabCaseRefs(ctx, tx, c, repo.LabCaseCreated); err != nil {
+		if !errors.Is(err, repo.ErrNotFound) && !errors.Is(err, repo.ErrLabCaseNotReceived) {
+			h.log(ctx).Error("Error to check lab case", logger.Error(err))
+		}
+		return nil, err
+	}
+
+	id := uuid.NewString()
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO
+		lab_cases(
+			id,
+			client_id,
+			appointment_id,
+			lab_id,
+			work_type,
+			shade,
+			teeth,
+			due_on,
+			cost,
+			note
+		) VALUES ($1, $2, NULLIF($3, '')::UUID, $4, $5, $6, $7, $8, $9, $10)`,
+		id,
+		c.ClientId,
+		c.AppointmentId,
+		c.LabId,
+		c.WorkType,
+		c.Shade,
+		pq.Array(c.Teeth),
+		c.DueOn,
+		c.Cost,
+		c.Note,
+	)
+	if err != nil {
+		h.log(ctx).Error("Error to create lab case", logger.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetCase(ctx, id)
+}
+
+// This function is get a lab case
+func (h *labCaseRepo) GetCase(ctx context.Context, id string) (*repo.LabCase, error) {
+	query := `
+	SELECT` + labCaseColumns + `
+	FROM
+		lab_cases c` + labCaseJoins + `
+	WHERE
+		c.id = $1`
+
+	labCase, err := scanLabCase(h.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get lab case", logger.Error(err))
+		return nil, err
+	}
+
+	return labCase, nil
+}
+
+// This function is get the lab cases newest first
+func (h *labCaseRepo) GetCases(ctx context.Context, filter *repo.LabCaseFilter, params pagination.Params) (*repo.AllLabCases, error) {
+	q := newQuery()
+	if filter.ClientId != "" {
+		q.where("client_id = ?", filter.ClientId)
+	}
+	if filter.LabId != "" {
+		q.where("lab_id = ?", filter.LabId)
+	}
+	if filter.Status != "" {
+		q.where("status = ?", filter.Status)
+	}
+
+	var cases repo.AllLabCases
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM lab_cases WHERE `+q.sql(), q.args...).Scan(&cases.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count lab cases", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byNewest, params.Cursor)
+	query := fmt.Sprintf(`
+	WITH page AS (
+		SELECT
+			*
+		FROM
+			lab_cases
+		WHERE
+			%s
+		ORDER BY %s
+		LIMIT %s
+		OFFSET %s
+	)
+	SELECT`+labCaseColumns+`
+	FROM
+		page c`+labCaseJoins+`
+	ORDER BY c.created_at DESC, c.id DESC`, q.sql(), byNewest.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get lab cases", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		labCase, err := scanLabCase(rows)
+		if err != nil {
+			h.log(ctx).Error("Error to get lab cases", logger.Error(err))
+			return nil, err
+		}
+		cases.Cases = append(cases.Cases, labCase)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cases.Cases, cases.NextCursor = pagination.Trim(cases.Cases, params.Limit, func(c *repo.LabCase) pagination.Cursor {
+		return pagination.Cursor{Key: c.CreatedAt.Format(time.RFC3339Nano), Id: c.Id}
+	})
+
+	return &cases, nil
+}
+
+// This function is update the details of a lab case not fitted or
+// cancelled. It fails with ErrNotFound when the lab or the appointment
+// does not exist.
+func (h *labCaseRepo) UpdateCase(ctx context.Context, c *repo.LabCase) (*repo.LabCase, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT client_id, status FROM lab_cases WHERE id = $1 FOR UPDATE`, c.Id).Scan(&c.ClientId, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to lock lab case", logger.Error(err))
+		return nil, err
+	}
+	if status == repo.LabCaseFitted || status == repo.LabCaseCancelled {
+		return nil, repo.ErrLabCaseState
+	}
+	if err = checkLabCaseRefs(ctx, tx, c, status); err != nil {
+		if !errors.Is(err, repo.ErrNotFound) && !errors.Is(err, repo.ErrLabCaseNotReceived) {
+			h.log(ctx).Error("Error to check lab case", logger.Error(err))
+		}
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		lab_cases
+	SET
+		appointment_id = NULLIF($2, '')::UUID,
+		lab_id = $3,
+		work_type = $4,
+		shade = $5,
+		teeth = $6,
+		due_on = $7,
+		cost = $8,
+		note = $9,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1`,
+		c.Id,
+		c.AppointmentId,
+		c.LabId,
+		c.WorkType,
+		c.Shade,
+		pq.Array(c.Teeth),
+		c.DueOn,
+		c.Cost,
+		c.Note,
+	)
+	if err != nil {
+		h.log(ctx).Error("Error to update lab case", logger.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetCase(ctx, c.Id)
+}
+
+// This function is mark a lab case as sent to the lab
+func (h *labCaseRepo) SendCase(ctx context.Context, id string, sentOn, dueOn civil.Date) (*repo.LabCase, error) {
+	return h.move(ctx, id, []string{repo.LabCaseCreated}, `status = 'sent', sent_on = $3, due_on = COALESCE($4, due_on)`, sentOn, dueOn)
+}
+
+// This function is mark a lab case as delivered by the lab
+func (h *labCaseRepo) ReceiveCase(ctx context.Context, id string, receivedOn civil.Date) (*repo.LabCase, error) {
+	return h.move(ctx, id, []string{repo.LabCaseSent}, `status = 'received', received_on = $3`, receivedOn)
+}
+
+// This function is mark a lab case as fitted
+func (h *labCaseRepo) FitCase(ctx context.Context, id string, fittedOn civil.Date) (*repo.LabCase, error) {
+	return h.move(ctx, id, []string{repo.LabCaseReceived}, `status = 'fitted', fitted_on = $3`, fittedOn)
+}
+
+// This function is cancel a lab case
+func (h *labCaseRepo) CancelCase(ctx context.Context, id string) (*repo.LabCase, error) {
+	return h.move(ctx, id, []string{repo.LabCaseCreated, repo.LabCaseSent, repo.LabCaseReceived}, `status = 'cancelled'`)
+}
+
+// This function is get the lab cases not received that were due before
+// today
+func (h *labCaseRepo) OverdueCases(ctx context.Context, today civil.Date) ([]*repo.LabCase, error) {
+	query := `
+	SELECT` + labCaseColumns + `
+	FROM
+		lab_cases c` + labCaseJoins + `
+	WHERE
+		c.status IN ('created', 'sent')
+	AND
+		c.due_on < $1
+	ORDER BY c.due_on, c.id`
+
+	rows, err := h.db.QueryContext(ctx, query, today)
+	if err != nil {
+		h.log(ctx).Error("Error to get overdue lab cases", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cases []*repo.LabCase
+	for rows.Next() {
+		labCase, err := scanLabCase(rows)
+		if err != nil {
+			h.log(ctx).Error("Error to get overdue lab cases", logger.Error(err))
+			return nil, err
+		}
+		cases = append(cases, labCase)
+	}
+	return cases, rows.Err()
+}
+
+// move sets a lab case in one of the statuses from, failing with
+// ErrLabCaseState when it is in another one. set is the SET clause,
+// its arguments start at $3.
+func (h *labCaseRepo) move(ctx context.Context, id string, from []string, set string, args ...interface{}) (*repo.LabCase, error) {
+	result, err := h.db.ExecContext(ctx, `
+	UPDATE
+		lab_cases
+	SET
+		`+set+`,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	AND
+		status = ANY($2)`, append([]interface{}{id, pq.Array(from)}, args...)...)
+	if err != nil {
+		h.log(ctx).Error("Error to update lab case status", logger.Error(err))
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		var exists bool
+		err = h.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM lab_cases WHERE id = $1)`, id).Scan(&exists)
+		if err != nil {
+			h.log(ctx).Error("Error to check lab case", logger.Error(err))
+			return nil, err
+		}
+		if !exists {
+			return nil, repo.ErrNotFound
+		}
+		return nil, repo.ErrLabCaseState
+	}
+	return h.GetCase(ctx, id)
+}
+
+// checkLabCaseRefs fails with ErrNotFound when the lab of c does not
+// exist, or its appointment does not or is of another client, and with
+// ErrLabCaseNotReceived when the case, in status, is not received and the
+// appointment is confirmed or later already. The appointment is locked so
+// it is not confirmed meanwhile.
+func checkLabCaseRefs(ctx context.Context, tx *sql.Tx, c *repo.LabCase, status string) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM suppliers WHERE id = $1 AND deleted_at IS NULL)`, c.LabId).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return repo.ErrNotFound
+	}
+	if c.AppointmentId == "" {
+		return nil
+	}
+	var appointmentStatus string
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		status
+	FROM
+		appointments
+	WHERE
+		id = $1
+	AND
+		client_id = $2
+	AND
+		deleted_at IS NULL
+	FOR SHARE`, c.AppointmentId, c.ClientId).Scan(&appointmentStatus)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repo.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if fittingStatus(appointmentStatus) && (status == repo.LabCaseCreated || status == repo.LabCaseSent) {
+		return repo.ErrLabCaseNotReceived
+	}
+	return nil
+}
+
+// fittingStatus reports whether an appointment in status has its lab
+// cases fitted, so they must be received
+func fittingStatus(status string) bool {
+	return status == repo.StatusConfirmed || status == repo.StatusCheckedIn || status == repo.StatusCompleted
+}
+
+// checkFitting fails with ErrLabCaseNotReceived when a lab case fitted at
+// the appointment is not delivered yet. The cases are locked so they are
+// not moved back meanwhile.
+func checkFitting(ctx context.Context, tx *sql.Tx, appointmentId string) error {
+	rows, err := tx.QueryContext(ctx, `
+	SELECT
+		status
+	FROM
+		lab_cases
+	WHERE
+		appointment_id = $1
+	FOR SHARE`, appointmentId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	pending := false
+	for rows.Next() {
+		var status string
+		if err = rows.Scan(&status); err != nil {
+			return err
+		}
+		if status == repo.LabCaseCreated || status == repo.LabCaseSent {
+			pending = true
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if pending {
+		return repo.ErrLabCaseNotReceived
+	}
+	return nil
+}
+
+func scanLabCase(row interface{ Scan(...interface{}) error }) (*repo.LabCase, error) {
+	var (
+		c               repo.LabCase
+		appointmentDate sql.NullTime
+		teeth           []int64
+	)
+	err := row.Scan(
+		&c.Id,
+		&c.ClientId,
+		&c.ClientName,
+		&c.AppointmentId,
+		&appointmentDate,
+		&c.LabId,
+		&c.LabName,
+		&c.WorkType,
+		&c.Shade,
+		pq.Array(&teeth),
+		&c.Status,
+		&c.SentOn,
+		&c.DueOn,
+		&c.ReceivedOn,
+		&c.FittedOn,
+		&c.Cost,
+		&c.Note,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.AppointmentDate = appointmentDate.Time
+	c.Teeth = make([]int, 0, len(teeth))
+	for _, t := range teeth {
+		c.Teeth = append(c.Teeth, int(t))
+	}
+	return &c, nil
+}
+
+func (h *labCaseRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
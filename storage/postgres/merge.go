@@ -0,0 +1,779 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// maxDuplicates bounds the candidates returned for one client
+const maxDuplicates = 20
+
+// mergeFields are the client columns the survivor of a merge takes over
+// from the duplicate when its own are empty
+var mergeFields = []string{"last_name", "father_name", "phone_number", "address", "birth_date"}
+
+type mergeRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewMergeRepo(db *sqlx.DB, log logger.Logger) repo.NewMergeI {
+	return &mergeRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+// phoneDigits is the comparable part of a phone number: the last nine
+// digits, i.e. the number without the +998 country code or a leading 0.
+// It must match the clients_phone_digits_idx expression.
+func phoneDigits(alias string) string {
+	return fmt.Sprintf(`right(regexp_replace(%s.phone_number, '\D', '', 'g'), 9)`, alias)
+}
+
+// fullName must match the clients_full_name_trgm_idx expression
+func fullName(alias string) string {
+	return fmt.Sprintf(`(%[1]s.name || ' ' || COALESCE(%[1]s.last_name, ''))`, alias)
+}
+
+// duplicatesQuery selects the candidate pairs (a, b) of active clients
+// matching where, similarity is the placeholder of the name threshold
+func duplicatesQuery(where, similarity string) string {
+	phoneMatch := fmt.Sprintf("(length(%s) = 9 AND %[1]s = %s)", phoneDigits("a"), phoneDigits("b"))
+	return fmt.Sprintf(`
+	SELECT
+		a.id,
+		a.name,
+		COALESCE(a.last_name, ''),
+		COALESCE(a.father_name, ''),
+		COALESCE(a.phone_number, ''),
+		a.birth_date,
+		a.created_at,
+		b.id,
+		b.name,
+		COALESCE(b.last_name, ''),
+		COALESCE(b.father_name, ''),
+		COALESCE(b.phone_number, ''),
+		b.birth_date,
+		b.created_at,
+		COALESCE(%[1]s, FALSE) AS phone_match,
+		COALESCE(a.birth_date = b.birth_date, FALSE) AS birth_date_match,
+		similarity(%[2]s, %[3]s) AS name_similarity
+	FROM
+		clients a
+	JOIN
+		clients b ON b.id <> a.id
+	WHERE
+		a.deleted_at IS NULL
+	AND
+		b.deleted_at IS NULL
+	AND
+		%[4]s
+	AND
+		(%[1]s OR (a.birth_date = b.birth_date AND similarity(%[2]s, %[3]s) >= %[5]s))`,
+		phoneMatch, fullName("a"), fullName("b"), where, similarity)
+}
+
+func scanDuplicates(rows *sql.Rows) ([]*repo.DuplicateMatch, error) {
+	var matches []*repo.DuplicateMatch
+	for rows.Next() {
+		var m repo.DuplicateMatch
+		err := rows.Scan(
+			&m.Client.Id,
+			&m.Client.Name,
+			&m.Client.LastName,
+			&m.Client.FatherName,
+			&m.Client.PhoneNumber,
+			&m.Client.BirthDate,
+			&m.Client.CreatedAt,
+			&m.Duplicate.Id,
+			&m.Duplicate.Name,
+			&m.Duplicate.LastName,
+			&m.Duplicate.FatherName,
+			&m.Duplicate.PhoneNumber,
+			&m.Duplicate.BirthDate,
+			&m.Duplicate.CreatedAt,
+			&m.PhoneMatch,
+			&m.BirthDateMatch,
+			&m.NameSimilarity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, &m)
+	}
+	return matches, rows.Err()
+}
+
+// This function is find the clients that are likely the same person as
+// the given one
+func (h *mergeRepo) FindDuplicates(ctx context.Context, clientId string, nameSimilarity float64) ([]*repo.DuplicateMatch, error) {
+	query := duplicatesQuery("a.id = $1", "$2") + `
+	ORDER BY phone_match DESC, name_similarity DESC, b.id
+	LIMIT $3`
+
+	rows, err := h.db.QueryContext(ctx, query, clientId, nameSimilarity, maxDuplicates)
+	if err != nil {
+		h.log(ctx).Error("Error to find duplicates", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches, err := scanDuplicates(rows)
+	if err != nil {
+		h.log(ctx).Error("Error to find duplicates", logger.Error(err))
+		return nil, err
+	}
+	return matches, nil
+}
+
+// This function is find every candidate pair, each pair once
+func (h *mergeRepo) FindAllDuplicates(ctx context.Context, nameSimilarity float64, params pagination.Params) (*repo.AllDuplicateMatches, error) {
+	pairs := duplicatesQuery("a.id < b.id", "$1")
+
+	var response repo.AllDuplicateMatches
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM (`+pairs+`) pairs`, nameSimilarity).Scan(&response.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count duplicates", logger.Error(err))
+		return nil, err
+	}
+
+	rows, err := h.db.QueryContext(ctx, pairs+`
+	ORDER BY phone_match DESC, name_similarity DESC, a.id, b.id
+	LIMIT $2
+	OFFSET $3`, nameSimilarity, params.Limit, params.Offset)
+	if err != nil {
+		h.log(ctx).Error("Error to find duplicates", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	response.Matches, err = scanDuplicates(rows)
+	if err != nil {
+		h.log(ctx).Error("Error to find duplicates", logger.Error(err))
+		return nil, err
+	}
+	return &response, nil
+}
+
+// This function is merge the duplicate into the survivor in one
+// transaction and record what was changed so that it can be undone
+func (h *mergeRepo) MergeClients(ctx context.Context, survivorId, mergedId string) (*repo.ClientMerge, error) {
+	if survivorId == mergedId {
+		return nil, repo.ErrSameClient
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Error("Error creating transaction merge clients", logger.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// both rows are locked in id order so that two opposite merges cannot
+	// deadlock
+	rows, err := tx.QueryContext(ctx, `
+	SELECT
+		id,
+		COALESCE(last_name, ''),
+		COALESCE(father_name, ''),
+		COALESCE(phone_number, ''),
+		COALESCE(address, ''),
+		COALESCE(birth_date::TEXT, '')
+	FROM
+		clients
+	WHERE
+		id IN ($1, $2)
+	AND
+		deleted_at IS NULL
+	AND
+		anonymized_at IS NULL
+	ORDER BY id
+	FOR UPDATE`, survivorId, mergedId)
+	if err != nil {
+		h.log(ctx).Error("Error to get clients to merge", logger.Error(err))
+		return nil, err
+	}
+	values := map[string][]string{}
+	for rows.Next() {
+		var (
+			id     string
+			fields = make([]string, len(mergeFields))
+		)
+		dest := []interface{}{&id}
+		for i := range fields {
+			dest = append(dest, &fields[i])
+		}
+		if err = rows.Scan(dest...); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		values[id] = fields
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(values) != 2 {
+		return nil, repo.ErrNotFound
+	}
+
+	filled := map[string]string{}
+	q := newQuery()
+	var sets []string
+	for i, field := range mergeFields {
+		if values[survivorId][i] != "" || values[mergedId][i] == "" {
+			continue
+		}
+		filled[field] = values[mergedId][i]
+		value := q.arg(values[mergedId][i])
+		if field == "birth_date" {
+			value += "::DATE"
+		}
+		sets = append(sets, field+" = "+value)
+	}
+	if len(sets) > 0 {
+		_, err = tx.ExecContext(ctx, `
+		UPDATE
+			clients
+		SET
+			`+strings.Join(sets, ", ")+`,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			id = `+q.arg(survivorId), q.args...)
+		if err != nil {
+			h.log(ctx).Error("Error to fill survivor of merge", logger.Error(err))
+			return nil, err
+		}
+	}
+
+	// deleted appointments move as well, the history must stay whole
+	moved, err := scanAppointments(tx.QueryContext(ctx, `
+	UPDATE
+		appointments
+	SET
+		client_id = $1,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		client_id = $2
+	RETURNING id, client_id, COALESCE(doctor_id::TEXT, ''), date, diagnostics, treatment, amount, status`, survivorId, mergedId))
+	if err != nil {
+		h.log(ctx).Error("Error to move appointments of merge", logger.Error(err))
+		return nil, err
+	}
+	appointmentIds := make([]string, 0, len(moved))
+	for _, a := range moved {
+		appointmentIds = append(appointmentIds, a.Id)
+	}
+
+	// so do the lab cases, the waitlist entries and the online bookings
+	labCaseIds, err := moveClientRows(ctx, tx, "lab_cases", true, survivorId, mergedId, nil)
+	if err != nil {
+		h.log(ctx).Error("Error to move lab cases of merge", logger.Error(err))
+		return nil, err
+	}
+	waitlistEntryIds, err := moveClientRows(ctx, tx, "waitlist_entries", true, survivorId, mergedId, nil)
+	if err != nil {
+		h.log(ctx).Error("Error to move waitlist entries of merge", logger.Error(err))
+		return nil, err
+	}
+	bookingRequestIds, err := moveClientRows(ctx, tx, "booking_requests", false, survivorId, mergedId, nil)
+	if err != nil {
+		h.log(ctx).Error("Error to move booking requests of merge", logger.Error(err))
+		return nil, err
+	}
+
+	// relations of the duplicate are copied to the survivor, the rows of
+	// the duplicate itself stay for the undo
+	var relationsJSON []byte
+	err = tx.QueryRowContext(ctx, `
+	WITH copied AS (
+		INSERT INTO
+			client_relations(
+				client_id,
+				related_id,
+				kind
+			)
+		SELECT
+			CASE WHEN client_id = $2 THEN $1::UUID ELSE client_id END,
+			CASE WHEN related_id = $2 THEN $1::UUID ELSE related_id END,
+			kind
+		FROM
+			client_relations
+		WHERE
+			(client_id = $2 OR related_id = $2)
+		AND
+			NOT (client_id IN ($1, $2) AND related_id IN ($1, $2))
+		ON CONFLICT DO NOTHING
+		RETURNING client_id, related_id, kind
+	)
+	SELECT COALESCE(json_agg(copied), '[]') FROM copied`, survivorId, mergedId).Scan(&relationsJSON)
+	if err != nil {
+		h.log(ctx).Error("Error to copy relations of merge", logger.Error(err))
+		return nil, err
+	}
+
+	// clients the duplicate answered for are answered for by the survivor,
+	// a survivor guaranteed by the duplicate answers for itself
+	var guaranteedIds []string
+	err = tx.QueryRowContext(ctx, `
+	WITH moved AS (
+		UPDATE
+			clients
+		SET
+			guarantor_id = CASE WHEN id = $1 THEN NULL ELSE $1::UUID END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			guarantor_id = $2
+		RETURNING id
+	)
+	SELECT COALESCE(array_agg(id), '{}') FROM moved`, survivorId, mergedId).Scan(pq.Array(&guaranteedIds))
+	if err != nil {
+		h.log(ctx).Error("Error to move guarantor of merge", logger.Error(err))
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		clients
+	SET
+		deleted_at = CURRENT_TIMESTAMP,
+		merged_into = $1,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $2`, survivorId, mergedId)
+	if err != nil {
+		h.log(ctx).Error("Error to delete merged client", logger.Error(err))
+		return nil, err
+	}
+
+	err = writeMovedAppointments(ctx, tx, moved)
+	if err == nil {
+		err = writeEvent(ctx, tx, events.AggregateClient, mergedId, events.ClientDeleted, events.DeletedData{Id: mergedId})
+	}
+	if err == nil && len(sets) > 0 {
+		err = writeClientUpdated(ctx, tx, survivorId)
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to write merge events", logger.Error(err))
+		return nil, err
+	}
+
+	filledJSON, err := json.Marshal(filled)
+	if err != nil {
+		return nil, err
+	}
+	merge := repo.ClientMerge{
+		Id:                uuid.NewString(),
+		SurvivorId:        survivorId,
+		MergedId:          mergedId,
+		AppointmentIds:    appointmentIds,
+		LabCaseIds:        labCaseIds,
+		WaitlistEntryIds:  waitlistEntryIds,
+		BookingRequestIds: bookingRequestIds,
+		FilledFields:      filledFields(filled),
+	}
+	err = tx.QueryRowContext(ctx, `
+	INSERT INTO
+		client_merges(
+			id,
+			survivor_id,
+			merged_id,
+			appointment_ids,
+			filled,
+			relations,
+			guaranteed_ids,
+			lab_case_ids,
+			waitlist_entry_ids,
+			booking_request_ids
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	RETURNING merged_at`,
+		merge.Id,
+		survivorId,
+		mergedId,
+		pq.Array(appointmentIds),
+		string(filledJSON),
+		string(relationsJSON),
+		pq.Array(guaranteedIds),
+		pq.Array(labCaseIds),
+		pq.Array(waitlistEntryIds),
+		pq.Array(bookingRequestIds),
+	).Scan(&merge.MergedAt)
+	if err != nil {
+		h.log(ctx).Error("Error to record merge", logger.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &merge, nil
+}
+
+// This function is undo a merge: the duplicate comes back with the
+// appointments, lab cases, waitlist entries, online bookings, relations
+// and guaranteed clients it had, survivor fields
+// filled by the merge are emptied again unless they were edited since
+func (h *mergeRepo) UndoMerge(ctx context.Context, id string, window time.Duration) (*repo.ClientMerge, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Error("Error creating transaction undo merge", logger.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var (
+		merge         repo.ClientMerge
+		filledJSON    []byte
+		relationsJSON []byte
+		guaranteedIds []string
+	)
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		id,
+		survivor_id,
+		merged_id,
+		appointment_ids,
+		filled,
+		relations,
+		guaranteed_ids,
+		lab_case_ids,
+		waitlist_entry_ids,
+		booking_request_ids,
+		merged_at,
+		undone_at
+	FROM
+		client_merges
+	WHERE
+		id = $1
+	FOR UPDATE`, id).Scan(
+		&merge.Id,
+		&merge.SurvivorId,
+		&merge.MergedId,
+		pq.Array(&merge.AppointmentIds),
+		&filledJSON,
+		&relationsJSON,
+		pq.Array(&guaranteedIds),
+		pq.Array(&merge.LabCaseIds),
+		pq.Array(&merge.WaitlistEntryIds),
+		pq.Array(&merge.BookingRequestIds),
+		&merge.MergedAt,
+		&merge.UndoneAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get merge", logger.Error(err))
+		return nil, err
+	}
+	if merge.UndoneAt != nil {
+		return nil, repo.ErrMergeUndone
+	}
+	if time.Since(merge.MergedAt) > window {
+		return nil, repo.ErrMergeExpired
+	}
+	filled := map[string]string{}
+	if err = json.Unmarshal(filledJSON, &filled); err != nil {
+		return nil, err
+	}
+	merge.FilledFields = filledFields(filled)
+
+	// a survivor merged further on must be split off first, and a
+	// duplicate that was purged or anonymized cannot come back
+	var restored repo.Client
+	err = tx.QueryRowContext(ctx, `
+	UPDATE
+		clients
+	SET
+		deleted_at = NULL,
+		merged_into = NULL,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	AND
+		merged_into = $2
+	AND
+		anonymized_at IS NULL
+	AND
+		EXISTS (SELECT 1 FROM clients s WHERE s.id = $2 AND s.merged_into IS NULL)
+	RETURNING `+clientEventColumns, merge.MergedId, merge.SurvivorId).Scan(clientEventDest(&restored)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrMergeExpired
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to restore merged client", logger.Error(err))
+		return nil, err
+	}
+
+	moved, err := scanAppointments(tx.QueryContext(ctx, `
+	UPDATE
+		appointments
+	SET
+		client_id = $1,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = ANY($2)
+	AND
+		client_id = $3
+	RETURNING id, client_id, COALESCE(doctor_id::TEXT, ''), date, diagnostics, treatment, amount, status`,
+		merge.MergedId, pq.Array(merge.AppointmentIds), merge.SurvivorId))
+	if err != nil {
+		h.log(ctx).Error("Error to move appointments back", logger.Error(err))
+		return nil, err
+	}
+
+	// records moved to another client since the merge are left alone
+	if _, err = moveClientRows(ctx, tx, "lab_cases", true, merge.MergedId, merge.SurvivorId, merge.LabCaseIds); err != nil {
+		h.log(ctx).Error("Error to move lab cases back", logger.Error(err))
+		return nil, err
+	}
+	if _, err = moveClientRows(ctx, tx, "waitlist_entries", true, merge.MergedId, merge.SurvivorId, merge.WaitlistEntryIds); err != nil {
+		h.log(ctx).Error("Error to move waitlist entries back", logger.Error(err))
+		return nil, err
+	}
+	if _, err = moveClientRows(ctx, tx, "booking_requests", false, merge.MergedId, merge.SurvivorId, merge.BookingRequestIds); err != nil {
+		h.log(ctx).Error("Error to move booking requests back", logger.Error(err))
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM
+		client_relations r
+	USING
+		json_to_recordset($1::JSON) AS x(client_id UUID, related_id UUID, kind TEXT)
+	WHERE
+		r.client_id = x.client_id
+	AND
+		r.related_id = x.related_id
+	AND
+		r.kind = x.kind`, string(relationsJSON))
+	if err != nil {
+		h.log(ctx).Error("Error to delete copied relations", logger.Error(err))
+		return nil, err
+	}
+
+	// guarantors changed since the merge are left alone
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		clients
+	SET
+		guarantor_id = $1,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = ANY($3)
+	AND
+		guarantor_id IS NOT DISTINCT FROM CASE WHEN id = $2 THEN NULL ELSE $2::UUID END`,
+		merge.MergedId, merge.SurvivorId, pq.Array(guaranteedIds))
+	if err != nil {
+		h.log(ctx).Error("Error to move guarantor back", logger.Error(err))
+		return nil, err
+	}
+
+	if len(filled) > 0 {
+		q := newQuery()
+		var sets []string
+		for _, field := range merge.FilledFields {
+			value := q.arg(filled[field])
+			if field == "birth_date" {
+				value += "::DATE"
+			}
+			sets = append(sets, fmt.Sprintf("%[1]s = CASE WHEN %[1]s = %[2]s THEN NULL ELSE %[1]s END", field, value))
+		}
+		_, err = tx.ExecContext(ctx, `
+		UPDATE
+			clients
+		SET
+			`+strings.Join(sets, ", ")+`,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			id = `+q.arg(merge.SurvivorId), q.args...)
+		if err != nil {
+			h.log(ctx).Error("Error to empty survivor fields", logger.Error(err))
+			return nil, err
+		}
+	}
+
+	err = writeEvent(ctx, tx, events.AggregateClient, restored.Id, events.ClientRestored, events.Client(&restored))
+	if err == nil {
+		err = writeMovedAppointments(ctx, tx, moved)
+	}
+	if err == nil && len(filled) > 0 {
+		err = writeClientUpdated(ctx, tx, merge.SurvivorId)
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to write undo merge events", logger.Error(err))
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+	UPDATE
+		client_merges
+	SET
+		undone_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	RETURNING undone_at`, id).Scan(&merge.UndoneAt)
+	if err != nil {
+		h.log(ctx).Error("Error to record undo of merge", logger.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &merge, nil
+}
+
+// This function is get the merges a client took part in, newest first
+func (h *mergeRepo) GetClientMerges(ctx context.Context, clientId string) ([]*repo.ClientMerge, error) {
+	rows, err := h.db.QueryContext(ctx, `
+	SELECT
+		id,
+		survivor_id,
+		merged_id,
+		appointment_ids,
+		lab_case_ids,
+		waitlist_entry_ids,
+		booking_request_ids,
+		filled,
+		merged_at,
+		undone_at
+	FROM
+		client_merges
+	WHERE
+		survivor_id = $1
+	OR
+		merged_id = $1
+	ORDER BY merged_at DESC`, clientId)
+	if err != nil {
+		h.log(ctx).Error("Error to get client merges", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var merges []*repo.ClientMerge
+	for rows.Next() {
+		var (
+			merge      repo.ClientMerge
+			filledJSON []byte
+		)
+		err = rows.Scan(
+			&merge.Id,
+			&merge.SurvivorId,
+			&merge.MergedId,
+			pq.Array(&merge.AppointmentIds),
+			pq.Array(&merge.LabCaseIds),
+			pq.Array(&merge.WaitlistEntryIds),
+			pq.Array(&merge.BookingRequestIds),
+			&filledJSON,
+			&merge.MergedAt,
+			&merge.UndoneAt,
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to get client merges", logger.Error(err))
+			return nil, err
+		}
+		filled := map[string]string{}
+		if err = json.Unmarshal(filledJSON, &filled); err != nil {
+			return nil, err
+		}
+		merge.FilledFields = filledFields(filled)
+		merges = append(merges, &merge)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return merges, nil
+}
+
+// moveClientRows moves the rows of table from one client to another and
+// returns their ids, only the rows in ids when ids is not nil. touch is
+// whether table has an updated_at column.
+func moveClientRows(ctx context.Context, tx *sql.Tx, table string, touch bool, to, from string, ids []string) ([]string, error) {
+	q := newQuery()
+	set := "client_id = " + q.arg(to)
+	if touch {
+		set += ", updated_at = CURRENT_TIMESTAMP"
+	}
+	q.where("client_id = ?", from)
+	if ids != nil {
+		q.where("id = ANY(?)", pq.Array(ids))
+	}
+
+	moved := []string{}
+	err := tx.QueryRowContext(ctx, `
+	WITH moved AS (
+		UPDATE
+			`+table+`
+		SET
+			`+set+`
+		WHERE
+			`+q.sql()+`
+		RETURNING id
+	)
+	SELECT COALESCE(array_agg(id), '{}') FROM moved`, q.args...).Scan(pq.Array(&moved))
+	return moved, err
+}
+
+// clientEventColumns are the client columns of its events, read with
+// clientEventDest
+const clientEventColumns = `id, name, COALESCE(last_name, ''), COALESCE(father_name, ''), COALESCE(phone_number, ''), COALESCE(address, ''), birth_date`
+
+func clientEventDest(c *repo.Client) []interface{} {
+	return []interface{}{&c.Id, &c.Name, &c.LastName, &c.FatherName, &c.PhoneNumber, &c.Address, &c.BirthDate}
+}
+
+// writeClientUpdated adds client.updated of the client as it is now to
+// the outbox
+func writeClientUpdated(ctx context.Context, tx *sql.Tx, id string) error {
+	var c repo.Client
+	err := tx.QueryRowContext(ctx, `SELECT `+clientEventColumns+` FROM clients WHERE id = $1`, id).Scan(clientEventDest(&c)...)
+	if err != nil {
+		return err
+	}
+	return writeEvent(ctx, tx, events.AggregateClient, id, events.ClientUpdated, events.Client(&c))
+}
+
+// writeMovedAppointments adds appointment.updated of each appointment
+// moved to another client to the outbox
+func writeMovedAppointments(ctx context.Context, tx *sql.Tx, moved []*repo.Appointment) error {
+	for _, a := range moved {
+		err := writeEvent(ctx, tx, events.AggregateAppointment, a.Id, events.AppointmentUpdated, events.Appointment(a))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filledFields returns the keys of filled in a stable order
+func filledFields(filled map[string]string) []string {
+	fields := make([]string, 0, len(filled))
+	for field := range filled {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func (h *mergeRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
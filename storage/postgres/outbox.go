@@ -0,0 +1,413 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// outboxLock is the advisory lock writers of events hold until they
+// commit, see the outbox migration
+const outboxLock = 7_000_001
+
+type outboxRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewOutboxRepo(db *sqlx.DB, log logger.Logger) repo.NewOutboxI {
+	return &outboxRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+// writeEvent adds an event to the outbox in tx, it is published and
+// notified only if tx commits
+func writeEvent(ctx context.Context, tx *sql.Tx, aggregateType, aggregateId, eventType string, data interface{}) error {
+	event, err := events.New(aggregateType, aggregateId, eventType, data)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, outboxLock); err != nil {
+		return err
+	}
+	err = tx.QueryRowContext(ctx, `
+	INSERT INTO
+		outbox(
+			id,
+			aggregate_type,
+			aggregate_id,
+			event,
+			payload,
+			created_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING seq`,
+		event.Id,
+		event.AggregateType,
+		event.AggregateId,
+		event.Type,
+		string(event.Payload),
+		event.CreatedAt,
+	).Scan(&event.Seq)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `SELECT pg_notify($1, $2::TEXT)`, repo.OutboxChannel, event.Seq)
+	return err
+}
+
+// outboxEntry is an event with what the subscriber did with it so far
+type outboxEntry struct {
+	repo.DomainEvent
+	status        string
+	attempts      int
+	nextAttemptAt sql.NullTime
+}
+
+// This function is hand the next events to a subscriber. Holding the lock
+// of its offset keeps other instances off meanwhile.
+func (h *outboxRepo) Process(ctx context.Context, subscriber string, limit int, retry repo.OutboxRetry, handle func(ctx context.Context, event *repo.DomainEvent) error) (int, error) {
+	_, err := h.db.ExecContext(ctx, `INSERT INTO outbox_offsets(subscriber) VALUES ($1) ON CONFLICT DO NOTHING`, subscriber)
+	if err != nil {
+		h.log(ctx).Error("Error to add outbox subscriber", logger.Error(err))
+		return 0, err
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var offset int64
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		seq
+	FROM
+		outbox_offsets
+	WHERE
+		subscriber = $1
+	FOR UPDATE SKIP LOCKED`, subscriber).Scan(&offset)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to lock outbox offset", logger.Error(err))
+		return 0, err
+	}
+
+	// events waiting out a backoff, and the later ones of their
+	// aggregates, are not read, so they cannot fill the batch. The offset
+	// does not move past the first of them.
+	now := time.Now()
+	var waiting sql.NullInt64
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		MIN(seq)
+	FROM
+		outbox_attempts
+	WHERE
+		subscriber = $1
+	AND
+		seq > $2
+	AND
+		status = 'retry'
+	AND
+		next_attempt_at > $3`, subscriber, offset, now).Scan(&waiting)
+	if err != nil {
+		h.log(ctx).Error("Error to read outbox attempts", logger.Error(err))
+		return 0, err
+	}
+
+	entries, err := h.entries(ctx, tx, subscriber, offset, now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	// an aggregate is blocked from its first event that is not handled,
+	// the offset moves up to the first event that is not done. Events
+	// that were not read below the first waiting one are all done.
+	blocked := map[string]bool{}
+	behind := false
+	handed := 0
+	for _, e := range entries {
+		if waiting.Valid && e.Seq > waiting.Int64 {
+			behind = true
+		}
+		aggregate := e.AggregateType + "/" + e.AggregateId
+		done := false
+		if !blocked[aggregate] {
+			done, err = h.handle(ctx, tx, subscriber, e, retry, handle, behind)
+			if err != nil {
+				return 0, err
+			}
+			handed++
+			if !done {
+				blocked[aggregate] = true
+			}
+		}
+
+		if !done {
+			behind = true
+		} else if !behind {
+			offset = e.Seq
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		outbox_offsets
+	SET
+		seq = $2,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		subscriber = $1`, subscriber, offset)
+	if err != nil {
+		h.log(ctx).Error("Error to move outbox offset", logger.Error(err))
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM
+		outbox_attempts
+	WHERE
+		subscriber = $1
+	AND
+		seq <= $2
+	AND
+		status <> 'dead'`, subscriber, offset)
+	if err != nil {
+		h.log(ctx).Error("Error to clean outbox attempts", logger.Error(err))
+		return 0, err
+	}
+
+	return handed, tx.Commit()
+}
+
+// handle hands one event and records the outcome when the offset cannot
+// simply move past it. It reports whether the event is done with.
+func (h *outboxRepo) handle(ctx context.Context, tx *sql.Tx, subscriber string, e *outboxEntry, retry repo.OutboxRetry, handle func(ctx context.Context, event *repo.DomainEvent) error, behind bool) (bool, error) {
+	handleErr := handle(ctx, &e.DomainEvent)
+	if handleErr == nil {
+		if !behind {
+			return true, nil
+		}
+		return true, h.recordAttempt(ctx, tx, subscriber, e.Seq, "done", e.attempts, nil, "")
+	}
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	attempts := e.attempts + 1
+	if attempts >= retry.MaxAttempts {
+		h.log(ctx).Error("Outbox event is dead, the subscriber gave up on it",
+			logger.String("subscriber", subscriber),
+			logger.String("event_id", e.Id),
+			logger.String("event", e.Type),
+			logger.Int("attempts", attempts),
+			logger.Error(handleErr),
+		)
+		return true, h.recordAttempt(ctx, tx, subscriber, e.Seq, "dead", attempts, nil, handleErr.Error())
+	}
+	next := time.Now().Add(retry.Backoff(attempts))
+	return false, h.recordAttempt(ctx, tx, subscriber, e.Seq, "retry", attempts, &next, handleErr.Error())
+}
+
+func (h *outboxRepo) recordAttempt(ctx context.Context, tx *sql.Tx, subscriber string, seq int64, status string, attempts int, next *time.Time, lastError string) error {
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO
+		outbox_attempts(
+			subscriber,
+			seq,
+			status,
+			attempts,
+			next_attempt_at,
+			last_error
+		) VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (subscriber, seq) DO UPDATE SET
+		status = EXCLUDED.status,
+		attempts = EXCLUDED.attempts,
+		next_attempt_at = EXCLUDED.next_attempt_at,
+		last_error = EXCLUDED.last_error,
+		updated_at = CURRENT_TIMESTAMP`,
+		subscriber, seq, status, attempts, next, lastError,
+	)
+	if err != nil {
+		h.log(ctx).Error("Error to record outbox attempt", logger.Error(err))
+	}
+	return err
+}
+
+// This function is read the events of types after a seq, for readers that
+// keep their own position such as the live board
+func (h *outboxRepo) Events(ctx context.Context, after int64, types []string, limit int) ([]*repo.DomainEvent, error) {
+	rows, err := h.db.QueryContext(ctx, `
+	SELECT
+		seq,
+		id,
+		aggregate_type,
+		aggregate_id,
+		event,
+		payload,
+		created_at
+	FROM
+		outbox
+	WHERE
+		seq > $1
+	AND
+		event = ANY($2)
+	ORDER BY seq
+	LIMIT $3`, after, pq.Array(types), limit)
+	if err != nil {
+		h.log(ctx).Error("Error to read outbox events", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*repo.DomainEvent
+	for rows.Next() {
+		var e repo.DomainEvent
+		err = rows.Scan(
+			&e.Seq,
+			&e.Id,
+			&e.AggregateType,
+			&e.AggregateId,
+			&e.Type,
+			&e.Payload,
+			&e.CreatedAt,
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to read outbox events", logger.Error(err))
+			return nil, err
+		}
+		list = append(list, &e)
+	}
+
+	return list, rows.Err()
+}
+
+// This function is get the seq of the latest event
+func (h *outboxRepo) LastSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	err := h.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), 0) FROM outbox`).Scan(&seq)
+	if err != nil {
+		h.log(ctx).Error("Error to get last outbox seq", logger.Error(err))
+		return 0, err
+	}
+	return seq, nil
+}
+
+// This function is delete the events every subscriber is past, keeping
+// the latest keep for the boards that reconnect
+func (h *outboxRepo) Prune(ctx context.Context, subscribers []string, keep int, before time.Time) (int, error) {
+	// a subscriber without an offset yet has handled nothing
+	result, err := h.db.ExecContext(ctx, `
+	DELETE FROM
+		outbox
+	WHERE
+		seq <= (
+			SELECT MIN(COALESCE(f.seq, 0))
+			FROM unnest($1::TEXT[]) s(subscriber)
+			LEFT JOIN outbox_offsets f ON f.subscriber = s.subscriber
+		)
+	AND
+		seq <= (SELECT COALESCE(MAX(seq), 0) FROM outbox) - $2
+	AND
+		created_at < $3`, pq.Array(subscribers), keep, before)
+	if err != nil {
+		h.log(ctx).Error("Error to prune outbox", logger.Error(err))
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// entries reads the events after offset the subscriber has to handle now:
+// not done, not waiting out a backoff, and not behind one that is in their
+// aggregate
+func (h *outboxRepo) entries(ctx context.Context, tx *sql.Tx, subscriber string, offset int64, now time.Time, limit int) ([]*outboxEntry, error) {
+	rows, err := tx.QueryContext(ctx, `
+	SELECT
+		o.seq,
+		o.id,
+		o.aggregate_type,
+		o.aggregate_id,
+		o.event,
+		o.payload,
+		o.created_at,
+		COALESCE(a.status, ''),
+		COALESCE(a.attempts, 0),
+		a.next_attempt_at
+	FROM
+		outbox o
+	LEFT JOIN outbox_attempts a ON a.subscriber = $1 AND a.seq = o.seq
+	WHERE
+		o.seq > $2
+	AND
+		(a.status IS NULL OR (a.status = 'retry' AND a.next_attempt_at <= $3))
+	AND NOT EXISTS (
+		SELECT
+			1
+		FROM
+			outbox_attempts w
+		JOIN
+			outbox wo ON wo.seq = w.seq
+		WHERE
+			w.subscriber = $1
+		AND
+			w.seq > $2
+		AND
+			w.seq < o.seq
+		AND
+			w.status = 'retry'
+		AND
+			w.next_attempt_at > $3
+		AND
+			wo.aggregate_type = o.aggregate_type
+		AND
+			wo.aggregate_id = o.aggregate_id
+	)
+	ORDER BY o.seq
+	LIMIT $4`, subscriber, offset, now, limit)
+	if err != nil {
+		h.log(ctx).Error("Error to read outbox", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*outboxEntry
+	for rows.Next() {
+		var e outboxEntry
+		err = rows.Scan(
+			&e.Seq,
+			&e.Id,
+			&e.AggregateType,
+			&e.AggregateId,
+			&e.Type,
+			&e.Payload,
+			&e.CreatedAt,
+			&e.status,
+			&e.attempts,
+			&e.nextAttemptAt,
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to read outbox", logger.Error(err))
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (h *outboxRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+func writeTestEvent(t *testing.T, db *sqlx.DB, aggregateId string) int64 {
+	t.Helper()
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if err = writeEvent(ctx, tx, "test", aggregateId, "test.written", map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	var seq int64
+	err = tx.QueryRowContext(ctx, `SELECT MAX(seq) FROM outbox WHERE aggregate_id = $1`, aggregateId).Scan(&seq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	return seq
+}
+
+func TestProcessBlockedAggregate(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	outbox := NewOutboxRepo(db, testLogger())
+
+	// the subscriber starts after the events already there
+	subscriber := "test-" + uuid.NewString()
+	var base int64
+	err := db.QueryRow(`
+	INSERT INTO outbox_offsets(subscriber, seq)
+	SELECT $1, COALESCE(MAX(seq), 0) FROM outbox
+	RETURNING seq`, subscriber).Scan(&base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocked, other := uuid.NewString(), uuid.NewString()
+	first := writeTestEvent(t, db, blocked)
+	passing := writeTestEvent(t, db, other)
+	second := writeTestEvent(t, db, blocked)
+
+	retry := repo.OutboxRetry{
+		MaxAttempts: 5,
+		Backoff:     func(int) time.Duration { return time.Hour },
+	}
+	failing := true
+	var handed []int64
+	handle := func(ctx context.Context, e *repo.DomainEvent) error {
+		handed = append(handed, e.Seq)
+		if failing && e.Seq == first {
+			return errors.New("failed")
+		}
+		return nil
+	}
+	offset := func() int64 {
+		var seq int64
+		err := db.QueryRow(`SELECT seq FROM outbox_offsets WHERE subscriber = $1`, subscriber).Scan(&seq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return seq
+	}
+	process := func(want ...int64) {
+		t.Helper()
+		handed = nil
+		n, err := outbox.Process(ctx, subscriber, 10, retry, handle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(want) || !reflect.DeepEqual(handed, want) {
+			t.Fatalf("Process handed %v (%d), want %v", handed, n, want)
+		}
+	}
+
+	// the other aggregate goes on, the offset waits at the failed event
+	process(first, passing)
+	if got := offset(); got != base {
+		t.Fatalf("offset = %d, want %d", got, base)
+	}
+
+	// nothing is due while the backoff runs
+	process()
+	if got := offset(); got != base {
+		t.Fatalf("offset = %d, want %d", got, base)
+	}
+
+	// once due the aggregate is handed in order and the offset catches up
+	_, err = db.Exec(`
+	UPDATE outbox_attempts
+	SET next_attempt_at = CURRENT_TIMESTAMP - INTERVAL '1 second'
+	WHERE subscriber = $1 AND seq = $2`, subscriber, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing = false
+	process(first, second)
+	if got := offset(); got != second {
+		t.Fatalf("offset = %d, want %d", got, second)
+	}
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/dentist/migrations"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/migrate"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// testDSN names the database the tests of this package run against, they
+// are skipped without it. It is migrated up once and its rows are not
+// cleaned, every test works on rows of its own.
+const testDSN = "TEST_POSTGRES_DSN"
+
+var (
+	testOnce sync.Once
+	testConn *sqlx.DB
+	testErr  error
+)
+
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	dsn := os.Getenv(testDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSN)
+	}
+
+	testOnce.Do(func() {
+		testConn, testErr = sqlx.Connect("postgres", dsn)
+		if testErr != nil {
+			return
+		}
+		var m *migrate.Migrator
+		if m, testErr = migrate.New(testConn.DB, migrations.FS); testErr == nil {
+			_, testErr = m.Up(context.Background())
+		}
+	})
+	if testErr != nil {
+		t.Fatal(testErr)
+	}
+	return testConn
+}
+
+func testLogger() logger.Logger {
+	return logger.New("error", "test")
+}
+
+func insertClient(t *testing.T, db *sqlx.DB) string {
+	t.Helper()
+	id := uuid.NewString()
+	if _, err := db.Exec(`INSERT INTO clients(id, name) VALUES ($1, 'Test')`, id); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func insertDoctor(t *testing.T, db *sqlx.DB) string {
+	t.Helper()
+	id := uuid.NewString()
+	if _, err := db.Exec(`INSERT INTO doctors(id, name) VALUES ($1, 'Test')`, id); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
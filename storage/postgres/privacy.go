@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/lib/pq"
+)
+
+// The SET lists that strip a client and its appointments of personal data,
+// shared by AnonymizeClient and the anonymizing purge of the trash. Date,
+// amount, status and doctor of the appointments stay for the statistics.
+const (
+	anonymizeClientSet = `
+		name = '',
+		last_name = '',
+		father_name = '',
+		phone_number = '',
+		address = '',
+		birth_date = NULL,
+		birth_date_raw = NULL,
+		anonymized_at = CURRENT_TIMESTAMP`
+	anonymizeAppointmentSet = `
+		diagnostics = '',
+		treatment = '',
+		anonymized_at = CURRENT_TIMESTAMP`
+)
+
+// The personal data overwritten in the client and appointment data of
+// events, the ids, statuses and times stay
+const (
+	scrubbedClientData      = `'{"name": "", "last_name": "", "father_name": "", "phone_number": "", "address": "", "birth_date": ""}'::JSONB`
+	scrubbedAppointmentData = `'{"diagnostics": "", "treatment": ""}'::JSONB`
+)
+
+// scrubPersonalData strips the personal data of the clients and the
+// appointments that are erased from where it was copied: the payloads of
+// their events and webhook deliveries, the fields merges took from them,
+// the contacts of their online bookings and the notes of their waitlist
+// entries and lab cases. The appointments of the clients are included.
+func scrubPersonalData(ctx context.Context, tx *sql.Tx, clientIds, appointmentIds []string) error {
+	if len(clientIds) == 0 && len(appointmentIds) == 0 {
+		return nil
+	}
+	clients, appointments := pq.Array(clientIds), pq.Array(appointmentIds)
+	queries := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`UPDATE outbox
+		SET payload = payload || ` + scrubbedClientData + `
+		WHERE aggregate_type = 'client' AND aggregate_id = ANY($1::TEXT[]) AND payload ? 'name'`,
+			[]interface{}{clients}},
+		{`UPDATE outbox
+		SET payload = payload || ` + scrubbedAppointmentData + `
+		WHERE aggregate_type = 'appointment' AND (aggregate_id = ANY($2::TEXT[]) OR payload->>'client_id' = ANY($1::TEXT[]))`,
+			[]interface{}{clients, appointments}},
+		{`UPDATE webhook_deliveries
+		SET payload = jsonb_set(payload, '{data}', (payload->'data') || ` + scrubbedClientData + `)
+		WHERE event LIKE 'client.%' AND payload->'data'->>'id' = ANY($1::TEXT[]) AND payload->'data' ? 'name'`,
+			[]interface{}{clients}},
+		{`UPDATE webhook_deliveries
+		SET payload = jsonb_set(payload, '{data}', (payload->'data') || ` + scrubbedAppointmentData + `)
+		WHERE event LIKE 'appointment.%' AND (payload->'data'->>'id' = ANY($2::TEXT[]) OR payload->'data'->>'client_id' = ANY($1::TEXT[]))`,
+			[]interface{}{clients, appointments}},
+		// a merge takes fields of the same person from either side
+		{`UPDATE client_merges SET filled = '{}' WHERE merged_id = ANY($1::UUID[]) OR survivor_id = ANY($1::UUID[])`,
+			[]interface{}{clients}},
+		{`UPDATE booking_requests SET phone_digits = '', client_ip = '' WHERE client_id = ANY($1::UUID[])`,
+			[]interface{}{clients}},
+		{`UPDATE waitlist_entries SET note = '' WHERE client_id = ANY($1::UUID[])`,
+			[]interface{}{clients}},
+		{`UPDATE lab_cases SET note = '' WHERE client_id = ANY($1::UUID[])`,
+			[]interface{}{clients}},
+	}
+	for _, q := range queries {
+		if _, err := tx.ExecContext(ctx, q.query, q.args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// This function is collect a client with every appointment it ever had,
+// an anonymized client has nothing left to export
+func (h *clientRepo) ExportClient(ctx context.Context, id string) (*repo.ClientExport, error) {
+	var export repo.ClientExport
+	err := h.db.QueryRowContext(ctx, `
+	SELECT
+		id,
+		name,
+		last_name,
+		father_name,
+		phone_number,
+		address,
+		birth_date,
+		created_at,
+		deleted_at
+	FROM
+		clients
+	WHERE
+		id = $1
+	AND
+		anonymized_at IS NULL`, id).Scan(
+		&export.Client.Id,
+		&export.Client.Name,
+		&export.Client.LastName,
+		&export.Client.FatherName,
+		&export.Client.PhoneNumber,
+		&export.Client.Address,
+		&export.Client.BirthDate,
+		&export.Client.CreatedAt,
+		&export.DeletedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get client for export", logger.Error(err))
+		return nil, err
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+	SELECT
+		a.id,
+		a.client_id,
+		COALESCE(a.doctor_id::TEXT, ''),
+		COALESCE(d.name || ' ' || d.last_name, ''),
+		a.date,
+		COALESCE(a.diagnostics, ''),
+		COALESCE(a.treatment, ''),
+		COALESCE(a.amount, 0),
+		a.status,
+		a.deleted_at
+	FROM
+		appointments a
+	LEFT JOIN
+		doctors d ON d.id = a.doctor_id
+	WHERE
+		a.client_id = $1
+	ORDER BY a.date NULLS LAST, a.id`, id)
+	if err != nil {
+		h.log(ctx).Error("Error to get appointments for export", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			appointment repo.ExportedAppointment
+			date        sql.NullTime
+		)
+		err = rows.Scan(
+			&appointment.Id,
+			&appointment.ClientId,
+			&appointment.DoctorId,
+			&appointment.DoctorName,
+			&date,
+			&appointment.Diagnostics,
+			&appointment.Treatment,
+			&appointment.Amount,
+			&appointment.Status,
+			&appointment.DeletedAt,
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to get appointments for export", logger.Error(err))
+			return nil, err
+		}
+		appointment.Date = date.Time
+		export.Appointments = append(export.Appointments, &appointment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// This function is erase a client: the personal data and the free text of
+// the appointments are overwritten, with the copies of them elsewhere, and
+// the client is deleted, the appointment rows stay for statistics. It
+// cannot be undone.
+func (h *clientRepo) AnonymizeClient(ctx context.Context, id string) (time.Time, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Error("Error creating transaction anonymize client", logger.Error(err))
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	var anonymizedAt time.Time
+	err = tx.QueryRowContext(ctx, `
+	UPDATE
+		clients
+	SET `+anonymizeClientSet+`,
+		deleted_at = COALESCE(deleted_at, CURRENT_TIMESTAMP),
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	AND
+		anonymized_at IS NULL
+	RETURNING anonymized_at`, id).Scan(&anonymizedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to anonymize client", logger.Error(err))
+		return time.Time{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		appointments
+	SET `+anonymizeAppointmentSet+`,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		client_id = $1
+	AND
+		anonymized_at IS NULL`, id)
+	if err != nil {
+		h.log(ctx).Error("Error to anonymize client's appointments", logger.Error(err))
+		return time.Time{}, err
+	}
+
+	if err = scrubPersonalData(ctx, tx, []string{id}, nil); err != nil {
+		h.log(ctx).Error("Error to scrub personal data of client", logger.Error(err))
+		return time.Time{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return time.Time{}, err
+	}
+
+	return anonymizedAt, nil
+}
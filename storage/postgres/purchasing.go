@@ -0,0 +1,879 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type purchasingRepo struct {
+	db     *sqlx.DB
+	loc    *time.Location
+	logger logger.Logger
+}
+
+func NewPurchasingRepo(db *sqlx.DB, loc *time.Location, log logger.Logger) repo.NewPurchasingI {
+	return &purchasingRepo{
+		db:     db,
+		loc:    loc,
+		logger: log,
+	}
+}
+
+var byNewest = ordering{column: "created_at", desc: true}
+
+const supplierColumns = `
+		id,
+		name,
+		contact,
+		phone,
+		email,
+		note,
+		created_at,
+		updated_at`
+
+const orderColumns = `
+		o.id,
+		o.supplier_id,
+		s.name,
+		o.status,
+		o.expected_on,
+		o.note,
+		COALESCE((SELECT SUM(ROUND(l.quantity * l.unit_price, 2)) FROM purchase_order_lines l WHERE l.order_id = o.id), 0),
+		o.ordered_at,
+		o.created_at,
+		o.updated_at`
+
+// This function is create a supplier
+func (h *purchasingRepo) CreateSupplier(ctx context.Context, supplier *repo.Supplier) (*repo.Supplier, error) {
+	query := `
+	INSERT INTO
+		suppliers(
+			id,
+			name,
+			contact,
+			phone,
+			email,
+			note
+		) VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING` + supplierColumns
+
+	created, err := scanSupplier(h.db.QueryRowContext(ctx, query,
+		uuid.NewString(),
+		supplier.Name,
+		supplier.Contact,
+		supplier.Phone,
+		supplier.Email,
+		supplier.Note,
+	))
+	if isUniqueViolation(err) {
+		return nil, repo.ErrNameTaken
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to create supplier", logger.Error(err))
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// This function is get a supplier
+func (h *purchasingRepo) GetSupplier(ctx context.Context, id string) (*repo.Supplier, error) {
+	query := `
+	SELECT` + supplierColumns + `
+	FROM
+		suppliers
+	WHERE
+		id = $1
+	AND
+		deleted_at IS NULL`
+
+	supplier, err := scanSupplier(h.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get supplier", logger.Error(err))
+		return nil, err
+	}
+
+	return supplier, nil
+}
+
+// This function is get the suppliers by name
+func (h *purchasingRepo) GetSuppliers(ctx context.Context, params pagination.Params) (*repo.AllSuppliers, error) {
+	q := newQuery().where("deleted_at IS NULL")
+
+	var suppliers repo.AllSuppliers
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM suppliers WHERE `+q.sql(), q.args...).Scan(&suppliers.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count suppliers", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byName, params.Cursor)
+	query := fmt.Sprintf(`
+	SELECT`+supplierColumns+`
+	FROM
+		suppliers
+	WHERE
+		%s
+	ORDER BY %s
+	LIMIT %s
+	OFFSET %s`, q.sql(), byName.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get suppliers", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		supplier, err := scanSupplier(rows)
+		if err != nil {
+			h.log(ctx).Error("Error to get suppliers", logger.Error(err))
+			return nil, err
+		}
+		suppliers.Suppliers = append(suppliers.Suppliers, supplier)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	suppliers.Suppliers, suppliers.NextCursor = pagination.Trim(suppliers.Suppliers, params.Limit, func(s *repo.Supplier) pagination.Cursor {
+		return pagination.Cursor{Key: s.Name, Id: s.Id}
+	})
+
+	return &suppliers, nil
+}
+
+// This function is update the details of a supplier
+func (h *purchasingRepo) UpdateSupplier(ctx context.Context, supplier *repo.Supplier) (*repo.Supplier, error) {
+	query := `
+	UPDATE
+		suppliers
+	SET
+		name = $2,
+		contact = $3,
+		phone = $4,
+		email = $5,
+		note = $6,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	AND
+		deleted_at IS NULL
+	RETURNING` + supplierColumns
+
+	updated, err := scanSupplier(h.db.QueryRowContext(ctx, query,
+		supplier.Id,
+		supplier.Name,
+		supplier.Contact,
+		supplier.Phone,
+		supplier.Email,
+		supplier.Note,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if isUniqueViolation(err) {
+		return nil, repo.ErrNameTaken
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to update supplier", logger.Error(err))
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// This function is delete a supplier
+func (h *purchasingRepo) DeleteSupplier(ctx context.Context, id string) error {
+	result, err := h.db.ExecContext(ctx, `UPDATE suppliers SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		h.log(ctx).Error("Error to delete supplier", logger.Error(err))
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// This function is create a draft purchase order. It fails with
+// ErrNotFound when the supplier or an item does not exist.
+func (h *purchasingRepo) CreateOrder(ctx context.Context, order *repo.PurchaseOrder) (*repo.PurchaseOrder, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err = checkOrderRefs(ctx, tx, order); err != nil {
+		if !errors.Is(err, repo.ErrNotFound) {
+			h.log(ctx).Error("Error to check purchase order", logger.Error(err))
+		}
+		return nil, err
+	}
+
+	id := uuid.NewString()
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO
+		purchase_orders(
+			id,
+			supplier_id,
+			expected_on,
+			note
+		) VALUES ($1, $2, $3, $4)`,
+		id,
+		order.SupplierId,
+		order.ExpectedOn,
+		order.Note,
+	)
+	if err != nil {
+		h.log(ctx).Error("Error to create purchase order", logger.Error(err))
+		return nil, err
+	}
+	if err = insertOrderLines(ctx, tx, id, order.Lines); err != nil {
+		h.log(ctx).Error("Error to create purchase order lines", logger.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetOrder(ctx, id)
+}
+
+// This function is get a purchase order with its lines
+func (h *purchasingRepo) GetOrder(ctx context.Context, id string) (*repo.PurchaseOrder, error) {
+	query := `
+	SELECT` + orderColumns + `
+	FROM
+		purchase_orders o
+	JOIN
+		suppliers s ON s.id = o.supplier_id
+	WHERE
+		o.id = $1`
+
+	order, err := scanOrder(h.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get purchase order", logger.Error(err))
+		return nil, err
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+	SELECT
+		l.id,
+		l.item_id,
+		i.name,
+		i.unit,
+		l.quantity,
+		l.unit_price,
+		l.received
+	FROM
+		purchase_order_lines l
+	JOIN
+		inventory_items i ON i.id = l.item_id
+	WHERE
+		l.order_id = $1
+	ORDER BY l.position`, id)
+	if err != nil {
+		h.log(ctx).Error("Error to get purchase order lines", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l repo.PurchaseOrderLine
+		err = rows.Scan(&l.Id, &l.ItemId, &l.ItemName, &l.Unit, &l.Quantity, &l.UnitPrice, &l.Received)
+		if err != nil {
+			h.log(ctx).Error("Error to get purchase order lines", logger.Error(err))
+			return nil, err
+		}
+		order.Lines = append(order.Lines, &l)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// This function is get the purchase orders newest first
+func (h *purchasingRepo) GetOrders(ctx context.Context, filter *repo.PurchaseOrderFilter, params pagination.Params) (*repo.AllPurchaseOrders, error) {
+	q := newQuery()
+	if filter.SupplierId != "" {
+		q.where("supplier_id = ?", filter.SupplierId)
+	}
+	if filter.Status != "" {
+		q.where("status = ?", filter.Status)
+	}
+
+	var orders repo.AllPurchaseOrders
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM purchase_orders WHERE `+q.sql(), q.args...).Scan(&orders.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count purchase orders", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byNewest, params.Cursor)
+	query := fmt.Sprintf(`
+	WITH page AS (
+		SELECT
+			*
+		FROM
+			purchase_orders
+		WHERE
+			%s
+		ORDER BY %s
+		LIMIT %s
+		OFFSET %s
+	)
+	SELECT`+orderColumns+`
+	FROM
+		page o
+	JOIN
+		suppliers s ON s.id = o.supplier_id
+	ORDER BY o.created_at DESC, o.id DESC`, q.sql(), byNewest.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get purchase orders", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			h.log(ctx).Error("Error to get purchase orders", logger.Error(err))
+			return nil, err
+		}
+		orders.Orders = append(orders.Orders, order)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	orders.Orders, orders.NextCursor = pagination.Trim(orders.Orders, params.Limit, func(o *repo.PurchaseOrder) pagination.Cursor {
+		return pagination.Cursor{Key: o.CreatedAt.Format(time.RFC3339Nano), Id: o.Id}
+	})
+
+	return &orders, nil
+}
+
+// This function is replace the supplier, delivery date, note and lines of
+// a draft purchase order
+func (h *purchasingRepo) UpdateOrder(ctx context.Context, order *repo.PurchaseOrder) (*repo.PurchaseOrder, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err = lockOrder(ctx, tx, order.Id, repo.OrderDraft); err != nil {
+		if !errors.Is(err, repo.ErrNotFound) && !errors.Is(err, repo.ErrOrderState) {
+			h.log(ctx).Error("Error to lock purchase order", logger.Error(err))
+		}
+		return nil, err
+	}
+	if err = checkOrderRefs(ctx, tx, order); err != nil {
+		if !errors.Is(err, repo.ErrNotFound) {
+			h.log(ctx).Error("Error to check purchase order", logger.Error(err))
+		}
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		purchase_orders
+	SET
+		supplier_id = $2,
+		expected_on = $3,
+		note = $4,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1`,
+		order.Id,
+		order.SupplierId,
+		order.ExpectedOn,
+		order.Note,
+	)
+	if err != nil {
+		h.log(ctx).Error("Error to update purchase order", logger.Error(err))
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM purchase_order_lines WHERE order_id = $1`, order.Id); err != nil {
+		h.log(ctx).Error("Error to delete purchase order lines", logger.Error(err))
+		return nil, err
+	}
+	if err = insertOrderLines(ctx, tx, order.Id, order.Lines); err != nil {
+		h.log(ctx).Error("Error to create purchase order lines", logger.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetOrder(ctx, order.Id)
+}
+
+// This function is place a draft purchase order with the supplier
+func (h *purchasingRepo) PlaceOrder(ctx context.Context, id string) (*repo.PurchaseOrder, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err = lockOrder(ctx, tx, id, repo.OrderDraft); err != nil {
+		if !errors.Is(err, repo.ErrNotFound) && !errors.Is(err, repo.ErrOrderState) {
+			h.log(ctx).Error("Error to lock purchase order", logger.Error(err))
+		}
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		purchase_orders
+	SET
+		status = $2,
+		ordered_at = CURRENT_TIMESTAMP,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1`, id, repo.OrderOrdered)
+	if err != nil {
+		h.log(ctx).Error("Error to place purchase order", logger.Error(err))
+		return nil, err
+	}
+	if err = recordPrices(ctx, tx, id); err != nil {
+		h.log(ctx).Error("Error to record supplier prices", logger.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetOrder(ctx, id)
+}
+
+// This function is cancel a purchase order not fully received
+func (h *purchasingRepo) CancelOrder(ctx context.Context, id string) (*repo.PurchaseOrder, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = lockOrder(ctx, tx, id, repo.OrderDraft, repo.OrderOrdered, repo.OrderPartiallyReceived)
+	if err != nil {
+		if !errors.Is(err, repo.ErrNotFound) && !errors.Is(err, repo.ErrOrderState) {
+			h.log(ctx).Error("Error to lock purchase order", logger.Error(err))
+		}
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE purchase_orders SET status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id, repo.OrderCancelled)
+	if err != nil {
+		h.log(ctx).Error("Error to cancel purchase order", logger.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetOrder(ctx, id)
+}
+
+// This function is put a delivery of a placed purchase order into stock.
+// It fails with ErrNotFound when the location or a line of the order does
+// not exist, and with ErrOverReceipt when more of a line is received than
+// is outstanding.
+func (h *purchasingRepo) Receive(ctx context.Context, receipt *repo.Receipt) (*repo.PurchaseOrder, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = lockOrder(ctx, tx, receipt.OrderId, repo.OrderOrdered, repo.OrderPartiallyReceived)
+	if err != nil {
+		if !errors.Is(err, repo.ErrNotFound) && !errors.Is(err, repo.ErrOrderState) {
+			h.log(ctx).Error("Error to lock purchase order", logger.Error(err))
+		}
+		return nil, err
+	}
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM storage_locations WHERE id = $1)`, receipt.LocationId).Scan(&exists)
+	if err != nil {
+		h.log(ctx).Error("Error to check storage location", logger.Error(err))
+		return nil, err
+	}
+	if !exists {
+		return nil, repo.ErrNotFound
+	}
+
+	note := "purchase order " + receipt.OrderId
+	if receipt.Note != "" {
+		note += ": " + receipt.Note
+	}
+
+	for _, r := range receipt.Lines {
+		var (
+			itemId      string
+			unitPrice   float64
+			outstanding float64
+		)
+		err = tx.QueryRowContext(ctx, `
+		SELECT
+			item_id,
+			unit_price,
+			quantity - received
+		FROM
+			purchase_order_lines
+		WHERE
+			id = $1
+		AND
+			order_id = $2`, r.LineId, receipt.OrderId).Scan(&itemId, &unitPrice, &outstanding)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		if err != nil {
+			h.log(ctx).Error("Error to get purchase order line", logger.Error(err))
+			return nil, err
+		}
+		if round3(r.Quantity) > round3(outstanding) {
+			return nil, repo.ErrOverReceipt
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE purchase_order_lines SET received = received + $2 WHERE id = $1`, r.LineId, r.Quantity)
+		if err != nil {
+			h.log(ctx).Error("Error to update purchase order line", logger.Error(err))
+			return nil, err
+		}
+		batchId, err := insertBatch(ctx, tx, &repo.InventoryBatch{
+			ItemId:     itemId,
+			LocationId: receipt.LocationId,
+			Lot:        r.Lot,
+			ExpiresOn:  r.ExpiresOn,
+			Quantity:   r.Quantity,
+		}, note)
+		if err != nil {
+			h.log(ctx).Error("Error to create batch", logger.Error(err))
+			return nil, err
+		}
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO
+			purchase_receipts(
+				id,
+				order_id,
+				line_id,
+				batch_id,
+				quantity,
+				unit_price
+			) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.NewString(),
+			receipt.OrderId,
+			r.LineId,
+			batchId,
+			r.Quantity,
+			unitPrice,
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to create purchase receipt", logger.Error(err))
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		purchase_orders o
+	SET
+		status = CASE
+			WHEN NOT EXISTS (SELECT 1 FROM purchase_order_lines l WHERE l.order_id = o.id AND l.received < l.quantity) THEN $2
+			ELSE $3
+		END,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1`, receipt.OrderId, repo.OrderReceived, repo.OrderPartiallyReceived)
+	if err != nil {
+		h.log(ctx).Error("Error to update purchase order status", logger.Error(err))
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetOrder(ctx, receipt.OrderId)
+}
+
+// This function is get the price history of a supplier newest first
+func (h *purchasingRepo) GetPrices(ctx context.Context, supplierId, itemId string) ([]*repo.SupplierPrice, error) {
+	q := newQuery().where("p.supplier_id = ?", supplierId)
+	if itemId != "" {
+		q.where("p.item_id = ?", itemId)
+	}
+
+	rows, err := h.db.QueryContext(ctx, `
+	SELECT
+		p.supplier_id,
+		p.item_id,
+		i.name,
+		p.unit_price,
+		COALESCE(p.order_id::TEXT, ''),
+		p.recorded_at
+	FROM
+		supplier_prices p
+	JOIN
+		inventory_items i ON i.id = p.item_id
+	WHERE
+		`+q.sql()+`
+	ORDER BY p.recorded_at DESC, p.id DESC`, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get supplier prices", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []*repo.SupplierPrice
+	for rows.Next() {
+		var p repo.SupplierPrice
+		if err = rows.Scan(&p.SupplierId, &p.ItemId, &p.ItemName, &p.UnitPrice, &p.OrderId, &p.RecordedAt); err != nil {
+			h.log(ctx).Error("Error to get supplier prices", logger.Error(err))
+			return nil, err
+		}
+		prices = append(prices, &p)
+	}
+	return prices, rows.Err()
+}
+
+// This function is get the spend per supplier per month, counted from
+// what was received at the unit prices ordered
+func (h *purchasingRepo) GetSpend(ctx context.Context, from, to civil.Date) ([]*repo.MonthlySpend, error) {
+	start := from.In(h.loc)
+	start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, h.loc)
+	end := to.In(h.loc)
+	end = time.Date(end.Year(), end.Month()+1, 1, 0, 0, 0, 0, h.loc)
+
+	rows, err := h.db.QueryContext(ctx, `
+	SELECT
+		s.id,
+		s.name,
+		TO_CHAR(r.received_at AT TIME ZONE $1, 'YYYY-MM') AS month,
+		SUM(ROUND(r.quantity * r.unit_price, 2)),
+		COUNT(DISTINCT r.order_id)
+	FROM
+		purchase_receipts r
+	JOIN
+		purchase_orders o ON o.id = r.order_id
+	JOIN
+		suppliers s ON s.id = o.supplier_id
+	WHERE
+		r.received_at >= $2
+	AND
+		r.received_at < $3
+	GROUP BY s.id, s.name, month
+	ORDER BY month, s.name, s.id`, h.loc.String(), start, end)
+	if err != nil {
+		h.log(ctx).Error("Error to get supplier spend", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var spend []*repo.MonthlySpend
+	for rows.Next() {
+		var m repo.MonthlySpend
+		if err = rows.Scan(&m.SupplierId, &m.SupplierName, &m.Month, &m.Amount, &m.Orders); err != nil {
+			h.log(ctx).Error("Error to get supplier spend", logger.Error(err))
+			return nil, err
+		}
+		spend = append(spend, &m)
+	}
+	return spend, rows.Err()
+}
+
+// lockOrder locks a purchase order for the rest of tx, failing with
+// ErrOrderState when its status is not one of statuses
+func lockOrder(ctx context.Context, tx *sql.Tx, id string, statuses ...string) error {
+	var status string
+	err := tx.QueryRowContext(ctx, `SELECT status FROM purchase_orders WHERE id = $1 FOR UPDATE`, id).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repo.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		if status == s {
+			return nil
+		}
+	}
+	return repo.ErrOrderState
+}
+
+// checkOrderRefs fails with ErrNotFound when the supplier or an item of
+// the lines of order does not exist
+func checkOrderRefs(ctx context.Context, tx *sql.Tx, order *repo.PurchaseOrder) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM suppliers WHERE id = $1 AND deleted_at IS NULL)`, order.SupplierId).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return repo.ErrNotFound
+	}
+	for _, l := range order.Lines {
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM inventory_items WHERE id = $1 AND deleted_at IS NULL)`, l.ItemId).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return repo.ErrNotFound
+		}
+	}
+	return nil
+}
+
+// recordPrices writes the unit prices of the lines of an order to the
+// price history of its supplier
+func recordPrices(ctx context.Context, tx *sql.Tx, orderId string) error {
+	rows, err := tx.QueryContext(ctx, `
+	SELECT
+		o.supplier_id,
+		l.item_id,
+		l.unit_price
+	FROM
+		purchase_order_lines l
+	JOIN
+		purchase_orders o ON o.id = l.order_id
+	WHERE
+		l.order_id = $1
+	ORDER BY l.position`, orderId)
+	if err != nil {
+		return err
+	}
+	var prices []*repo.SupplierPrice
+	for rows.Next() {
+		var p repo.SupplierPrice
+		if err = rows.Scan(&p.SupplierId, &p.ItemId, &p.UnitPrice); err != nil {
+			rows.Close()
+			return err
+		}
+		prices = append(prices, &p)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range prices {
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO
+			supplier_prices(
+				id,
+				supplier_id,
+				item_id,
+				unit_price,
+				order_id
+			) VALUES ($1, $2, $3, $4, $5)`,
+			uuid.NewString(),
+			p.SupplierId,
+			p.ItemId,
+			p.UnitPrice,
+			orderId,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertOrderLines(ctx context.Context, tx *sql.Tx, orderId string, lines []*repo.PurchaseOrderLine) error {
+	for i, l := range lines {
+		_, err := tx.ExecContext(ctx, `
+		INSERT INTO
+			purchase_order_lines(
+				id,
+				order_id,
+				position,
+				item_id,
+				quantity,
+				unit_price
+			) VALUES ($1, $2, $3, $4, $5, $6)`,
+			uuid.NewString(),
+			orderId,
+			i,
+			l.ItemId,
+			l.Quantity,
+			l.UnitPrice,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanSupplier(row interface{ Scan(...interface{}) error }) (*repo.Supplier, error) {
+	var s repo.Supplier
+	err := row.Scan(
+		&s.Id,
+		&s.Name,
+		&s.Contact,
+		&s.Phone,
+		&s.Email,
+		&s.Note,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func scanOrder(row interface{ Scan(...interface{}) error }) (*repo.PurchaseOrder, error) {
+	var (
+		o         repo.PurchaseOrder
+		orderedAt sql.NullTime
+	)
+	err := row.Scan(
+		&o.Id,
+		&o.SupplierId,
+		&o.SupplierName,
+		&o.Status,
+		&o.ExpectedOn,
+		&o.Note,
+		&o.Total,
+		&orderedAt,
+		&o.CreatedAt,
+		&o.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if orderedAt.Valid {
+		o.OrderedAt = &orderedAt.Time
+	}
+	return &o, nil
+}
+
+func (h *purchasingRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
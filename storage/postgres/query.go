@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dentist/pkg/pagination"
+)
+
+// queryBuilder collects the WHERE conditions of a SELECT together with their
+// arguments, numbering the $n placeholders as conditions are added, so
+// filters can be combined freely without ever formatting a value into SQL.
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+func newQuery() *queryBuilder {
+	return &queryBuilder{}
+}
+
+// arg registers v and returns its placeholder
+func (q *queryBuilder) arg(v interface{}) string {
+	q.args = append(q.args, v)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+// where adds a condition, every ? in cond becomes the placeholder of the
+// next value
+func (q *queryBuilder) where(cond string, values ...interface{}) *queryBuilder {
+	for _, v := range values {
+		cond = strings.Replace(cond, "?", q.arg(v), 1)
+	}
+	q.conditions = append(q.conditions, cond)
+	return q
+}
+
+// after restricts the rows to those following cursor in order
+func (q *queryBuilder) after(order ordering, cursor *pagination.Cursor) *queryBuilder {
+	if cursor == nil {
+		return q
+	}
+	op := ">"
+	if order.desc {
+		op = "<"
+	}
+	if cursor.Key == "" {
+		return q.where(fmt.Sprintf("(%s IS NULL AND id %s ?)", order.column, op), cursor.Id)
+	}
+
+	key, id := q.arg(cursor.Key), q.arg(cursor.Id)
+	q.conditions = append(q.conditions, fmt.Sprintf(
+		"(%[1]s %[2]s %[3]s OR (%[1]s = %[3]s AND id %[2]s %[4]s) OR %[1]s IS NULL)",
+		order.column, op, key, id,
+	))
+	return q
+}
+
+// sql returns the conditions joined for a WHERE clause
+func (q *queryBuilder) sql() string {
+	if len(q.conditions) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(q.conditions, " AND ")
+}
+
+// ordering is a sort column, rows with NULL in it come last and id breaks
+// ties so that pages are stable
+type ordering struct {
+	column string
+	desc   bool
+}
+
+func (o ordering) sql() string {
+	dir := "ASC"
+	if o.desc {
+		dir = "DESC"
+	}
+	return fmt.Sprintf("%s %s NULLS LAST, id %s", o.column, dir, dir)
+}
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/storage/repo"
+	"github.com/jmoiron/sqlx"
+)
+
+type rateLimitRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewRateLimitRepo(db *sqlx.DB, log logger.Logger) repo.NewRateLimitI {
+	return &rateLimitRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+// bucketLevel is the tokens of bucket b refilled up to now, $2 is the
+// burst and $3 the tokens given back a second
+const bucketLevel = `LEAST($2::FLOAT8, b.tokens + EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - b.updated_at)::FLOAT8 * $3::FLOAT8)`
+
+// This function is take a request from a bucket in one statement, so
+// instances taking from the same bucket at once see each other
+func (h *rateLimitRepo) Take(ctx context.Context, key string, limit repo.RateLimit) (*repo.RateResult, error) {
+	rate := float64(limit.Burst) / limit.Per.Seconds()
+	query := `
+	INSERT INTO
+		rate_limit_buckets AS b(
+			key,
+			tokens,
+			allowed,
+			updated_at,
+			expires_at
+		) VALUES ($1, $2::FLOAT8 - 1, TRUE, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP + $4 * INTERVAL '1 second')
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = ` + bucketLevel + ` - CASE WHEN ` + bucketLevel + ` >= 1 THEN 1 ELSE 0 END,
+		allowed = ` + bucketLevel + ` >= 1,
+		updated_at = CURRENT_TIMESTAMP,
+		expires_at = EXCLUDED.expires_at
+	RETURNING tokens, allowed`
+
+	var (
+		tokens float64
+		result repo.RateResult
+	)
+	err := h.db.QueryRowContext(ctx, query, key, limit.Burst, rate, limit.Per.Seconds()).Scan(&tokens, &result.Allowed)
+	if err != nil {
+		h.log(ctx).Error("Error to take from rate limit bucket", logger.Error(err))
+		return nil, err
+	}
+
+	result.Remaining = int(math.Floor(tokens))
+	if !result.Allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return &result, nil
+}
+
+// This function is get until when a key is locked out
+func (h *rateLimitRepo) Locked(ctx context.Context, key string) (time.Time, error) {
+	var until time.Time
+	err := h.db.QueryRowContext(ctx, `
+	SELECT
+		locked_until
+	FROM
+		rate_limit_lockouts
+	WHERE
+		key = $1
+	AND
+		locked_until > CURRENT_TIMESTAMP`, key).Scan(&until)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get lockout", logger.Error(err))
+		return time.Time{}, err
+	}
+	return until, nil
+}
+
+// This function is count a failed attempt, the one that reaches the
+// attempts of the policy locks the key out and starts counting anew
+func (h *rateLimitRepo) Fail(ctx context.Context, key string, policy repo.LockoutPolicy) (time.Time, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	var failures int
+	err = tx.QueryRowContext(ctx, `
+	INSERT INTO
+		rate_limit_lockouts AS l(
+			key,
+			failures,
+			window_start,
+			expires_at
+		) VALUES ($1, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP + $2 * INTERVAL '1 second')
+	ON CONFLICT (key) DO UPDATE SET
+		failures = CASE WHEN l.window_start + $2 * INTERVAL '1 second' <= CURRENT_TIMESTAMP THEN 1 ELSE l.failures + 1 END,
+		window_start = CASE WHEN l.window_start + $2 * INTERVAL '1 second' <= CURRENT_TIMESTAMP THEN CURRENT_TIMESTAMP ELSE l.window_start END,
+		expires_at = GREATEST(l.expires_at, EXCLUDED.expires_at)
+	RETURNING failures`, key, policy.Window.Seconds()).Scan(&failures)
+	if err != nil {
+		h.log(ctx).Error("Error to count failed attempt", logger.Error(err))
+		return time.Time{}, err
+	}
+
+	var until time.Time
+	if failures >= policy.Attempts {
+		err = tx.QueryRowContext(ctx, `
+		UPDATE
+			rate_limit_lockouts
+		SET
+			failures = 0,
+			window_start = CURRENT_TIMESTAMP,
+			locked_until = CURRENT_TIMESTAMP + $2 * INTERVAL '1 second',
+			expires_at = GREATEST(expires_at, CURRENT_TIMESTAMP + $2 * INTERVAL '1 second')
+		WHERE
+			key = $1
+		RETURNING locked_until`, key, policy.Duration.Seconds()).Scan(&until)
+		if err != nil {
+			h.log(ctx).Error("Error to lock out", logger.Error(err))
+			return time.Time{}, err
+		}
+	}
+
+	return until, tx.Commit()
+}
+
+// This function is forget the failed attempts of a key, a lockout in
+// force is kept
+func (h *rateLimitRepo) Clear(ctx context.Context, key string) error {
+	_, err := h.db.ExecContext(ctx, `
+	DELETE FROM
+		rate_limit_lockouts
+	WHERE
+		key = $1
+	AND
+		(locked_until IS NULL OR locked_until <= CURRENT_TIMESTAMP)`, key)
+	if err != nil {
+		h.log(ctx).Error("Error to clear failed attempts", logger.Error(err))
+	}
+	return err
+}
+
+// This function is drop the buckets and lockouts that expired
+func (h *rateLimitRepo) Sweep(ctx context.Context) (int, error) {
+	var swept int64
+	for _, query := range []string{
+		`DELETE FROM rate_limit_buckets WHERE expires_at <= CURRENT_TIMESTAMP`,
+		`DELETE FROM rate_limit_lockouts WHERE expires_at <= CURRENT_TIMESTAMP`,
+	} {
+		result, err := h.db.ExecContext(ctx, query)
+		if err != nil {
+			h.log(ctx).Error("Error to sweep rate limits", logger.Error(err))
+			return int(swept), err
+		}
+		n, _ := result.RowsAffected()
+		swept += n
+	}
+	return int(swept), nil
+}
+
+func (h *rateLimitRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
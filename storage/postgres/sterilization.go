@@ -0,0 +1,530 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type sterilizationRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewSterilizationRepo(db *sqlx.DB, log logger.Logger) repo.NewSterilizationI {
+	return &sterilizationRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+var byStartedAt = ordering{column: "started_at", desc: true}
+
+const cycleColumns = `
+		c.id,
+		c.autoclave_id,
+		a.name,
+		c.cycle_number,
+		c.program,
+		c.started_at,
+		c.temperature_c,
+		c.pressure_kpa,
+		c.hold_minutes,
+		c.operator,
+		c.note,
+		c.result,
+		c.indicator,
+		c.result_note,
+		c.result_at,
+		c.created_at`
+
+const packColumns = `
+		p.id,
+		p.code,
+		p.cycle_id,
+		c.result,
+		p.contents,
+		COALESCE(p.appointment_id::TEXT, ''),
+		p.used_at`
+
+// This function is create an autoclave
+func (h *sterilizationRepo) CreateAutoclave(ctx context.Context, autoclave *repo.Autoclave) (*repo.Autoclave, error) {
+	var created repo.Autoclave
+	err := h.db.QueryRowContext(ctx, `
+	INSERT INTO
+		autoclaves(
+			id,
+			name,
+			serial_number
+		) VALUES ($1, $2, $3)
+	RETURNING id, name, serial_number, created_at`,
+		uuid.NewString(),
+		autoclave.Name,
+		autoclave.SerialNumber,
+	).Scan(&created.Id, &created.Name, &created.SerialNumber, &created.CreatedAt)
+	if isUniqueViolation(err) {
+		return nil, repo.ErrNameTaken
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to create autoclave", logger.Error(err))
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// This function is get the autoclaves by name
+func (h *sterilizationRepo) GetAutoclaves(ctx context.Context) ([]*repo.Autoclave, error) {
+	rows, err := h.db.QueryContext(ctx, `SELECT id, name, serial_number, created_at FROM autoclaves ORDER BY name, id`)
+	if err != nil {
+		h.log(ctx).Error("Error to get autoclaves", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var autoclaves []*repo.Autoclave
+	for rows.Next() {
+		var a repo.Autoclave
+		if err = rows.Scan(&a.Id, &a.Name, &a.SerialNumber, &a.CreatedAt); err != nil {
+			h.log(ctx).Error("Error to get autoclaves", logger.Error(err))
+			return nil, err
+		}
+		autoclaves = append(autoclaves, &a)
+	}
+	return autoclaves, rows.Err()
+}
+
+// This function is log a sterilization cycle with its packs
+func (h *sterilizationRepo) CreateCycle(ctx context.Context, cycle *repo.SterilizationCycle) (*repo.SterilizationCycle, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM autoclaves WHERE id = $1)`, cycle.AutoclaveId).Scan(&exists)
+	if err != nil {
+		h.log(ctx).Error("Error to check autoclave", logger.Error(err))
+		return nil, err
+	}
+	if !exists {
+		return nil, repo.ErrNotFound
+	}
+
+	id := uuid.NewString()
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO
+		sterilization_cycles(
+			id,
+			autoclave_id,
+			cycle_number,
+			program,
+			started_at,
+			temperature_c,
+			pressure_kpa,
+			hold_minutes,
+			operator,
+			note
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		id,
+		cycle.AutoclaveId,
+		cycle.CycleNumber,
+		cycle.Program,
+		cycle.StartedAt,
+		cycle.TemperatureC,
+		cycle.PressureKpa,
+		cycle.HoldMinutes,
+		cycle.Operator,
+		cycle.Note,
+	)
+	if isUniqueViolation(err) {
+		return nil, repo.ErrDuplicate
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to create sterilization cycle", logger.Error(err))
+		return nil, err
+	}
+
+	for _, p := range cycle.Packs {
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO
+			sterilization_packs(
+				id,
+				code,
+				cycle_id,
+				contents
+			) VALUES ($1, $2, $3, $4)`,
+			uuid.NewString(),
+			p.Code,
+			id,
+			p.Contents,
+		)
+		if isUniqueViolation(err) {
+			return nil, repo.ErrDuplicate
+		}
+		if err != nil {
+			h.log(ctx).Error("Error to create sterilization pack", logger.Error(err))
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetCycle(ctx, id)
+}
+
+// This function is get a sterilization cycle with its packs
+func (h *sterilizationRepo) GetCycle(ctx context.Context, id string) (*repo.SterilizationCycle, error) {
+	query := `
+	SELECT` + cycleColumns + `
+	FROM
+		sterilization_cycles c
+	JOIN
+		autoclaves a ON a.id = c.autoclave_id
+	WHERE
+		c.id = $1`
+
+	cycle, err := scanCycle(h.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get sterilization cycle", logger.Error(err))
+		return nil, err
+	}
+
+	cycle.Packs, err = h.packs(ctx, "p.cycle_id = $1 ORDER BY p.code", id)
+	if err != nil {
+		h.log(ctx).Error("Error to get sterilization packs", logger.Error(err))
+		return nil, err
+	}
+	return cycle, nil
+}
+
+// This function is get the sterilization cycles last started first
+func (h *sterilizationRepo) GetCycles(ctx context.Context, filter *repo.CycleFilter, params pagination.Params) (*repo.AllSterilizationCycles, error) {
+	q := newQuery()
+	if filter.AutoclaveId != "" {
+		q.where("autoclave_id = ?", filter.AutoclaveId)
+	}
+	if filter.Result != "" {
+		q.where("result = ?", filter.Result)
+	}
+
+	var cycles repo.AllSterilizationCycles
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sterilization_cycles WHERE `+q.sql(), q.args...).Scan(&cycles.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count sterilization cycles", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byStartedAt, params.Cursor)
+	query := fmt.Sprintf(`
+	WITH page AS (
+		SELECT
+			*
+		FROM
+			sterilization_cycles
+		WHERE
+			%s
+		ORDER BY %s
+		LIMIT %s
+		OFFSET %s
+	)
+	SELECT`+cycleColumns+`
+	FROM
+		page c
+	JOIN
+		autoclaves a ON a.id = c.autoclave_id
+	ORDER BY c.started_at DESC, c.id DESC`, q.sql(), byStartedAt.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get sterilization cycles", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		cycle, err := scanCycle(rows)
+		if err != nil {
+			h.log(ctx).Error("Error to get sterilization cycles", logger.Error(err))
+			return nil, err
+		}
+		cycles.Cycles = append(cycles.Cycles, cycle)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cycles.Cycles, cycles.NextCursor = pagination.Trim(cycles.Cycles, params.Limit, func(c *repo.SterilizationCycle) pagination.Cursor {
+		return pagination.Cursor{Key: c.StartedAt.Format(time.RFC3339Nano), Id: c.Id}
+	})
+
+	return &cycles, nil
+}
+
+// This function is record the indicator result of a sterilization cycle
+func (h *sterilizationRepo) RecordResult(ctx context.Context, id string, passed bool, indicator, note string) (*repo.SterilizationCycle, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var (
+		previous string
+		data     events.CycleFailedData
+	)
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		c.result,
+		c.id,
+		c.autoclave_id,
+		a.name,
+		c.cycle_number
+	FROM
+		sterilization_cycles c
+	JOIN
+		autoclaves a ON a.id = c.autoclave_id
+	WHERE
+		c.id = $1
+	FOR UPDATE OF c`, id).Scan(&previous, &data.CycleId, &data.AutoclaveId, &data.Autoclave, &data.CycleNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to lock sterilization cycle", logger.Error(err))
+		return nil, err
+	}
+
+	result := repo.CycleFailed
+	if passed {
+		result = repo.CyclePassed
+	}
+	// a pending cycle takes either result, a passed one can only fail
+	if previous == repo.CycleFailed || (previous == repo.CyclePassed && passed) {
+		return nil, repo.ErrCycleState
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		sterilization_cycles
+	SET
+		result = $2,
+		indicator = $3,
+		result_note = $4,
+		result_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1`, id, result, indicator, note)
+	if err != nil {
+		h.log(ctx).Error("Error to record sterilization cycle result", logger.Error(err))
+		return nil, err
+	}
+
+	if !passed {
+		data.Indicator = indicator
+		data.ExposedAppointments = []string{}
+		err = tx.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(array_agg(appointment_id::TEXT ORDER BY used_at), '{}')
+		FROM
+			sterilization_packs
+		WHERE
+			cycle_id = $1
+		AND
+			appointment_id IS NOT NULL`, id).Scan(pq.Array(&data.ExposedAppointments))
+		if err != nil {
+			h.log(ctx).Error("Error to get exposed appointments", logger.Error(err))
+			return nil, err
+		}
+		if err = writeEvent(ctx, tx, events.AggregateCycle, id, events.CycleFailed, data); err != nil {
+			h.log(ctx).Error("Error to write sterilization cycle event", logger.Error(err))
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h.GetCycle(ctx, id)
+}
+
+// This function is get the appointments the packs of a cycle were used at
+func (h *sterilizationRepo) Exposures(ctx context.Context, cycleId string) ([]*repo.Exposure, error) {
+	rows, err := h.db.QueryContext(ctx, `
+	SELECT
+		ap.id,
+		ap.date,
+		cl.id,
+		cl.name || ' ' || COALESCE(cl.last_name, ''),
+		COALESCE(cl.phone_number, ''),
+		p.code,
+		p.used_at
+	FROM
+		sterilization_packs p
+	JOIN
+		appointments ap ON ap.id = p.appointment_id
+	JOIN
+		clients cl ON cl.id = ap.client_id
+	WHERE
+		p.cycle_id = $1
+	ORDER BY p.used_at, p.code`, cycleId)
+	if err != nil {
+		h.log(ctx).Error("Error to get exposures", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exposures []*repo.Exposure
+	for rows.Next() {
+		var (
+			e    repo.Exposure
+			date sql.NullTime
+		)
+		err = rows.Scan(&e.AppointmentId, &date, &e.ClientId, &e.ClientName, &e.PhoneNumber, &e.PackCode, &e.UsedAt)
+		if err != nil {
+			h.log(ctx).Error("Error to get exposures", logger.Error(err))
+			return nil, err
+		}
+		e.AppointmentDate = date.Time
+		exposures = append(exposures, &e)
+	}
+	return exposures, rows.Err()
+}
+
+// This function is get an instrument pack by its code
+func (h *sterilizationRepo) GetPack(ctx context.Context, code string) (*repo.SterilizationPack, error) {
+	packs, err := h.packs(ctx, "p.code = $1", code)
+	if err != nil {
+		h.log(ctx).Error("Error to get sterilization pack", logger.Error(err))
+		return nil, err
+	}
+	if len(packs) == 0 {
+		return nil, repo.ErrNotFound
+	}
+	return packs[0], nil
+}
+
+// This function is record that an instrument pack was opened at an
+// appointment
+func (h *sterilizationRepo) UsePack(ctx context.Context, code, appointmentId string) (*repo.SterilizationPack, error) {
+	result, err := h.db.ExecContext(ctx, `
+	UPDATE
+		sterilization_packs p
+	SET
+		appointment_id = $2,
+		used_at = CURRENT_TIMESTAMP
+	FROM
+		sterilization_cycles c
+	WHERE
+		p.code = $1
+	AND
+		c.id = p.cycle_id
+	AND
+		c.result = 'passed'
+	AND
+		p.appointment_id IS NULL`, code, appointmentId)
+	if err != nil {
+		h.log(ctx).Error("Error to use sterilization pack", logger.Error(err))
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		if _, err = h.GetPack(ctx, code); err != nil {
+			return nil, err
+		}
+		return nil, repo.ErrPackUnusable
+	}
+	return h.GetPack(ctx, code)
+}
+
+// This function is get the packs used at an appointment
+func (h *sterilizationRepo) GetAppointmentPacks(ctx context.Context, appointmentId string) ([]*repo.SterilizationPack, error) {
+	packs, err := h.packs(ctx, "p.appointment_id = $1 ORDER BY p.used_at, p.code", appointmentId)
+	if err != nil {
+		h.log(ctx).Error("Error to get appointment packs", logger.Error(err))
+		return nil, err
+	}
+	return packs, nil
+}
+
+// packs returns the packs matching where, which may end in ORDER BY
+func (h *sterilizationRepo) packs(ctx context.Context, where string, args ...interface{}) ([]*repo.SterilizationPack, error) {
+	rows, err := h.db.QueryContext(ctx, `
+	SELECT`+packColumns+`
+	FROM
+		sterilization_packs p
+	JOIN
+		sterilization_cycles c ON c.id = p.cycle_id
+	WHERE
+		`+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var packs []*repo.SterilizationPack
+	for rows.Next() {
+		var (
+			p      repo.SterilizationPack
+			usedAt sql.NullTime
+		)
+		err = rows.Scan(&p.Id, &p.Code, &p.CycleId, &p.CycleResult, &p.Contents, &p.AppointmentId, &usedAt)
+		if err != nil {
+			return nil, err
+		}
+		if usedAt.Valid {
+			p.UsedAt = &usedAt.Time
+		}
+		packs = append(packs, &p)
+	}
+	return packs, rows.Err()
+}
+
+func scanCycle(row interface{ Scan(...interface{}) error }) (*repo.SterilizationCycle, error) {
+	var (
+		c        repo.SterilizationCycle
+		resultAt sql.NullTime
+	)
+	err := row.Scan(
+		&c.Id,
+		&c.AutoclaveId,
+		&c.AutoclaveName,
+		&c.CycleNumber,
+		&c.Program,
+		&c.StartedAt,
+		&c.TemperatureC,
+		&c.PressureKpa,
+		&c.HoldMinutes,
+		&c.Operator,
+		&c.Note,
+		&c.Result,
+		&c.Indicator,
+		&c.ResultNote,
+		&resultAt,
+		&c.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if resultAt.Valid {
+		c.ResultAt = &resultAt.Time
+	}
+	return &c, nil
+}
+
+func (h *sterilizationRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
@@ -0,0 +1,489 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dentist/pkg/events"
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// purgeLockKey is the advisory lock that keeps replicas from purging at
+// the same time
+const purgeLockKey = 7270434
+
+// byDeletedAt lists the most recently deleted rows first
+var byDeletedAt = ordering{column: "deleted_at", desc: true}
+
+type trashRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewTrashRepo(db *sqlx.DB, log logger.Logger) repo.NewTrashI {
+	return &trashRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+// This function is get deleted clients that can still be restored, merged
+// duplicates come back through undoing the merge instead
+func (h *trashRepo) GetDeletedClients(ctx context.Context, params pagination.Params) (*repo.AllDeletedClients, error) {
+	q := newQuery().where("deleted_at IS NOT NULL").where("anonymized_at IS NULL").where("merged_into IS NULL")
+
+	var clients repo.AllDeletedClients
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM clients WHERE `+q.sql(), q.args...).Scan(&clients.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count deleted clients", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byDeletedAt, params.Cursor)
+	query := fmt.Sprintf(`
+	SELECT
+		id,
+		name,
+		last_name,
+		father_name,
+		phone_number,
+		address,
+		birth_date,
+		created_at,
+		deleted_at
+	FROM
+		clients
+	WHERE
+		%s
+	ORDER BY %s
+	LIMIT %s
+	OFFSET %s`, q.sql(), byDeletedAt.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get deleted clients", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var client repo.DeletedClient
+		err = rows.Scan(
+			&client.Id,
+			&client.Name,
+			&client.LastName,
+			&client.FatherName,
+			&client.PhoneNumber,
+			&client.Address,
+			&client.BirthDate,
+			&client.CreatedAt,
+			&client.DeletedAt,
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to get deleted clients", logger.Error(err))
+			return nil, err
+		}
+		clients.Clients = append(clients.Clients, &client)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	clients.Clients, clients.NextCursor = pagination.Trim(clients.Clients, params.Limit, func(c *repo.DeletedClient) pagination.Cursor {
+		return pagination.Cursor{Key: c.DeletedAt.Format(time.RFC3339Nano), Id: c.Id}
+	})
+
+	return &clients, nil
+}
+
+// This function is get deleted appointments that can still be restored
+func (h *trashRepo) GetDeletedAppointments(ctx context.Context, params pagination.Params) (*repo.AllDeletedAppointments, error) {
+	q := newQuery().where("deleted_at IS NOT NULL").where("anonymized_at IS NULL")
+
+	var appointments repo.AllDeletedAppointments
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM appointments WHERE `+q.sql(), q.args...).Scan(&appointments.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count deleted appointments", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byDeletedAt, params.Cursor)
+	query := fmt.Sprintf(`
+	SELECT
+		id,
+		client_id,
+		COALESCE(doctor_id::TEXT, ''),
+		date,
+		diagnostics,
+		treatment,
+		amount,
+		status,
+		deleted_at
+	FROM
+		appointments
+	WHERE
+		%s
+	ORDER BY %s
+	LIMIT %s
+	OFFSET %s`, q.sql(), byDeletedAt.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get deleted appointments", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			appointment repo.DeletedAppointment
+			date        sql.NullTime
+		)
+		err = rows.Scan(
+			&appointment.Id,
+			&appointment.ClientId,
+			&appointment.DoctorId,
+			&date,
+			&appointment.Diagnostics,
+			&appointment.Treatment,
+			&appointment.Amount,
+			&appointment.Status,
+			&appointment.DeletedAt,
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to get deleted appointments", logger.Error(err))
+			return nil, err
+		}
+		appointment.Date = date.Time
+		appointments.Appointments = append(appointments.Appointments, &appointment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	appointments.Appointments, appointments.NextCursor = pagination.Trim(appointments.Appointments, params.Limit, func(a *repo.DeletedAppointment) pagination.Cursor {
+		return pagination.Cursor{Key: a.DeletedAt.Format(time.RFC3339Nano), Id: a.Id}
+	})
+
+	return &appointments, nil
+}
+
+// This function is restore a deleted client. DeleteClient stamps the client
+// and its appointments in one transaction, so they share deleted_at and
+// appointments deleted on their own before stay deleted.
+func (h *trashRepo) RestoreClient(ctx context.Context, id string) (int, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Error("Error creating transaction restore client", logger.Error(err))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var deletedAt time.Time
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		deleted_at
+	FROM
+		clients
+	WHERE
+		id = $1
+	AND
+		deleted_at IS NOT NULL
+	AND
+		anonymized_at IS NULL
+	AND
+		merged_into IS NULL
+	FOR UPDATE`, id).Scan(&deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get deleted client", logger.Error(err))
+		return 0, err
+	}
+
+	var client repo.Client
+	err = tx.QueryRowContext(ctx, `
+	UPDATE
+		clients
+	SET
+		deleted_at = NULL,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	RETURNING id, name, last_name, father_name, phone_number, address, birth_date`, id).Scan(
+		&client.Id,
+		&client.Name,
+		&client.LastName,
+		&client.FatherName,
+		&client.PhoneNumber,
+		&client.Address,
+		&client.BirthDate,
+	)
+	if err != nil {
+		h.log(ctx).Error("Error to restore client", logger.Error(err))
+		return 0, err
+	}
+
+	restored, err := scanAppointments(tx.QueryContext(ctx, `
+	UPDATE
+		appointments
+	SET
+		deleted_at = NULL,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		client_id = $1
+	AND
+		deleted_at = $2
+	AND
+		anonymized_at IS NULL
+	RETURNING id, client_id, COALESCE(doctor_id::TEXT, ''), date, diagnostics, treatment, amount, status`, id, deletedAt))
+	if err != nil {
+		h.log(ctx).Error("Error to restore client's appointments", logger.Error(err))
+		return 0, err
+	}
+
+	err = writeEvent(ctx, tx, events.AggregateClient, id, events.ClientRestored, events.Client(&client))
+	if err == nil {
+		err = writeRestoredAppointments(ctx, tx, restored)
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to write client event", logger.Error(err))
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(restored), nil
+}
+
+// This function is restore a deleted appointment of an active client
+func (h *trashRepo) RestoreAppointment(ctx context.Context, id string) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Error("Error creating transaction restore appointment", logger.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	// a legacy appointment may point to no client at all, it is treated
+	// like one of a deleted client
+	var clientActive bool
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		c.deleted_at IS NULL AND c.id IS NOT NULL
+	FROM
+		appointments a
+	LEFT JOIN
+		clients c ON c.id = a.client_id
+	WHERE
+		a.id = $1
+	AND
+		a.deleted_at IS NOT NULL
+	AND
+		a.anonymized_at IS NULL
+	FOR UPDATE OF a`, id).Scan(&clientActive)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get deleted appointment", logger.Error(err))
+		return err
+	}
+	if !clientActive {
+		return repo.ErrClientDeleted
+	}
+
+	restored, err := scanAppointments(tx.QueryContext(ctx, `
+	UPDATE
+		appointments
+	SET
+		deleted_at = NULL,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	RETURNING id, client_id, COALESCE(doctor_id::TEXT, ''), date, diagnostics, treatment, amount, status`, id))
+	if err != nil {
+		h.log(ctx).Error("Error to restore appointment", logger.Error(err))
+		return err
+	}
+	if err = writeRestoredAppointments(ctx, tx, restored); err != nil {
+		h.log(ctx).Error("Error to write appointment event", logger.Error(err))
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// This function is permanently remove, or anonymize, the rows deleted
+// before the given time. When another replica holds the purge lock it does
+// nothing.
+func (h *trashRepo) Purge(ctx context.Context, before time.Time, anonymize bool) (*repo.PurgeResult, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.log(ctx).Error("Error creating transaction purge", logger.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err = tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, purgeLockKey).Scan(&locked); err != nil {
+		return nil, err
+	}
+	if !locked {
+		return &repo.PurgeResult{}, nil
+	}
+
+	// the personal data the purged rows left elsewhere goes with them
+	var clientIds, appointmentIds []string
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		(SELECT COALESCE(array_agg(id), '{}') FROM clients WHERE deleted_at < $1 AND anonymized_at IS NULL),
+		(SELECT COALESCE(array_agg(id), '{}') FROM appointments WHERE deleted_at < $1 AND anonymized_at IS NULL)`,
+		before).Scan(pq.Array(&clientIds), pq.Array(&appointmentIds))
+	if err != nil {
+		h.log(ctx).Error("Error to get purged rows", logger.Error(err))
+		return nil, err
+	}
+	if err = scrubPersonalData(ctx, tx, clientIds, appointmentIds); err != nil {
+		h.log(ctx).Error("Error to scrub personal data of purged rows", logger.Error(err))
+		return nil, err
+	}
+
+	// an appointment an instrument pack was used at is the trace of who
+	// was exposed to its sterilization cycle, it is anonymized instead
+	// of deleted
+	keptAppointmentsQuery := `
+	UPDATE
+		appointments
+	SET ` + anonymizeAppointmentSet + `
+	WHERE
+		((deleted_at < $1 AND anonymized_at IS NULL)
+		OR client_id IN (SELECT id FROM clients WHERE deleted_at < $1 AND anonymized_at IS NULL))
+	AND
+		anonymized_at IS NULL
+	AND
+		EXISTS (SELECT 1 FROM sterilization_packs p WHERE p.appointment_id = appointments.id)`
+	appointmentsQuery := `
+	DELETE FROM
+		appointments
+	WHERE
+		((deleted_at < $1 AND anonymized_at IS NULL)
+		OR client_id IN (SELECT id FROM clients WHERE deleted_at < $1 AND anonymized_at IS NULL))
+	AND NOT
+		EXISTS (SELECT 1 FROM sterilization_packs p WHERE p.appointment_id = appointments.id)`
+	// lab cases are records of work paid to a lab and keep their client,
+	// as do the appointments kept above. Such a client is anonymized
+	// instead of deleted.
+	keptClientsQuery := `
+	UPDATE
+		clients
+	SET ` + anonymizeClientSet + `
+	WHERE
+		deleted_at < $1
+	AND
+		anonymized_at IS NULL
+	AND
+		(EXISTS (SELECT 1 FROM lab_cases l WHERE l.client_id = clients.id)
+		OR EXISTS (SELECT 1 FROM appointments a WHERE a.client_id = clients.id))`
+	clientsQuery := `
+	DELETE FROM
+		clients
+	WHERE
+		deleted_at < $1
+	AND
+		anonymized_at IS NULL`
+	// an erased client stays as an anonymized row because its
+	// appointments are kept for the statistics
+	if anonymize {
+		keptAppointmentsQuery = ""
+		keptClientsQuery = ""
+		appointmentsQuery = `
+		UPDATE
+			appointments
+		SET ` + anonymizeAppointmentSet + `
+		WHERE
+			deleted_at < $1
+		AND
+			anonymized_at IS NULL`
+		clientsQuery = `
+		UPDATE
+			clients
+		SET ` + anonymizeClientSet + `
+		WHERE
+			deleted_at < $1
+		AND
+			anonymized_at IS NULL`
+	}
+
+	var (
+		result repo.PurgeResult
+		res    sql.Result
+	)
+	if keptAppointmentsQuery != "" {
+		res, err = tx.ExecContext(ctx, keptAppointmentsQuery, before)
+		if err != nil {
+			h.log(ctx).Error("Error to anonymize purged appointments", logger.Error(err))
+			return nil, err
+		}
+		kept, _ := res.RowsAffected()
+		result.Appointments = int(kept)
+	}
+
+	res, err = tx.ExecContext(ctx, appointmentsQuery, before)
+	if err != nil {
+		h.log(ctx).Error("Error to purge appointments", logger.Error(err))
+		return nil, err
+	}
+	appointments, _ := res.RowsAffected()
+	result.Appointments += int(appointments)
+
+	if keptClientsQuery != "" {
+		res, err = tx.ExecContext(ctx, keptClientsQuery, before)
+		if err != nil {
+			h.log(ctx).Error("Error to anonymize purged clients", logger.Error(err))
+			return nil, err
+		}
+		kept, _ := res.RowsAffected()
+		result.Clients = int(kept)
+	}
+
+	res, err = tx.ExecContext(ctx, clientsQuery, before)
+	if err != nil {
+		h.log(ctx).Error("Error to purge clients", logger.Error(err))
+		return nil, err
+	}
+	clients, _ := res.RowsAffected()
+	result.Clients += int(clients)
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// writeRestoredAppointments adds appointment.restored of each to the
+// outbox, the appointment takes its time back
+func writeRestoredAppointments(ctx context.Context, tx *sql.Tx, restored []*repo.Appointment) error {
+	for _, a := range restored {
+		err := writeEvent(ctx, tx, events.AggregateAppointment, a.Id, events.AppointmentRestored, events.Appointment(a))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *trashRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
@@ -0,0 +1,630 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type waitlistRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewWaitlistRepo(db *sqlx.DB, log logger.Logger) repo.NewWaitlistI {
+	return &waitlistRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+const entryColumns = `
+		id,
+		client_id,
+		COALESCE(doctor_id::TEXT, ''),
+		treatment,
+		date_from,
+		date_to,
+		priority,
+		note,
+		status,
+		COALESCE(appointment_id::TEXT, ''),
+		created_at`
+
+const offerColumns = `
+		o.id,
+		o.slot_id,
+		o.entry_id,
+		o.rank,
+		o.status,
+		o.expires_at,
+		o.notified_at,
+		o.accepted_at,
+		o.created_at,
+		COALESCE(s.doctor_id::TEXT, ''),
+		s.date,
+		e.client_id,
+		TRIM(c.name || ' ' || COALESCE(c.last_name, '')),
+		COALESCE(c.phone_number, ''),
+		e.treatment`
+
+const offerTables = `
+		waitlist_offers o
+	JOIN waitlist_slots s ON s.id = o.slot_id
+	JOIN waitlist_entries e ON e.id = o.entry_id
+	JOIN clients c ON c.id = e.client_id`
+
+// occupied is true when an appointment is booked at the time and with the
+// doctor of the slot s
+const occupied = `
+	EXISTS (
+		SELECT
+			1
+		FROM
+			appointments a
+		WHERE
+			a.date = s.date
+		AND
+			a.doctor_id IS NOT DISTINCT FROM s.doctor_id
+		AND
+			a.deleted_at IS NULL
+		AND
+			a.status NOT IN ('cancelled', 'no_show')
+	)`
+
+// This function is put a client on the waitlist
+func (h *waitlistRepo) CreateEntry(ctx context.Context, entry *repo.WaitlistEntry) (*repo.WaitlistEntry, error) {
+	query := `
+	INSERT INTO
+		waitlist_entries(
+			id,
+			client_id,
+			doctor_id,
+			treatment,
+			date_from,
+			date_to,
+			priority,
+			note
+		) VALUES ($1, $2, NULLIF($3, '')::UUID, $4, $5, $6, $7, $8)
+	RETURNING` + entryColumns
+
+	row := h.db.QueryRowContext(ctx, query,
+		uuid.NewString(),
+		entry.ClientId,
+		entry.DoctorId,
+		entry.Treatment,
+		entry.DateFrom,
+		entry.DateTo,
+		entry.Priority,
+		entry.Note,
+	)
+	created, err := scanEntry(row)
+	if err != nil {
+		h.log(ctx).Error("Error to create waitlist entry", logger.Error(err))
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// This function is get a waitlist entry
+func (h *waitlistRepo) GetEntry(ctx context.Context, id string) (*repo.WaitlistEntry, error) {
+	query := `
+	SELECT` + entryColumns + `
+	FROM
+		waitlist_entries
+	WHERE
+		id = $1`
+
+	entry, err := scanEntry(h.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get waitlist entry", logger.Error(err))
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// This function is get the waitlist entries, the longest waiting first
+func (h *waitlistRepo) GetEntries(ctx context.Context, filter *repo.WaitlistFilter, params pagination.Params) (*repo.AllWaitlistEntries, error) {
+	q := newQuery()
+	if filter.Status != "" {
+		q.where("status = ?", filter.Status)
+	}
+	if filter.DoctorId != "" {
+		q.where("doctor_id = ?", filter.DoctorId)
+	}
+	if filter.ClientId != "" {
+		q.where("client_id = ?", filter.ClientId)
+	}
+
+	var entries repo.AllWaitlistEntries
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM waitlist_entries WHERE `+q.sql(), q.args...).Scan(&entries.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count waitlist entries", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byCreatedAt, params.Cursor)
+	query := fmt.Sprintf(`
+	SELECT`+entryColumns+`
+	FROM
+		waitlist_entries
+	WHERE
+		%s
+	ORDER BY %s
+	LIMIT %s
+	OFFSET %s`, q.sql(), byCreatedAt.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get waitlist entries", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			h.log(ctx).Error("Error to get waitlist entries", logger.Error(err))
+			return nil, err
+		}
+		entries.Entries = append(entries.Entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	entries.Entries, entries.NextCursor = pagination.Trim(entries.Entries, params.Limit, func(e *repo.WaitlistEntry) pagination.Cursor {
+		return pagination.Cursor{Key: e.CreatedAt.Format(time.RFC3339Nano), Id: e.Id}
+	})
+
+	return &entries, nil
+}
+
+// This function is take a waiting entry off the list, its pending offers
+// are withdrawn
+func (h *waitlistRepo) CancelEntry(ctx context.Context, id string) (bool, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+	UPDATE
+		waitlist_entries
+	SET
+		status = 'cancelled',
+		updated_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	AND
+		status = 'waiting'`, id)
+	if err != nil {
+		h.log(ctx).Error("Error to cancel waitlist entry", logger.Error(err))
+		return false, err
+	}
+	if cancelled, _ := result.RowsAffected(); cancelled == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		waitlist_offers
+	SET
+		status = 'withdrawn'
+	WHERE
+		entry_id = $1
+	AND
+		status = 'pending'`, id)
+	if err != nil {
+		h.log(ctx).Error("Error to withdraw waitlist offers", logger.Error(err))
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// This function is get the offers made to an entry, newest first
+func (h *waitlistRepo) GetEntryOffers(ctx context.Context, entryId string) ([]*repo.WaitlistOffer, error) {
+	query := `
+	SELECT` + offerColumns + `
+	FROM` + offerTables + `
+	WHERE
+		o.entry_id = $1
+	ORDER BY o.created_at DESC`
+
+	offers, err := h.offers(ctx, query, entryId)
+	if err != nil {
+		h.log(ctx).Error("Error to get waitlist offers", logger.Error(err))
+		return nil, err
+	}
+	return offers, nil
+}
+
+// This function is offer a freed slot to the best matching entries. An
+// entry matches when the day of the slot is in its window, and its doctor
+// and treatment are those of the slot or empty. Entries asking for the
+// doctor or the treatment come before those taking any.
+func (h *waitlistRepo) OfferSlot(ctx context.Context, slot *repo.WaitlistSlot, limit int, ttl time.Duration) ([]*repo.WaitlistOffer, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+	INSERT INTO
+		waitlist_slots(
+			id,
+			doctor_id,
+			date,
+			treatment,
+			source_appointment_id
+		) VALUES ($1, NULLIF($2, '')::UUID, $3, $4, $5)
+	ON CONFLICT (id) DO NOTHING`,
+		slot.Id,
+		slot.DoctorId,
+		slot.Date,
+		slot.Treatment,
+		slot.SourceAppointmentId,
+	)
+	if err != nil {
+		h.log(ctx).Error("Error to create waitlist slot", logger.Error(err))
+		return nil, err
+	}
+
+	if created, _ := result.RowsAffected(); created == 1 {
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO
+			waitlist_offers(
+				id,
+				slot_id,
+				entry_id,
+				rank,
+				expires_at
+			)
+		SELECT
+			md5($1::TEXT || e.id::TEXT)::UUID,
+			$1::UUID,
+			e.id,
+			ROW_NUMBER() OVER (ORDER BY e.priority DESC, e.doctor_id IS NULL, e.treatment = '', e.created_at),
+			$7::TIMESTAMPTZ
+		FROM
+			waitlist_entries e
+		JOIN clients c ON c.id = e.client_id AND c.deleted_at IS NULL
+		WHERE
+			e.status = 'waiting'
+		AND
+			$3::TIMESTAMPTZ::DATE BETWEEN e.date_from AND e.date_to
+		AND
+			(e.doctor_id IS NULL OR e.doctor_id = NULLIF($2, '')::UUID)
+		AND
+			(e.treatment = '' OR LOWER(e.treatment) = LOWER($4))
+		AND
+			e.client_id::TEXT <> $5
+		AND NOT EXISTS (
+			SELECT
+				1
+			FROM
+				appointments a
+			WHERE
+				a.client_id = e.client_id
+			AND
+				a.date = $3::TIMESTAMPTZ
+			AND
+				a.deleted_at IS NULL
+			AND
+				a.status NOT IN ('cancelled', 'no_show')
+		)
+		ORDER BY e.priority DESC, e.doctor_id IS NULL, e.treatment = '', e.created_at
+		LIMIT $6`,
+			slot.Id,
+			slot.DoctorId,
+			slot.Date,
+			slot.Treatment,
+			slot.SourceClientId,
+			limit,
+			time.Now().Add(ttl),
+		)
+		if err != nil {
+			h.log(ctx).Error("Error to create waitlist offers", logger.Error(err))
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT` + offerColumns + `
+	FROM` + offerTables + `
+	WHERE
+		o.slot_id = $1
+	AND
+		o.status = 'pending'
+	AND
+		o.notified_at IS NULL
+	AND
+		o.expires_at > CURRENT_TIMESTAMP
+	ORDER BY o.rank`
+
+	offers, err := h.offers(ctx, query, slot.Id)
+	if err != nil {
+		h.log(ctx).Error("Error to get waitlist offers", logger.Error(err))
+		return nil, err
+	}
+	return offers, nil
+}
+
+// This function is set the hash of the token in the link of an offer
+func (h *waitlistRepo) SetOfferToken(ctx context.Context, offerId, tokenHash string) error {
+	_, err := h.db.ExecContext(ctx, `UPDATE waitlist_offers SET token_hash = $2 WHERE id = $1`, offerId, tokenHash)
+	if err != nil {
+		h.log(ctx).Error("Error to set waitlist offer token", logger.Error(err))
+	}
+	return err
+}
+
+// This function is remember that the client of an offer was told
+func (h *waitlistRepo) MarkNotified(ctx context.Context, offerId string) error {
+	_, err := h.db.ExecContext(ctx, `UPDATE waitlist_offers SET notified_at = CURRENT_TIMESTAMP WHERE id = $1`, offerId)
+	if err != nil {
+		h.log(ctx).Error("Error to mark waitlist offer notified", logger.Error(err))
+	}
+	return err
+}
+
+// This function is get a waitlist offer
+func (h *waitlistRepo) GetOffer(ctx context.Context, id string) (*repo.WaitlistOffer, error) {
+	return h.offer(ctx, "o.id = $1", id)
+}
+
+// This function is get the waitlist offer of the token of a link
+func (h *waitlistRepo) GetOfferByToken(ctx context.Context, tokenHash string) (*repo.WaitlistOffer, error) {
+	return h.offer(ctx, "o.token_hash = $1", tokenHash)
+}
+
+// This function is take the slot of an offer. The slot row is locked by
+// the first claim, a concurrent one waits for it and finds it taken.
+func (h *waitlistRepo) ClaimSlot(ctx context.Context, offerId string) (*repo.WaitlistOffer, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var (
+		slotId, status string
+		expiresAt      time.Time
+	)
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		slot_id,
+		status,
+		expires_at
+	FROM
+		waitlist_offers
+	WHERE
+		id = $1
+	FOR UPDATE`, offerId).Scan(&slotId, &status, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get waitlist offer", logger.Error(err))
+		return nil, err
+	}
+	if status != repo.OfferPending || !expiresAt.After(time.Now()) {
+		return nil, repo.ErrOfferClosed
+	}
+
+	result, err := tx.ExecContext(ctx, `
+	UPDATE
+		waitlist_slots s
+	SET
+		status = 'taken'
+	WHERE
+		id = $1
+	AND
+		status = 'open'
+	AND NOT`+occupied, slotId)
+	if err != nil {
+		h.log(ctx).Error("Error to claim waitlist slot", logger.Error(err))
+		return nil, err
+	}
+
+	outcome, claimErr := repo.OfferAccepted, error(nil)
+	if claimed, _ := result.RowsAffected(); claimed == 0 {
+		outcome, claimErr = repo.OfferLost, repo.ErrSlotTaken
+	}
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		waitlist_offers
+	SET
+		status = $2,
+		accepted_at = CASE WHEN $2 = 'accepted' THEN CURRENT_TIMESTAMP END
+	WHERE
+		id = $1`, offerId, outcome)
+	if err != nil {
+		h.log(ctx).Error("Error to update waitlist offer", logger.Error(err))
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	if claimErr != nil {
+		return nil, claimErr
+	}
+
+	return h.GetOffer(ctx, offerId)
+}
+
+// This function is give the slot of an accepted offer back
+func (h *waitlistRepo) ReleaseSlot(ctx context.Context, offerId string) error {
+	_, err := h.db.ExecContext(ctx, `
+	WITH released AS (
+		UPDATE
+			waitlist_offers
+		SET
+			status = 'pending',
+			accepted_at = NULL
+		WHERE
+			id = $1
+		AND
+			status = 'accepted'
+		RETURNING slot_id
+	)
+	UPDATE
+		waitlist_slots
+	SET
+		status = 'open'
+	WHERE
+		id IN (SELECT slot_id FROM released)`, offerId)
+	if err != nil {
+		h.log(ctx).Error("Error to release waitlist slot", logger.Error(err))
+	}
+	return err
+}
+
+// This function is book the entry of an accepted offer, and close the
+// offers that cannot be accepted any more
+func (h *waitlistRepo) CompleteOffer(ctx context.Context, offerId, appointmentId string) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var entryId, slotId string
+	err = tx.QueryRowContext(ctx, `
+	UPDATE
+		waitlist_entries e
+	SET
+		status = 'booked',
+		appointment_id = $2,
+		updated_at = CURRENT_TIMESTAMP
+	FROM
+		waitlist_offers o
+	WHERE
+		o.id = $1
+	AND
+		e.id = o.entry_id
+	RETURNING e.id, o.slot_id`, offerId, appointmentId).Scan(&entryId, &slotId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to book waitlist entry", logger.Error(err))
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		waitlist_offers
+	SET
+		status = CASE WHEN slot_id = $2 THEN 'lost' ELSE 'withdrawn' END
+	WHERE
+		(slot_id = $2 OR entry_id = $3)
+	AND
+		id <> $1
+	AND
+		status = 'pending'`, offerId, slotId, entryId)
+	if err != nil {
+		h.log(ctx).Error("Error to close waitlist offers", logger.Error(err))
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (h *waitlistRepo) offer(ctx context.Context, cond string, arg string) (*repo.WaitlistOffer, error) {
+	query := `
+	SELECT` + offerColumns + `
+	FROM` + offerTables + `
+	WHERE
+		` + cond
+
+	offers, err := h.offers(ctx, query, arg)
+	if err != nil {
+		h.log(ctx).Error("Error to get waitlist offer", logger.Error(err))
+		return nil, err
+	}
+	if len(offers) == 0 {
+		return nil, repo.ErrNotFound
+	}
+	return offers[0], nil
+}
+
+func (h *waitlistRepo) offers(ctx context.Context, query string, args ...interface{}) ([]*repo.WaitlistOffer, error) {
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offers := []*repo.WaitlistOffer{}
+	for rows.Next() {
+		var o repo.WaitlistOffer
+		err = rows.Scan(
+			&o.Id,
+			&o.SlotId,
+			&o.EntryId,
+			&o.Rank,
+			&o.Status,
+			&o.ExpiresAt,
+			&o.NotifiedAt,
+			&o.AcceptedAt,
+			&o.CreatedAt,
+			&o.DoctorId,
+			&o.Date,
+			&o.ClientId,
+			&o.ClientName,
+			&o.PhoneNumber,
+			&o.Treatment,
+		)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, &o)
+	}
+
+	return offers, rows.Err()
+}
+
+func scanEntry(row interface{ Scan(...interface{}) error }) (*repo.WaitlistEntry, error) {
+	var e repo.WaitlistEntry
+	err := row.Scan(
+		&e.Id,
+		&e.ClientId,
+		&e.DoctorId,
+		&e.Treatment,
+		&e.DateFrom,
+		&e.DateTo,
+		&e.Priority,
+		&e.Note,
+		&e.Status,
+		&e.AppointmentId,
+		&e.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (h *waitlistRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// insertOffers adds an open slot of the doctor at date offered to n
+// entries, it returns the offers
+func insertOffers(t *testing.T, db *sqlx.DB, doctorId string, date time.Time, n int) []string {
+	t.Helper()
+	slotId := uuid.NewString()
+	_, err := db.Exec(`
+	INSERT INTO waitlist_slots(id, doctor_id, date, source_appointment_id)
+	VALUES ($1, $2, $3, $4)`, slotId, doctorId, date, uuid.NewString())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var offers []string
+	for i := 0; i < n; i++ {
+		entryId, offerId := uuid.NewString(), uuid.NewString()
+		_, err = db.Exec(`
+		INSERT INTO waitlist_entries(id, client_id, doctor_id, date_from, date_to)
+		VALUES ($1, $2, $3, $4, $4)`, entryId, insertClient(t, db), doctorId, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = db.Exec(`
+		INSERT INTO waitlist_offers(id, slot_id, entry_id, rank, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`, offerId, slotId, entryId, i+1, time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		offers = append(offers, offerId)
+	}
+	return offers
+}
+
+func TestClaimSlotConcurrent(t *testing.T) {
+	db := testDB(t)
+	waitlist := NewWaitlistRepo(db, testLogger())
+	date := time.Now().Add(48 * time.Hour).Truncate(time.Minute)
+	offers := insertOffers(t, db, insertDoctor(t, db), date, 2)
+
+	errs := make([]error, len(offers))
+	var wg sync.WaitGroup
+	for i, offerId := range offers {
+		wg.Add(1)
+		go func(i int, offerId string) {
+			defer wg.Done()
+			_, errs[i] = waitlist.ClaimSlot(context.Background(), offerId)
+		}(i, offerId)
+	}
+	wg.Wait()
+
+	won, lost := 0, 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			won++
+		case errors.Is(err, repo.ErrSlotTaken):
+			lost++
+		default:
+			t.Fatal(err)
+		}
+	}
+	if won != 1 || lost != 1 {
+		t.Fatalf("ClaimSlot won %d and lost %d, want 1 and 1", won, lost)
+	}
+}
+
+func TestClaimSlotOccupied(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	waitlist := NewWaitlistRepo(db, testLogger())
+	doctorId := insertDoctor(t, db)
+	date := time.Now().Add(48 * time.Hour).Truncate(time.Minute)
+	offers := insertOffers(t, db, doctorId, date, 1)
+
+	// the time was booked meanwhile
+	_, err := db.Exec(`
+	INSERT INTO appointments(id, client_id, date, doctor_id)
+	VALUES ($1, $2, $3, $4)`, uuid.NewString(), insertClient(t, db), date, doctorId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = waitlist.ClaimSlot(ctx, offers[0]); !errors.Is(err, repo.ErrSlotTaken) {
+		t.Fatalf("ClaimSlot err = %v, want %v", err, repo.ErrSlotTaken)
+	}
+	offer, err := waitlist.GetOffer(ctx, offers[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offer.Status != repo.OfferLost {
+		t.Fatalf("offer status = %q, want %q", offer.Status, repo.OfferLost)
+	}
+}
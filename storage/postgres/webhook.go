@@ -0,0 +1,429 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dentist/pkg/logger"
+	"github.com/dentist/pkg/pagination"
+	"github.com/dentist/storage/repo"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type webhookRepo struct {
+	db     *sqlx.DB
+	logger logger.Logger
+}
+
+func NewWebhookRepo(db *sqlx.DB, log logger.Logger) repo.NewWebhookI {
+	return &webhookRepo{
+		db:     db,
+		logger: log,
+	}
+}
+
+const webhookColumns = `
+		id,
+		url,
+		events,
+		secret,
+		description,
+		created_at`
+
+const deliveryColumns = `
+		d.id,
+		d.webhook_id,
+		d.event_id,
+		d.event,
+		d.payload,
+		d.status,
+		d.attempts,
+		d.next_attempt_at,
+		COALESCE(d.response_status, 0),
+		d.last_error,
+		COALESCE(d.replay_of::TEXT, ''),
+		d.created_at,
+		d.delivered_at`
+
+var byDeliveryCreatedAt = ordering{column: "created_at", desc: true}
+
+// This function is create a webhook subscription
+func (h *webhookRepo) CreateWebhook(ctx context.Context, w *repo.Webhook) (*repo.Webhook, error) {
+	query := `
+	INSERT INTO
+		webhooks(
+			id,
+			url,
+			events,
+			secret,
+			description
+		) VALUES ($1, $2, $3, $4, $5)
+	RETURNING created_at`
+
+	w.Id = uuid.NewString()
+	err := h.db.QueryRowContext(ctx, query,
+		w.Id,
+		w.Url,
+		pq.Array(w.Events),
+		w.Secret,
+		w.Description,
+	).Scan(&w.CreatedAt)
+	if err != nil {
+		h.log(ctx).Error("Error to create webhook", logger.Error(err))
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// This function is get a webhook that is not deleted
+func (h *webhookRepo) GetWebhook(ctx context.Context, id string) (*repo.Webhook, error) {
+	query := `
+	SELECT` + webhookColumns + `
+	FROM
+		webhooks
+	WHERE
+		id = $1
+	AND
+		deleted_at IS NULL`
+
+	w, err := scanWebhook(h.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to get webhook", logger.Error(err))
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// This function is get every webhook that is not deleted
+func (h *webhookRepo) GetWebhooks(ctx context.Context) ([]*repo.Webhook, error) {
+	query := `
+	SELECT` + webhookColumns + `
+	FROM
+		webhooks
+	WHERE
+		deleted_at IS NULL
+	ORDER BY created_at`
+
+	rows, err := h.db.QueryContext(ctx, query)
+	if err != nil {
+		h.log(ctx).Error("Error to get webhooks", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*repo.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			h.log(ctx).Error("Error to get webhooks", logger.Error(err))
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// This function is delete a webhook, its pending deliveries are dropped
+// and the delivered ones are kept in the log
+func (h *webhookRepo) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+	UPDATE
+		webhooks
+	SET
+		deleted_at = CURRENT_TIMESTAMP
+	WHERE
+		id = $1
+	AND
+		deleted_at IS NULL`, id)
+	if err != nil {
+		h.log(ctx).Error("Error to delete webhook", logger.Error(err))
+		return false, err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		webhook_deliveries
+	SET
+		status = 'failed',
+		last_error = 'webhook deleted'
+	WHERE
+		webhook_id = $1
+	AND
+		status = 'pending'`, id)
+	if err != nil {
+		h.log(ctx).Error("Error to delete webhook", logger.Error(err))
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// This function is queue the event for every webhook subscribed to it,
+// the id of a delivery is derived from the event and the webhook so an
+// event is queued only once
+func (h *webhookRepo) Enqueue(ctx context.Context, event *repo.WebhookEvent) (int, error) {
+	query := `
+	INSERT INTO
+		webhook_deliveries(
+			id,
+			webhook_id,
+			event_id,
+			event,
+			payload,
+			created_at
+		)
+	SELECT
+		md5($1::TEXT || w.id::TEXT)::UUID,
+		w.id,
+		$1::UUID,
+		$2,
+		$3::JSONB,
+		$4::TIMESTAMPTZ
+	FROM
+		webhooks w
+	WHERE
+		w.deleted_at IS NULL
+	AND
+		(w.events @> ARRAY[$2::TEXT] OR w.events @> ARRAY['*'])
+	ON CONFLICT (id) DO NOTHING`
+
+	result, err := h.db.ExecContext(ctx, query, event.Id, event.Type, string(event.Payload), event.CreatedAt)
+	if err != nil {
+		h.log(ctx).Error("Error to enqueue webhook event", logger.Error(err))
+		return 0, err
+	}
+	queued, _ := result.RowsAffected()
+
+	return int(queued), nil
+}
+
+// This function is take the due deliveries, SKIP LOCKED lets every
+// instance claim different ones
+func (h *webhookRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*repo.WebhookDelivery, error) {
+	query := `
+	UPDATE
+		webhook_deliveries d
+	SET
+		next_attempt_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 millisecond'
+	FROM
+		webhooks w
+	WHERE
+		w.id = d.webhook_id
+	AND
+		d.id IN (
+			SELECT
+				id
+			FROM
+				webhook_deliveries
+			WHERE
+				status = 'pending'
+			AND
+				next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+	RETURNING` + deliveryColumns + `,
+		w.url,
+		w.secret`
+
+	rows, err := h.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		h.log(ctx).Error("Error to claim webhook deliveries", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*repo.WebhookDelivery
+	for rows.Next() {
+		var d repo.WebhookDelivery
+		if err = rows.Scan(append(deliveryFields(&d), &d.Url, &d.Secret)...); err != nil {
+			h.log(ctx).Error("Error to claim webhook deliveries", logger.Error(err))
+			return nil, err
+		}
+		deliveries = append(deliveries, &d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// This function is save the outcome of an attempt to send a delivery
+func (h *webhookRepo) RecordAttempt(ctx context.Context, d *repo.WebhookDelivery) error {
+	query := `
+	UPDATE
+		webhook_deliveries
+	SET
+		status = $2,
+		attempts = $3,
+		next_attempt_at = $4,
+		response_status = NULLIF($5, 0),
+		last_error = $6,
+		delivered_at = $7
+	WHERE
+		id = $1`
+
+	_, err := h.db.ExecContext(ctx, query,
+		d.Id,
+		d.Status,
+		d.Attempts,
+		d.NextAttemptAt,
+		d.ResponseStatus,
+		d.LastError,
+		d.DeliveredAt,
+	)
+	if err != nil {
+		h.log(ctx).Error("Error to record webhook delivery", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// This function is get the delivery log of a webhook, newest first
+func (h *webhookRepo) GetDeliveries(ctx context.Context, webhookId string, params pagination.Params) (*repo.AllWebhookDeliveries, error) {
+	q := newQuery().where("webhook_id = ?", webhookId)
+
+	var deliveries repo.AllWebhookDeliveries
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhook_deliveries WHERE `+q.sql(), q.args...).Scan(&deliveries.Total)
+	if err != nil {
+		h.log(ctx).Error("Error to count webhook deliveries", logger.Error(err))
+		return nil, err
+	}
+
+	q.after(byDeliveryCreatedAt, params.Cursor)
+	query := fmt.Sprintf(`
+	SELECT`+deliveryColumns+`
+	FROM
+		webhook_deliveries d
+	WHERE
+		%s
+	ORDER BY %s
+	LIMIT %s
+	OFFSET %s`, q.sql(), byDeliveryCreatedAt.sql(), q.arg(params.Limit+1), q.arg(params.Offset))
+
+	rows, err := h.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		h.log(ctx).Error("Error to get webhook deliveries", logger.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d repo.WebhookDelivery
+		if err = rows.Scan(deliveryFields(&d)...); err != nil {
+			h.log(ctx).Error("Error to get webhook deliveries", logger.Error(err))
+			return nil, err
+		}
+		deliveries.Deliveries = append(deliveries.Deliveries, &d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	deliveries.Deliveries, deliveries.NextCursor = pagination.Trim(deliveries.Deliveries, params.Limit, func(d *repo.WebhookDelivery) pagination.Cursor {
+		return pagination.Cursor{Key: d.CreatedAt.Format(time.RFC3339Nano), Id: d.Id}
+	})
+
+	return &deliveries, nil
+}
+
+// This function is queue the payload of a delivery again, for a webhook
+// that is not deleted
+func (h *webhookRepo) ReplayDelivery(ctx context.Context, id string) (*repo.WebhookDelivery, error) {
+	query := `
+	INSERT INTO
+		webhook_deliveries AS d(
+			id,
+			webhook_id,
+			event_id,
+			event,
+			payload,
+			replay_of
+		)
+	SELECT
+		$2::UUID,
+		o.webhook_id,
+		o.event_id,
+		o.event,
+		o.payload,
+		o.id
+	FROM
+		webhook_deliveries o
+	JOIN webhooks w ON w.id = o.webhook_id
+	WHERE
+		o.id = $1
+	AND
+		w.deleted_at IS NULL
+	RETURNING` + deliveryColumns
+
+	var d repo.WebhookDelivery
+	err := h.db.QueryRowContext(ctx, query, id, uuid.NewString()).Scan(deliveryFields(&d)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repo.ErrNotFound
+	}
+	if err != nil {
+		h.log(ctx).Error("Error to replay webhook delivery", logger.Error(err))
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+func scanWebhook(row interface{ Scan(...interface{}) error }) (*repo.Webhook, error) {
+	var w repo.Webhook
+	err := row.Scan(
+		&w.Id,
+		&w.Url,
+		pq.Array(&w.Events),
+		&w.Secret,
+		&w.Description,
+		&w.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// deliveryFields are the scan targets of deliveryColumns
+func deliveryFields(d *repo.WebhookDelivery) []interface{} {
+	return []interface{}{
+		&d.Id,
+		&d.WebhookId,
+		&d.EventId,
+		&d.Event,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.ResponseStatus,
+		&d.LastError,
+		&d.ReplayOf,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	}
+}
+
+func (h *webhookRepo) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, h.logger)
+}
@@ -1,29 +1,102 @@
 package repo
 
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/pagination"
+)
+
+// Appointment statuses, see the appointments_status_check constraint
+const (
+	// StatusPending holds the time of an appointment booked online until
+	// it is confirmed
+	StatusPending   = "pending"
+	StatusScheduled = "scheduled"
+	StatusConfirmed = "confirmed"
+	StatusCheckedIn = "checked_in"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+	StatusNoShow    = "no_show"
+)
+
+// ValidStatus reports whether s is one of the known appointment statuses
+func ValidStatus(s string) bool {
+	switch s {
+	case StatusPending, StatusScheduled, StatusConfirmed, StatusCheckedIn, StatusCompleted, StatusCancelled, StatusNoShow:
+		return true
+	}
+	return false
+}
+
 type Appointment struct {
 	Id string
 	ClientId string
-	Date string
+	DoctorId string
+	Date time.Time
 	Diagnostics string
 	Treatment string
 	Amount int
+	Status string
 }
 
 type AllAppointments struct {
 	Appointment []*Appointment
+	Total int
+	NextCursor string
 }
 
 type GetAllAppointment struct{
-	Page int
-	Limit int
+	pagination.Params
+}
+
+// Sort keys of AppointmentFilter
+const (
+	SortDate   = "date"
+	SortAmount = "amount"
+)
+
+// AppointmentFilter narrows GetAppointments, zero fields are ignored.
+// From is inclusive and To is exclusive, MinAmount and MaxAmount are both
+// inclusive. Text is a web-search style query (words, "phrases", -word)
+// matched against diagnostics and treatment.
+type AppointmentFilter struct {
+	From      *time.Time
+	To        *time.Time
+	ClientId  string
+	DoctorId  string
+	Statuses  []string
+	MinAmount *int
+	MaxAmount *int
+	Text      string
+
+	Sort string
+	Desc bool
+}
+
+// AppointmentRow is an appointment with the names it refers to, as listed
+// in exports
+type AppointmentRow struct {
+	Appointment
+	ClientName  string
+	ClientPhone string
+	DoctorName  string
 }
 
 type NewAppointmentI interface {
-	CreateAppointment(*Appointment)(*Appointment, error)
-	GetAppointment(id string)(*Appointment, error)
-	UpdateAppointment(*Appointment)(*Appointment, error)
-	DeleteAppointment(id string)(bool, error)
-	GetAllAppointments(*GetAllAppointment)(*AllAppointments, error)
-	GetAppointmentsWithDate(req, page, limit int) (*AllAppointments, error)
-	GetAppointmentsWithClientId(id string, page, limit int) ([]Appointment, error)
+	CreateAppointment(ctx context.Context, req *Appointment)(*Appointment, error)
+	GetAppointment(ctx context.Context, id string)(*Appointment, error)
+	// UpdateAppointment fails with ErrLabCaseNotReceived when confirming,
+	// checking in or completing the fitting of a lab case the lab has not
+	// delivered yet
+	UpdateAppointment(ctx context.Context, req *Appointment)(*Appointment, error)
+	DeleteAppointment(ctx context.Context, id string)(bool, error)
+	GetAllAppointments(ctx context.Context, req *GetAllAppointment)(*AllAppointments, error)
+	GetAppointmentsWithDate(ctx context.Context, req int, params pagination.Params) (*AllAppointments, error)
+	GetAppointmentsWithClientId(ctx context.Context, id string, params pagination.Params) (*AllAppointments, error)
+	GetAppointments(ctx context.Context, filter *AppointmentFilter, params pagination.Params) (*AllAppointments, error)
+	CountAppointmentsByStatus(ctx context.Context, from, to time.Time) (map[string]int, error)
+	// StreamAppointments calls fn for every appointment matching filter, in
+	// its order, reading them from the database one at a time
+	StreamAppointments(ctx context.Context, filter *AppointmentFilter, fn func(*AppointmentRow) error) error
 }
\ No newline at end of file
@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/pagination"
+)
+
+// Statuses of a booking request
+const (
+	BookingPending   = "pending"
+	BookingConfirmed = "confirmed"
+	BookingRejected  = "rejected"
+	BookingExpired   = "expired"
+)
+
+// BookingRequest is an appointment booked on the website, pending until
+// it is confirmed with a code or by staff
+type BookingRequest struct {
+	Id            string
+	AppointmentId string
+	ClientId      string
+	Procedure     string
+	Duration      time.Duration
+	DoctorId      string
+	Date          time.Time
+	PhoneDigits   string
+	ClientIp      string
+	// CodeHash is empty when staff confirm the request
+	CodeHash    string
+	Attempts    int
+	Status      string
+	ConfirmedBy string
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+	DecidedAt   *time.Time
+
+	// NewClient tells the client was created for the request, not matched
+	NewClient bool
+}
+
+// BookingClient is who books, matched to a client by phone and name
+type BookingClient struct {
+	Name        string
+	LastName    string
+	PhoneNumber string
+	PhoneDigits string
+}
+
+// BusyTime is when a doctor has an appointment, Duration is 0 when the
+// appointment does not tell how long it lasts
+type BusyTime struct {
+	DoctorId string
+	Start    time.Time
+	Duration time.Duration
+}
+
+type AllBookingRequests struct {
+	Requests   []*BookingRequest
+	Total      int
+	NextCursor string
+}
+
+type NewBookingI interface {
+	// Doctors returns the doctors that can be booked, every one that is
+	// not deleted
+	Doctors(ctx context.Context) ([]*Doctor, error)
+	// BusyTimes returns the appointments of doctors that hold their time
+	// and start from from to to
+	BusyTimes(ctx context.Context, from, to time.Time) ([]BusyTime, error)
+	// CreateRequest books the time of req as a pending appointment for
+	// the client matched by phone and name, or for a new one. It fails
+	// with ErrSlotTaken when an appointment of the doctor of req that
+	// lasts minDuration at least overlaps it.
+	CreateRequest(ctx context.Context, req *BookingRequest, client *BookingClient, minDuration time.Duration) (*BookingRequest, error)
+	GetRequest(ctx context.Context, id string) (*BookingRequest, error)
+	GetRequests(ctx context.Context, status string, params pagination.Params) (*AllBookingRequests, error)
+	// CountRecent counts the requests made from ip since
+	CountRecent(ctx context.Context, ip string, since time.Time) (int, error)
+	// CountPending counts the pending requests of a phone
+	CountPending(ctx context.Context, phoneDigits string) (int, error)
+
+	// ConfirmWithCode confirms a pending request whose code hashes to
+	// codeHash. A wrong code fails with ErrWrongCode and counts as an
+	// attempt, the request is rejected after maxAttempts.
+	ConfirmWithCode(ctx context.Context, id, codeHash string, maxAttempts int) (*BookingRequest, error)
+	// Confirm confirms a pending request on behalf of staff
+	Confirm(ctx context.Context, id string) (*BookingRequest, error)
+	// Reject rejects a pending request and cancels its appointment
+	Reject(ctx context.Context, id string) (*BookingRequest, error)
+	// Expire marks the pending requests that are past their time expired
+	// and cancels their appointments, it returns how many
+	Expire(ctx context.Context) (int, error)
+}
@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"context"
+	"time"
+)
+
+// CalendarFeed is a secret link to the schedule of a doctor, or of the
+// whole clinic when DoctorId is empty. MaskPatients leaves only initials
+// of the patients in the events.
+type CalendarFeed struct {
+	Id           string
+	TokenHash    string
+	DoctorId     string
+	Name         string
+	MaskPatients bool
+	CreatedAt    time.Time
+	RevokedAt    *time.Time
+}
+
+type NewCalendarI interface {
+	CreateFeed(ctx context.Context, feed *CalendarFeed) (*CalendarFeed, error)
+	GetFeeds(ctx context.Context) ([]*CalendarFeed, error)
+	// GetFeedByToken finds a feed that is not revoked, ErrNotFound otherwise
+	GetFeedByToken(ctx context.Context, tokenHash string) (*CalendarFeed, error)
+	// RevokeFeed returns false when there is no such feed left to revoke
+	RevokeFeed(ctx context.Context, id string) (bool, error)
+}
@@ -1,5 +1,13 @@
 package repo
 
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/pagination"
+)
+
 type Client struct {
 	Id          string
 	Name        string
@@ -7,24 +15,50 @@ type Client struct {
 	FatherName  string
 	PhoneNumber string
 	Address     string
-	BirthDate   string
+	BirthDate   civil.Date
+	CreatedAt   time.Time
 }
 
 type AllClients struct {
-	Clients []*Client
+	Clients    []*Client
+	Total      int
+	NextCursor string
 }
 
 type GetAllClient struct {
-	Page  int
-	Limit int
+	pagination.Params
 }
 
 type NewClientI interface {
-	CreateClient(*Client) (*Client, error)
-	GetClient(id string) (*Client, error)
-	UpdateClient(*Client) (*Client, error)
-	DeleteClient(id string) (bool, error)
-	GetAllClients(*GetAllClient) (*AllClients, error)
-	GetAllClientsCount() (int, error)
-	SearchClients(str string) (*AllClients, error)
+	CreateClient(ctx context.Context, c *Client) (*Client, error)
+	GetClient(ctx context.Context, id string) (*Client, error)
+	UpdateClient(ctx context.Context, c *Client) (*Client, error)
+	DeleteClient(ctx context.Context, id string) (bool, error)
+	GetAllClients(ctx context.Context, req *GetAllClient) (*AllClients, error)
+	GetAllClientsCount(ctx context.Context) (int, error)
+	SearchClients(ctx context.Context, str string, params pagination.Params) (*AllClients, error)
+	// StreamClients calls fn for every active client, of those whose name
+	// contains search when it is set, reading them one at a time
+	StreamClients(ctx context.Context, search string, fn func(*Client) error) error
+	// ExportClient collects everything stored about a client, deleted
+	// appointments included
+	ExportClient(ctx context.Context, id string) (*ClientExport, error)
+	// AnonymizeClient irreversibly wipes the personal data of a client and
+	// the free text of its appointments, the rows stay for statistics. The
+	// copies in events, webhook deliveries, merges, online bookings,
+	// waitlist entries and lab cases are wiped too.
+	AnonymizeClient(ctx context.Context, id string) (time.Time, error)
+}
+
+// ClientExport is the data a client may ask a copy of
+type ClientExport struct {
+	Client       Client
+	DeletedAt    *time.Time
+	Appointments []*ExportedAppointment
+}
+
+type ExportedAppointment struct {
+	Appointment
+	DoctorName string
+	DeletedAt  *time.Time
 }
@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/pagination"
+)
+
+type Doctor struct {
+	Id          string
+	Name        string
+	LastName    string
+	Specialty   string
+	PhoneNumber string
+	CreatedAt   time.Time
+}
+
+type AllDoctors struct {
+	Doctors    []*Doctor
+	Total      int
+	NextCursor string
+}
+
+type NewDoctorI interface {
+	CreateDoctor(ctx context.Context, d *Doctor) (*Doctor, error)
+	GetDoctor(ctx context.Context, id string) (*Doctor, error)
+	UpdateDoctor(ctx context.Context, d *Doctor) (*Doctor, error)
+	DeleteDoctor(ctx context.Context, id string) (bool, error)
+	GetAllDoctors(ctx context.Context, params pagination.Params) (*AllDoctors, error)
+}
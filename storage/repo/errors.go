@@ -0,0 +1,64 @@
+package repo
+
+import "errors"
+
+// ErrNotFound is returned when the requested row does not exist or is
+// deleted
+var ErrNotFound = errors.New("not found")
+
+// ErrClientDeleted is returned when an appointment cannot be restored
+// because its client is deleted
+var ErrClientDeleted = errors.New("client is deleted")
+
+// ErrOfferClosed is returned when accepting a waitlist offer that expired
+// or is not pending any more
+var ErrOfferClosed = errors.New("offer is closed")
+
+// ErrSlotTaken is returned when accepting a waitlist offer of a slot that
+// someone else took first, or booking a time that is not free
+var ErrSlotTaken = errors.New("slot is taken")
+
+// ErrBookingClosed is returned when confirming or rejecting a booking
+// request that expired or was decided already
+var ErrBookingClosed = errors.New("booking request is closed")
+
+// ErrWrongCode is returned when a booking request is confirmed with a
+// code that is not the one sent
+var ErrWrongCode = errors.New("wrong confirmation code")
+
+// ErrNameTaken is returned when naming an inventory item, a storage
+// location or a supplier like another one
+var ErrNameTaken = errors.New("name is taken")
+
+// ErrInsufficientStock is returned when an adjustment would take more
+// out of a batch than it holds
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrOrderState is returned when a purchase order is changed in a way its
+// status does not allow, such as editing one that was placed
+var ErrOrderState = errors.New("purchase order status does not allow this")
+
+// ErrOverReceipt is returned when receiving more of a purchase order line
+// than is still outstanding
+var ErrOverReceipt = errors.New("more received than ordered")
+
+// ErrLabCaseState is returned when a lab case is moved in a way its
+// status does not allow, such as receiving one that was not sent
+var ErrLabCaseState = errors.New("lab case status does not allow this")
+
+// ErrLabCaseNotReceived is returned when confirming the fitting
+// appointment of a lab case the lab has not delivered yet, or attaching
+// such a case to an appointment confirmed already
+var ErrLabCaseNotReceived = errors.New("lab case is not received")
+
+// ErrDuplicate is returned when recording a sterilization cycle number of
+// an autoclave or a pack code that is recorded already
+var ErrDuplicate = errors.New("already recorded")
+
+// ErrCycleState is returned when recording a result a sterilization cycle
+// cannot take, such as passing one that failed
+var ErrCycleState = errors.New("sterilization cycle result does not allow this")
+
+// ErrPackUnusable is returned when using an instrument pack that was used
+// already or whose cycle has not passed
+var ErrPackUnusable = errors.New("pack is not usable")
@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+)
+
+// Kinds of relations between clients. RelationWard is never stored, it is
+// the guardian relation seen from the guardian's side.
+const (
+	RelationGuardian = "guardian"
+	RelationSpouse   = "spouse"
+	RelationSibling  = "sibling"
+	RelationWard     = "ward"
+)
+
+// ValidRelation reports whether kind can be stored
+func ValidRelation(kind string) bool {
+	return kind == RelationGuardian || kind == RelationSpouse || kind == RelationSibling
+}
+
+// ErrNotRelated is returned when a guarantor is neither a guardian nor a
+// spouse of the client
+var ErrNotRelated = errors.New("guarantor must be a guardian or spouse of the client")
+
+// Relation says Related is the Kind of the client it was listed for
+type Relation struct {
+	Kind      string
+	Related   Client
+	CreatedAt time.Time
+}
+
+// IsMinor reports whether the client is younger than adultAge on today,
+// clients without a birth date count as adults
+func (c *Client) IsMinor(today civil.Date, adultAge int) bool {
+	return !c.BirthDate.IsZero() && c.BirthDate.YearsUntil(today) < adultAge
+}
+
+type FamilyMember struct {
+	Client
+	// GuarantorId is empty when the client answers for itself
+	GuarantorId string
+}
+
+// Family is everyone connected to a client through relations
+type Family struct {
+	Members  []*FamilyMember
+	Upcoming []*Appointment
+	// Balance is the sum of the completed appointments of all members,
+	// payments are not recorded separately
+	Balance int
+}
+
+type NewFamilyI interface {
+	// AddRelation stores that relatedId is the kind of clientId, both
+	// clients must be active
+	AddRelation(ctx context.Context, clientId, relatedId, kind string) error
+	// DeleteRelation also clears the guarantor when it loses the relation
+	// that allowed it
+	DeleteRelation(ctx context.Context, clientId, relatedId, kind string) (bool, error)
+	GetRelations(ctx context.Context, clientId string) ([]*Relation, error)
+	// CountGuardians counts the active guardians of the client
+	CountGuardians(ctx context.Context, clientId string) (int, error)
+	// SetGuarantor sets who gets the reminders and bills of the client,
+	// an empty guarantorId makes the client answer for itself
+	SetGuarantor(ctx context.Context, clientId, guarantorId string) error
+	// GetContact returns the guarantor of the client, or the client itself
+	GetContact(ctx context.Context, clientId string) (*Client, error)
+	// GetFamily returns the family of the client with the appointments
+	// scheduled from the given time on
+	GetFamily(ctx context.Context, clientId string, from time.Time) (*Family, error)
+}
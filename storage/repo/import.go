@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+)
+
+// Kinds of import
+const (
+	ImportClients      = "clients"
+	ImportAppointments = "appointments"
+)
+
+// Outcomes of an imported row. RowValid is what a dry run reports for a
+// row that would be imported.
+const (
+	RowImported  = "imported"
+	RowValid     = "valid"
+	RowDuplicate = "duplicate"
+	RowError     = "error"
+)
+
+// ClientKey is what an imported row is matched against an active client
+// by. Phone holds the last 9 digits of the phone number and Name the lower
+// cased name and last name.
+type ClientKey struct {
+	Id        string
+	Phone     string
+	Name      string
+	BirthDate civil.Date
+}
+
+type AppointmentKey struct {
+	ClientId string
+	Date     time.Time
+}
+
+// ImportProblem is a row that was not imported, Row counts from 1 with the
+// header as row 1 so that it matches the spreadsheet
+type ImportProblem struct {
+	Row    int      `json:"row"`
+	Status string   `json:"status"`
+	Errors []string `json:"errors"`
+	Values []string `json:"values"`
+}
+
+type ImportRun struct {
+	Id             string
+	Kind           string
+	FileName       string
+	DryRun         bool
+	Total          int
+	Imported       int
+	CreatedClients int
+	Duplicates     int
+	Failed         int
+	Header         []string
+	Problems       []*ImportProblem
+	CreatedAt      time.Time
+}
+
+type NewImportI interface {
+	ClientKeys(ctx context.Context) ([]*ClientKey, error)
+	// ExistingAppointments returns the keys that already have an active
+	// appointment
+	ExistingAppointments(ctx context.Context, keys []AppointmentKey) ([]AppointmentKey, error)
+	// ImportBatch inserts the clients and then the appointments in one
+	// transaction
+	ImportBatch(ctx context.Context, clients []*Client, appointments []*Appointment) error
+	SaveImport(ctx context.Context, run *ImportRun) error
+	GetImport(ctx context.Context, id string) (*ImportRun, error)
+}
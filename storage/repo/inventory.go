@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/pagination"
+)
+
+// Reasons of a stock movement
+const (
+	MovementReceipt     = "receipt"
+	MovementConsumption = "consumption"
+	MovementAdjustment  = "adjustment"
+)
+
+// InventoryItem is a material kept in stock, Stock is the sum of its
+// batches
+type InventoryItem struct {
+	Id        string
+	Name      string
+	Unit      string
+	Sku       string
+	MinStock  float64
+	Stock     float64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type AllInventoryItems struct {
+	Items      []*InventoryItem
+	Total      int
+	NextCursor string
+}
+
+type StorageLocation struct {
+	Id        string
+	Name      string
+	CreatedAt time.Time
+}
+
+// InventoryBatch is a quantity of an item received into a location
+type InventoryBatch struct {
+	Id           string
+	ItemId       string
+	LocationId   string
+	LocationName string
+	Lot          string
+	// ExpiresOn is zero for a batch that does not expire
+	ExpiresOn  civil.Date
+	Quantity   float64
+	ReceivedAt time.Time
+}
+
+// ExpiringBatch is a batch in stock that expires soon, or did
+type ExpiringBatch struct {
+	InventoryBatch
+	ItemName string
+	Unit     string
+}
+
+// ProcedureMaterial is the quantity of an item a procedure uses up
+type ProcedureMaterial struct {
+	Procedure string
+	ItemId    string
+	ItemName  string
+	Unit      string
+	Quantity  float64
+}
+
+// StockMovement is a change of stock, negative when stock was taken out.
+// BatchId is empty for a consumption stock did not cover.
+type StockMovement struct {
+	Id            string
+	ItemId        string
+	BatchId       string
+	LocationId    string
+	Quantity      float64
+	Reason        string
+	AppointmentId string
+	Note          string
+	CreatedAt     time.Time
+}
+
+type MovementFilter struct {
+	ItemId        string
+	AppointmentId string
+	Reason        string
+}
+
+type AllStockMovements struct {
+	Movements  []*StockMovement
+	Total      int
+	NextCursor string
+}
+
+type NewInventoryI interface {
+	CreateItem(ctx context.Context, item *InventoryItem) (*InventoryItem, error)
+	GetItem(ctx context.Context, id string) (*InventoryItem, error)
+	GetItems(ctx context.Context, params pagination.Params) (*AllInventoryItems, error)
+	UpdateItem(ctx context.Context, item *InventoryItem) (*InventoryItem, error)
+	// DeleteItem deletes an item, its history is kept
+	DeleteItem(ctx context.Context, id string) error
+
+	CreateLocation(ctx context.Context, location *StorageLocation) (*StorageLocation, error)
+	GetLocations(ctx context.Context) ([]*StorageLocation, error)
+
+	// ReceiveBatch puts a batch into stock with a receipt movement
+	ReceiveBatch(ctx context.Context, batch *InventoryBatch, note string) (*InventoryBatch, error)
+	GetBatch(ctx context.Context, id string) (*InventoryBatch, error)
+	// GetBatches returns the batches of an item still in stock, first
+	// expiring first
+	GetBatches(ctx context.Context, itemId string) ([]*InventoryBatch, error)
+	// AdjustBatch changes the quantity of a batch by delta, such as after
+	// a count, failing with ErrInsufficientStock below zero
+	AdjustBatch(ctx context.Context, id string, delta float64, note string) (*InventoryBatch, error)
+
+	// SetMaterials replaces the bill of materials of a procedure
+	SetMaterials(ctx context.Context, procedure string, materials []*ProcedureMaterial) error
+	// GetMaterials returns the bills of materials, of one procedure when
+	// procedure is set
+	GetMaterials(ctx context.Context, procedure string) ([]*ProcedureMaterial, error)
+	// Consume takes the materials of the treatment of an appointment out
+	// of stock, first expiring first and skipping expired batches. It
+	// does so once per appointment and reports whether it did now.
+	Consume(ctx context.Context, appointmentId, treatment string) (bool, error)
+
+	GetMovements(ctx context.Context, filter *MovementFilter, params pagination.Params) (*AllStockMovements, error)
+	// LowStock returns the items below their minimum stock
+	LowStock(ctx context.Context) ([]*InventoryItem, error)
+	// ExpiringBatches returns the batches in stock expiring on before or
+	// earlier
+	ExpiringBatches(ctx context.Context, before civil.Date) ([]*ExpiringBatch, error)
+}
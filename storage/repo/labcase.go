@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/pagination"
+)
+
+// Statuses of a lab case
+const (
+	LabCaseCreated   = "created"
+	LabCaseSent      = "sent"
+	LabCaseReceived  = "received"
+	LabCaseFitted    = "fitted"
+	LabCaseCancelled = "cancelled"
+)
+
+// ValidWorkType reports whether t is a kind of work labs are sent
+func ValidWorkType(t string) bool {
+	switch t {
+	case "crown", "bridge", "denture", "veneer", "inlay", "implant", "other":
+		return true
+	}
+	return false
+}
+
+// ValidTooth reports whether n is a tooth in FDI notation, permanent in
+// quadrants 1 to 4 and primary in 5 to 8
+func ValidTooth(n int) bool {
+	quadrant, tooth := n/10, n%10
+	switch {
+	case quadrant >= 1 && quadrant <= 4:
+		return tooth >= 1 && tooth <= 8
+	case quadrant >= 5 && quadrant <= 8:
+		return tooth >= 1 && tooth <= 5
+	}
+	return false
+}
+
+// LabCase is prosthetic work an outside lab makes for a client. The lab
+// is a supplier, AppointmentId is the fitting appointment when one is
+// booked.
+type LabCase struct {
+	Id            string
+	ClientId      string
+	ClientName    string
+	AppointmentId string
+	// AppointmentDate is zero when no fitting appointment is booked
+	AppointmentDate time.Time
+	LabId           string
+	LabName         string
+	WorkType        string
+	Shade           string
+	Teeth           []int
+	Status          string
+	SentOn          civil.Date
+	DueOn           civil.Date
+	ReceivedOn      civil.Date
+	FittedOn        civil.Date
+	Cost            float64
+	Note            string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+type LabCaseFilter struct {
+	ClientId string
+	LabId    string
+	Status   string
+}
+
+type AllLabCases struct {
+	Cases      []*LabCase
+	Total      int
+	NextCursor string
+}
+
+type NewLabCaseI interface {
+	// CreateCase creates a case not sent yet. It fails with ErrNotFound
+	// when the client, the lab or the appointment does not exist, or the
+	// appointment is of another client, and with ErrLabCaseNotReceived
+	// when the appointment is confirmed, checked in or completed.
+	CreateCase(ctx context.Context, c *LabCase) (*LabCase, error)
+	GetCase(ctx context.Context, id string) (*LabCase, error)
+	// GetCases returns the cases newest first
+	GetCases(ctx context.Context, filter *LabCaseFilter, params pagination.Params) (*AllLabCases, error)
+	// UpdateCase changes the details of a case not fitted or cancelled,
+	// failing with ErrLabCaseState otherwise. It fails with
+	// ErrLabCaseNotReceived when a case not received yet is attached to an
+	// appointment confirmed, checked in or completed.
+	UpdateCase(ctx context.Context, c *LabCase) (*LabCase, error)
+	// SendCase marks a created case as sent to the lab on sentOn, due back
+	// on dueOn
+	SendCase(ctx context.Context, id string, sentOn, dueOn civil.Date) (*LabCase, error)
+	// ReceiveCase marks a sent case as delivered by the lab on receivedOn
+	ReceiveCase(ctx context.Context, id string, receivedOn civil.Date) (*LabCase, error)
+	// FitCase marks a received case as fitted on fittedOn
+	FitCase(ctx context.Context, id string, fittedOn civil.Date) (*LabCase, error)
+	// CancelCase cancels a case not fitted yet
+	CancelCase(ctx context.Context, id string) (*LabCase, error)
+	// OverdueCases returns the cases not received that were due before
+	// today, longest overdue first
+	OverdueCases(ctx context.Context, today civil.Date) ([]*LabCase, error)
+}
@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dentist/pkg/pagination"
+)
+
+var (
+	// ErrMergeUndone is returned when a merge is undone twice
+	ErrMergeUndone = errors.New("merge is already undone")
+	// ErrMergeExpired is returned when the undo window of a merge is over
+	// or the merged client does not exist anymore
+	ErrMergeExpired = errors.New("merge can no longer be undone")
+	// ErrSameClient is returned when a client is merged into itself
+	ErrSameClient = errors.New("cannot merge a client into itself")
+)
+
+// DuplicateMatch is a pair of active clients that are likely the same
+// person: same phone number, or similar names with the same birth date
+type DuplicateMatch struct {
+	Client         Client
+	Duplicate      Client
+	PhoneMatch     bool
+	BirthDateMatch bool
+	// NameSimilarity is the trigram similarity of the full names, 0..1
+	NameSimilarity float64
+}
+
+type AllDuplicateMatches struct {
+	Matches []*DuplicateMatch
+	Total   int
+}
+
+type ClientMerge struct {
+	Id             string
+	SurvivorId     string
+	MergedId       string
+	AppointmentIds []string
+	// LabCaseIds, WaitlistEntryIds and BookingRequestIds are the other
+	// records moved to the survivor
+	LabCaseIds        []string
+	WaitlistEntryIds  []string
+	BookingRequestIds []string
+	// FilledFields are the survivor fields taken from the duplicate
+	FilledFields []string
+	MergedAt     time.Time
+	UndoneAt     *time.Time
+}
+
+type NewMergeI interface {
+	// FindDuplicates returns the candidates for one client, best first
+	FindDuplicates(ctx context.Context, clientId string, nameSimilarity float64) ([]*DuplicateMatch, error)
+	// FindAllDuplicates pages through every candidate pair, offset only
+	FindAllDuplicates(ctx context.Context, nameSimilarity float64, params pagination.Params) (*AllDuplicateMatches, error)
+	// MergeClients moves everything of mergedId to survivorId, fills the
+	// empty survivor fields from the duplicate and deletes the duplicate
+	MergeClients(ctx context.Context, survivorId, mergedId string) (*ClientMerge, error)
+	// UndoMerge reverts a merge made less than window ago
+	UndoMerge(ctx context.Context, id string, window time.Duration) (*ClientMerge, error)
+	GetClientMerges(ctx context.Context, clientId string) ([]*ClientMerge, error)
+}
@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxChannel is notified with the seq of every event written to the
+// outbox, when its transaction commits
+const OutboxChannel = "outbox"
+
+// DomainEvent is a row of the outbox, Seq orders the events as they were
+// committed
+type DomainEvent struct {
+	Seq           int64
+	Id            string
+	AggregateType string
+	AggregateId   string
+	Type          string
+	Payload       []byte
+	CreatedAt     time.Time
+}
+
+// OutboxRetry is how a subscriber that failed an event gets it again. The
+// later events of the aggregate wait meanwhile, the others go on. After
+// MaxAttempts the event is put aside as dead and the aggregate goes on.
+type OutboxRetry struct {
+	MaxAttempts int
+	Backoff     func(attempts int) time.Duration
+}
+
+type NewOutboxI interface {
+	// Process hands up to limit events the subscriber has not had to
+	// handle, in order, and remembers which were handled. Events waiting
+	// out a backoff, and the later ones of their aggregate, are left for
+	// a later call. It returns how many events it handed, 0 as well when
+	// another instance is processing for the subscriber. An event is
+	// handed again when recording its outcome fails, so handle must
+	// tolerate repeats.
+	Process(ctx context.Context, subscriber string, limit int, retry OutboxRetry, handle func(ctx context.Context, event *DomainEvent) error) (int, error)
+	// Events returns up to limit events of types after seq, in order
+	Events(ctx context.Context, after int64, types []string, limit int) ([]*DomainEvent, error)
+	// LastSeq is the seq of the latest event, 0 for an empty outbox
+	LastSeq(ctx context.Context) (int64, error)
+	// Prune deletes the events written before before that every one of
+	// subscribers has handled, except the latest keep. Dead attempts go
+	// with their event. It returns how many events it deleted.
+	Prune(ctx context.Context, subscribers []string, keep int, before time.Time) (int, error)
+}
@@ -0,0 +1,145 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/pagination"
+)
+
+// Statuses of a purchase order
+const (
+	OrderDraft             = "draft"
+	OrderOrdered           = "ordered"
+	OrderPartiallyReceived = "partially_received"
+	OrderReceived          = "received"
+	OrderCancelled         = "cancelled"
+)
+
+type Supplier struct {
+	Id        string
+	Name      string
+	Contact   string
+	Phone     string
+	Email     string
+	Note      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type AllSuppliers struct {
+	Suppliers  []*Supplier
+	Total      int
+	NextCursor string
+}
+
+// PurchaseOrder is an order of items from a supplier. Total is the sum
+// of its lines at their unit price.
+type PurchaseOrder struct {
+	Id           string
+	SupplierId   string
+	SupplierName string
+	Status       string
+	// ExpectedOn is zero when no delivery date was given
+	ExpectedOn civil.Date
+	Note       string
+	Total      float64
+	Lines      []*PurchaseOrderLine
+	OrderedAt  *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type PurchaseOrderLine struct {
+	Id        string
+	ItemId    string
+	ItemName  string
+	Unit      string
+	Quantity  float64
+	UnitPrice float64
+	// Received is how much of Quantity was delivered so far
+	Received float64
+}
+
+type PurchaseOrderFilter struct {
+	SupplierId string
+	Status     string
+}
+
+type AllPurchaseOrders struct {
+	Orders     []*PurchaseOrder
+	Total      int
+	NextCursor string
+}
+
+// ReceiptLine is a delivered quantity of an order line, put into stock as
+// a batch
+type ReceiptLine struct {
+	LineId    string
+	Quantity  float64
+	Lot       string
+	ExpiresOn civil.Date
+}
+
+// Receipt is a delivery of a purchase order, full or partial
+type Receipt struct {
+	OrderId    string
+	LocationId string
+	Note       string
+	Lines      []*ReceiptLine
+}
+
+// SupplierPrice is the unit price a supplier was ordered an item at
+type SupplierPrice struct {
+	SupplierId string
+	ItemId     string
+	ItemName   string
+	UnitPrice  float64
+	OrderId    string
+	RecordedAt time.Time
+}
+
+// MonthlySpend is what was received from a supplier in a month, at the
+// unit prices ordered
+type MonthlySpend struct {
+	SupplierId   string
+	SupplierName string
+	Month        string
+	Amount       float64
+	Orders       int
+}
+
+type NewPurchasingI interface {
+	CreateSupplier(ctx context.Context, supplier *Supplier) (*Supplier, error)
+	GetSupplier(ctx context.Context, id string) (*Supplier, error)
+	GetSuppliers(ctx context.Context, params pagination.Params) (*AllSuppliers, error)
+	UpdateSupplier(ctx context.Context, supplier *Supplier) (*Supplier, error)
+	// DeleteSupplier deletes a supplier, its orders are kept
+	DeleteSupplier(ctx context.Context, id string) error
+
+	// CreateOrder creates a draft order with its lines
+	CreateOrder(ctx context.Context, order *PurchaseOrder) (*PurchaseOrder, error)
+	GetOrder(ctx context.Context, id string) (*PurchaseOrder, error)
+	// GetOrders returns the orders newest first, without their lines
+	GetOrders(ctx context.Context, filter *PurchaseOrderFilter, params pagination.Params) (*AllPurchaseOrders, error)
+	// UpdateOrder replaces the delivery date, note and lines of a draft,
+	// failing with ErrOrderState once it is placed
+	UpdateOrder(ctx context.Context, order *PurchaseOrder) (*PurchaseOrder, error)
+	// PlaceOrder marks a draft as ordered and records the prices of its
+	// lines in the price history of the supplier
+	PlaceOrder(ctx context.Context, id string) (*PurchaseOrder, error)
+	// CancelOrder cancels an order not fully received, what was received
+	// stays in stock
+	CancelOrder(ctx context.Context, id string) (*PurchaseOrder, error)
+	// Receive puts a delivery into stock as batches, marking the order
+	// received once every line is, and partially received before
+	Receive(ctx context.Context, receipt *Receipt) (*PurchaseOrder, error)
+
+	// GetPrices returns the price history of a supplier, newest first, of
+	// one item when itemId is set
+	GetPrices(ctx context.Context, supplierId, itemId string) ([]*SupplierPrice, error)
+	// GetSpend returns the spend per supplier per month of the clinic time
+	// zone, of the months from to to inclusive
+	GetSpend(ctx context.Context, from, to civil.Date) ([]*MonthlySpend, error)
+}
@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit lets Burst requests through at once and gives them back
+// evenly over Per, a token bucket
+type RateLimit struct {
+	Burst int
+	Per   time.Duration
+}
+
+type RateResult struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is when the next request is let through, set when this
+	// one was not
+	RetryAfter time.Duration
+}
+
+// LockoutPolicy locks a key out for Duration after Attempts failures
+// within Window
+type LockoutPolicy struct {
+	Attempts int
+	Window   time.Duration
+	Duration time.Duration
+}
+
+type NewRateLimitI interface {
+	// Take takes a request from the bucket of key, filled up to limit
+	Take(ctx context.Context, key string, limit RateLimit) (*RateResult, error)
+	// Locked returns until when key is locked out, the zero time when it
+	// is not
+	Locked(ctx context.Context, key string) (time.Time, error)
+	// Fail counts a failed attempt of key and returns until when it is
+	// locked out, the zero time while it is not
+	Fail(ctx context.Context, key string, policy LockoutPolicy) (time.Time, error)
+	// Clear forgets the failed attempts of key
+	Clear(ctx context.Context, key string) error
+	// Sweep drops the buckets and lockouts that expired, it returns how
+	// many
+	Sweep(ctx context.Context) (int, error)
+}
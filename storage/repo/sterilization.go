@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/pagination"
+)
+
+// Results of a sterilization cycle
+const (
+	CyclePending = "pending"
+	CyclePassed  = "passed"
+	CycleFailed  = "failed"
+)
+
+// Indicators a cycle result is read from
+const (
+	IndicatorChemical   = "chemical"
+	IndicatorBiological = "biological"
+)
+
+type Autoclave struct {
+	Id           string
+	Name         string
+	SerialNumber string
+	CreatedAt    time.Time
+}
+
+// SterilizationCycle is a run of an autoclave. Its packs are used only
+// once it passed.
+type SterilizationCycle struct {
+	Id            string
+	AutoclaveId   string
+	AutoclaveName string
+	CycleNumber   int
+	Program       string
+	StartedAt     time.Time
+	TemperatureC  float64
+	PressureKpa   float64
+	HoldMinutes   int
+	Operator      string
+	Note          string
+	Result        string
+	Indicator     string
+	ResultNote    string
+	ResultAt      *time.Time
+	Packs         []*SterilizationPack
+	CreatedAt     time.Time
+}
+
+type CycleFilter struct {
+	AutoclaveId string
+	Result      string
+}
+
+type AllSterilizationCycles struct {
+	Cycles     []*SterilizationCycle
+	Total      int
+	NextCursor string
+}
+
+// SterilizationPack is an instrument pack processed in a cycle, known by
+// the code on its label. AppointmentId is set once it is used.
+type SterilizationPack struct {
+	Id            string
+	Code          string
+	CycleId       string
+	CycleResult   string
+	Contents      string
+	AppointmentId string
+	UsedAt        *time.Time
+}
+
+// Exposure is an appointment a pack of a cycle was used at, with the
+// client to reach
+type Exposure struct {
+	AppointmentId   string
+	AppointmentDate time.Time
+	ClientId        string
+	ClientName      string
+	PhoneNumber     string
+	PackCode        string
+	UsedAt          time.Time
+}
+
+type NewSterilizationI interface {
+	CreateAutoclave(ctx context.Context, autoclave *Autoclave) (*Autoclave, error)
+	GetAutoclaves(ctx context.Context) ([]*Autoclave, error)
+
+	// CreateCycle logs a cycle with its packs, its result pending. It
+	// fails with ErrNotFound when the autoclave does not exist, and with
+	// ErrDuplicate when the cycle number or a pack code is recorded.
+	CreateCycle(ctx context.Context, cycle *SterilizationCycle) (*SterilizationCycle, error)
+	GetCycle(ctx context.Context, id string) (*SterilizationCycle, error)
+	// GetCycles returns the cycles last started first, without their
+	// packs
+	GetCycles(ctx context.Context, filter *CycleFilter, params pagination.Params) (*AllSterilizationCycles, error)
+	// RecordResult records the indicator result of a pending cycle, or
+	// fails a passed one. A cycle that fails writes
+	// sterilization.cycle_failed with the appointments its packs were used
+	// at. Other changes fail with ErrCycleState.
+	RecordResult(ctx context.Context, id string, passed bool, indicator, note string) (*SterilizationCycle, error)
+	// Exposures returns the appointments the packs of a cycle were used
+	// at, earliest first
+	Exposures(ctx context.Context, cycleId string) ([]*Exposure, error)
+
+	GetPack(ctx context.Context, code string) (*SterilizationPack, error)
+	// UsePack records that a pack was opened at an appointment, failing
+	// with ErrPackUnusable when it was used already or its cycle has not
+	// passed
+	UsePack(ctx context.Context, code, appointmentId string) (*SterilizationPack, error)
+	// GetAppointmentPacks returns the packs used at an appointment
+	GetAppointmentPacks(ctx context.Context, appointmentId string) ([]*SterilizationPack, error)
+}
@@ -0,0 +1,55 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/pagination"
+)
+
+type DeletedClient struct {
+	Client
+	DeletedAt time.Time
+}
+
+type DeletedAppointment struct {
+	Appointment
+	DeletedAt time.Time
+}
+
+type AllDeletedClients struct {
+	Clients    []*DeletedClient
+	Total      int
+	NextCursor string
+}
+
+type AllDeletedAppointments struct {
+	Appointments []*DeletedAppointment
+	Total        int
+	NextCursor   string
+}
+
+// PurgeResult counts the rows the retention job removed or anonymized
+type PurgeResult struct {
+	Clients      int
+	Appointments int
+}
+
+// NewTrashI lists and restores soft-deleted rows. Rows anonymized by Purge
+// are not in the trash anymore.
+type NewTrashI interface {
+	GetDeletedClients(ctx context.Context, params pagination.Params) (*AllDeletedClients, error)
+	GetDeletedAppointments(ctx context.Context, params pagination.Params) (*AllDeletedAppointments, error)
+	// RestoreClient restores the client together with the appointments
+	// that were deleted with it and returns how many of them came back
+	RestoreClient(ctx context.Context, id string) (int, error)
+	// RestoreAppointment returns ErrClientDeleted when the appointment's
+	// client is in the trash too
+	RestoreAppointment(ctx context.Context, id string) error
+	// Purge deletes the rows deleted before the given time, or only wipes
+	// their personal data when anonymize is set. Appointments instrument
+	// packs were used at, and clients with lab cases or such appointments,
+	// are always anonymized, they are kept for tracing. The copies of the
+	// personal data are wiped as by AnonymizeClient.
+	Purge(ctx context.Context, before time.Time, anonymize bool) (*PurgeResult, error)
+}
@@ -0,0 +1,120 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/civil"
+	"github.com/dentist/pkg/pagination"
+)
+
+// Statuses of a waitlist entry
+const (
+	WaitlistWaiting   = "waiting"
+	WaitlistBooked    = "booked"
+	WaitlistCancelled = "cancelled"
+)
+
+// Statuses of a waitlist offer
+const (
+	OfferPending   = "pending"
+	OfferAccepted  = "accepted"
+	OfferLost      = "lost"
+	OfferWithdrawn = "withdrawn"
+)
+
+// WaitlistEntry is a client waiting for an appointment on a day from
+// DateFrom to DateTo, with DoctorId and Treatment or with any when empty
+type WaitlistEntry struct {
+	Id            string
+	ClientId      string
+	DoctorId      string
+	Treatment     string
+	DateFrom      civil.Date
+	DateTo        civil.Date
+	Priority      int
+	Note          string
+	Status        string
+	AppointmentId string
+	CreatedAt     time.Time
+}
+
+type WaitlistFilter struct {
+	Status   string
+	DoctorId string
+	ClientId string
+}
+
+type AllWaitlistEntries struct {
+	Entries    []*WaitlistEntry
+	Total      int
+	NextCursor string
+}
+
+// WaitlistSlot is the time of an appointment that was cancelled or moved
+// away, Id is derived from the event that freed it
+type WaitlistSlot struct {
+	Id                  string
+	DoctorId            string
+	Date                time.Time
+	Treatment           string
+	SourceAppointmentId string
+	// SourceClientId is not offered the slot back
+	SourceClientId string
+}
+
+// WaitlistOffer is a slot offered to an entry, with what it takes to
+// tell the client about it
+type WaitlistOffer struct {
+	Id         string
+	SlotId     string
+	EntryId    string
+	Rank       int
+	Status     string
+	ExpiresAt  time.Time
+	NotifiedAt *time.Time
+	AcceptedAt *time.Time
+	CreatedAt  time.Time
+
+	DoctorId    string
+	Date        time.Time
+	ClientId    string
+	ClientName  string
+	PhoneNumber string
+	Treatment   string
+}
+
+type NewWaitlistI interface {
+	CreateEntry(ctx context.Context, entry *WaitlistEntry) (*WaitlistEntry, error)
+	GetEntry(ctx context.Context, id string) (*WaitlistEntry, error)
+	GetEntries(ctx context.Context, filter *WaitlistFilter, params pagination.Params) (*AllWaitlistEntries, error)
+	// CancelEntry takes a waiting entry off the list and withdraws its
+	// pending offers
+	CancelEntry(ctx context.Context, id string) (bool, error)
+	GetEntryOffers(ctx context.Context, entryId string) ([]*WaitlistOffer, error)
+
+	// OfferSlot offers slot to up to limit waiting entries, ranked by
+	// priority, by how closely they match and by how long they wait. A
+	// slot that was offered already is not offered again. It returns the
+	// pending offers of the slot whose client was not notified yet.
+	OfferSlot(ctx context.Context, slot *WaitlistSlot, limit int, ttl time.Duration) ([]*WaitlistOffer, error)
+	// SetOfferToken replaces the hash of the token in the link of an
+	// offer, a link sent before stops working
+	SetOfferToken(ctx context.Context, offerId, tokenHash string) error
+	MarkNotified(ctx context.Context, offerId string) error
+	GetOffer(ctx context.Context, id string) (*WaitlistOffer, error)
+	GetOfferByToken(ctx context.Context, tokenHash string) (*WaitlistOffer, error)
+
+	// ClaimSlot takes the slot of a pending offer for it, the first claim
+	// wins. It fails with ErrOfferClosed or ErrSlotTaken, the offer is
+	// then lost. A slot an appointment was booked into meanwhile is
+	// taken too.
+	ClaimSlot(ctx context.Context, offerId string) (*WaitlistOffer, error)
+	// ReleaseSlot gives the slot of a claimed offer back, when booking it
+	// failed
+	ReleaseSlot(ctx context.Context, offerId string) error
+	// CompleteOffer books the entry of a claimed offer with the
+	// appointment, the other offers of the slot are lost and the other
+	// offers of the entry withdrawn
+	CompleteOffer(ctx context.Context, offerId, appointmentId string) error
+}
@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/pagination"
+)
+
+// Statuses of a webhook delivery
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook subscribes Url to the event types in Events, "*" subscribes it
+// to all of them
+type Webhook struct {
+	Id          string
+	Url         string
+	Events      []string
+	Secret      string
+	Description string
+	CreatedAt   time.Time
+}
+
+// WebhookEvent is what happened, Payload is the JSON body sent for it
+type WebhookEvent struct {
+	Id        string
+	Type      string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+type WebhookDelivery struct {
+	Id            string
+	WebhookId     string
+	EventId       string
+	Event         string
+	Payload       []byte
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	// ResponseStatus is the HTTP status of the last attempt, 0 when it got
+	// no response
+	ResponseStatus int
+	LastError      string
+	ReplayOf       string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+
+	// Url and Secret are of the webhook, filled in by ClaimDeliveries
+	Url    string
+	Secret string
+}
+
+type AllWebhookDeliveries struct {
+	Deliveries []*WebhookDelivery
+	Total      int
+	NextCursor string
+}
+
+type NewWebhookI interface {
+	CreateWebhook(ctx context.Context, w *Webhook) (*Webhook, error)
+	GetWebhook(ctx context.Context, id string) (*Webhook, error)
+	GetWebhooks(ctx context.Context) ([]*Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) (bool, error)
+
+	// Enqueue adds a pending delivery of event for every webhook
+	// subscribed to it and returns how many were added
+	Enqueue(ctx context.Context, event *WebhookEvent) (int, error)
+	// ClaimDeliveries takes up to limit pending deliveries that are due
+	// and moves their next attempt lease ahead, so that another instance
+	// does not send them at the same time
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*WebhookDelivery, error)
+	// RecordAttempt saves the outcome of sending d: its status, attempts,
+	// next attempt and response
+	RecordAttempt(ctx context.Context, d *WebhookDelivery) error
+	GetDeliveries(ctx context.Context, webhookId string, params pagination.Params) (*AllWebhookDeliveries, error)
+	// ReplayDelivery queues the payload of a delivery again as a new one
+	ReplayDelivery(ctx context.Context, id string) (*WebhookDelivery, error)
+}
@@ -1,6 +1,10 @@
 package storage
 
 import (
+	"context"
+	"time"
+
+	"github.com/dentist/pkg/logger"
 	"github.com/dentist/storage/postgres"
 	"github.com/dentist/storage/repo"
 	"github.com/jmoiron/sqlx"
@@ -9,17 +13,67 @@ import (
 type StorageI interface {
 	Client() repo.NewClientI
 	Appointment() repo.NewAppointmentI
+	Doctor() repo.NewDoctorI
+	Trash() repo.NewTrashI
+	Merge() repo.NewMergeI
+	Family() repo.NewFamilyI
+	Import() repo.NewImportI
+	Calendar() repo.NewCalendarI
+	Webhook() repo.NewWebhookI
+	Outbox() repo.NewOutboxI
+	Waitlist() repo.NewWaitlistI
+	Booking() repo.NewBookingI
+	RateLimit() repo.NewRateLimitI
+	Inventory() repo.NewInventoryI
+	Purchasing() repo.NewPurchasingI
+	LabCase() repo.NewLabCaseI
+	Sterilization() repo.NewSterilizationI
+	Ping(ctx context.Context) error
 }
 
 type storagePg struct {
+	db *sqlx.DB
 	clientRepo repo.NewClientI
 	appoinmentRepo repo.NewAppointmentI
+	doctorRepo repo.NewDoctorI
+	trashRepo repo.NewTrashI
+	mergeRepo repo.NewMergeI
+	familyRepo repo.NewFamilyI
+	importRepo repo.NewImportI
+	calendarRepo repo.NewCalendarI
+	webhookRepo repo.NewWebhookI
+	outboxRepo repo.NewOutboxI
+	waitlistRepo repo.NewWaitlistI
+	bookingRepo repo.NewBookingI
+	rateLimitRepo repo.NewRateLimitI
+	inventoryRepo repo.NewInventoryI
+	purchasingRepo repo.NewPurchasingI
+	labCaseRepo repo.NewLabCaseI
+	sterilizationRepo repo.NewSterilizationI
 }
 
-func NewStoragePg(db *sqlx.DB) StorageI {
+// NewStoragePg builds the postgres repositories, loc is the clinic time
+// zone in which calendar days are cut
+func NewStoragePg(db *sqlx.DB, loc *time.Location, log logger.Logger) StorageI {
 	return &storagePg{
-        clientRepo: postgres.NewClientRepo(db),
-        appoinmentRepo: postgres.NewAppointmentRepo(db),
+		db: db,
+        clientRepo: postgres.NewClientRepo(db, log),
+        appoinmentRepo: postgres.NewAppointmentRepo(db, loc, log),
+        doctorRepo: postgres.NewDoctorRepo(db, log),
+        trashRepo: postgres.NewTrashRepo(db, log),
+        mergeRepo: postgres.NewMergeRepo(db, log),
+        familyRepo: postgres.NewFamilyRepo(db, log),
+        importRepo: postgres.NewImportRepo(db, log),
+        calendarRepo: postgres.NewCalendarRepo(db, log),
+        webhookRepo: postgres.NewWebhookRepo(db, log),
+        outboxRepo: postgres.NewOutboxRepo(db, log),
+        waitlistRepo: postgres.NewWaitlistRepo(db, log),
+        bookingRepo: postgres.NewBookingRepo(db, log),
+        rateLimitRepo: postgres.NewRateLimitRepo(db, log),
+        inventoryRepo: postgres.NewInventoryRepo(db, log),
+        purchasingRepo: postgres.NewPurchasingRepo(db, loc, log),
+        labCaseRepo: postgres.NewLabCaseRepo(db, log),
+        sterilizationRepo: postgres.NewSterilizationRepo(db, log),
     }
 }
 
@@ -28,4 +82,54 @@ func (s *storagePg) Appointment() repo.NewAppointmentI {
 }
 func (s *storagePg) Client() repo.NewClientI {
 	return s.clientRepo
+}
+func (s *storagePg) Doctor() repo.NewDoctorI {
+	return s.doctorRepo
+}
+func (s *storagePg) Trash() repo.NewTrashI {
+	return s.trashRepo
+}
+func (s *storagePg) Merge() repo.NewMergeI {
+	return s.mergeRepo
+}
+func (s *storagePg) Family() repo.NewFamilyI {
+	return s.familyRepo
+}
+func (s *storagePg) Import() repo.NewImportI {
+	return s.importRepo
+}
+func (s *storagePg) Calendar() repo.NewCalendarI {
+	return s.calendarRepo
+}
+func (s *storagePg) Webhook() repo.NewWebhookI {
+	return s.webhookRepo
+}
+func (s *storagePg) Outbox() repo.NewOutboxI {
+	return s.outboxRepo
+}
+func (s *storagePg) Waitlist() repo.NewWaitlistI {
+	return s.waitlistRepo
+}
+func (s *storagePg) Booking() repo.NewBookingI {
+	return s.bookingRepo
+}
+func (s *storagePg) RateLimit() repo.NewRateLimitI {
+	return s.rateLimitRepo
+}
+func (s *storagePg) Inventory() repo.NewInventoryI {
+	return s.inventoryRepo
+}
+func (s *storagePg) Purchasing() repo.NewPurchasingI {
+	return s.purchasingRepo
+}
+func (s *storagePg) LabCase() repo.NewLabCaseI {
+	return s.labCaseRepo
+}
+func (s *storagePg) Sterilization() repo.NewSterilizationI {
+	return s.sterilizationRepo
+}
+
+// Ping checks that the database answers, used by the readiness probe
+func (s *storagePg) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
 }
\ No newline at end of file